@@ -0,0 +1,64 @@
+package cog
+
+import (
+	"hash/maphash"
+	"iter"
+)
+
+// ASCIIMap is a map with ascii keys of type K. Go map keys must be comparable,
+// which byte slices are not, so the keys are stored as strings. Indexing with
+// m[string(k)] does not allocate.
+type ASCIIMap[K ~[]byte, V any] map[string]V
+
+// All returns an iterator over the keys and values of the map.
+func (m ASCIIMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, v := range m {
+			if !yield(K(k), v) {
+				return
+			}
+		}
+	}
+}
+
+// ASCIISet is a set of ascii values of type K, stored as strings like the keys
+// of an ASCIIMap.
+type ASCIISet[K ~[]byte] map[string]struct{}
+
+// All returns an iterator over the values in the set.
+func (s ASCIISet[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s {
+			if !yield(K(k)) {
+				return
+			}
+		}
+	}
+}
+
+// Copy returns a copy of the set.
+func (s ASCIISet[K]) Copy() ASCIISet[K] {
+	cpy := make(ASCIISet[K], len(s))
+
+	for k := range s {
+		cpy[k] = struct{}{}
+	}
+
+	return cpy
+}
+
+// ASCIIHash is the key type ascii-keyed maps and sets used to be lowered to.
+//
+// Deprecated: ascii-keyed maps and sets are lowered to ASCIIMap and ASCIISet,
+// which keep their keys and cannot collide. ASCIIHash is only kept for code
+// generated by earlier versions.
+type ASCIIHash uint64
+
+var seed = maphash.MakeSeed()
+
+// HashASCII hashes an ASCII value to a uint64 to use for map and set keys.
+//
+// Deprecated: use ASCIIMap and ASCIISet, see ASCIIHash.
+func HashASCII[Out ~uint64, In ~[]byte](in In) Out {
+	return Out(maphash.Bytes(seed, in))
+}
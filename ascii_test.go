@@ -0,0 +1,73 @@
+package cog
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+type name ASCII
+
+func TestASCIIMap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("keys", func(t *testing.T) {
+		t.Parallel()
+
+		m := ASCIIMap[name, int]{"a": 1, "b": 2}
+
+		key := name("a")
+		if m[string(key)] != 1 {
+			t.Errorf("m[%q] = %d, want 1", key, m[string(key)])
+		}
+
+		got := make(map[string]int)
+		for k, v := range m.All() {
+			got[string(k)] = v
+		}
+
+		if !maps.Equal(got, map[string]int{"a": 1, "b": 2}) {
+			t.Errorf("All() = %v, want a: 1, b: 2", got)
+		}
+	})
+
+	t.Run("break", func(t *testing.T) {
+		t.Parallel()
+
+		m := ASCIIMap[ASCII, int]{"a": 1, "b": 2}
+
+		n := 0
+		for range m.All() {
+			n++
+			break
+		}
+
+		if n != 1 {
+			t.Errorf("iterated %d times after break, want 1", n)
+		}
+	})
+}
+
+func TestASCIISet(t *testing.T) {
+	t.Parallel()
+
+	s := ASCIISet[name]{"a": {}, "b": {}}
+
+	var got []string
+	for k := range s.All() {
+		got = append(got, string(k))
+	}
+
+	slices.Sort(got)
+
+	if !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("All() = %v, want [a b]", got)
+	}
+
+	cpy := s.Copy()
+	delete(cpy, "a")
+
+	if len(s) != 2 || len(cpy) != 1 {
+		t.Errorf("Copy() shares storage: len(s) = %d, len(cpy) = %d", len(s), len(cpy))
+	}
+}
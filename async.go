@@ -0,0 +1,43 @@
+package cog
+
+import "context"
+
+// Void is the value of the handle of a procedure without return value.
+type Void = struct{}
+
+// Future is the handle of a procedure called async.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+}
+
+// Async runs proc in a new goroutine and returns its handle. The procedure gets
+// a child of ctx, so cancelling ctx (e.g. when main receives a signal) cancels
+// the procedure too. The child context is cancelled when the procedure returns.
+func Async[T any](ctx context.Context, proc func(ctx context.Context) T) *Future[T] {
+	f := &Future[T]{
+		done: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer close(f.done)
+		defer cancel()
+
+		f.value = proc(ctx)
+	}()
+
+	return f
+}
+
+// Await blocks until the procedure returns, and returns its value.
+func (f *Future[T]) Await() T {
+	<-f.done
+	return f.value
+}
+
+// Done returns a channel that is closed when the procedure returns.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
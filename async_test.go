@@ -0,0 +1,70 @@
+package cog
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("await_value", func(t *testing.T) {
+		t.Parallel()
+
+		f := Async(t.Context(), func(ctx context.Context) int64 {
+			return 42
+		})
+
+		if got := f.Await(); got != 42 {
+			t.Errorf("Await() = %d, want 42", got)
+		}
+
+		// Awaiting again returns the same value.
+		if got := f.Await(); got != 42 {
+			t.Errorf("second Await() = %d, want 42", got)
+		}
+	})
+
+	t.Run("cancel_propagates", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+
+		f := Async(ctx, func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		select {
+		case <-f.Done():
+			t.Fatal("procedure returned before its parent was cancelled")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		cancel()
+
+		if err := f.Await(); err != context.Canceled {
+			t.Errorf("Await() = %v, want %v", err, context.Canceled)
+		}
+	})
+
+	t.Run("child_cancelled_on_return", func(t *testing.T) {
+		t.Parallel()
+
+		var child context.Context
+
+		f := Async(t.Context(), func(ctx context.Context) struct{} {
+			child = ctx
+			return struct{}{}
+		})
+
+		f.Await()
+
+		select {
+		case <-child.Done():
+		case <-time.After(time.Second):
+			t.Fatal("child context not cancelled after the procedure returned")
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package builtin
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samborkent/cog"
+)
+
+// The formatters below are called by the code generated for @print and
+// @format, which picks one for the static type of each value. Composite values
+// take the formatters of their elements.
+
+type (
+	signed interface {
+		~int8 | ~int16 | ~int32 | ~int64
+	}
+	unsigned interface {
+		~uint8 | ~uint16 | ~uint32 | ~uint64
+	}
+)
+
+// Any formats a value whose type is only known at run time, such as a type
+// parameter or a Go type.
+func Any(v any) string {
+	return fmt.Sprint(v)
+}
+
+func Bool[T ~bool](v T) string {
+	return strconv.FormatBool(bool(v))
+}
+
+func Int[T signed](v T) string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+// IntVerb formats v with the verb d, b, o, x or X.
+func IntVerb[T signed](v T, verb byte) string {
+	return upper(strconv.FormatInt(int64(v), base(verb)), verb)
+}
+
+func Uint[T unsigned](v T) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+// UintVerb formats v with the verb d, b, o, x or X.
+func UintVerb[T unsigned](v T, verb byte) string {
+	return upper(strconv.FormatUint(uint64(v), base(verb)), verb)
+}
+
+func Int128(v cog.Int128) string {
+	return Int128Verb(v, 'd')
+}
+
+// Int128Verb formats v with the verb d, b, o, x or X.
+func Int128Verb(v cog.Int128, verb byte) string {
+	if v.IsNeg() {
+		// The negation of the minimum overflows, but its bits are the
+		// magnitude as a Uint128.
+		return "-" + Uint128Verb(cog.Int128ToUint128(v.Neg()), verb)
+	}
+
+	return Uint128Verb(cog.Int128ToUint128(v), verb)
+}
+
+func Uint128(v cog.Uint128) string {
+	return v.String()
+}
+
+// Uint128Verb formats v with the verb d, b, o, x or X.
+func Uint128Verb(v cog.Uint128, verb byte) string {
+	return upper(v.Big().Text(base(verb)), verb)
+}
+
+func Float16(v cog.Float16) string {
+	return strconv.FormatFloat(float64(v.Float32()), 'g', -1, 32)
+}
+
+func Float32[T ~float32](v T) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+func Float64[T ~float64](v T) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 64)
+}
+
+// FloatVerb formats v, a float of bitSize bits, with the verb e, E, f, g or G.
+// A precision of -1 uses the fewest digits that represent v exactly.
+func FloatVerb(v float64, verb byte, precision, bitSize int) string {
+	return strconv.FormatFloat(v, verb, precision, bitSize)
+}
+
+func Complex64(v complex64) string {
+	return strconv.FormatComplex(complex128(v), 'g', -1, 64)
+}
+
+func Complex128(v complex128) string {
+	return strconv.FormatComplex(v, 'g', -1, 128)
+}
+
+// Quote formats a string as a Cog string literal.
+func Quote[T ~string | ~[]byte](v T) string {
+	return strconv.Quote(string(v))
+}
+
+// Option formats the value of o, or none if o is not set.
+func Option[T any](o cog.Option[T], value func(T) string) string {
+	if !o.Set {
+		return "none"
+	}
+
+	return value(o.Value)
+}
+
+// Result formats the value of r, or its error after a !.
+func Result[T, E any](r cog.Result[T, E], value func(T) string, err func(E) string) string {
+	if r.IsError {
+		return "!" + err(r.Error)
+	}
+
+	return value(r.Value)
+}
+
+// Either formats the side of e that is set.
+func Either[L, R any](e cog.Either[L, R], left func(L) string, right func(R) string) string {
+	if e.IsRight {
+		return right(e.Right)
+	}
+
+	return left(e.Left)
+}
+
+// Ref formats the value p points to after a &, or nil.
+func Ref[T any](p *T, value func(T) string) string {
+	if p == nil {
+		return "nil"
+	}
+
+	return "&" + value(*p)
+}
+
+// Slice formats the elements of a slice or array: [1, 2, 3].
+func Slice[T any](s []T, elem func(T) string) string {
+	elems := make([]string, len(s))
+
+	for i := range s {
+		elems[i] = elem(s[i])
+	}
+
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// Set formats s as a Cog set literal, with its elements in sorted order.
+func Set[S ~map[T]struct{}, T comparable](s S, elem func(T) string) string {
+	elems := make([]string, 0, len(s))
+
+	for v := range s {
+		elems = append(elems, elem(v))
+	}
+
+	slices.Sort(elems)
+
+	return "{" + strings.Join(elems, ", ") + "}"
+}
+
+// Map formats m as a Cog map literal, with its entries in sorted order.
+func Map[M ~map[K]V, K comparable, V any](m M, key func(K) string, value func(V) string) string {
+	entries := make([]string, 0, len(m))
+
+	for k, v := range m {
+		entries = append(entries, key(k)+": "+value(v))
+	}
+
+	slices.Sort(entries)
+
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// Pad pads s with spaces, or zeros after the sign, to width characters. The
+// padding goes after s if left is set, and before it otherwise.
+func Pad(s string, width int, left, zero bool) string {
+	n := width - utf8.RuneCountInString(s)
+	if n <= 0 {
+		return s
+	}
+
+	switch {
+	case left:
+		return s + strings.Repeat(" ", n)
+	case zero:
+		sign := ""
+		if s != "" && (s[0] == '-' || s[0] == '+') {
+			sign, s = s[:1], s[1:]
+		}
+
+		return sign + strings.Repeat("0", n) + s
+	default:
+		return strings.Repeat(" ", n) + s
+	}
+}
+
+// base returns the number base of an integer verb.
+func base(verb byte) int {
+	switch verb {
+	case 'b':
+		return 2
+	case 'o':
+		return 8
+	case 'x', 'X':
+		return 16
+	default:
+		return 10
+	}
+}
+
+// upper returns the digits of the X verb in upper case.
+func upper(s string, verb byte) string {
+	if verb == 'X' {
+		return strings.ToUpper(s)
+	}
+
+	return s
+}
@@ -0,0 +1,35 @@
+package builtin
+
+import "iter"
+
+// Range returns the integers from start up to end, step apart: start..end, or
+// start..=end if inclusive. A negative step counts down from start to end.
+func Range[T signed | unsigned](start, end, step T, inclusive bool) iter.Seq[T] {
+	if step == 0 {
+		panic("range: step is zero")
+	}
+
+	var zero T
+
+	return func(yield func(T) bool) {
+		for i := start; ; {
+			if step > zero && (i > end || i == end && !inclusive) ||
+				step < zero && (i < end || i == end && !inclusive) {
+				return
+			}
+
+			if !yield(i) {
+				return
+			}
+
+			next := i + step
+
+			// Stop at the end of the integer type, instead of wrapping around.
+			if step > zero && next < i || step < zero && next > i {
+				return
+			}
+
+			i = next
+		}
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"go/format"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/project"
+	"github.com/samborkent/cog/internal/remap"
+	"github.com/samborkent/cog/internal/transpiler"
+)
+
+// exportImplDir is the directory, relative to the output module, where the
+// transpiled packages are written in export mode.
+const exportImplDir = "internal/impl"
+
+// requiredModDir is the directory, relative to the output module, where the
+// required Cog modules are written, each as its own Go module.
+const requiredModDir = "_cogmod"
+
+// compiler transpiles a Cog project or script to a Go module in outputDir.
+type compiler struct {
+	debug           bool
+	replaceLocalCog bool
+	format          diagFormat // output format of diagnostics
+	export          bool       // generate a Go facade for the exported symbols
+	tests           bool       // include _test.cog files and generate a test harness
+	outputDir       string     // directory of the generated Go module
+	modulePath      string     // Go module path, defaults to the package or script name
+
+	imports   map[string]struct{}       // Go import paths used by the generated code
+	instances *transpiler.Instances     // instances of the generic functions of the program
+	names     map[string]string         // Go names of the Cog globals that differ
+	output    map[*project.Package]bool // imported packages that were written
+	modules   map[*project.Module]bool  // required Cog modules that were written
+}
+
+// compiledModule describes the Go module written by the compiler.
+type compiledModule struct {
+	name    string // script or entry package name
+	module  string // Go module path
+	mainPkg string // Go package of the main program, empty if there is none
+	tests   int    // number of Cog tests in the harness
+}
+
+// compile transpiles the .cog/.cogs file or package directory at input, and
+// writes the generated Go module.
+func (c *compiler) compile(ctx context.Context, input string) (*compiledModule, error) {
+	mod, err := c.transpile(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.writeGoMod(mod.module); err != nil {
+		return nil, err
+	}
+
+	return mod, nil
+}
+
+// transpile writes the Go files transpiled from the .cog/.cogs file or package
+// directory at input, without the go.mod of the generated module.
+func (c *compiler) transpile(ctx context.Context, input string) (*compiledModule, error) {
+	c.imports = make(map[string]struct{})
+	c.names = make(map[string]string)
+	c.output = make(map[*project.Package]bool)
+	c.modules = make(map[*project.Module]bool)
+
+	loader := &project.Loader{Debug: c.debug, Tests: c.tests}
+
+	files, err := loader.Files(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(c.outputDir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating output dir: %w", err)
+	}
+
+	// Script mode: single .cogs file.
+	if strings.HasSuffix(files[0], ".cogs") {
+		if c.export {
+			return nil, errors.New("scripts cannot be exported")
+		}
+
+		scriptName := strings.TrimSuffix(filepath.Base(files[0]), ".cogs")
+
+		// The module path in cog.mod is the default Go module name. Errors in
+		// cog.mod are reported by the loader.
+		goModuleName := scriptName
+		if mod, err := project.FindModule(filepath.Dir(files[0])); err == nil && mod != nil {
+			goModuleName = mod.Path
+		}
+
+		if c.modulePath != "" {
+			goModuleName = c.modulePath
+		}
+
+		script, err := loader.LoadScript(ctx, filepath.Dir(files[0]), files[0])
+		if err != nil {
+			return nil, err
+		}
+
+		c.collectInstances(goModuleName, nil, []*project.Package{script})
+
+		if err := c.compileScript(goModuleName, script); err != nil {
+			return nil, err
+		}
+
+		return &compiledModule{name: scriptName, module: goModuleName, mainPkg: "./cmd/" + scriptName}, nil
+	}
+
+	// Determine project root: the directory of the entry package.
+	// Import paths are resolved relative to this root.
+	return c.compileProject(ctx, loader, filepath.Dir(files[0]), files)
+}
+
+// compileScript compiles a single loaded .cogs script file.
+// Script files have no package declaration; the transpiled output is placed
+// in cmd/{scriptName}/ with package main and a func main() wrapping the body.
+func (c *compiler) compileScript(goModuleName string, pkg *project.Package) error {
+	// Transpile imported packages first.
+	if err := c.transpileImports(goModuleName, pkg); err != nil {
+		return err
+	}
+
+	// Transpile the script file.
+	f := pkg.Files[0].AST
+	scriptName := scriptName(pkg)
+	outDir := filepath.Join(c.outputDir, "cmd", scriptName)
+
+	if err := setLineFiles(outDir, f); err != nil {
+		return err
+	}
+
+	remap.Names(pkg.Symbols, c.names)
+
+	t := transpiler.NewTranspilerWithModule(goModuleName, []*ast.File{f},
+		transpiler.WithImportPaths(c.goImportPaths(goModuleName, pkg)),
+		transpiler.WithInstances(c.instances, path.Join(goModuleName, "cmd", scriptName)))
+
+	gofile, err := t.TranspileScript()
+	if err != nil {
+		return sourcePaths(err, []*ast.File{f}, pkg.Files)
+	}
+
+	return c.writeGoFile(t, outDir, "main.go", gofile)
+}
+
+// compileProject compiles the entry package and all its imported packages,
+// and any .cogs script files next to it.
+func (c *compiler) compileProject(ctx context.Context, loader *project.Loader, projectRoot string, entryFiles []string) (*compiledModule, error) {
+	entryPkg, err := loader.Load(ctx, projectRoot, entryFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Go module name for the transpiled project matches the module path in
+	// cog.mod, or else the entry package name, unless set explicitly.
+	goModuleName := entryPkg.Name
+	if entryPkg.Module != nil {
+		goModuleName = entryPkg.Module.Path
+	}
+
+	if c.modulePath != "" {
+		goModuleName = c.modulePath
+	}
+
+	if c.export && entryPkg.Name == "main" {
+		return nil, errors.New("package main cannot be exported")
+	}
+
+	// Load any .cogs script files in the project root, which are compiled
+	// along with the project.
+	var scripts []*project.Package
+
+	if !c.export && !c.tests {
+		for _, sf := range project.Scripts(projectRoot) {
+			script, err := loader.LoadScript(ctx, projectRoot, sf)
+			if err != nil {
+				return nil, err
+			}
+
+			scripts = append(scripts, script)
+		}
+	}
+
+	c.collectInstances(goModuleName, entryPkg, scripts)
+
+	// Transpile and output imported packages first.
+	if err := c.transpileImports(goModuleName, entryPkg); err != nil {
+		return nil, err
+	}
+
+	if err := c.transpileAndOutput(goModuleName, entryPkg); err != nil {
+		return nil, err
+	}
+
+	module := &compiledModule{name: entryPkg.Name, module: goModuleName}
+
+	if c.tests {
+		module.tests, err = c.writeTestHarness(entryPkg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, hasMain := entryPkg.Symbols.Resolve("main"); hasMain {
+		module.mainPkg = "."
+	}
+
+	for _, script := range scripts {
+		if err := c.compileScript(goModuleName, script); err != nil {
+			return nil, err
+		}
+	}
+
+	return module, nil
+}
+
+// transpileImports transpiles and outputs the packages imported by pkg,
+// directly or indirectly. Packages imported by several packages, or also by a
+// script, are written once.
+func (c *compiler) transpileImports(goModuleName string, pkg *project.Package) error {
+	for _, dep := range pkg.Deps() {
+		if c.output[dep] {
+			continue
+		}
+
+		c.output[dep] = true
+
+		if err := c.transpileAndOutput(goModuleName, dep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectInstances collects the instances of the generic functions of the
+// program, of the entry package, which is nil for a script, the scripts, and
+// the packages they import.
+func (c *compiler) collectInstances(goModuleName string, entryPkg *project.Package, scripts []*project.Package) {
+	c.instances = transpiler.NewInstances()
+
+	var pkgs []*project.Package
+
+	if entryPkg != nil {
+		pkgs = append(entryPkg.Deps(), entryPkg)
+	}
+
+	for _, script := range scripts {
+		pkgs = append(pkgs, script.Deps()...)
+	}
+
+	seen := make(map[*project.Package]bool, len(pkgs))
+
+	for _, pkg := range pkgs {
+		if seen[pkg] {
+			continue
+		}
+
+		seen[pkg] = true
+
+		astFiles := make([]*ast.File, len(pkg.Files))
+		for i, file := range pkg.Files {
+			astFiles[i] = file.AST
+		}
+
+		c.instances.Add(c.goPackagePath(goModuleName, pkg), astFiles, c.goImportPaths(goModuleName, pkg))
+	}
+
+	for _, script := range scripts {
+		c.instances.AddScript(path.Join(goModuleName, "cmd", scriptName(script)), script.Files[0].AST,
+			c.goImportPaths(goModuleName, script))
+	}
+
+	c.instances.Collect()
+}
+
+// scriptName returns the name of the loaded script pkg.
+func scriptName(pkg *project.Package) string {
+	return strings.TrimSuffix(filepath.Base(pkg.Files[0].Path), ".cogs")
+}
+
+// implModule returns the Go module path and output directory of the module
+// the transpiled package is written to. The packages of a required Cog module
+// are written to their own Go module below requiredModDir. In export mode the
+// other packages are written below the exportImplDir directory.
+func (c *compiler) implModule(goModuleName string, pkg *project.Package) (string, string) {
+	switch {
+	case isRequired(pkg):
+		return pkg.Module.Path, filepath.Join(c.outputDir, requiredModDir, filepath.FromSlash(pkg.Module.Path)+"@"+pkg.Module.Version)
+	case c.export:
+		return path.Join(goModuleName, exportImplDir), filepath.Join(c.outputDir, filepath.FromSlash(exportImplDir))
+	default:
+		return goModuleName, c.outputDir
+	}
+}
+
+// goImportPaths returns the Go import paths of the Cog packages imported by
+// pkg, by Cog import path.
+func (c *compiler) goImportPaths(goModuleName string, pkg *project.Package) map[string]string {
+	paths := make(map[string]string, len(pkg.Imports))
+
+	for importPath, imported := range pkg.Imports {
+		paths[importPath] = c.goPackagePath(goModuleName, imported)
+	}
+
+	return paths
+}
+
+// goPackagePath returns the Go import path of the transpiled pkg.
+func (c *compiler) goPackagePath(goModuleName string, pkg *project.Package) string {
+	implModule, _ := c.implModule(goModuleName, pkg)
+	return path.Join(implModule, pkg.ImportPath)
+}
+
+// isRequired reports whether pkg belongs to a module required in cog.mod.
+func isRequired(pkg *project.Package) bool {
+	return pkg.Module != nil && pkg.Module.Version != ""
+}
+
+// transpileAndOutput transpiles a single package and writes its Go files.
+// In export mode the package is written below the exportImplDir directory, and
+// a Go facade for its exported symbols is written in its place.
+func (c *compiler) transpileAndOutput(goModuleName string, pkg *project.Package) error {
+	implModule, implRoot := c.implModule(goModuleName, pkg)
+
+	if isRequired(pkg) {
+		c.modules[pkg.Module] = true
+	}
+
+	outDir := filepath.Join(implRoot, filepath.FromSlash(pkg.ImportPath))
+
+	astFiles := make([]*ast.File, len(pkg.Files))
+	for i, file := range pkg.Files {
+		astFiles[i] = file.AST
+	}
+
+	if err := setLineFiles(outDir, astFiles...); err != nil {
+		return err
+	}
+
+	remap.Names(pkg.Symbols, c.names)
+
+	t := transpiler.NewTranspilerWithModule(implModule, astFiles,
+		transpiler.WithImportPaths(c.goImportPaths(goModuleName, pkg)),
+		transpiler.WithInstances(c.instances, c.goPackagePath(goModuleName, pkg)))
+
+	gofiles, err := t.TranspileFiles()
+	if err != nil {
+		return sourcePaths(err, astFiles, pkg.Files)
+	}
+
+	for i, file := range pkg.Files {
+		outName := strings.TrimSuffix(filepath.Base(file.Path), ".cog") + ".go"
+
+		if err := c.writeGoFile(t, outDir, outName, gofiles[i]); err != nil {
+			return err
+		}
+	}
+
+	// Required modules are not part of the exported API.
+	if !c.export || isRequired(pkg) {
+		return nil
+	}
+
+	// Write the Go facade, wrapping the transpiled package.
+	facade, err := t.TranspileExport(path.Join(implModule, pkg.ImportPath))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := t.Print(&buf, facade); err != nil {
+		return fmt.Errorf("printing output: %w", err)
+	}
+
+	// The facade is read by Go developers, so it is formatted like Go code.
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting output: %w", err)
+	}
+
+	c.addImports(facade)
+
+	outDir = filepath.Join(c.outputDir, filepath.FromSlash(pkg.ImportPath))
+
+	if err := os.MkdirAll(outDir, 0o700); err != nil {
+		return fmt.Errorf("creating output dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outDir, pkg.Name+".go"), src, 0o600); err != nil {
+		return fmt.Errorf("writing output file: %w", err)
+	}
+
+	return nil
+}
+
+// setLineFiles makes the file names used in //line directives relative to
+// dir, the directory of the generated files, against which Go resolves them.
+func setLineFiles(dir string, files ...*ast.File) error {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving output dir: %w", err)
+	}
+
+	for _, f := range files {
+		absFile, err := filepath.Abs(f.Name)
+		if err != nil {
+			return fmt.Errorf("resolving %q: %w", f.Name, err)
+		}
+
+		rel, err := filepath.Rel(absDir, absFile)
+		if err != nil {
+			return fmt.Errorf("resolving %q: %w", f.Name, err)
+		}
+
+		f.Name = filepath.ToSlash(rel)
+	}
+
+	return nil
+}
+
+// writeGoFile prints a generated Go file to dir/name.
+func (c *compiler) writeGoFile(t *transpiler.Transpiler, dir, name string, gofile *goast.File) error {
+	c.addImports(gofile)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("creating output dir: %w", err)
+	}
+
+	outFile, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+
+	if err := t.Print(outFile, gofile); err != nil {
+		_ = outFile.Close()
+
+		return fmt.Errorf("printing output: %w", err)
+	}
+
+	return outFile.Close()
+}
+
+// addImports records the import paths of a generated Go file, which decide
+// whether the module dependencies have to be resolved again.
+func (c *compiler) addImports(gofile *goast.File) {
+	for _, imp := range gofile.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err == nil {
+			c.imports[importPath] = struct{}{}
+		}
+	}
+}
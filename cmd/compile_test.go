@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestBuildExample transpiles the example project and builds the generated
+// module, against the Cog runtime of this checkout.
+func TestBuildExample(t *testing.T) {
+	t.Parallel()
+
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatalf("resolving project root: %v", err)
+	}
+
+	c := &compiler{outputDir: t.TempDir(), format: formatText}
+
+	mod, err := c.transpile(t.Context(), filepath.Join(root, "example"))
+	if err != nil {
+		t.Fatalf("transpiling example: %v", err)
+	}
+
+	// The go.mod is written by hand, as `go mod tidy` would resolve the
+	// runtime from the network.
+	gomod := fmt.Sprintf(`module %s
+
+go %s
+
+require (
+	%s v0.0.0
+	github.com/samborkent/adaptive-gc v0.0.0
+	github.com/pbnjay/memory v0.0.0
+)
+
+replace (
+	%s => %s
+	github.com/samborkent/adaptive-gc => %s
+	github.com/pbnjay/memory => %s
+)
+`, mod.module, goVersion, cogModule, cogModule, root, filepath.Join(root, "..", "adaptive-gc"), moduleDir(t, "github.com/pbnjay/memory"))
+
+	if err := os.WriteFile(filepath.Join(c.outputDir, "go.mod"), []byte(gomod), 0o600); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	build := exec.CommandContext(t.Context(), "go", "build", "-o", os.DevNull, "./...")
+	build.Dir = c.outputDir
+	build.Env = goEnv(os.Environ())
+
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building example: %v\n%s", err, out)
+	}
+}
+
+// moduleDir returns the directory of the latest version of module in the Go
+// module cache.
+func moduleDir(t *testing.T, module string) string {
+	t.Helper()
+
+	out, err := exec.Command("go", "mod", "download", "-json", module+"@latest").Output()
+	if err != nil {
+		t.Fatalf("go mod download %s: %v", module, err)
+	}
+
+	var info struct{ Dir string }
+
+	if err := json.Unmarshal(out, &info); err != nil {
+		t.Fatalf("decoding go mod download output: %v", err)
+	}
+
+	return info.Dir
+}
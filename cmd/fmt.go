@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cogfmt "github.com/samborkent/cog/internal/format"
+	"github.com/samborkent/cog/internal/project"
+)
+
+// runFmt rewrites Cog source files in their canonical layout.
+func runFmt(ctx context.Context, args []string) error {
+	c := new(compiler)
+	fs := newFlagSet("fmt", "[path]", c)
+
+	var list bool
+
+	fs.BoolVar(&list, "l", false, "List the files whose layout differs, instead of rewriting them.")
+
+	input, rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "cog fmt: unexpected arguments %q\n", rest)
+		fs.Usage()
+
+		return errUsage
+	}
+
+	files, err := c.loadFormatted(ctx, input)
+	if err != nil {
+		return c.report(err)
+	}
+
+	for _, f := range files {
+		src, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+
+		out, err := cogfmt.Source(ctx, f.AST, src)
+		if err != nil {
+			return err
+		}
+
+		if bytes.Equal(src, out) {
+			continue
+		}
+
+		if list {
+			fmt.Fprintln(os.Stdout, f.Path)
+			continue
+		}
+
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(f.Path, out, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing formatted file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadFormatted parses the files to format at input: a .cog file, a .cogs
+// script, or the package and scripts in a directory. A file is parsed with
+// the other files of its package, but only the files at input are returned.
+func (c *compiler) loadFormatted(ctx context.Context, input string) ([]*project.File, error) {
+	loader := &project.Loader{Debug: c.debug, Tests: true}
+
+	input = filepath.Clean(input)
+
+	if strings.HasSuffix(input, ".cogs") {
+		pkg, err := loader.LoadScript(ctx, filepath.Dir(input), input)
+		if err != nil {
+			return nil, err
+		}
+
+		return pkg.Files, nil
+	}
+
+	dir, only := input, ""
+
+	if strings.HasSuffix(input, ".cog") {
+		dir, only = filepath.Dir(input), input
+	}
+
+	var files []*project.File
+
+	paths, err := loader.Files(dir)
+	if err == nil {
+		pkg, err := loader.Load(ctx, dir, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range pkg.Files {
+			if only == "" || f.Path == only {
+				files = append(files, f)
+			}
+		}
+	}
+
+	if only != "" {
+		if len(files) == 0 {
+			return nil, fmt.Errorf("cannot format %q: %w", input, err)
+		}
+
+		return files, nil
+	}
+
+	scripts := project.Scripts(dir)
+	if err != nil && len(scripts) == 0 {
+		return nil, err
+	}
+
+	var errs []error
+
+	for _, script := range scripts {
+		pkg, err := loader.LoadScript(ctx, dir, script)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		files = append(files, pkg.Files...)
+	}
+
+	return files, errors.Join(errs...)
+}
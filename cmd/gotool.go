@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/samborkent/cog/internal/project"
+	"github.com/samborkent/cog/internal/remap"
+)
+
+const (
+	// goVersion is the Go version declared in generated go.mod files.
+	goVersion = "1.26.2"
+
+	// cogModule is the module path of the Cog runtime imported by generated code.
+	cogModule = "github.com/samborkent/cog"
+
+	// tidyStamp records the dependencies go.mod was last tidied for, so that
+	// `go mod tidy` only runs when they change.
+	tidyStamp = ".cog-tidy"
+)
+
+// runGo runs a go command in the output directory, with the arenas experiment
+// enabled and the standard streams attached. Diagnostics and stack traces in
+// its output are rewritten in terms of the Cog source.
+func (c *compiler) runGo(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = c.outputDir
+	cmd.Env = goEnv(os.Environ())
+
+	return c.runRemapped(cmd)
+}
+
+// runRemapped runs cmd with the standard streams attached, rewriting its
+// output in terms of the Cog source.
+func (c *compiler) runRemapped(cmd *exec.Cmd) error {
+	mapper := remap.New(c.outputDir, c.names)
+	stdout, stderr := mapper.Writer(os.Stdout), mapper.Writer(os.Stderr)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	if closeErr := errors.Join(stdout.Close(), stderr.Close()); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// runProgram runs a compiled program with the standard streams attached. Only
+// its stderr is rewritten, so that panics are reported in terms of the Cog
+// source, while its stdout is passed on as is, and unbuffered.
+func (c *compiler) runProgram(cmd *exec.Cmd) error {
+	stderr := remap.New(c.outputDir, c.names).Writer(os.Stderr)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+
+	if closeErr := stderr.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// goEnv returns env with the arenas experiment added to GOEXPERIMENT, which
+// the Cog runtime requires.
+func goEnv(env []string) []string {
+	env = slices.Clone(env)
+
+	for i, kv := range env {
+		value, ok := strings.CutPrefix(kv, "GOEXPERIMENT=")
+		if !ok {
+			continue
+		}
+
+		if slices.Contains(strings.Split(value, ","), "arenas") {
+			return env
+		}
+
+		if value == "" {
+			env[i] = "GOEXPERIMENT=arenas"
+		} else {
+			env[i] = "GOEXPERIMENT=" + value + ",arenas"
+		}
+
+		return env
+	}
+
+	return append(env, "GOEXPERIMENT=arenas")
+}
+
+// cacheDir returns the directory to which the input is transpiled by the
+// build, run and test commands. Every input gets its own directory, so that
+// resolved module dependencies are reused between runs.
+func cacheDir(input string) (string, error) {
+	root, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating cache dir: %w", err)
+	}
+
+	abs, err := filepath.Abs(input)
+	if err != nil {
+		return "", fmt.Errorf("resolving %q: %w", input, err)
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+
+	return filepath.Join(root, "cog", hex.EncodeToString(sum[:8])), nil
+}
+
+// cleanGoFiles removes the Go files from a previous run, so that Cog files
+// deleted since do not linger in the build.
+func cleanGoFiles(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+
+			return err
+		}
+
+		if !d.IsDir() && strings.HasSuffix(path, ".go") {
+			return os.Remove(path)
+		}
+
+		return nil
+	})
+}
+
+// writeGoMod writes the go.mod of the generated module and resolves its
+// dependencies. Both are skipped when the module name and imported packages
+// are unchanged since the last run.
+func (c *compiler) writeGoMod(goModuleName string) error {
+	gomod := fmt.Sprintf("module %s\n\ngo %s\n", goModuleName, goVersion)
+
+	if c.replaceLocalCog {
+		cogDir, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("locating local cog module: %w", err)
+		}
+
+		gomod += fmt.Sprintf("\nreplace %s => %s\n", cogModule, cogDir)
+	}
+
+	// Required Cog modules are written as Go modules below requiredModDir,
+	// and required at the version selected from cog.mod.
+	modules := slices.SortedFunc(maps.Keys(c.modules), func(a, b *project.Module) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	for _, mod := range modules {
+		dir := path.Join(requiredModDir, mod.Path+"@"+mod.Version)
+
+		gomod += fmt.Sprintf("\nrequire %s %s\n\nreplace %s => ./%s\n", mod.Path, mod.Version, mod.Path, dir)
+
+		modFile := filepath.Join(c.outputDir, filepath.FromSlash(dir), "go.mod")
+
+		if err := os.MkdirAll(filepath.Dir(modFile), 0o700); err != nil {
+			return fmt.Errorf("creating output dir: %w", err)
+		}
+
+		if err := os.WriteFile(modFile, []byte(fmt.Sprintf("module %s\n\ngo %s\n", mod.Path, goVersion)), 0o600); err != nil {
+			return fmt.Errorf("writing go.mod of module %s: %w", mod.Path, err)
+		}
+	}
+
+	imports := make([]string, 0, len(c.imports))
+	for imp := range c.imports {
+		imports = append(imports, imp)
+	}
+
+	slices.Sort(imports)
+
+	sum := sha256.Sum256([]byte(gomod + strings.Join(imports, "\n")))
+	stamp := hex.EncodeToString(sum[:])
+
+	stampPath := filepath.Join(c.outputDir, tidyStamp)
+
+	if prev, err := os.ReadFile(stampPath); err == nil && string(prev) == stamp {
+		if _, err := os.Stat(filepath.Join(c.outputDir, "go.mod")); err == nil {
+			return nil
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(c.outputDir, "go.mod"), []byte(gomod), 0o600); err != nil {
+		return fmt.Errorf("writing go.mod: %w", err)
+	}
+
+	// Only declare the module and Go version; `go mod tidy` resolves all dependencies.
+	tidy := exec.Command("go", "mod", "tidy")
+	tidy.Dir = c.outputDir
+	tidy.Env = goEnv(os.Environ())
+
+	// The exit code of tidy is not passed on, as its output is captured.
+	if out, err := tidy.CombinedOutput(); err != nil {
+		return fmt.Errorf("go mod tidy: %v\n%s", err, out)
+	}
+
+	if err := os.WriteFile(stampPath, []byte(stamp), 0o600); err != nil {
+		return fmt.Errorf("writing %s: %w", tidyStamp, err)
+	}
+
+	return nil
+}
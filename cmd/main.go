@@ -2,556 +2,346 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
 
-	"github.com/samborkent/cog/internal/ast"
-	"github.com/samborkent/cog/internal/lexer"
-	"github.com/samborkent/cog/internal/parser"
-	"github.com/samborkent/cog/internal/tokens"
-	"github.com/samborkent/cog/internal/transpiler"
+	"github.com/samborkent/cog/internal/lsp"
 )
 
-var (
-	fileName        string
-	debug           bool
-	write           bool
-	replaceLocalCog bool
-)
+const usage = `Cog compiles Cog source code to Go.
 
-func main() {
-	flag.StringVar(&fileName, "file", "", "Name of .cog/.cogs file or directory containing .cog files.")
-	flag.BoolVar(&debug, "debug", false, "Enable debug parser mode.")
-	flag.BoolVar(&write, "write", false, "Write to file.")
-	flag.BoolVar(&replaceLocalCog, "replace-local-cog", false, "Add replace directive for local cog module in generated go.mod.")
-	flag.Parse()
+Usage:
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
-	defer stop()
+	cog <command> [flags] [path] [arguments]
 
-	if fileName == "" {
-		panic("missing file or directory name")
-	}
+The path is a .cog file, a .cogs script, or a package directory, and defaults
+to the current directory.
 
-	files := discoverFiles(fileName)
+Commands:
 
-	// Script mode: single .cogs file.
-	if strings.HasSuffix(files[0], ".cogs") {
-		projectRoot := filepath.Dir(files[0])
-		runScript(ctx, projectRoot, files[0], "")
+	build      compile a program to a binary; arguments are passed to go build
+	fmt        rewrite source files in the canonical layout
+	lsp        serve the language server protocol on stdin and stdout
+	run        compile and run a program; arguments are passed to the program
+	test       run the tests in _test.cog files; arguments are passed to go test
+	transpile  write the generated Go module to a directory
+	vet        report suspicious constructs; arguments are passed to go vet
 
-		return
-	}
+Run 'cog <command> -h' for the flags of a command.
+`
 
-	// Determine project root: the directory of the entry package.
-	// Import paths are resolved relative to this root.
-	projectRoot := filepath.Dir(files[0])
+// errUsage reports invalid command line arguments, after they have been
+// explained to the user.
+var errUsage = errors.New("usage")
 
-	runProject(ctx, projectRoot, files)
-}
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-// discoverFiles resolves the input flag to a sorted list of .cog file paths.
-// If a single .cog file is given, only that file is returned.
-// If a directory is given, all .cog files in that directory are returned.
-func discoverFiles(input string) []string {
-	input = filepath.Clean(input)
+	os.Exit(exitCode(run(ctx, os.Args[1:])))
+}
 
-	info, err := os.Stat(input)
-	if err != nil {
-		panic(fmt.Errorf("cannot access %q: %w", input, err))
+// exitCode maps the error of a command to the exit code of cog. A failing Go
+// tool or program passes on its own exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
 	}
 
-	// Single file: return just that file.
-	if !info.IsDir() {
-		if !strings.HasSuffix(input, ".cog") && !strings.HasSuffix(input, ".cogs") {
-			panic("invalid file extension, must be .cog or .cogs")
-		}
-
-		return []string{input}
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
 	}
 
-	// Directory: scan for all .cog files.
-	entries, err := os.ReadDir(input)
-	if err != nil {
-		panic(fmt.Errorf("reading directory %q: %w", input, err))
+	if errors.Is(err, errUsage) {
+		return 2
 	}
 
-	files := make([]string, 0, len(entries))
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".cog") {
-			continue
-		}
-
-		files = append(files, filepath.Join(input, entry.Name()))
+	if errors.Is(err, errReported) {
+		// The diagnostics have already been reported.
+		return 1
 	}
 
-	if len(files) == 0 {
-		panic(fmt.Errorf("no .cog files found in %q", input))
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		// The command has already reported the failure itself.
+		return exitErr.ExitCode()
 	}
 
-	sort.Strings(files)
+	fmt.Fprintf(os.Stderr, "cog: %v\n", err)
 
-	return files
+	return 1
 }
 
-// lexFile lexes a single .cog file and returns its token stream.
-func lexFile(ctx context.Context, path string, fileID uint16) ([]tokens.Token, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("opening %q: %w", path, err)
-	}
-
-	defer func() { _ = file.Close() }()
-
-	l := lexer.NewLexerWithFileID(file, fileID)
-
-	toks, err := l.Parse(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("lexing %q: %w", path, err)
+// run dispatches the command line to a subcommand.
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "build":
+		return runBuild(ctx, args[1:])
+	case "fmt":
+		return runFmt(ctx, args[1:])
+	case "lsp":
+		return runLSP(ctx, args[1:])
+	case "run":
+		return runRun(ctx, args[1:])
+	case "test":
+		return runTest(ctx, args[1:])
+	case "transpile":
+		return runTranspile(ctx, args[1:])
+	case "vet":
+		return runVet(ctx, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return nil
+	default:
+		fmt.Fprintf(os.Stderr, "cog %s: unknown command\n\n%s", args[0], usage)
+		return errUsage
 	}
-
-	return toks, nil
 }
 
-// runScript compiles a single .cogs script file.
-// Script files have no package declaration; the transpiled output is placed
-// in cmd/{scriptName}/ with package main and a func main() wrapping the body.
-// If goModuleName is empty, the script name is used and go.mod is written.
-func runScript(ctx context.Context, projectRoot string, scriptPath string, goModuleName string) {
-	toks, err := lexFile(ctx, scriptPath, 0)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
-	}
+// newFlagSet returns the flag set of a subcommand, with the flags shared by
+// all subcommands.
+func newFlagSet(name, args string, c *compiler) *flag.FlagSet {
+	fs := flag.NewFlagSet("cog "+name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
 
-	symbols := parser.NewSymbolTable()
-
-	p, err := parser.NewScriptParserWithSymbols(toks, symbols, debug)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cog %s [flags] %s\n\nFlags:\n", name, args)
+		fs.SetOutput(os.Stderr)
+		fs.PrintDefaults()
+		fs.SetOutput(io.Discard)
 	}
 
-	p.FindGlobals(ctx)
+	fs.BoolVar(&c.debug, "debug", false, "Enable debug parser mode.")
+	c.format = formatText
+	fs.Var(&c.format, "format", "Output `format` of diagnostics: text or json.")
+	fs.BoolVar(&c.replaceLocalCog, "replace-local-cog", false, "Use the cog module in the working directory as the runtime of the generated module.")
 
-	// Process imported packages.
-	importedPkgs := make(map[string]*compiledPackage)
+	return fs
+}
 
-	for _, imp := range symbols.CogImports() {
-		pkg := compileImportedPackage(ctx, projectRoot, imp.Path)
-		if pkg == nil {
-			fmt.Printf("failed to compile imported package %q\n", imp.Path)
-			return
+// parseFlags parses the flags of a subcommand, and splits the remaining
+// arguments in the input path and the arguments passed on.
+func parseFlags(fs *flag.FlagSet, args []string) (string, []string, error) {
+	// Parse already prints the usage on errors and -h.
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return "", nil, err
 		}
 
-		importedPkgs[imp.Path] = pkg
-		populateImportExports(imp, pkg.symbols)
-	}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", fs.Name(), err)
 
-	f, err := p.ParseOnly(ctx, scriptPath)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return "", nil, errUsage
 	}
 
-	// Determine script name from file name (without extension).
-	scriptName := strings.TrimSuffix(filepath.Base(scriptPath), ".cogs")
-
-	standalone := goModuleName == ""
-	if standalone {
-		goModuleName = scriptName
-	}
+	rest := fs.Args()
 
-	// Transpile imported packages first.
-	if write {
-		if err := os.MkdirAll("tmp", 0o700); err != nil {
-			panic(fmt.Errorf("creating temp dir: %w", err))
-		}
+	// The path is optional, any argument that is not a flag is taken as path.
+	if len(rest) == 0 || strings.HasPrefix(rest[0], "-") {
+		return ".", rest, nil
 	}
 
-	for _, pkg := range importedPkgs {
-		transpileAndOutput(goModuleName, pkg)
-	}
-
-	// Transpile the script file.
-	t := transpiler.NewTranspilerWithModule(goModuleName, []*ast.File{f})
+	return rest[0], rest[1:], nil
+}
 
-	gofile, err := t.TranspileScript()
+// compileCached transpiles the input to its directory in the cache.
+func compileCached(ctx context.Context, c *compiler, input string) (*compiledModule, error) {
+	dir, err := cacheDir(input)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return nil, err
 	}
 
-	outDir := filepath.Join("tmp", "cmd", scriptName)
-	outName := "main.go"
-
-	if write {
-		if err := os.MkdirAll(outDir, 0o700); err != nil {
-			panic(fmt.Errorf("creating output dir: %w", err))
-		}
-
-		outFile, err := os.Create(filepath.Join(outDir, outName))
-		if err != nil {
-			panic(fmt.Errorf("creating output file: %w", err))
-		}
-
-		if err := t.Print(outFile, gofile); err != nil {
-			_ = outFile.Close()
-
-			panic(fmt.Errorf("printing output: %w", err))
-		}
-
-		_ = outFile.Close()
-
-		// Only write go.mod when running as a standalone script (not part of a project).
-		if standalone {
-			gomod := fmt.Sprintf("module %s\n\ngo 1.26.2\n", goModuleName)
-			if replaceLocalCog {
-				gomod += "\nreplace github.com/samborkent/cog => ./..\n"
-			}
-
-			if err := os.WriteFile(filepath.Join("tmp", "go.mod"), []byte(gomod), 0o600); err != nil {
-				panic(fmt.Errorf("writing go.mod: %w", err))
-			}
-
-			tidy := exec.Command("go", "mod", "tidy")
-
-			tidy.Dir = "tmp"
-			if out, err := tidy.CombinedOutput(); err != nil {
-				panic(fmt.Errorf("go mod tidy: %s\n%w", out, err))
-			}
-		}
+	if err := cleanGoFiles(dir); err != nil {
+		return nil, fmt.Errorf("cleaning cache dir: %w", err)
 	}
 
-	// fmt.Printf("--- %s ---\n", filepath.Join(outDir, outName))
+	c.outputDir = dir
 
-	// if err := t.Print(os.Stdout, gofile); err != nil {
-	// 	panic(fmt.Errorf("printing output: %w", err))
-	// }
+	mod, err := c.compile(ctx, input)
+	if err != nil {
+		return nil, c.report(err)
+	}
 
-	// fmt.Println()
+	return mod, nil
 }
 
-// compiledPackage holds the output of compiling a single cog package.
-type compiledPackage struct {
-	importPath string      // relative import path (empty for the entry package)
-	pkgName    string      // Go package name
-	files      []lexedFile // original file paths
-	astFiles   []*ast.File // parsed ASTs
-	symbols    *parser.SymbolTable
-}
+// runBuild compiles a program to a binary.
+func runBuild(ctx context.Context, args []string) error {
+	c := new(compiler)
+	fs := newFlagSet("build", "[path] [go build flags]", c)
 
-type lexedFile struct {
-	path   string
-	tokens []tokens.Token
-	fileID uint16
-}
+	var output string
+
+	fs.StringVar(&output, "o", "", "Output file of the binary. Defaults to the package or script name.")
 
-// runProject compiles the entry package and all its imported packages.
-func runProject(ctx context.Context, projectRoot string, entryFiles []string) error {
-	// Step 1: Lex and validate the entry package.
-	entryLexed, entryPkgName, err := lexAndValidate(ctx, entryFiles)
+	input, goFlags, err := parseFlags(fs, args)
 	if err != nil {
 		return err
 	}
 
-	// The Go module name for the transpiled project matches the entry package name.
-	goModuleName := entryPkgName
-
-	// Step 2: FindGlobals on the entry package (discovers globals + import paths).
-	entrySymbols := parser.NewSymbolTable()
-
-	entryParsers := findGlobals(ctx, entryLexed, entrySymbols)
-	if entryParsers == nil {
-		return fmt.Errorf("failed to find globals")
+	mod, err := compileCached(ctx, c, input)
+	if err != nil {
+		return err
 	}
 
-	// A package that declares a main proc must be named "main".
-	if _, hasMain := entrySymbols.Resolve("main"); hasMain && entryPkgName != "main" {
-		return fmt.Errorf("package %q declares a main proc but is not named \"main\"", entryPkgName)
+	if mod.mainPkg == "" {
+		return fmt.Errorf("package %q has no main proc", mod.name)
 	}
 
-	// Step 3: Process imported packages.
-	importedPkgs := make(map[string]*compiledPackage) // key: import path
-
-	for _, imp := range entrySymbols.CogImports() {
-		pkg := compileImportedPackage(ctx, projectRoot, imp.Path)
-		if pkg == nil {
-			return fmt.Errorf("failed to compile imported package %q", imp.Path)
-		}
-
-		importedPkgs[imp.Path] = pkg
-
-		// Populate the entry package's import exports from the imported package.
-		populateImportExports(imp, pkg.symbols)
+	if output == "" {
+		output = mod.name
 	}
 
-	// Step 4: Full parse the entry package (now with import exports available).
-	entryASTs := make([]*ast.File, len(entryLexed))
-
-	for i, lf := range entryLexed {
-		f, err := entryParsers[i].ParseOnly(ctx, lf.path)
-		if err != nil {
-			fmt.Println(err.Error())
-		}
-
-		entryASTs[i] = f
-
-		if !write {
-			fmt.Printf("--- %s ---\n%s\n\n", lf.path, f)
+	// The go command runs in the cache dir. Like go build, an output ending
+	// in a slash is a directory to write the binary to.
+	isDir := strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator))
 
-			if err != nil {
-				return err
-			}
-		}
+	output, err = filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("resolving output file: %w", err)
 	}
 
-	// Step 5: Transpile and output.
-	entryPkg := &compiledPackage{
-		pkgName:  entryPkgName,
-		files:    entryLexed,
-		astFiles: entryASTs,
-		symbols:  entrySymbols,
+	if isDir {
+		output += string(filepath.Separator)
 	}
 
-	outputProject(goModuleName, entryPkg, importedPkgs)
+	goArgs := append([]string{"build", "-o", output}, goFlags...)
 
-	// Step 6: Discover and compile any .cogs script files in the project root.
-	scriptFiles := discoverScripts(projectRoot)
-	for _, sf := range scriptFiles {
-		runScript(ctx, projectRoot, sf, goModuleName)
-	}
-
-	return nil
+	return c.runGo(ctx, append(goArgs, mod.mainPkg)...)
 }
 
-// discoverScripts finds all .cogs files in the given directory.
-func discoverScripts(dir string) []string {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return nil
-	}
+// runRun compiles a program and runs it.
+func runRun(ctx context.Context, args []string) error {
+	c := new(compiler)
+	fs := newFlagSet("run", "[path] [program arguments]", c)
 
-	var scripts []string
+	var goFlags string
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".cogs") {
-			continue
-		}
+	fs.StringVar(&goFlags, "goflags", "", "Space separated flags passed to go build.")
 
-		scripts = append(scripts, filepath.Join(dir, entry.Name()))
+	input, progArgs, err := parseFlags(fs, args)
+	if err != nil {
+		return err
 	}
 
-	sort.Strings(scripts)
-
-	return scripts
-}
-
-// lexAndValidate lexes all files and validates they declare the same package.
-func lexAndValidate(ctx context.Context, files []string) ([]lexedFile, string, error) {
-	lexed := make([]lexedFile, 0, len(files))
-
-	for i, path := range files {
-		toks, err := lexFile(ctx, path, uint16(i))
-		if err != nil {
-			return nil, "", err
-		}
-
-		lexed = append(lexed, lexedFile{path: path, tokens: toks, fileID: uint16(i)})
+	mod, err := compileCached(ctx, c, input)
+	if err != nil {
+		return err
 	}
 
-	dirName := filepath.Base(filepath.Dir(files[0]))
-
-	var pkgName string
-
-	for _, lf := range lexed {
-		if len(lf.tokens) < 2 || lf.tokens[0].Type != tokens.Package {
-			return nil, "", fmt.Errorf("%s: missing package declaration", lf.path)
-		}
-
-		name := lf.tokens[1].Literal
-
-		if pkgName == "" {
-			pkgName = name
-
-			if pkgName != "main" && dirName != "." && pkgName != dirName {
-				return nil, "", fmt.Errorf("%s: package %q does not match directory name %q", lf.path, pkgName, dirName)
-			}
-		} else if name != pkgName {
-			return nil, "", fmt.Errorf("%s: declares package %q, but other files use %q", lf.path, name, pkgName)
-		}
+	if mod.mainPkg == "" {
+		return fmt.Errorf("package %q has no main proc", mod.name)
 	}
 
-	return lexed, pkgName, nil
-}
-
-// findGlobals runs FindGlobals on all files with a shared symbol table.
-func findGlobals(ctx context.Context, lexed []lexedFile, symbols *parser.SymbolTable) []*parser.Parser {
-	parsers := make([]*parser.Parser, len(lexed))
+	binary := filepath.Join(c.outputDir, "bin", mod.name)
 
-	for i, lf := range lexed {
-		p, err := parser.NewParserWithSymbols(lf.tokens, symbols, debug, lf.path)
-		if err != nil {
-			fmt.Println(err.Error())
-			return nil
-		}
+	goArgs := append([]string{"build", "-o", binary}, strings.Fields(goFlags)...)
 
-		p.FindGlobals(ctx)
-		parsers[i] = p
+	if err := c.runGo(ctx, append(goArgs, mod.mainPkg)...); err != nil {
+		return err
 	}
 
-	return parsers
+	// Panics of the program are reported at their position in the Cog source.
+	return c.runProgram(exec.CommandContext(ctx, binary, progArgs...))
 }
 
-// compileImportedPackage discovers, lexes, parses, and validates an imported package.
-func compileImportedPackage(ctx context.Context, projectRoot, importPath string) *compiledPackage {
-	pkgDir := filepath.Join(projectRoot, filepath.FromSlash(importPath))
-	files := discoverFiles(pkgDir)
+// runTest runs the tests of a package.
+func runTest(ctx context.Context, args []string) error {
+	c := &compiler{tests: true}
+	fs := newFlagSet("test", "[path] [go test flags]", c)
 
-	lexed, pkgName, err := lexAndValidate(ctx, files)
+	input, goFlags, err := parseFlags(fs, args)
 	if err != nil {
-		fmt.Println(err.Error())
-		return nil
+		return err
 	}
 
-	symbols := parser.NewSymbolTable()
-
-	parsers := findGlobals(ctx, lexed, symbols)
-	if parsers == nil {
-		return nil
+	mod, err := compileCached(ctx, c, input)
+	if err != nil {
+		return err
 	}
 
-	// Imported packages must not declare a main proc.
-	if sym, hasMain := symbols.Resolve("main"); hasMain {
-		ln, col := sym.Identifier.Token.Ln, sym.Identifier.Token.Col
-		fmt.Printf("%s:%d:%d: imported package %q must not declare a main proc\n",
-			files[0], ln, col, pkgName)
-
-		return nil
+	if mod.tests == 0 {
+		return errors.New("no tests found, tests are declared in _test.cog files")
 	}
 
-	astFiles := make([]*ast.File, len(lexed))
-	for i, lf := range lexed {
-		f, err := parsers[i].ParseOnly(ctx, lf.path)
-		if err != nil {
-			fmt.Println(err.Error())
-			return nil
-		}
+	goArgs := append([]string{"test"}, goFlags...)
 
-		astFiles[i] = f
-	}
-
-	return &compiledPackage{
-		importPath: importPath,
-		pkgName:    pkgName,
-		files:      lexed,
-		astFiles:   astFiles,
-		symbols:    symbols,
-	}
+	return c.runGo(ctx, append(goArgs, ".")...)
 }
 
-// populateImportExports fills a CogImport's Exports map from the imported package's symbol table.
-func populateImportExports(imp *parser.CogImport, symbols *parser.SymbolTable) {
-	symbols.ForEachGlobal(func(name string, sym parser.Symbol) {
-		if sym.Identifier.Exported {
-			imp.Exports[name] = sym
-		}
-	})
-}
+// runVet reports suspicious constructs in the generated Go code of a package,
+// at their position in the Cog source.
+func runVet(ctx context.Context, args []string) error {
+	c := &compiler{tests: true}
+	fs := newFlagSet("vet", "[path] [go vet flags]", c)
 
-// outputProject transpiles and writes all packages.
-func outputProject(goModuleName string, entry *compiledPackage, imported map[string]*compiledPackage) {
-	if write {
-		if err := os.MkdirAll("tmp", 0o700); err != nil {
-			panic(fmt.Errorf("creating temp dir: %w", err))
-		}
+	input, goFlags, err := parseFlags(fs, args)
+	if err != nil {
+		return err
 	}
 
-	// Transpile and output imported packages first.
-	for _, pkg := range imported {
-		transpileAndOutput(goModuleName, pkg)
+	if _, err := compileCached(ctx, c, input); err != nil {
+		return err
 	}
 
-	// Transpile and output the entry package.
-	transpileAndOutput(goModuleName, entry)
-
-	if write {
-		// Write go.mod so `go run .` works from tmp/.
-		// Only declare the module and Go version; `go mod tidy` resolves all dependencies.
-		gomod := fmt.Sprintf("module %s\n\ngo 1.26.2\n", goModuleName)
-		if replaceLocalCog {
-			gomod += "\nreplace github.com/samborkent/cog => ./..\n"
-		}
+	goArgs := append([]string{"vet"}, goFlags...)
 
-		if err := os.WriteFile(filepath.Join("tmp", "go.mod"), []byte(gomod), 0o600); err != nil {
-			panic(fmt.Errorf("writing go.mod: %w", err))
-		}
-
-		// Run go mod tidy to resolve all dependencies.
-		tidy := exec.Command("go", "mod", "tidy")
-
-		tidy.Dir = "tmp"
-
-		if out, err := tidy.CombinedOutput(); err != nil {
-			panic(fmt.Errorf("go mod tidy: %s\n%w", out, err))
-		}
-	}
+	return c.runGo(ctx, append(goArgs, "./...")...)
 }
 
-// transpileAndOutput transpiles a single package and writes/prints its Go files.
-func transpileAndOutput(goModuleName string, pkg *compiledPackage) {
-	t := transpiler.NewTranspilerWithModule(goModuleName, pkg.astFiles)
+// runTranspile writes the generated Go module.
+func runTranspile(ctx context.Context, args []string) error {
+	c := new(compiler)
+	fs := newFlagSet("transpile", "[path]", c)
+
+	fs.StringVar(&c.outputDir, "o", "tmp", "Output directory of the generated Go module.")
+	fs.StringVar(&c.modulePath, "module", "", "Go module path of the generated module. Defaults to the package name.")
+	fs.BoolVar(&c.export, "export", false, "Generate a Go facade for the exported symbols of the package.")
 
-	gofiles, err := t.TranspileFiles()
+	input, rest, err := parseFlags(fs, args)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		return err
 	}
 
-	// Determine output directory.
-	outDir := "tmp"
-	if pkg.importPath != "" {
-		outDir = filepath.Join("tmp", filepath.FromSlash(pkg.importPath))
-	}
+	if len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "cog transpile: unexpected arguments %q\n", rest)
+		fs.Usage()
 
-	if write {
-		if err := os.MkdirAll(outDir, 0o700); err != nil {
-			panic(fmt.Errorf("creating output dir: %w", err))
-		}
+		return errUsage
 	}
 
-	for i, lf := range pkg.files {
-		outName := filepath.Base(lf.path)
-		outName = strings.TrimSuffix(outName, ".cog") + ".go"
-
-		if write {
-			outFile, err := os.Create(filepath.Join(outDir, outName))
-			if err != nil {
-				panic(fmt.Errorf("creating output file: %w", err))
-			}
+	_, err = c.compile(ctx, input)
 
-			if err := t.Print(outFile, gofiles[i]); err != nil {
-				_ = outFile.Close()
+	return c.report(err)
+}
 
-				panic(fmt.Errorf("printing output: %w", err))
-			}
+// runLSP serves the language server protocol on stdin and stdout, for editors.
+func runLSP(ctx context.Context, args []string) error {
+	c := new(compiler)
+	fs := newFlagSet("lsp", "", c)
 
-			_ = outFile.Close()
-		} else {
-			// fmt.Printf("--- %s ---\n", filepath.Join(outDir, outName))
+	input, rest, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
 
-			// if err := t.Print(os.Stdout, gofiles[i]); err != nil {
-			// 	panic(fmt.Errorf("printing output: %w", err))
-			// }
+	if input != "." || len(rest) > 0 {
+		fmt.Fprintf(os.Stderr, "cog lsp: unexpected arguments %q\n", fs.Args())
+		fs.Usage()
 
-			// fmt.Println()
-		}
+		return errUsage
 	}
+
+	return lsp.NewServer(c.debug).Serve(ctx, os.Stdin, os.Stdout)
 }
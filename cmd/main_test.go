@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/types"
+)
+
+func TestGoEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  []string
+		want string
+	}{
+		{name: "unset", env: []string{"HOME=/root"}, want: "GOEXPERIMENT=arenas"},
+		{name: "empty", env: []string{"GOEXPERIMENT="}, want: "GOEXPERIMENT=arenas"},
+		{name: "other", env: []string{"GOEXPERIMENT=jsonv2"}, want: "GOEXPERIMENT=jsonv2,arenas"},
+		{name: "enabled", env: []string{"GOEXPERIMENT=arenas,jsonv2"}, want: "GOEXPERIMENT=arenas,jsonv2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := goEnv(tt.env)
+			if !slices.Contains(got, tt.want) {
+				t.Errorf("goEnv(%q) = %q, want %q", tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTestName(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]bool{
+		"testAdd":  true,
+		"testÉcho": true,
+		"test":     false,
+		"testing":  false,
+		"add":      false,
+	} {
+		if got := isTestName(name); got != want {
+			t.Errorf("isTestName(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestTestHarness(t *testing.T) {
+	t.Parallel()
+
+	src, err := testHarness("mathx", []cogTest{
+		{name: "testAdd", goName: "testAdd", result: types.Basics[types.Bool]},
+		{name: "testParse", goName: "testParse", proc: true, result: &types.Result{Value: types.Basics[types.Int64], Error: types.Basics[types.UTF8]}},
+		{name: "testPrint", goName: "testPrint", result: types.None},
+	})
+	if err != nil {
+		t.Fatalf("testHarness: %v", err)
+	}
+
+	got := string(src)
+
+	for _, want := range []string{
+		"package mathx",
+		"func TestAdd(t *testing.T) {\n\tif !testAdd() {",
+		"if res := testParse(t.Context()); res.IsError {",
+		"func TestPrint(t *testing.T) {\n\ttestPrint()\n}",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("harness missing %q\ngot:\n%s", want, got)
+		}
+	}
+}
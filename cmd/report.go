@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/project"
+)
+
+// errReported is returned when the diagnostics of a failed compilation have
+// been reported.
+var errReported = errors.New("compilation failed")
+
+// diagFormat is the output format of diagnostics.
+type diagFormat string
+
+const (
+	formatText diagFormat = "text"
+	formatJSON diagFormat = "json"
+)
+
+func (f *diagFormat) String() string {
+	return string(*f)
+}
+
+func (f *diagFormat) Set(value string) error {
+	switch diagFormat(value) {
+	case formatText, formatJSON:
+		*f = diagFormat(value)
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, must be %q or %q", value, formatText, formatJSON)
+	}
+}
+
+// report writes the diagnostics of a failed compilation to stderr, in the
+// format of the command line.
+func (c *compiler) report(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if writeErr := writeDiagnostics(os.Stderr, c.format, diag.All(err)); writeErr != nil {
+		return errors.Join(err, writeErr)
+	}
+
+	return errReported
+}
+
+// writeDiagnostics writes diagnostics as JSON, or rendered with a snippet of
+// their source.
+func writeDiagnostics(w io.Writer, f diagFormat, diags []*diag.Diagnostic) error {
+	if f == formatJSON {
+		return diag.WriteJSON(w, diags)
+	}
+
+	sources := make(map[string][]byte)
+
+	for i, d := range diags {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+
+		src, ok := sources[d.File]
+		if !ok && d.File != "" {
+			// The snippet is left out if the source cannot be read.
+			src, _ = os.ReadFile(d.File)
+			sources[d.File] = src
+		}
+
+		if err := diag.Render(w, d, src); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sourcePaths names the source files in the diagnostics of err and their
+// notes. The transpiler names files like the //line directives, relative to
+// the output directory.
+func sourcePaths(err error, astFiles []*ast.File, files []*project.File) error {
+	path := func(name string) string {
+		for i, f := range astFiles {
+			if name != "" && name == f.Name {
+				return files[i].Path
+			}
+		}
+
+		return name
+	}
+
+	for _, d := range diag.All(err) {
+		d.File = path(d.File)
+
+		for i := range d.Notes {
+			d.Notes[i].File = path(d.Notes[i].File)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/project"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// harnessFile is the generated Go test file calling the Cog tests.
+const harnessFile = "cog_harness_test.go"
+
+// cogTest is a test declared in a _test.cog file.
+type cogTest struct {
+	name   string // Cog name, e.g. testAdd
+	goName string // name of the transpiled Go function
+	proc   bool   // procedures take a context
+	result types.Type
+}
+
+// isTestName reports whether name is the name of a test: test followed by an
+// uppercase letter.
+func isTestName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "test")
+	if !ok || rest == "" {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(rest)
+
+	return unicode.IsUpper(r)
+}
+
+// findTests returns the tests of a package: procedures and functions without
+// parameters, declared in _test.cog files with a test name. A test fails if it
+// panics, returns false, or returns an error result.
+func findTests(pkg *project.Package) ([]cogTest, error) {
+	var tests []cogTest
+
+	for _, file := range pkg.Files {
+		if !project.IsTestFile(file.Path) {
+			continue
+		}
+
+		for _, stmt := range file.AST.Statements {
+			decl, ok := stmt.(*ast.Declaration)
+			if !ok || decl.Assignment.Expression == nil || !isTestName(decl.Assignment.Identifier.Name) {
+				continue
+			}
+
+			procType, ok := decl.Assignment.Expression.Type().(*types.Procedure)
+			if !ok {
+				continue
+			}
+
+			ident := decl.Assignment.Identifier
+			ln, col := decl.Pos()
+
+			if len(procType.Parameters) > 0 || len(procType.TypeParams) > 0 {
+				return nil, fmt.Errorf("%s:%d:%d: test %q must not have parameters", file.Path, ln, col, ident.Name)
+			}
+
+			result := procType.ReturnType
+			if !types.IsNone(result) && !types.IsBool(result) && result.Kind() != types.ResultKind {
+				return nil, fmt.Errorf("%s:%d:%d: test %q must return nothing, a bool or a result", file.Path, ln, col, ident.Name)
+			}
+
+			tests = append(tests, cogTest{
+				name:   ident.Name,
+				goName: component.ConvertExport(ident.Name, ident.Exported, ident.Global),
+				proc:   !procType.Function,
+				result: result,
+			})
+		}
+	}
+
+	return tests, nil
+}
+
+// writeTestHarness writes a Go test file with a Go test for every Cog test of
+// the package, and returns the number of tests.
+func (c *compiler) writeTestHarness(pkg *project.Package) (int, error) {
+	tests, err := findTests(pkg)
+	if err != nil || len(tests) == 0 {
+		return 0, err
+	}
+
+	src, err := testHarness(pkg.Name, tests)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.WriteFile(filepath.Join(c.outputDir, harnessFile), src, 0o600); err != nil {
+		return 0, fmt.Errorf("writing test harness: %w", err)
+	}
+
+	return len(tests), nil
+}
+
+// testHarness generates the source of the Go test file.
+func testHarness(pkgName string, tests []cogTest) ([]byte, error) {
+	var out strings.Builder
+
+	fmt.Fprintf(&out, "// Code generated by cog test. DO NOT EDIT.\n\npackage %s\n\nimport \"testing\"\n", pkgName)
+
+	for _, test := range tests {
+		call := test.goName + "()"
+		if test.proc {
+			call = test.goName + "(t.Context())"
+		}
+
+		fmt.Fprintf(&out, "\nfunc Test%s(t *testing.T) {\n", strings.TrimPrefix(test.name, "test"))
+
+		switch {
+		case types.IsNone(test.result):
+			fmt.Fprintf(&out, "\t%s\n", call)
+		case types.IsBool(test.result):
+			fmt.Fprintf(&out, "\tif !%s {\n\t\tt.Fatal(%q)\n\t}\n", call, test.name+" returned false")
+		default:
+			fmt.Fprintf(&out, "\tif res := %s; res.IsError {\n\t\tt.Fatalf(\"%s: %%v\", res.Error)\n\t}\n", call, test.name)
+		}
+
+		out.WriteString("}\n")
+	}
+
+	src, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting test harness: %w", err)
+	}
+
+	return src, nil
+}
@@ -0,0 +1,42 @@
+// Code generated by the Cog transpiler from copyBenchSource in
+// internal/transpiler/copy_test.go. DO NOT EDIT.
+
+package cog
+
+import (
+	go_slices "slices"
+)
+
+type Bench struct {
+	Name  string
+	Items []int64
+	Tags  map[string][]string
+}
+
+func Copy_Bench(x Bench) Bench {
+	x.Items = go_slices.Clone(x.Items)
+	x.Tags = copy_map_utf8_slice_utf8(x.Tags)
+	return x
+}
+
+func copy_map_utf8_slice_utf8(x map[string][]string) map[string][]string {
+	if x == nil {
+		return nil
+	}
+	y := make(map[string][]string, len(x))
+	for k, v := range x {
+		y[k] = go_slices.Clone(v)
+	}
+	return y
+}
+
+func copy_slice_Bench(x []Bench) []Bench {
+	if x == nil {
+		return nil
+	}
+	y := make([]Bench, len(x))
+	for i := range x {
+		y[i] = Copy_Bench(x[i])
+	}
+	return y
+}
@@ -1,6 +1,7 @@
 package cog
 
 import (
+	"maps"
 	"reflect"
 	"testing"
 )
@@ -394,3 +395,57 @@ func TestCopy(t *testing.T) {
 		}
 	})
 }
+
+func BenchmarkCopy(b *testing.B) {
+	bench := Bench{
+		Name:  "bench",
+		Items: []int64{1, 2, 3, 4, 5, 6, 7, 8},
+		Tags:  map[string][]string{"a": {"x", "y"}, "b": {"z"}},
+	}
+
+	benches := make([]Bench, 100)
+	for i := range benches {
+		benches[i] = bench
+	}
+
+	ints := make(map[int64]int64, 100)
+	for i := range int64(100) {
+		ints[i] = i
+	}
+
+	b.Run("struct/reflect", func(b *testing.B) {
+		for b.Loop() {
+			_ = Copy(bench)
+		}
+	})
+
+	b.Run("struct/generated", func(b *testing.B) {
+		for b.Loop() {
+			_ = Copy_Bench(bench)
+		}
+	})
+
+	b.Run("slice_of_structs/reflect", func(b *testing.B) {
+		for b.Loop() {
+			_ = Copy(benches)
+		}
+	})
+
+	b.Run("slice_of_structs/generated", func(b *testing.B) {
+		for b.Loop() {
+			_ = copy_slice_Bench(benches)
+		}
+	})
+
+	b.Run("map/reflect", func(b *testing.B) {
+		for b.Loop() {
+			_ = Copy(ints)
+		}
+	})
+
+	b.Run("map/generated", func(b *testing.B) {
+		for b.Loop() {
+			_ = maps.Clone(ints)
+		}
+	})
+}
@@ -41,6 +41,10 @@ func TestEBNFConsistency(t *testing.T) {
 		"EOF":        true,
 		"letter":     true,
 		"digit":      true,
+		"decimals":   true,
+		"hex_digit":  true,
+		"bin_digit":  true,
+		"oct_digit":  true,
 		"character":  true,
 		"comment":    true,
 	}
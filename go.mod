@@ -5,6 +5,7 @@ go 1.26.2
 require (
 	github.com/ryanavella/wide v0.0.0-20190709032049-e93517939246 // TODO: fork implementation of wide.Int128
 	github.com/x448/float16 v0.8.4
+	golang.org/x/mod v0.34.0
 	golang.org/x/text v0.36.0
 	lukechampine.com/uint128 v1.3.0
 )
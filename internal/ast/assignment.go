@@ -13,6 +13,7 @@ type Assignment struct {
 
 	Token      tokens.Token
 	Identifier *Identifier
+	Field      *Selector // field that is assigned, named by Identifier, or nil
 	Expression Expression
 }
 
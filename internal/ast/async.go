@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+var _ Expression = &Async{}
+
+// Async is a procedure call that runs in its own goroutine: async proc(...).
+type Async struct {
+	expression
+
+	Token      tokens.Token // async
+	Call       *Call
+	SignalType *types.Signal
+}
+
+func (a *Async) Pos() (uint32, uint16) {
+	return a.Token.Ln, a.Token.Col
+}
+
+func (a *Async) Hash() uint64 {
+	return hash(a)
+}
+
+func (a *Async) stringTo(out *strings.Builder) {
+	_, _ = out.WriteString("async ")
+	a.Call.stringTo(out)
+}
+
+func (a *Async) String() string {
+	var out strings.Builder
+	a.stringTo(&out)
+
+	return out.String()
+}
+
+func (a *Async) Type() types.Type {
+	return a.SignalType
+}
@@ -32,6 +32,8 @@ func (d *Declaration) stringTo(out *strings.Builder) {
 		_, _ = out.WriteString("var ")
 	case QualifierDynamic:
 		_, _ = out.WriteString("dyn ")
+	case QualifierCompileTime:
+		_, _ = out.WriteString("comp ")
 	}
 
 	if d.Assignment.Expression == nil {
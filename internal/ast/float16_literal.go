@@ -2,7 +2,6 @@ package ast
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
 	f16 "github.com/x448/float16"
@@ -23,7 +22,7 @@ type Float16Literal struct {
 }
 
 func NewFloat16Literal(t tokens.Token) (*Float16Literal, error) {
-	value, err := strconv.ParseFloat(t.Literal, 32)
+	value, err := parseFloat(t, 32)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse float literal to float16: %w", err)
 	}
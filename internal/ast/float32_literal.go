@@ -19,7 +19,7 @@ type Float32Literal struct {
 }
 
 func NewFloat32Literal(t tokens.Token) (*Float32Literal, error) {
-	value, err := strconv.ParseFloat(t.Literal, 32)
+	value, err := parseFloat(t, 32)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse float literal to float32: %w", err)
 	}
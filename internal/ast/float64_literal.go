@@ -19,7 +19,7 @@ type Float64Literal struct {
 }
 
 func NewFloat64Literal(t tokens.Token) (*Float64Literal, error) {
-	value, err := strconv.ParseFloat(t.Literal, 64)
+	value, err := parseFloat(t, 64)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse float literal to float64: %w", err)
 	}
@@ -0,0 +1,136 @@
+package ast
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+var _ Expression = &FormatString{}
+
+// FormatString is a string literal with interpolated values, which is the
+// first argument of @print and @format: "x = {x}, y = {y:.2f}". A {} takes
+// the next argument of the builtin.
+type FormatString struct {
+	expression
+
+	Token tokens.Token
+	Parts []*FormatPart
+}
+
+// FormatPart is either literal text, or a value formatted by Spec if Text is
+// empty.
+type FormatPart struct {
+	Text   string
+	Value  Expression
+	Spec   FormatSpec
+	Inline bool // the value is written between the braces
+}
+
+// FormatSpec is the format of a value after the colon in its braces:
+// [align][0][width][.precision][verb].
+type FormatSpec struct {
+	Align     byte // < or >, 0 for the default of the value type
+	Zero      bool
+	Width     int
+	Precision int // -1 if not set
+	Verb      byte
+}
+
+// ValueType returns the type the value of p is formatted as. Checked option
+// and result identifiers are formatted as their value.
+func (p *FormatPart) ValueType() types.Type {
+	typ := p.Value.Type()
+
+	if _, ok := p.Value.(*Identifier); ok {
+		underlying := typ
+		if alias, ok := typ.(*types.Alias); ok {
+			underlying = alias.Underlying()
+		}
+
+		switch t := underlying.(type) {
+		case *types.Option:
+			return t.Value
+		case *types.Result:
+			return t.Value
+		}
+	}
+
+	return typ
+}
+
+func (s FormatSpec) String() string {
+	var out strings.Builder
+
+	if s.Align != 0 {
+		_ = out.WriteByte(s.Align)
+	}
+
+	if s.Zero {
+		_ = out.WriteByte('0')
+	}
+
+	if s.Width > 0 {
+		_, _ = out.WriteString(strconv.Itoa(s.Width))
+	}
+
+	if s.Precision >= 0 {
+		_ = out.WriteByte('.')
+		_, _ = out.WriteString(strconv.Itoa(s.Precision))
+	}
+
+	if s.Verb != 0 {
+		_ = out.WriteByte(s.Verb)
+	}
+
+	return out.String()
+}
+
+func (s *FormatString) Pos() (uint32, uint16) {
+	return s.Token.Ln, s.Token.Col
+}
+
+func (s *FormatString) Hash() uint64 {
+	return hash(s)
+}
+
+func (s *FormatString) stringTo(out *strings.Builder) {
+	_ = out.WriteByte('"')
+
+	for _, part := range s.Parts {
+		if part.Value == nil {
+			text := strings.ReplaceAll(part.Text, "{", "{{")
+			_, _ = out.WriteString(strings.ReplaceAll(text, "}", "}}"))
+
+			continue
+		}
+
+		_ = out.WriteByte('{')
+
+		if part.Inline {
+			part.Value.stringTo(out)
+		}
+
+		if spec := part.Spec.String(); spec != "" {
+			_ = out.WriteByte(':')
+			_, _ = out.WriteString(spec)
+		}
+
+		_ = out.WriteByte('}')
+	}
+
+	_ = out.WriteByte('"')
+}
+
+func (s *FormatString) String() string {
+	var out strings.Builder
+	s.stringTo(&out)
+
+	return out.String()
+}
+
+func (s *FormatString) Type() types.Type {
+	return types.Basics[types.UTF8]
+}
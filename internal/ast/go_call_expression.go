@@ -1,6 +1,7 @@
 package ast
 
 import (
+	gotypes "go/types"
 	"strings"
 
 	"github.com/samborkent/cog/internal/tokens"
@@ -14,8 +15,11 @@ type GoCallExpression struct {
 
 	Token          tokens.Token
 	Import         *Identifier
+	Path           string // import path of the Go package
 	CallIdentifier *Identifier
 	Arguments      []Expression
+	ReturnType     types.Type // nil for Go functions without results
+	Signature      *gotypes.Signature
 }
 
 func (e *GoCallExpression) Pos() (uint32, uint16) {
@@ -40,8 +44,24 @@ func (e *GoCallExpression) String() string {
 	return out.String()
 }
 
-// TODO: figure out how to infer Go types and how to convert between Cog and Go types
 func (e *GoCallExpression) Type() types.Type {
-	// Currently, we cannot infer Go types.
-	return types.None
+	if e.ReturnType == nil {
+		return types.None
+	}
+
+	return e.ReturnType
+}
+
+// IsGoLiteral reports whether arg is a literal passed to Go as an untyped
+// constant, which takes the type of the Go parameter.
+func IsGoLiteral(arg Expression) bool {
+	switch arg.(type) {
+	case *BoolLiteral, *UTF8Literal,
+		*Int8Literal, *Int16Literal, *Int32Literal, *Int64Literal,
+		*Uint8Literal, *Uint16Literal, *Uint32Literal, *Uint64Literal,
+		*Float32Literal, *Float64Literal:
+		return true
+	}
+
+	return false
 }
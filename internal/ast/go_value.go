@@ -0,0 +1,47 @@
+package ast
+
+import (
+	gotypes "go/types"
+	"strings"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+var _ Expression = &GoValue{}
+
+// GoValue is a Go constant or variable referenced through the @go namespace.
+type GoValue struct {
+	expression
+
+	Token      tokens.Token
+	Import     *Identifier
+	Identifier *Identifier // ValueType holds the Cog type of the value
+	GoType     gotypes.Type
+}
+
+func (e *GoValue) Pos() (uint32, uint16) {
+	return e.Token.Ln, e.Token.Col
+}
+
+func (e *GoValue) Hash() uint64 {
+	return hash(e)
+}
+
+func (e *GoValue) stringTo(out *strings.Builder) {
+	_, _ = out.WriteString("@go.")
+	_, _ = out.WriteString(e.Import.Name)
+	_ = out.WriteByte('.')
+	_, _ = out.WriteString(e.Identifier.Name)
+}
+
+func (e *GoValue) String() string {
+	var out strings.Builder
+	e.stringTo(&out)
+
+	return out.String()
+}
+
+func (e *GoValue) Type() types.Type {
+	return e.Identifier.ValueType
+}
@@ -15,7 +15,7 @@ const (
 	QualifierImmutable
 	QualifierVariable
 	QualifierDynamic
-	// QualifierConstant
+	QualifierCompileTime // comp, the value is known at compile time
 )
 
 var _ Expression = &Identifier{}
@@ -0,0 +1,152 @@
+package ast
+
+// Inspect traverses the statements and expressions of node in depth-first
+// order, calling f for node and each of its descendants. The descendants of
+// a node are skipped when f returns false for it. Types, such as the lengths
+// of array types, are not traversed.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		inspectStatements(n.Statements, f)
+	case *Block:
+		inspectStatements(n.Statements, f)
+	case *Assignment:
+		Inspect(n.Identifier, f)
+		inspectExpression(n.Expression, f)
+	case *Declaration:
+		Inspect(n.Assignment, f)
+	case *ExpressionStatement:
+		inspectExpression(n.Expression, f)
+	case *ForStatement:
+		inspectExpression(n.Range, f)
+
+		if n.Loop != nil {
+			Inspect(n.Loop, f)
+		}
+	case *IfStatement:
+		inspectExpression(n.Condition, f)
+
+		if n.Consequence != nil {
+			Inspect(n.Consequence, f)
+		}
+
+		if n.Alternative != nil {
+			Inspect(n.Alternative, f)
+		}
+	case *Match:
+		inspectExpression(n.Subject, f)
+
+		for _, c := range n.Cases {
+			Inspect(c, f)
+		}
+
+		if n.Default != nil {
+			Inspect(n.Default, f)
+		}
+	case *MatchCase:
+		inspectStatements(n.Body, f)
+	case *Method:
+		Inspect(n.Declaration, f)
+	case *Return:
+		inspectExpressions(n.Values, f)
+	case *Switch:
+		if n.Identifier != nil {
+			Inspect(n.Identifier, f)
+		}
+
+		for _, c := range n.Cases {
+			Inspect(c, f)
+		}
+
+		if n.Default != nil {
+			Inspect(n.Default, f)
+		}
+	case *Case:
+		inspectExpression(n.Condition, f)
+		inspectStatements(n.Body, f)
+	case *Default:
+		inspectStatements(n.Body, f)
+	case *ArrayLiteral:
+		inspectExpressions(n.Values, f)
+	case *Async:
+		Inspect(n.Call, f)
+	case *Builtin:
+		inspectExpressions(n.Arguments, f)
+	case *Call:
+		inspectExpression(n.Expression, f)
+		inspectExpressions(n.Arguments, f)
+	case *EitherLiteral:
+		inspectExpression(n.Value, f)
+	case *FormatString:
+		for _, part := range n.Parts {
+			inspectExpression(part.Value, f)
+		}
+	case *GoCallExpression:
+		inspectExpressions(n.Arguments, f)
+	case *Index:
+		inspectExpression(n.Identifier, f)
+		inspectExpression(n.Index, f)
+	case *Infix:
+		inspectExpression(n.Left, f)
+		inspectExpression(n.Right, f)
+	case *MapLiteral:
+		for _, pair := range n.Pairs {
+			inspectExpression(pair.Key, f)
+			inspectExpression(pair.Value, f)
+		}
+	case *Prefix:
+		inspectExpression(n.Right, f)
+	case *Propagate:
+		inspectExpression(n.Left, f)
+	case *ProcedureLiteral:
+		if n.Body != nil {
+			Inspect(n.Body, f)
+		}
+	case *Range:
+		inspectExpression(n.Start, f)
+		inspectExpression(n.End, f)
+		inspectExpression(n.Step, f)
+	case *ResultLiteral:
+		inspectExpression(n.Value, f)
+	case *Selector:
+		inspectExpression(n.Expression, f)
+		Inspect(n.Field, f)
+	case *SetLiteral:
+		inspectExpressions(n.Values, f)
+	case *SliceLiteral:
+		inspectExpressions(n.Values, f)
+	case *StructLiteral:
+		for _, field := range n.Values {
+			inspectExpression(field.Value, f)
+		}
+	case *Suffix:
+		inspectExpression(n.Left, f)
+	case *TupleLiteral:
+		inspectExpressions(n.Values, f)
+	case *UnionLiteral:
+		inspectExpression(n.Value, f)
+	}
+}
+
+func inspectStatements(stmts []Statement, f func(Node) bool) {
+	for _, stmt := range stmts {
+		Inspect(stmt, f)
+	}
+}
+
+func inspectExpressions(exprs []Expression, f func(Node) bool) {
+	for _, expr := range exprs {
+		inspectExpression(expr, f)
+	}
+}
+
+// inspectExpression inspects expr, which may be nil.
+func inspectExpression(expr Expression, f func(Node) bool) {
+	if expr != nil {
+		Inspect(expr, f)
+	}
+}
@@ -1,8 +1,6 @@
 package ast
 
 import (
-	"errors"
-	"math/big"
 	"strings"
 
 	"github.com/ryanavella/wide"
@@ -22,11 +20,9 @@ type Int128Literal struct {
 }
 
 func NewInt128Literal(t tokens.Token) (*Int128Literal, error) {
-	value := new(big.Int)
-
-	_, ok := value.SetString(t.Literal, 10)
-	if !ok {
-		return nil, errors.New("unable to parse int literal to int128")
+	value, err := parseInt(t.Literal, types.Basics[types.Int128])
+	if err != nil {
+		return nil, err
 	}
 
 	return &Int128Literal{
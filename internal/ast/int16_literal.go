@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Int16Literal struct {
 }
 
 func NewInt16Literal(t tokens.Token) (*Int16Literal, error) {
-	value, err := strconv.ParseInt(t.Literal, 10, 16)
+	value, err := parseInt(t.Literal, types.Basics[types.Int16])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int16: %w", err)
+		return nil, err
 	}
 
 	return &Int16Literal{
 		Token: t,
-		Value: int16(value),
+		Value: int16(value.Int64()),
 	}, nil
 }
 
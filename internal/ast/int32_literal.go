@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Int32Literal struct {
 }
 
 func NewInt32Literal(t tokens.Token) (*Int32Literal, error) {
-	value, err := strconv.ParseInt(t.Literal, 10, 32)
+	value, err := parseInt(t.Literal, types.Basics[types.Int32])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int32: %w", err)
+		return nil, err
 	}
 
 	return &Int32Literal{
 		Token: t,
-		Value: int32(value),
+		Value: int32(value.Int64()),
 	}, nil
 }
 
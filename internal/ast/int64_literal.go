@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Int64Literal struct {
 }
 
 func NewInt64Literal(t tokens.Token) (*Int64Literal, error) {
-	value, err := strconv.ParseInt(t.Literal, 10, 64)
+	value, err := parseInt(t.Literal, types.Basics[types.Int64])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal: %w", err)
+		return nil, err
 	}
 
 	return &Int64Literal{
 		Token: t,
-		Value: value,
+		Value: value.Int64(),
 	}, nil
 }
 
@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Int8Literal struct {
 }
 
 func NewInt8Literal(t tokens.Token) (*Int8Literal, error) {
-	value, err := strconv.ParseInt(t.Literal, 10, 8)
+	value, err := parseInt(t.Literal, types.Basics[types.Int8])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int8: %w", err)
+		return nil, err
 	}
 
 	return &Int8Literal{
 		Token: t,
-		Value: int8(value),
+		Value: int8(value.Int64()),
 	}, nil
 }
 
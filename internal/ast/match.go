@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
 )
 
 var _ Statement = &Match{}
@@ -60,3 +61,15 @@ func (m *Match) String() string {
 
 	return out.String()
 }
+
+// Case returns the case that handles values of type typ, or nil if they are
+// handled by the default case.
+func (m *Match) Case(typ types.Type) *MatchCase {
+	for _, c := range m.Cases {
+		if c.Matches(typ) {
+			return c
+		}
+	}
+
+	return nil
+}
@@ -46,3 +46,14 @@ func (m *MatchCase) String() string {
 
 	return out.String()
 }
+
+// Matches reports whether the case handles values of type typ. A case of a
+// type matches only that type, while a ~ case also matches the types derived
+// from it.
+func (m *MatchCase) Matches(typ types.Type) bool {
+	if m.Tilde {
+		return types.Equal(typ, m.MatchType)
+	}
+
+	return typ.String() == m.MatchType.String() && types.Equal(typ, m.MatchType)
+}
@@ -0,0 +1,103 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// parseInt parses an integer literal of type typ, and reports an error if it
+// does not fit in typ.
+func parseInt(lit string, typ types.Type) (*big.Int, error) {
+	value, err := parseIntValue(lit)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bits   int
+		signed bool
+	)
+
+	switch typ.Kind() {
+	case types.Int8:
+		bits, signed = 8, true
+	case types.Int16:
+		bits, signed = 16, true
+	case types.Int32:
+		bits, signed = 32, true
+	case types.Int64:
+		bits, signed = 64, true
+	case types.Int128:
+		bits, signed = 128, true
+	case types.Uint8:
+		bits = 8
+	case types.Uint16:
+		bits = 16
+	case types.Uint32:
+		bits = 32
+	case types.Uint64:
+		bits = 64
+	case types.Uint128:
+		bits = 128
+	default:
+		return nil, fmt.Errorf("%s is not an integer type", typ)
+	}
+
+	lower, upper := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+
+	if signed {
+		upper.Rsh(upper, 1)
+		lower.Neg(upper)
+	}
+
+	// The upper bound is exclusive.
+	upper.Sub(upper, big.NewInt(1))
+
+	if value.Cmp(lower) < 0 || value.Cmp(upper) > 0 {
+		return nil, fmt.Errorf("integer literal %s overflows %s (range %s to %s)", lit, typ, lower, upper)
+	}
+
+	return value, nil
+}
+
+// parseIntValue parses an integer literal: decimal, or binary, octal or
+// hexadecimal with a 0b, 0o or 0x prefix, with optional _ digit separators.
+func parseIntValue(lit string) (*big.Int, error) {
+	digits := lit
+	if len(digits) > 0 && digits[0] == '-' {
+		digits = digits[1:]
+	}
+
+	// Go reads a leading 0 as an octal prefix, which is easy to miss.
+	if len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9' {
+		return nil, fmt.Errorf("invalid integer literal %s: use the 0o prefix for octal", lit)
+	}
+
+	value, ok := new(big.Int).SetString(lit, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer literal %s", lit)
+	}
+
+	return value, nil
+}
+
+// parseFloat parses a float literal, or an integer literal in a float
+// context, to a float of bitSize bits.
+func parseFloat(t tokens.Token, bitSize int) (float64, error) {
+	if t.Type != tokens.IntLiteral {
+		return strconv.ParseFloat(t.Literal, bitSize)
+	}
+
+	value, err := parseIntValue(t.Literal)
+	if err != nil {
+		return 0, err
+	}
+
+	f, _ := new(big.Float).SetInt(value).Float64()
+
+	return f, nil
+}
@@ -0,0 +1,55 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+var _ Expression = &Propagate{}
+
+// Propagate is the value of a result, where an error returns early from the
+// enclosing function, which returns the result type ReturnType: r!?
+type Propagate struct {
+	expression
+
+	Operator   tokens.Token // !
+	Left       Expression
+	ReturnType types.Type // of the enclosing function
+}
+
+func (p *Propagate) Pos() (uint32, uint16) {
+	return p.Operator.Ln, p.Operator.Col
+}
+
+func (p *Propagate) Hash() uint64 {
+	return hash(p)
+}
+
+func (p *Propagate) stringTo(out *strings.Builder) {
+	_ = out.WriteByte('(')
+	p.Left.stringTo(out)
+	_, _ = out.WriteString("!?)")
+}
+
+func (p *Propagate) String() string {
+	var out strings.Builder
+	p.stringTo(&out)
+
+	return out.String()
+}
+
+func (p *Propagate) Type() types.Type {
+	underlying := p.Left.Type()
+	if alias, ok := underlying.(*types.Alias); ok {
+		underlying = alias.Underlying()
+	}
+
+	result, ok := underlying.(*types.Result)
+	if !ok {
+		panic("propagation of non-result type")
+	}
+
+	return result.Value
+}
@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+var _ Expression = &Range{}
+
+// Range is an iterator over the integers from Start up to End: a..b, or
+// a..=b to include End. The integers are Step apart, which is 1 if Step is
+// nil, and count down if Step is negative.
+type Range struct {
+	expression
+
+	Operator   tokens.Token // .. or ..=
+	Start, End Expression
+	Step       Expression // may be nil
+}
+
+// Inclusive reports whether End is part of the range.
+func (r *Range) Inclusive() bool {
+	return r.Operator.Type == tokens.RangeInclusive
+}
+
+func (r *Range) Pos() (uint32, uint16) {
+	return r.Operator.Ln, r.Operator.Col
+}
+
+func (r *Range) Hash() uint64 {
+	return hash(r)
+}
+
+func (r *Range) stringTo(out *strings.Builder) {
+	_ = out.WriteByte('(')
+	r.Start.stringTo(out)
+	_, _ = out.WriteString(r.Operator.Type.String())
+	r.End.stringTo(out)
+
+	if r.Step != nil {
+		_, _ = out.WriteString(" by ")
+		r.Step.stringTo(out)
+	}
+
+	_ = out.WriteByte(')')
+}
+
+func (r *Range) String() string {
+	var out strings.Builder
+	r.stringTo(&out)
+
+	return out.String()
+}
+
+func (r *Range) Type() types.Type {
+	return &types.Iterator{Value: r.Start.Type()}
+}
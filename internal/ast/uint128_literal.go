@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strings"
 
 	u128 "lukechampine.com/uint128"
@@ -22,14 +21,14 @@ type Uint128Literal struct {
 }
 
 func NewUint128Literal(t tokens.Token) (*Uint128Literal, error) {
-	value, err := u128.FromString(t.Literal)
+	value, err := parseInt(t.Literal, types.Basics[types.Uint128])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to uint128: %w", err)
+		return nil, err
 	}
 
 	return &Uint128Literal{
 		Token: t,
-		Value: value,
+		Value: u128.FromBig(value),
 	}, nil
 }
 
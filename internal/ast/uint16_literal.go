@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Uint16Literal struct {
 }
 
 func NewUint16Literal(t tokens.Token) (*Uint16Literal, error) {
-	value, err := strconv.ParseUint(t.Literal, 10, 16)
+	value, err := parseInt(t.Literal, types.Basics[types.Uint16])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int16: %w", err)
+		return nil, err
 	}
 
 	return &Uint16Literal{
 		Token: t,
-		Value: uint16(value),
+		Value: uint16(value.Uint64()),
 	}, nil
 }
 
@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Uint32Literal struct {
 }
 
 func NewUint32Literal(t tokens.Token) (*Uint32Literal, error) {
-	value, err := strconv.ParseUint(t.Literal, 10, 32)
+	value, err := parseInt(t.Literal, types.Basics[types.Uint32])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int32: %w", err)
+		return nil, err
 	}
 
 	return &Uint32Literal{
 		Token: t,
-		Value: uint32(value),
+		Value: uint32(value.Uint64()),
 	}, nil
 }
 
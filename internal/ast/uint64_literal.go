@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Uint64Literal struct {
 }
 
 func NewUint64Literal(t tokens.Token) (*Uint64Literal, error) {
-	value, err := strconv.ParseUint(t.Literal, 10, 64)
+	value, err := parseInt(t.Literal, types.Basics[types.Uint64])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int64: %w", err)
+		return nil, err
 	}
 
 	return &Uint64Literal{
 		Token: t,
-		Value: value,
+		Value: value.Uint64(),
 	}, nil
 }
 
@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
 
@@ -19,14 +18,14 @@ type Uint8Literal struct {
 }
 
 func NewUint8Literal(t tokens.Token) (*Uint8Literal, error) {
-	value, err := strconv.ParseUint(t.Literal, 10, 8)
+	value, err := parseInt(t.Literal, types.Basics[types.Uint8])
 	if err != nil {
-		return nil, fmt.Errorf("unable to parse int literal to int8: %w", err)
+		return nil, err
 	}
 
 	return &Uint8Literal{
 		Token: t,
-		Value: uint8(value),
+		Value: uint8(value.Uint64()),
 	}, nil
 }
 
@@ -0,0 +1,582 @@
+// Package comptime evaluates comp declarations and calls to comp funcs before
+// transpilation.
+//
+// The syntax tree is not changed, as the formatter needs it as written.
+// Instead, Fold returns the literal of every expression it evaluated, which
+// the transpiler emits in place of the expression.
+package comptime
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// Folded maps expressions evaluated at compile time to the literal of their
+// value.
+type Folded map[ast.Expression]ast.Expression
+
+// Fold evaluates the initializers of comp declarations, calls to comp funcs
+// and array lengths in files, which make up one package. It reports an error
+// for every comp value that cannot be evaluated.
+func Fold(files []*ast.File) (Folded, error) {
+	f := &folder{
+		folded:     make(Folded),
+		globals:    make(map[string]*constant),
+		locals:     make(map[*ast.Identifier]*constant),
+		funcs:      make(map[string]*function),
+		localFuncs: make(map[*ast.Identifier]*function),
+		memo:       make(map[memoKey]value),
+		arrays:     make(map[*types.Array]bool),
+	}
+
+	for _, file := range files {
+		for _, stmt := range file.Statements {
+			decl, ok := stmt.(*ast.Declaration)
+			if !ok || decl.Assignment.Identifier.Qualifier != ast.QualifierCompileTime {
+				continue
+			}
+
+			ident := decl.Assignment.Identifier
+
+			if fn := newFunction(file, decl); fn != nil {
+				f.funcs[ident.Name] = fn
+			} else {
+				f.globals[ident.Name] = &constant{decl: decl, file: file}
+			}
+		}
+	}
+
+	for _, fn := range f.funcs {
+		f.checkPurity(fn)
+	}
+
+	for _, file := range files {
+		f.file = file
+
+		for _, stmt := range file.Statements {
+			f.at = stmt
+			f.statement(stmt)
+		}
+	}
+
+	return f.folded, errors.Join(f.errs...)
+}
+
+// maxSteps is the number of statements and calls one compile-time evaluation
+// may execute, and maxDepth the number of nested comp func calls.
+const (
+	maxSteps = 1 << 20
+	maxDepth = 256
+)
+
+// errFailed is returned when an evaluation depends on a comp value that could
+// not be evaluated, which has already been reported.
+var errFailed = errors.New("comp value failed to evaluate")
+
+type state uint8
+
+const (
+	unevaluated state = iota
+	evaluating
+	evaluated
+	failed
+)
+
+// constant is a comp declaration of a value, which is evaluated when it is
+// first used.
+type constant struct {
+	decl  *ast.Declaration
+	file  *ast.File
+	state state
+	value value
+}
+
+// function is a comp func.
+type function struct {
+	name   string
+	file   *ast.File
+	typ    *types.Procedure
+	body   *ast.Block
+	impure bool // calls to an impure func are not evaluated
+}
+
+// newFunction returns the comp func of a declaration, or nil if it does not
+// declare a func.
+func newFunction(file *ast.File, decl *ast.Declaration) *function {
+	typ, ok := decl.Assignment.Identifier.ValueType.(*types.Procedure)
+	if !ok {
+		return nil
+	}
+
+	literal, ok := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+	if !ok {
+		return nil
+	}
+
+	return &function{
+		name: decl.Assignment.Identifier.Name,
+		file: file,
+		typ:  typ,
+		body: literal.Body,
+	}
+}
+
+type memoKey struct {
+	fn   *function
+	args string
+}
+
+// call is a comp func call being evaluated.
+type call struct {
+	fn   *function
+	file *ast.File
+	at   ast.Node
+}
+
+type folder struct {
+	folded     Folded
+	globals    map[string]*constant
+	locals     map[*ast.Identifier]*constant
+	funcs      map[string]*function
+	localFuncs map[*ast.Identifier]*function
+	memo       map[memoKey]value
+	arrays     map[*types.Array]bool // array types whose length was folded
+	errs       []error
+
+	file   *ast.File // file of the node being evaluated
+	at     ast.Node  // statement being evaluated
+	frame  *frame    // comp func being interpreted, nil outside of calls
+	calls  []call
+	steps  int
+	inComp bool // walking the body of a comp func
+}
+
+// evaluate evaluates expr as the start of a new compile-time evaluation.
+func (f *folder) evaluate(expr ast.Expression) (value, error) {
+	f.steps = 0
+
+	return f.eval(expr)
+}
+
+// fold records the literal of the value of expr, or reports why it cannot be
+// evaluated.
+func (f *folder) fold(expr ast.Expression) {
+	v, err := f.evaluate(expr)
+	if err != nil {
+		f.report(err)
+		return
+	}
+
+	f.record(expr, v)
+}
+
+func (f *folder) record(expr ast.Expression, v value) {
+	start := diag.Start(f.at)
+
+	literal, err := v.literal(uint32(start.Ln), uint16(start.Col))
+	if err != nil {
+		f.report(f.errorf("%v", err))
+		return
+	}
+
+	f.folded[expr] = literal
+}
+
+func (f *folder) report(err error) {
+	if !errors.Is(err, errFailed) {
+		f.errs = append(f.errs, err)
+	}
+}
+
+// constant returns the value of a comp declaration, evaluating it on first use.
+func (f *folder) constant(c *constant) (value, error) {
+	switch c.state {
+	case evaluated:
+		return c.value, nil
+	case failed:
+		return value{}, errFailed
+	case evaluating:
+		ident := c.decl.Assignment.Identifier
+		return value{}, f.errorf("initialization cycle: %q refers to itself", ident.Name)
+	}
+
+	// Evaluate in the context of the declaration.
+	file, at, fr, calls, steps := f.file, f.at, f.frame, f.calls, f.steps
+	f.file, f.at, f.frame, f.calls = c.file, c.decl, nil, nil
+
+	defer func() {
+		f.file, f.at, f.frame, f.calls, f.steps = file, at, fr, calls, steps
+	}()
+
+	c.state = evaluating
+
+	v, err := f.eval(c.decl.Assignment.Expression)
+	if err != nil {
+		c.state = failed
+		f.report(err)
+
+		return value{}, errFailed
+	}
+
+	c.state, c.value = evaluated, v
+	f.record(c.decl.Assignment.Expression, v)
+
+	return v, nil
+}
+
+// maxNotes is the number of comp func calls an error notes it is evaluated in.
+const maxNotes = 8
+
+// errorf returns a diagnostic at the statement being evaluated, with a note
+// for the comp func calls it is evaluated in, innermost first. Repeated calls
+// from the same position, as in recursion, share a note. Expressions are not
+// used for the position, as identifiers and calls have the position of the
+// declaration they refer to.
+func (f *folder) errorf(format string, args ...any) *diag.Diagnostic {
+	d := diag.At(f.file, f.at, diag.CodeComptime, fmt.Sprintf(format, args...))
+
+	for i := len(f.calls) - 1; i >= 0; {
+		c := f.calls[i]
+
+		n := 1
+		for i-n >= 0 && f.calls[i-n].fn == c.fn && f.calls[i-n].at == c.at {
+			n++
+		}
+
+		i -= n
+
+		if len(d.Notes) == maxNotes {
+			d.Notes = append(d.Notes, diag.Note{Message: fmt.Sprintf("and %d more comp func calls", i+n+1)})
+			break
+		}
+
+		msg := fmt.Sprintf("in comp func %q called", c.fn.name)
+		if n > 1 {
+			msg = fmt.Sprintf("in %d calls of comp func %q", n, c.fn.name)
+		}
+
+		call := diag.At(c.file, c.at, diag.CodeComptime, "")
+		d.Notes = append(d.Notes, diag.Note{Message: msg, File: call.File, Pos: call.Span.Start})
+	}
+
+	return d
+}
+
+func (f *folder) block(b *ast.Block) {
+	if b != nil {
+		f.statements(b.Statements)
+	}
+}
+
+func (f *folder) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		f.at = stmt
+		f.statement(stmt)
+	}
+}
+
+func (f *folder) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.Assignment:
+		f.expression(s.Expression)
+	case *ast.Block:
+		f.block(s)
+	case *ast.Declaration:
+		f.declaration(s)
+	case *ast.ExpressionStatement:
+		f.expression(s.Expression)
+	case *ast.ForStatement:
+		f.expression(s.Range)
+		f.block(s.Loop)
+	case *ast.IfStatement:
+		f.expression(s.Condition)
+		f.block(s.Consequence)
+		f.block(s.Alternative)
+	case *ast.Match:
+		f.expression(s.Subject)
+
+		for _, c := range s.Cases {
+			f.foldType(c.MatchType)
+			f.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			f.statements(s.Default.Body)
+		}
+	case *ast.Method:
+		f.foldType(s.Type)
+		f.statement(s.Declaration)
+	case *ast.Return:
+		f.expressions(s.Values)
+	case *ast.Switch:
+		for _, c := range s.Cases {
+			f.expression(c.Condition)
+			f.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			f.statements(s.Default.Body)
+		}
+	case *ast.Type:
+		f.foldType(s.Alias)
+	}
+}
+
+func (f *folder) declaration(decl *ast.Declaration) {
+	ident := decl.Assignment.Identifier
+
+	f.foldType(ident.ValueType)
+
+	if ident.Qualifier != ast.QualifierCompileTime {
+		f.expression(decl.Assignment.Expression)
+		return
+	}
+
+	if fn := newFunction(f.file, decl); fn != nil {
+		if !ident.Global {
+			f.localFuncs[ident] = fn
+			f.checkPurity(fn)
+		}
+
+		inComp := f.inComp
+		f.inComp = true
+		f.expression(decl.Assignment.Expression)
+		f.inComp = inComp
+
+		return
+	}
+
+	c, ok := f.globals[ident.Name]
+	if !ident.Global || !ok {
+		c = &constant{decl: decl, file: f.file}
+		f.locals[ident] = c
+	}
+
+	f.steps = 0
+	_, _ = f.constant(c)
+}
+
+func (f *folder) expressions(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		f.expression(expr)
+	}
+}
+
+func (f *folder) expression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.ArrayLiteral:
+		f.arrayLiteral(e)
+	case *ast.Async:
+		f.expression(e.Call)
+	case *ast.Builtin:
+		f.expressions(e.Arguments)
+	case *ast.Call:
+		if fn := f.function(e); fn != nil && !f.inComp {
+			f.fold(e)
+			return
+		}
+
+		f.expression(e.Expression)
+		f.expressions(e.Arguments)
+	case *ast.EitherLiteral:
+		f.expression(e.Value)
+	case *ast.FormatString:
+		for _, part := range e.Parts {
+			if part.Value != nil {
+				f.expression(part.Value)
+			}
+		}
+	case *ast.GoCallExpression:
+		f.expressions(e.Arguments)
+	case *ast.Index:
+		f.expression(e.Identifier)
+		f.expression(e.Index)
+	case *ast.Infix:
+		f.expression(e.Left)
+		f.expression(e.Right)
+	case *ast.MapLiteral:
+		for _, pair := range e.Pairs {
+			f.expression(pair.Key)
+			f.expression(pair.Value)
+		}
+	case *ast.Prefix:
+		f.expression(e.Right)
+	case *ast.Propagate:
+		f.expression(e.Left)
+	case *ast.ProcedureLiteral:
+		f.foldType(e.ProcedureType)
+		f.block(e.Body)
+	case *ast.Range:
+		f.expression(e.Start)
+		f.expression(e.End)
+		f.expression(e.Step)
+	case *ast.ResultLiteral:
+		f.expression(e.Value)
+	case *ast.Selector:
+		f.expression(e.Expression)
+	case *ast.SetLiteral:
+		f.expressions(e.Values)
+	case *ast.SliceLiteral:
+		f.expressions(e.Values)
+	case *ast.StructLiteral:
+		for _, field := range e.Values {
+			f.expression(field.Value)
+		}
+	case *ast.Suffix:
+		f.expression(e.Left)
+	case *ast.TupleLiteral:
+		f.expressions(e.Values)
+	case *ast.UnionLiteral:
+		f.expression(e.Value)
+	}
+}
+
+// function returns the comp func called by c, or nil if c does not call one
+// of this package.
+func (f *folder) function(c *ast.Call) *function {
+	ident, ok := c.Expression.(*ast.Identifier)
+	if !ok || c.Package != "" || ident.Qualifier != ast.QualifierCompileTime {
+		return nil
+	}
+
+	if fn, ok := f.localFuncs[ident]; ok {
+		return fn
+	}
+
+	return f.funcs[ident.Name]
+}
+
+// arrayLiteral checks that the values of an array literal fit in its length.
+func (f *folder) arrayLiteral(literal *ast.ArrayLiteral) {
+	f.expressions(literal.Values)
+	f.foldType(literal.ArrayType)
+
+	length, ok := f.folded[arrayLength(literal.ArrayType)]
+	if !ok {
+		return
+	}
+
+	v, _ := fromLiteral(length)
+	if v.i.Cmp(big.NewInt(int64(len(literal.Values)))) < 0 {
+		f.report(f.errorf("array literal has %d values, but the array length is %s", len(literal.Values), v))
+	}
+}
+
+func arrayLength(array *types.Array) ast.Expression {
+	if array == nil {
+		return nil
+	}
+
+	length, _ := array.Length.(ast.Expression)
+
+	return length
+}
+
+// foldType evaluates the lengths of the array types in typ.
+func (f *folder) foldType(typ types.Type) {
+	switch t := typ.(type) {
+	case *types.Alias:
+		if t.Global || t.Constraint != nil {
+			// Global aliases are folded where they are declared.
+			return
+		}
+
+		f.foldType(t.Derived)
+	case *types.Array:
+		f.foldType(t.Element)
+		f.foldArrayLength(t)
+	case *types.Map:
+		f.foldType(t.Key)
+		f.foldType(t.Value)
+	case *types.Option:
+		f.foldType(t.Value)
+	case *types.Procedure:
+		for _, param := range t.Parameters {
+			f.foldType(param.Type)
+		}
+
+		if t.ReturnType != nil {
+			f.foldType(t.ReturnType)
+		}
+	case *types.Reference:
+		f.foldType(t.Value)
+	case *types.Result:
+		f.foldType(t.Value)
+		f.foldType(t.Error)
+	case *types.Set:
+		f.foldType(t.Element)
+	case *types.Slice:
+		f.foldType(t.Element)
+	case *types.Struct:
+		for _, field := range t.Fields {
+			f.foldType(field.Type)
+		}
+	case *types.Tuple:
+		for _, elem := range t.Types {
+			f.foldType(elem)
+		}
+	}
+}
+
+// foldArrayLength evaluates the length of an array type, if it only depends on
+// compile-time values. Other lengths are left to the Go compiler.
+func (f *folder) foldArrayLength(array *types.Array) {
+	length := arrayLength(array)
+	if length == nil || f.arrays[array] || !isComptime(length) {
+		return
+	}
+
+	f.arrays[array] = true
+
+	v, err := f.evaluate(length)
+	if err != nil {
+		f.report(err)
+		return
+	}
+
+	if v.i.Sign() < 0 {
+		f.report(f.errorf("array length %s is negative", v))
+		return
+	}
+
+	if !v.i.IsUint64() {
+		f.report(f.errorf("array length %s is too large", v))
+		return
+	}
+
+	f.record(length, value{typ: types.Basics[types.Uint64], i: v.i})
+}
+
+// isComptime reports whether expr only consists of literals, comp values and
+// calls to comp funcs, and operators on them.
+func isComptime(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return e.Qualifier == ast.QualifierCompileTime
+	case *ast.Infix:
+		return isComptime(e.Left) && isComptime(e.Right)
+	case *ast.Prefix:
+		return isComptime(e.Right)
+	case *ast.Call:
+		ident, ok := e.Expression.(*ast.Identifier)
+		if !ok || e.Package != "" || ident.Qualifier != ast.QualifierCompileTime {
+			return false
+		}
+
+		for _, arg := range e.Arguments {
+			if !isComptime(arg) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		_, ok := fromLiteral(expr)
+		return ok
+	}
+}
@@ -0,0 +1,274 @@
+package comptime_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/comptime"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/parsetest"
+)
+
+// folded returns the folded value of comp declaration x.
+func folded(t *testing.T, src string) string {
+	t.Helper()
+
+	f := parsetest.Parse(t, src)
+
+	result, err := comptime.Fold([]*ast.File{f})
+	if err != nil {
+		t.Fatalf("fold error: %v", err)
+	}
+
+	for _, stmt := range f.Statements {
+		decl, ok := stmt.(*ast.Declaration)
+		if !ok || decl.Assignment.Identifier.Name != "x" {
+			continue
+		}
+
+		literal, ok := result[decl.Assignment.Expression]
+		if !ok {
+			t.Fatalf("x is not folded")
+		}
+
+		return literal.String()
+	}
+
+	t.Fatal("missing declaration of x")
+
+	return ""
+}
+
+func TestFold(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		src  string
+		want string
+	}{
+		"arithmetic": {`comp x : int64 = (7 + 5) * 3 - 10 / 4 % 3`, "(34 : int64)"},
+		"negative":   {`comp x : int8 = -128`, "(-128 : int8)"},
+		"bitwise":    {`comp x : uint16 = 0xff00 &^ 0x0f00 ||| 1 << 3 ^ 2`, "(61450 : uint16)"},
+		"int128":     {`comp x : int128 = -(1 << 126)`, "(-0x40000000000000000000000000000000 : int128)"},
+		"uint128":    {`comp x : uint128 = (1 << 127) - 1 + (1 << 127)`, "(340282366920938463463374607431768211455 : uint128)"},
+		"float32":    {`comp x : float32 = 1.0 / 3.0`, "(0.33333334 : float32)"},
+		"bool": {`comp one : int64 = 1
+comp small := one < 2
+comp four := one == 4
+comp x := small && !four || false`, "true"},
+		"short_circuit": {`comp divides : func(n : int64) bool = {
+	return n / n == 1
+}
+
+comp zero : int64 = 0
+comp positive := zero > 0
+comp x := positive && divides(zero)`, "false"},
+		"string": {`comp x : utf8 = "a" + "b" + "c"`, `("abc" : utf8)`},
+		"reference": {`comp y : int64 = 20
+comp x : int64 = y + z
+comp z : int64 = 1`, "(21 : int64)"},
+		"builtins": {`comp s : utf8 = "hello"
+comp x : uint64 = @if(@len(s) > 3, @len(s), 0)`, "(5 : uint64)"},
+		"recursion": {`comp fact : func(n : uint64) uint64 = {
+	if n == 0 {
+		return 1
+	}
+	return n * fact(n - 1)
+}
+
+comp x : uint64 = fact(20)`, "(2432902008176640000 : uint64)"},
+		"memoised": {`comp fib : func(n : uint64) uint64 = {
+	if n < 2 {
+		return n
+	}
+	return fib(n - 1) + fib(n - 2)
+}
+
+comp x : uint64 = fib(80)`, "(23416728348467685 : uint64)"},
+		"loop": {`comp sum : func(n : int64) int64 = {
+	var total : int64 = 0
+	outer: for i in 1..=n {
+		if i == 6 {
+			break outer
+		}
+		for j in 0..i {
+			if j == 3 {
+				continue outer
+			}
+			if j == 1 {
+				// Only leaves the if.
+				break
+			}
+			total = total + j
+		}
+	}
+	return total
+}
+
+comp x : int64 = sum(100)`, "(10 : int64)"},
+		"default_parameter": {`comp scale : func(v : int32, by? : int32 = 3, offset? : int32) int32 = {
+	return v * by + offset
+}
+
+comp x : int32 = scale(2) + scale(2, 2, 1)`, "(11 : int32)"},
+		"switch": {`comp name : func(n : uint8) ascii = {
+	switch n {
+	case 1:
+		return "one"
+	case 2:
+		return "two"
+	default:
+		return "many"
+	}
+}
+
+comp x : ascii = name(2) + name(9)`, `("twomany" : ascii)`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := folded(t, tt.src); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFoldShouldError(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		src   string
+		want  string
+		notes []string
+	}{
+		"overflow": {
+			src: `comp a : int8 = 100
+comp x : int8 = a + 28`,
+			want: "main.cog:4:6: constant 128 overflows int8 (range -128 to 127)",
+		},
+		"shift_overflow": {
+			src: `comp a : uint8 = 1
+comp x : uint8 = a << 8`,
+			want: "main.cog:4:6: constant 256 overflows uint8 (range 0 to 255)",
+		},
+		"float_overflow": {
+			src: `comp a : float16 = 60000.0
+comp x : float16 = a * 2.0`,
+			want: "main.cog:4:6: constant +Inf overflows float16",
+		},
+		"not_comp": {
+			src: `y := 3
+comp x : int64 = y`,
+			want: `main.cog:4:6: "y" is not comp, so its value is not known at compile time`,
+		},
+		"not_scalar": {
+			src:  `comp x : []int64 = {1, 2}`,
+			want: `main.cog:3:6: values of type "[]int64" cannot be computed at compile time`,
+		},
+		"no_return": {
+			src: `comp f : func(n : int64) int64 = {
+	if n > 0 {
+		return n
+	}
+}
+
+comp x : int64 = f(0)`,
+			want:  `main.cog:3:34: comp func "f" ends without returning a value`,
+			notes: []string{`in comp func "f" called at main.cog:9:6`},
+		},
+		"nested": {
+			src: `comp inverse : func(x : int64) int64 = {
+	return 1 / x
+}
+
+comp twice : func(x : int64) int64 = {
+	return inverse(x) * 2
+}
+
+comp x : int64 = twice(0)`,
+			want: "main.cog:4:2: division by zero",
+			notes: []string{
+				`in comp func "inverse" called at main.cog:8:2`,
+				`in comp func "twice" called at main.cog:11:6`,
+			},
+		},
+		"depth": {
+			src: `comp loop : func(n : int64) int64 = {
+	return loop(n + 1)
+}
+
+comp x : int64 = loop(0)`,
+			want: `main.cog:4:2: comp func "loop" exceeds the maximum call depth of 256`,
+			notes: []string{
+				`in 255 calls of comp func "loop" at main.cog:4:2`,
+				`in comp func "loop" called at main.cog:7:6`,
+			},
+		},
+		"mutual_depth": {
+			src: `comp ping : func(n : int64) int64 = {
+	return pong(n)
+}
+
+comp pong : func(n : int64) int64 = {
+	return ping(n)
+}
+
+comp x : int64 = ping(0)`,
+			want: `main.cog:8:2: comp func "ping" exceeds the maximum call depth of 256`,
+			notes: []string{
+				`in comp func "pong" called at main.cog:4:2`,
+				`in comp func "ping" called at main.cog:8:2`,
+				`in comp func "pong" called at main.cog:4:2`,
+				`in comp func "ping" called at main.cog:8:2`,
+				`in comp func "pong" called at main.cog:4:2`,
+				`in comp func "ping" called at main.cog:8:2`,
+				`in comp func "pong" called at main.cog:4:2`,
+				`in comp func "ping" called at main.cog:8:2`,
+				`and 248 more comp func calls`,
+			},
+		},
+		"impure_once": {
+			src: `comp f : func(n : int64) int64 = {
+	@print(n)
+	return n
+}
+
+comp x : int64 = f(1)
+comp y : int64 = f(2)`,
+			want: `main.cog:4:2: comp func "f" cannot call @print`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := comptime.Fold([]*ast.File{parsetest.Parse(t, tt.src)})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			diags := diag.All(err)
+			if len(diags) != 1 {
+				t.Fatalf("got %d diagnostics, want 1: %v", len(diags), err)
+			}
+
+			if diags[0].Code != diag.CodeComptime {
+				t.Errorf("got code %s, want %s", diags[0].Code, diag.CodeComptime)
+			}
+
+			if got := diags[0].Error(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			notes := make([]string, len(diags[0].Notes))
+			for i, note := range diags[0].Notes {
+				notes[i] = note.String()
+			}
+
+			if !slices.Equal(notes, tt.notes) {
+				t.Errorf("got notes %q, want %q", notes, tt.notes)
+			}
+		})
+	}
+}
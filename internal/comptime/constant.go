@@ -0,0 +1,71 @@
+package comptime
+
+import (
+	"errors"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// Constant evaluates expr if it consists of operators applied to literals
+// only, and returns an error if its value overflows its type or cannot be
+// computed, such as a division by zero. Other expressions are left to Fold
+// and the Go compiler, and bare literals are checked when they are parsed.
+func Constant(expr ast.Expression) error {
+	switch expr.(type) {
+	case *ast.Infix, *ast.Prefix:
+	default:
+		return nil
+	}
+
+	if _, err := evalConstant(expr); err != nil && !errors.Is(err, errUnsupported) {
+		return err
+	}
+
+	return nil
+}
+
+func evalConstant(expr ast.Expression) (value, error) {
+	if v, ok := fromLiteral(expr); ok {
+		return v, nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.Infix:
+		x, err := evalConstant(e.Left)
+		if err != nil {
+			return value{}, err
+		}
+
+		y, err := evalConstant(e.Right)
+		if err != nil {
+			return value{}, err
+		}
+
+		switch op := e.Operator.Type; {
+		case op == tokens.ShiftLeft || op == tokens.ShiftRight:
+			if !types.IsFixed(x.typ) || !types.IsFixed(y.typ) {
+				return value{}, errUnsupported
+			}
+		case x.typ.Kind() != y.typ.Kind():
+			// Mixed operands are reported by the parser.
+			return value{}, errUnsupported
+		case op == tokens.And:
+			return value{typ: e.Type(), b: x.b && y.b}, nil
+		case op == tokens.Or:
+			return value{typ: e.Type(), b: x.b || y.b}, nil
+		}
+
+		return infix(e.Operator.Type, e.Type(), x, y)
+	case *ast.Prefix:
+		v, err := evalConstant(e.Right)
+		if err != nil {
+			return value{}, err
+		}
+
+		return prefix(e.Operator.Type, v)
+	}
+
+	return value{}, errUnsupported
+}
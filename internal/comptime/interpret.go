@@ -0,0 +1,531 @@
+package comptime
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// frame holds the variables of a comp func call, in nested block scopes.
+type frame struct {
+	scopes []map[string]value
+	result *value
+}
+
+func (fr *frame) push() {
+	fr.scopes = append(fr.scopes, make(map[string]value))
+}
+
+func (fr *frame) pop() {
+	fr.scopes = fr.scopes[:len(fr.scopes)-1]
+}
+
+func (fr *frame) declare(name string, v value) {
+	fr.scopes[len(fr.scopes)-1][name] = v
+}
+
+// scope returns the innermost scope that declares name.
+func (fr *frame) scope(name string) (map[string]value, bool) {
+	for i := len(fr.scopes) - 1; i >= 0; i-- {
+		if _, ok := fr.scopes[i][name]; ok {
+			return fr.scopes[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// control is how a statement ends: normally, or by a break, continue or return.
+type control uint8
+
+const (
+	next control = iota
+	breaking
+	continuing
+	returning
+)
+
+// flow is the control of a statement, with the label of a break or continue.
+type flow struct {
+	control control
+	label   string
+}
+
+func (fl flow) consumes(control control, label *ast.Label) bool {
+	if fl.control != control {
+		return false
+	}
+
+	return fl.label == "" || label != nil && label.Label.Name == fl.label
+}
+
+// step counts an executed statement or call against the evaluation limit.
+func (f *folder) step() error {
+	f.steps++
+
+	if f.steps > maxSteps {
+		return f.errorf("compile-time evaluation exceeds %d steps", maxSteps)
+	}
+
+	return nil
+}
+
+func (f *folder) eval(expr ast.Expression) (value, error) {
+	if v, ok := fromLiteral(expr); ok {
+		return v, nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.Builtin:
+		return f.evalBuiltin(e)
+	case *ast.Call:
+		return f.evalCall(e)
+	case *ast.Identifier:
+		return f.evalIdentifier(e)
+	case *ast.Infix:
+		return f.evalInfix(e)
+	case *ast.Prefix:
+		v, err := f.eval(e.Right)
+		if err != nil {
+			return value{}, err
+		}
+
+		v, err = prefix(e.Operator.Type, v)
+		if err != nil {
+			return value{}, f.errorf("%v", err)
+		}
+
+		return v, nil
+	}
+
+	if !isScalar(expr.Type()) {
+		return value{}, f.errorf("values of type %q cannot be computed at compile time", expr.Type())
+	}
+
+	return value{}, f.errorf("%s cannot be evaluated at compile time", expr)
+}
+
+func (f *folder) evalIdentifier(ident *ast.Identifier) (value, error) {
+	if f.frame != nil {
+		if scope, ok := f.frame.scope(ident.Name); ok {
+			return scope[ident.Name], nil
+		}
+	}
+
+	if ident.Qualifier != ast.QualifierCompileTime {
+		return value{}, f.errorf("%q is not comp, so its value is not known at compile time", ident.Name)
+	}
+
+	if c, ok := f.locals[ident]; ok {
+		return f.constant(c)
+	}
+
+	if c, ok := f.globals[ident.Name]; ok && ident.Global {
+		return f.constant(c)
+	}
+
+	return value{}, f.errorf("%q cannot be evaluated at compile time", ident.Name)
+}
+
+func (f *folder) evalInfix(e *ast.Infix) (value, error) {
+	x, err := f.eval(e.Left)
+	if err != nil {
+		return value{}, err
+	}
+
+	// The logical operators only evaluate their right operand if needed.
+	switch e.Operator.Type {
+	case tokens.And:
+		if !x.b {
+			return x, nil
+		}
+
+		return f.eval(e.Right)
+	case tokens.Or:
+		if x.b {
+			return x, nil
+		}
+
+		return f.eval(e.Right)
+	}
+
+	y, err := f.eval(e.Right)
+	if err != nil {
+		return value{}, err
+	}
+
+	v, err := infix(e.Operator.Type, e.Type(), x, y)
+	if err != nil {
+		return value{}, f.errorf("%v", err)
+	}
+
+	return v, nil
+}
+
+func (f *folder) evalBuiltin(b *ast.Builtin) (value, error) {
+	switch {
+	case b.Name == "if" && len(b.Arguments) == 3:
+		condition, err := f.eval(b.Arguments[0])
+		if err != nil {
+			return value{}, err
+		}
+
+		if condition.b {
+			return f.eval(b.Arguments[1])
+		}
+
+		return f.eval(b.Arguments[2])
+	case b.Name == "len" && len(b.Arguments) == 1 && types.IsString(b.Arguments[0].Type()):
+		s, err := f.eval(b.Arguments[0])
+		if err != nil {
+			return value{}, err
+		}
+
+		return integer(b.Type(), big.NewInt(int64(len(s.s))))
+	default:
+		return value{}, f.errorf("@%s cannot be evaluated at compile time", b.Name)
+	}
+}
+
+func (f *folder) evalCall(c *ast.Call) (value, error) {
+	fn := f.function(c)
+	if fn == nil {
+		return value{}, f.errorf("%s is not a comp func, so it cannot be called at compile time", c.Expression)
+	}
+
+	if fn.impure {
+		return value{}, errFailed
+	}
+
+	if err := f.step(); err != nil {
+		return value{}, err
+	}
+
+	args := make([]value, len(fn.typ.Parameters))
+	keys := make([]string, len(fn.typ.Parameters))
+
+	for i, param := range fn.typ.Parameters {
+		var (
+			v   value
+			err error
+		)
+
+		switch {
+		case i < len(c.Arguments):
+			v, err = f.eval(c.Arguments[i])
+		case param.Default != nil:
+			v, err = f.eval(param.Default.(ast.Expression))
+		default:
+			v = zero(param.Type)
+		}
+
+		if err != nil {
+			return value{}, err
+		}
+
+		args[i], keys[i] = v, v.key()
+	}
+
+	key := memoKey{fn: fn, args: strings.Join(keys, ", ")}
+
+	if v, ok := f.memo[key]; ok {
+		return v, nil
+	}
+
+	if len(f.calls) >= maxDepth {
+		return value{}, f.errorf("comp func %q exceeds the maximum call depth of %d", fn.name, maxDepth)
+	}
+
+	// Interpret the body in the context of the func.
+	file, at, fr := f.file, f.at, f.frame
+	f.calls = append(f.calls, call{fn: fn, file: file, at: at})
+	f.file, f.frame = fn.file, &frame{}
+
+	defer func() {
+		f.file, f.at, f.frame = file, at, fr
+		f.calls = f.calls[:len(f.calls)-1]
+	}()
+
+	f.frame.push()
+
+	for i, param := range fn.typ.Parameters {
+		f.frame.declare(param.Name, args[i])
+	}
+
+	if _, err := f.execBlock(fn.body); err != nil {
+		return value{}, err
+	}
+
+	if f.frame.result == nil {
+		f.at = fn.body
+		return value{}, f.errorf("comp func %q ends without returning a value", fn.name)
+	}
+
+	result := *f.frame.result
+	f.memo[key] = result
+
+	return result, nil
+}
+
+func (f *folder) execBlock(b *ast.Block) (flow, error) {
+	f.frame.push()
+	defer f.frame.pop()
+
+	return f.execStatements(b.Statements)
+}
+
+func (f *folder) execStatements(stmts []ast.Statement) (flow, error) {
+	for _, stmt := range stmts {
+		fl, err := f.exec(stmt)
+		if err != nil || fl.control != next {
+			return fl, err
+		}
+	}
+
+	return flow{}, nil
+}
+
+func (f *folder) exec(stmt ast.Statement) (flow, error) {
+	f.at = stmt
+
+	if err := f.step(); err != nil {
+		return flow{}, err
+	}
+
+	switch s := stmt.(type) {
+	case *ast.Assignment:
+		v, err := f.eval(s.Expression)
+		if err != nil {
+			return flow{}, err
+		}
+
+		if s.Identifier.Name == "_" {
+			return flow{}, nil
+		}
+
+		scope, ok := f.frame.scope(s.Identifier.Name)
+		if !ok {
+			return flow{}, f.errorf("comp func cannot assign to %q, it is not declared in the func", s.Identifier.Name)
+		}
+
+		scope[s.Identifier.Name] = v
+	case *ast.Block:
+		return f.execBlock(s)
+	case *ast.Branch:
+		fl := flow{control: breaking}
+		if s.Token.Type == tokens.Continue {
+			fl.control = continuing
+		}
+
+		if s.Label != nil {
+			fl.label = s.Label.Name
+		}
+
+		return fl, nil
+	case *ast.Comment:
+	case *ast.Declaration:
+		v := zero(s.Assignment.Identifier.ValueType)
+
+		if s.Assignment.Expression != nil {
+			var err error
+
+			v, err = f.eval(s.Assignment.Expression)
+			if err != nil {
+				return flow{}, err
+			}
+		} else if !isScalar(v.typ) {
+			return flow{}, f.errorf("values of type %q cannot be computed at compile time", v.typ)
+		}
+
+		f.frame.declare(s.Assignment.Identifier.Name, v)
+	case *ast.ExpressionStatement:
+		_, err := f.eval(s.Expression)
+		return flow{}, err
+	case *ast.ForStatement:
+		return f.execFor(s)
+	case *ast.IfStatement:
+		condition, err := f.eval(s.Condition)
+		if err != nil {
+			return flow{}, err
+		}
+
+		var fl flow
+
+		if condition.b {
+			fl, err = f.execBlock(s.Consequence)
+		} else if s.Alternative != nil {
+			fl, err = f.execBlock(s.Alternative)
+		}
+
+		if err != nil || fl.consumes(breaking, s.Label) {
+			return flow{}, err
+		}
+
+		return fl, nil
+	case *ast.Return:
+		if len(s.Values) != 1 {
+			return flow{}, f.errorf("comp func must return a single value")
+		}
+
+		v, err := f.eval(s.Values[0])
+		if err != nil {
+			return flow{}, err
+		}
+
+		f.frame.result = &v
+
+		return flow{control: returning}, nil
+	case *ast.Switch:
+		return f.execSwitch(s)
+	default:
+		return flow{}, f.errorf("statement cannot be evaluated at compile time")
+	}
+
+	return flow{}, nil
+}
+
+func (f *folder) execFor(s *ast.ForStatement) (flow, error) {
+	// body runs one iteration, and reports whether the loop continues.
+	body := func() (bool, flow, error) {
+		if err := f.step(); err != nil {
+			return false, flow{}, err
+		}
+
+		fl, err := f.execBlock(s.Loop)
+
+		switch {
+		case err != nil:
+			return false, flow{}, err
+		case fl.consumes(breaking, s.Label):
+			return false, flow{}, nil
+		case fl.consumes(continuing, s.Label), fl.control == next:
+			return true, flow{}, nil
+		default:
+			return false, fl, nil
+		}
+	}
+
+	if s.Range == nil {
+		for {
+			more, fl, err := body()
+			if !more {
+				return fl, err
+			}
+		}
+	}
+
+	r, ok := s.Range.(*ast.Range)
+	if !ok {
+		return flow{}, f.errorf("comp func can only loop over a range of integers")
+	}
+
+	start, err := f.eval(r.Start)
+	if err != nil {
+		return flow{}, err
+	}
+
+	end, err := f.eval(r.End)
+	if err != nil {
+		return flow{}, err
+	}
+
+	step := big.NewInt(1)
+
+	if r.Step != nil {
+		v, err := f.eval(r.Step)
+		if err != nil {
+			return flow{}, err
+		}
+
+		if v.i.Sign() == 0 {
+			return flow{}, f.errorf("range step is zero")
+		}
+
+		step = v.i
+	}
+
+	i := new(big.Int).Set(start.i)
+
+	for {
+		cmp := i.Cmp(end.i)
+		if step.Sign() < 0 {
+			cmp = -cmp
+		}
+
+		if cmp > 0 || cmp == 0 && !r.Inclusive() {
+			return flow{}, nil
+		}
+
+		f.frame.push()
+
+		if s.Value != nil {
+			f.frame.declare(s.Value.Name, value{typ: start.typ, i: new(big.Int).Set(i)})
+		}
+
+		more, fl, err := body()
+
+		f.frame.pop()
+
+		if !more {
+			return fl, err
+		}
+
+		i.Add(i, step)
+
+		// Stop at the end of the integer type, instead of wrapping around.
+		if _, err := integer(start.typ, i); err != nil {
+			return flow{}, nil
+		}
+	}
+}
+
+func (f *folder) execSwitch(s *ast.Switch) (flow, error) {
+	var subject value
+
+	if s.Identifier != nil {
+		var err error
+
+		subject, err = f.eval(s.Identifier)
+		if err != nil {
+			return flow{}, err
+		}
+	}
+
+	body := s.Default
+
+	for _, c := range s.Cases {
+		v, err := f.eval(c.Condition)
+		if err != nil {
+			return flow{}, err
+		}
+
+		if s.Identifier != nil {
+			v, err = compare(tokens.Equal, types.Basics[types.Bool], subject, v)
+			if err != nil {
+				return flow{}, f.errorf("%v", err)
+			}
+		}
+
+		if v.b {
+			body = &ast.Default{Token: c.Token, Body: c.Body}
+			break
+		}
+	}
+
+	if body == nil {
+		return flow{}, nil
+	}
+
+	f.frame.push()
+	defer f.frame.pop()
+
+	fl, err := f.execStatements(body.Body)
+	if err != nil || fl.consumes(breaking, s.Label) {
+		return flow{}, err
+	}
+
+	return fl, nil
+}
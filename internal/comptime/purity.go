@@ -0,0 +1,209 @@
+package comptime
+
+import (
+	"fmt"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// impureBuiltins are the builtins with side effects, or that allocate, which
+// a comp func cannot call.
+var impureBuiltins = map[string]bool{
+	"await":  true,
+	"delete": true,
+	"map":    true,
+	"print":  true,
+	"ref":    true,
+	"set":    true,
+	"slice":  true,
+}
+
+// purity checks that the body of a comp func has no side effects, so it can
+// be evaluated at compile time.
+type purity struct {
+	f    *folder
+	fn   *function
+	at   ast.Statement // statement being checked
+	errs []error
+}
+
+// checkPurity reports every use of dyn variables, procs, Go interop and
+// impure builtins in the body of fn.
+func (f *folder) checkPurity(fn *function) {
+	p := &purity{f: f, fn: fn}
+
+	p.block(fn.body)
+
+	fn.impure = len(p.errs) > 0
+	f.errs = append(f.errs, p.errs...)
+}
+
+func (p *purity) errorf(format string, args ...any) {
+	msg := fmt.Sprintf("comp func %q ", p.fn.name) + fmt.Sprintf(format, args...)
+	p.errs = append(p.errs, diag.At(p.fn.file, p.at, diag.CodeComptime, msg))
+}
+
+func (p *purity) block(b *ast.Block) {
+	if b != nil {
+		p.statements(b.Statements)
+	}
+}
+
+func (p *purity) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		p.at = stmt
+		p.statement(stmt)
+	}
+}
+
+func (p *purity) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.Assignment:
+		p.identifier(s.Identifier)
+		p.expression(s.Expression)
+	case *ast.Block:
+		p.block(s)
+	case *ast.Declaration:
+		p.expression(s.Assignment.Expression)
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression)
+	case *ast.ForStatement:
+		p.expression(s.Range)
+		p.block(s.Loop)
+	case *ast.IfStatement:
+		p.expression(s.Condition)
+		p.block(s.Consequence)
+		p.block(s.Alternative)
+	case *ast.Match:
+		p.expression(s.Subject)
+
+		for _, c := range s.Cases {
+			p.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			p.statements(s.Default.Body)
+		}
+	case *ast.Return:
+		p.expressions(s.Values)
+	case *ast.Switch:
+		if s.Identifier != nil {
+			p.identifier(s.Identifier)
+		}
+
+		for _, c := range s.Cases {
+			p.expression(c.Condition)
+			p.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			p.statements(s.Default.Body)
+		}
+	}
+}
+
+func (p *purity) expressions(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		p.expression(expr)
+	}
+}
+
+func (p *purity) expression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.ArrayLiteral:
+		p.expressions(e.Values)
+	case *ast.Async:
+		p.errorf("cannot make async calls")
+	case *ast.Builtin:
+		if impureBuiltins[e.Name] {
+			p.errorf("cannot call @%s", e.Name)
+		}
+
+		p.expressions(e.Arguments)
+	case *ast.Call:
+		p.call(e)
+		p.expressions(e.Arguments)
+	case *ast.EitherLiteral:
+		p.expression(e.Value)
+	case *ast.FormatString:
+		for _, part := range e.Parts {
+			if part.Value != nil {
+				p.expression(part.Value)
+			}
+		}
+	case *ast.GoCallExpression:
+		p.errorf("cannot call Go function @go.%s.%s", e.Import.Name, e.CallIdentifier.Name)
+	case *ast.GoValue:
+		p.errorf("cannot use Go value @go.%s.%s", e.Import.Name, e.Identifier.Name)
+	case *ast.Identifier:
+		p.identifier(e)
+	case *ast.Index:
+		p.expression(e.Identifier)
+		p.expression(e.Index)
+	case *ast.Infix:
+		p.expression(e.Left)
+		p.expression(e.Right)
+	case *ast.MapLiteral:
+		for _, pair := range e.Pairs {
+			p.expression(pair.Key)
+			p.expression(pair.Value)
+		}
+	case *ast.Prefix:
+		p.expression(e.Right)
+	case *ast.Propagate:
+		p.expression(e.Left)
+	case *ast.ProcedureLiteral:
+		p.block(e.Body)
+	case *ast.Range:
+		p.expression(e.Start)
+		p.expression(e.End)
+		p.expression(e.Step)
+	case *ast.ResultLiteral:
+		p.expression(e.Value)
+	case *ast.Selector:
+		p.expression(e.Expression)
+	case *ast.SetLiteral:
+		p.expressions(e.Values)
+	case *ast.SliceLiteral:
+		p.expressions(e.Values)
+	case *ast.StructLiteral:
+		for _, field := range e.Values {
+			p.expression(field.Value)
+		}
+	case *ast.Suffix:
+		p.expression(e.Left)
+	case *ast.TupleLiteral:
+		p.expressions(e.Values)
+	case *ast.UnionLiteral:
+		p.expression(e.Value)
+	}
+}
+
+func (p *purity) identifier(ident *ast.Identifier) {
+	switch {
+	case ident.Qualifier == ast.QualifierDynamic:
+		p.errorf("cannot use dynamically scoped variable %q", ident.Name)
+	case ident.Global && ident.Qualifier == ast.QualifierVariable:
+		p.errorf("cannot use global variable %q", ident.Name)
+	}
+}
+
+func (p *purity) call(c *ast.Call) {
+	if c.Package != "" {
+		p.errorf("cannot call %q of package %q, only comp funcs of its own package", c.Expression, c.Package)
+		return
+	}
+
+	if p.f.function(c) != nil {
+		return
+	}
+
+	if procType, ok := c.Expression.Type().(*types.Procedure); ok && !procType.Function {
+		p.errorf("cannot call proc %s", c.Expression)
+		return
+	}
+
+	p.errorf("can only call comp funcs, and %s is not comp", c.Expression)
+}
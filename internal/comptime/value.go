@@ -0,0 +1,388 @@
+package comptime
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	f16 "github.com/x448/float16"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// value is a value known at compile time: a boolean, integer, float or
+// string of a Cog type. Only the field of the kind of typ is set.
+type value struct {
+	typ types.Type
+	b   bool
+	i   *big.Int
+	f   float64
+	s   string
+}
+
+// isScalar reports whether values of type typ can be computed at compile time.
+func isScalar(typ types.Type) bool {
+	return types.IsBool(typ) || types.IsFixed(typ) || types.IsFloat(typ) || types.IsString(typ)
+}
+
+func (v value) String() string {
+	switch {
+	case types.IsBool(v.typ):
+		return strconv.FormatBool(v.b)
+	case types.IsFixed(v.typ):
+		return v.i.String()
+	case types.IsFloat(v.typ):
+		return strconv.FormatFloat(v.f, 'g', -1, 64)
+	default:
+		return strconv.Quote(v.s)
+	}
+}
+
+// key identifies v in the memoised calls of comp funcs.
+func (v value) key() string {
+	return v.typ.String() + " " + v.String()
+}
+
+// zero returns the zero value of typ.
+func zero(typ types.Type) value {
+	return value{typ: typ, i: new(big.Int)}
+}
+
+// fromLiteral returns the value of a literal, or false if expr is not a
+// literal of a scalar type.
+func fromLiteral(expr ast.Expression) (value, bool) {
+	v := value{typ: expr.Type()}
+
+	switch l := expr.(type) {
+	case *ast.BoolLiteral:
+		v.b = l.Value
+	case *ast.Int8Literal:
+		v.i = big.NewInt(int64(l.Value))
+	case *ast.Int16Literal:
+		v.i = big.NewInt(int64(l.Value))
+	case *ast.Int32Literal:
+		v.i = big.NewInt(int64(l.Value))
+	case *ast.Int64Literal:
+		v.i = big.NewInt(l.Value)
+	case *ast.Int128Literal:
+		// The value is formatted in hexadecimal.
+		v.i, _ = new(big.Int).SetString(l.Value.String(), 0)
+	case *ast.Uint8Literal:
+		v.i = new(big.Int).SetUint64(uint64(l.Value))
+	case *ast.Uint16Literal:
+		v.i = new(big.Int).SetUint64(uint64(l.Value))
+	case *ast.Uint32Literal:
+		v.i = new(big.Int).SetUint64(uint64(l.Value))
+	case *ast.Uint64Literal:
+		v.i = new(big.Int).SetUint64(l.Value)
+	case *ast.Uint128Literal:
+		v.i = l.Value.Big()
+	case *ast.Float16Literal:
+		v.f = float64(l.Value.Float32())
+	case *ast.Float32Literal:
+		v.f = float64(l.Value)
+	case *ast.Float64Literal:
+		v.f = l.Value
+	case *ast.ASCIILiteral:
+		v.s = string(l.Value)
+	case *ast.UTF8Literal:
+		v.s = l.Value
+	default:
+		return value{}, false
+	}
+
+	return v, true
+}
+
+// literal returns the literal of v, positioned at ln and col.
+func (v value) literal(ln uint32, col uint16) (ast.Expression, error) {
+	t := tokens.Token{Ln: ln, Col: col, Literal: v.String()}
+
+	switch {
+	case types.IsBool(v.typ):
+		t.Type = tokens.False
+		if v.b {
+			t.Type = tokens.True
+		}
+
+		return ast.NewBoolLiteral(t)
+	case types.IsFixed(v.typ):
+		t.Type = tokens.IntLiteral
+	case types.IsFloat(v.typ):
+		t.Type = tokens.FloatLiteral
+	default:
+		t.Type, t.Literal = tokens.StringLiteral, v.s
+	}
+
+	switch v.typ.Kind() {
+	case types.Int8:
+		return ast.NewInt8Literal(t)
+	case types.Int16:
+		return ast.NewInt16Literal(t)
+	case types.Int32:
+		return ast.NewInt32Literal(t)
+	case types.Int64:
+		return ast.NewInt64Literal(t)
+	case types.Int128:
+		return ast.NewInt128Literal(t)
+	case types.Uint8:
+		return ast.NewUint8Literal(t)
+	case types.Uint16:
+		return ast.NewUint16Literal(t)
+	case types.Uint32:
+		return ast.NewUint32Literal(t)
+	case types.Uint64:
+		return ast.NewUint64Literal(t)
+	case types.Uint128:
+		return ast.NewUint128Literal(t)
+	case types.Float16:
+		return ast.NewFloat16Literal(t)
+	case types.Float32:
+		return ast.NewFloat32Literal(t)
+	case types.Float64:
+		return ast.NewFloat64Literal(t)
+	case types.ASCII:
+		return ast.NewASCIILiteral(t)
+	case types.UTF8:
+		return ast.NewUTF8Literal(t), nil
+	default:
+		return nil, fmt.Errorf("values of type %q cannot be computed at compile time", v.typ)
+	}
+}
+
+// bits returns the size and signedness of integer type typ.
+func bits(typ types.Type) (size uint, signed bool) {
+	switch typ.Kind() {
+	case types.Int8:
+		return 8, true
+	case types.Int16:
+		return 16, true
+	case types.Int32:
+		return 32, true
+	case types.Int64:
+		return 64, true
+	case types.Int128:
+		return 128, true
+	case types.Uint8:
+		return 8, false
+	case types.Uint16:
+		return 16, false
+	case types.Uint32:
+		return 32, false
+	case types.Uint64:
+		return 64, false
+	default:
+		return 128, false
+	}
+}
+
+// integer returns the integer i of type typ, or an error if it overflows typ.
+func integer(typ types.Type, i *big.Int) (value, error) {
+	size, signed := bits(typ)
+
+	lower, upper := new(big.Int), new(big.Int).Lsh(big.NewInt(1), size)
+
+	if signed {
+		upper.Rsh(upper, 1)
+		lower.Neg(upper)
+	}
+
+	upper.Sub(upper, big.NewInt(1))
+
+	if i.Cmp(lower) < 0 || i.Cmp(upper) > 0 {
+		return value{}, fmt.Errorf("constant %s overflows %s (range %s to %s)", i, typ, lower, upper)
+	}
+
+	return value{typ: typ, i: i}, nil
+}
+
+// float returns f rounded to float type typ, or an error if it is not finite.
+func float(typ types.Type, f float64) (value, error) {
+	switch typ.Kind() {
+	case types.Float16:
+		f = float64(f16.Fromfloat32(float32(f)).Float32())
+	case types.Float32:
+		f = float64(float32(f))
+	}
+
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return value{}, fmt.Errorf("constant %s overflows %s", strconv.FormatFloat(f, 'g', -1, 64), typ)
+	}
+
+	return value{typ: typ, f: f}, nil
+}
+
+var (
+	errDivisionByZero = errors.New("division by zero")
+	errUnsupported    = errors.New("cannot be evaluated at compile time")
+)
+
+// unsupported returns the error for operator op on a value of type typ that
+// is not computed at compile time.
+func unsupported(op tokens.Type, typ types.Type) error {
+	return fmt.Errorf("operator %s on %q %w", op, typ, errUnsupported)
+}
+
+// prefix applies unary operator op to v.
+func prefix(op tokens.Type, v value) (value, error) {
+	switch {
+	case op == tokens.Not && types.IsBool(v.typ):
+		return value{typ: v.typ, b: !v.b}, nil
+	case op == tokens.Minus && types.IsFixed(v.typ):
+		return integer(v.typ, new(big.Int).Neg(v.i))
+	case op == tokens.Minus && types.IsFloat(v.typ):
+		return float(v.typ, -v.f)
+	default:
+		return value{}, unsupported(op, v.typ)
+	}
+}
+
+// infix applies binary operator op to x and y, giving a value of type typ.
+// The logical operators are handled by the caller, as they short-circuit.
+func infix(op tokens.Type, typ types.Type, x, y value) (value, error) {
+	switch op {
+	case tokens.Equal, tokens.NotEqual, tokens.LT, tokens.LTEqual, tokens.GT, tokens.GTEqual:
+		return compare(op, typ, x, y)
+	}
+
+	switch {
+	case types.IsFixed(x.typ):
+		return integerInfix(op, typ, x.i, y.i)
+	case types.IsFloat(x.typ):
+		return floatInfix(op, typ, x.f, y.f)
+	case types.IsString(x.typ) && op == tokens.Plus:
+		return value{typ: typ, s: x.s + y.s}, nil
+	default:
+		return value{}, unsupported(op, x.typ)
+	}
+}
+
+func compare(op tokens.Type, typ types.Type, x, y value) (value, error) {
+	var cmp int
+
+	switch {
+	case types.IsBool(x.typ):
+		if op != tokens.Equal && op != tokens.NotEqual {
+			return value{}, unsupported(op, x.typ)
+		}
+
+		if x.b != y.b {
+			cmp = 1
+		}
+	case types.IsFixed(x.typ):
+		cmp = x.i.Cmp(y.i)
+	case types.IsFloat(x.typ):
+		cmp = compareFloat(x.f, y.f)
+	default:
+		cmp = strings.Compare(x.s, y.s)
+	}
+
+	result := value{typ: typ}
+
+	switch op {
+	case tokens.Equal:
+		result.b = cmp == 0
+	case tokens.NotEqual:
+		result.b = cmp != 0
+	case tokens.LT:
+		result.b = cmp < 0
+	case tokens.LTEqual:
+		result.b = cmp <= 0
+	case tokens.GT:
+		result.b = cmp > 0
+	case tokens.GTEqual:
+		result.b = cmp >= 0
+	}
+
+	return result, nil
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// maxShift is the largest shift count that is computed; larger left shifts
+// overflow every integer type, and larger right shifts give the same result.
+const maxShift = 128
+
+func integerInfix(op tokens.Type, typ types.Type, x, y *big.Int) (value, error) {
+	z := new(big.Int)
+
+	switch op {
+	case tokens.Plus:
+		z.Add(x, y)
+	case tokens.Minus:
+		z.Sub(x, y)
+	case tokens.Asterisk:
+		z.Mul(x, y)
+	case tokens.Divide, tokens.Percent:
+		if y.Sign() == 0 {
+			return value{}, errDivisionByZero
+		}
+
+		// Quo and Rem truncate towards zero, like Go.
+		if op == tokens.Divide {
+			z.Quo(x, y)
+		} else {
+			z.Rem(x, y)
+		}
+	case tokens.BitAnd:
+		z.And(x, y)
+	case tokens.BitOr:
+		z.Or(x, y)
+	case tokens.BitXor:
+		z.Xor(x, y)
+	case tokens.BitClear:
+		z.AndNot(x, y)
+	case tokens.ShiftLeft, tokens.ShiftRight:
+		if y.Sign() < 0 {
+			return value{}, fmt.Errorf("negative shift count %s", y)
+		}
+
+		n := uint(maxShift)
+		if y.IsUint64() && y.Uint64() < maxShift {
+			n = uint(y.Uint64())
+		}
+
+		if op == tokens.ShiftLeft {
+			z.Lsh(x, n)
+		} else {
+			z.Rsh(x, n)
+		}
+	default:
+		return value{}, unsupported(op, typ)
+	}
+
+	return integer(typ, z)
+}
+
+func floatInfix(op tokens.Type, typ types.Type, x, y float64) (value, error) {
+	switch op {
+	case tokens.Plus:
+		return float(typ, x+y)
+	case tokens.Minus:
+		return float(typ, x-y)
+	case tokens.Asterisk:
+		return float(typ, x*y)
+	case tokens.Divide:
+		if y == 0 {
+			return value{}, errDivisionByZero
+		}
+
+		return float(typ, x/y)
+	default:
+		return value{}, unsupported(op, typ)
+	}
+}
@@ -0,0 +1,194 @@
+// Package diag defines the diagnostics reported by the compiler: errors and
+// warnings at a position in the Cog source, with an optional suggested fix.
+// The lexer, parser and transpiler all report diagnostics, which are rendered
+// for humans with a snippet of the source, or encoded as JSON for tools.
+package diag
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity uint8
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityNote
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityNote:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", uint8(s))
+	}
+}
+
+// MarshalText encodes the severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity encoded by name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, severity := range []Severity{SeverityError, SeverityWarning, SeverityNote} {
+		if string(text) == severity.String() {
+			*s = severity
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// Code identifies the kind of a diagnostic. Codes are stable, so tools can
+// rely on them. The first letter is the phase reporting the diagnostic.
+type Code string
+
+const (
+	// Lexer.
+	CodeInvalidText  Code = "L001" // source text the scanner cannot read
+	CodeUnknownToken Code = "L002" // character that does not start a token
+
+	// Parser.
+	CodeSyntax     Code = "P001" // syntax or type error
+	CodeUndefined  Code = "P002" // use of an undeclared identifier
+	CodeRedeclared Code = "P003" // identifier declared twice in a scope
+	CodePackage    Code = "P004" // missing or inconsistent package declaration
+	CodeImport     Code = "P005" // import that cannot be loaded, or import cycle
+	CodeModule     Code = "P006" // invalid cog.mod file, or required module that cannot be found
+
+	// Purity check.
+	CodeSideEffect Code = "S001" // side effect in a func
+
+	// Mutability check.
+	CodeImmutable Code = "M001" // change to an immutable variable
+
+	// Compile-time evaluation.
+	CodeComptime Code = "C001" // comp value or call that cannot be evaluated at compile time
+
+	// Transpiler.
+	CodeTranspile Code = "T001" // construct the transpiler cannot convert
+)
+
+// Pos is a position in a source file. Lines and columns start at 1, columns
+// are counted in runes. The zero Pos is unknown.
+type Pos struct {
+	Ln  int `json:"line"`
+	Col int `json:"column"`
+}
+
+// IsValid reports whether the position is known.
+func (p Pos) IsValid() bool {
+	return p.Ln > 0
+}
+
+// Span is the range of source text from Start up to, but not including, End.
+type Span struct {
+	Start Pos `json:"start"`
+	End   Pos `json:"end"`
+}
+
+// Fix is a suggested fix: the span of the diagnostic is replaced by Text.
+type Fix struct {
+	Message string `json:"message"`
+	Span    Span   `json:"span"`
+	Text    string `json:"text"`
+}
+
+// Note adds information to a diagnostic, optionally about another position in
+// the source, like the call a diagnostic is reported in.
+type Note struct {
+	Message string `json:"message"`
+	File    string `json:"file,omitempty"`
+	Pos     Pos    `json:"position,omitzero"`
+}
+
+// String formats the note as its message, followed by its position if it has
+// one: message at file:line:column.
+func (n Note) String() string {
+	if !n.Pos.IsValid() {
+		return n.Message
+	}
+
+	pos := fmt.Sprintf("%d:%d", n.Pos.Ln, n.Pos.Col)
+	if n.File != "" {
+		pos = n.File + ":" + pos
+	}
+
+	return n.Message + " at " + pos
+}
+
+// Diagnostic is a problem found in the source. It implements error, so it
+// can be reported along other errors.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     Code     `json:"code,omitempty"`
+	File     string   `json:"file,omitempty"`
+	Span     Span     `json:"span"`
+	Message  string   `json:"message"`
+	Notes    []Note   `json:"notes,omitempty"`
+	Fix      *Fix     `json:"fix,omitempty"`
+}
+
+// Position formats the file and start of the diagnostic like the Go compiler
+// does: file:line:column. It is empty if both are unknown.
+func (d *Diagnostic) Position() string {
+	pos := d.File
+
+	if start := d.Span.Start; start.IsValid() {
+		if pos != "" {
+			pos += ":"
+		}
+
+		pos += fmt.Sprintf("%d:%d", start.Ln, start.Col)
+	}
+
+	return pos
+}
+
+// Error formats the diagnostic as its position and message.
+func (d *Diagnostic) Error() string {
+	if pos := d.Position(); pos != "" {
+		return pos + ": " + d.Message
+	}
+
+	return d.Message
+}
+
+// All returns the diagnostics reported by err. Errors joined by err are
+// flattened, and errors wrapping a diagnostic are unwrapped. Other errors are
+// returned as a diagnostic without position.
+func All(err error) []*Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case *Diagnostic:
+		return []*Diagnostic{e}
+	case interface{ Unwrap() []error }:
+		var diags []*Diagnostic
+		for _, inner := range e.Unwrap() {
+			diags = append(diags, All(inner)...)
+		}
+
+		return diags
+	case interface{ Unwrap() error }:
+		// The context added by wrapping, e.g. the phase, is already part of
+		// the diagnostics.
+		var d *Diagnostic
+		if inner := e.Unwrap(); errors.As(inner, &d) {
+			return All(inner)
+		}
+	}
+
+	return []*Diagnostic{{Severity: SeverityError, Message: err.Error()}}
+}
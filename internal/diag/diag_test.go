@@ -0,0 +1,165 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		d    Diagnostic
+		want string
+	}{
+		{
+			name: "position",
+			d:    Diagnostic{File: "main.cog", Span: Span{Start: Pos{Ln: 3, Col: 5}}, Message: "undefined identifier"},
+			want: "main.cog:3:5: undefined identifier",
+		},
+		{
+			name: "file_only",
+			d:    Diagnostic{File: "main.cog", Message: "missing package declaration"},
+			want: "main.cog: missing package declaration",
+		},
+		{
+			name: "no_position",
+			d:    Diagnostic{Message: "no files in package"},
+			want: "no files in package",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.d.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	a := &Diagnostic{File: "a.cog", Message: "a"}
+	b := &Diagnostic{File: "b.cog", Message: "b"}
+	other := errors.New("other")
+
+	err := errors.Join(
+		fmt.Errorf("parser error:\n%w", errors.Join(a, b)),
+		fmt.Errorf("context: %w", other),
+	)
+
+	diags := All(err)
+	if len(diags) != 3 {
+		t.Fatalf("got %d diagnostics, want 3", len(diags))
+	}
+
+	if diags[0] != a || diags[1] != b {
+		t.Errorf("got %v, want the diagnostics themselves", diags[:2])
+	}
+
+	// Errors without diagnostics keep their context.
+	if diags[2].Message != "context: other" || diags[2].Severity != SeverityError {
+		t.Errorf("got %+v, want error without position", diags[2])
+	}
+
+	if All(nil) != nil {
+		t.Error("expected no diagnostics for nil error")
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("package main\n\nmain : proc() = {\n\tx := cout + 1\n}\n")
+
+	d := &Diagnostic{
+		Code:    CodeUndefined,
+		File:    "main.cog",
+		Span:    Span{Start: Pos{Ln: 4, Col: 7}, End: Pos{Ln: 4, Col: 11}},
+		Message: `undefined identifier "cout"`,
+		Notes:   []Note{{Message: "a note"}, {Message: "declared", File: "other.cog", Pos: Pos{Ln: 2, Col: 3}}},
+		Fix:     &Fix{Message: `did you mean "count"?`},
+	}
+
+	var buf bytes.Buffer
+
+	if err := Render(&buf, d, src); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `error[P002]: undefined identifier "cout"
+ --> main.cog:4:7
+  |
+4 | 	x := cout + 1
+  | 	     ^^^^
+  = note: a note
+  = note: declared at other.cog:2:3
+  = help: did you mean "count"?
+`
+
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without source, only the position is shown.
+	buf.Reset()
+
+	if err := Render(&buf, &Diagnostic{Severity: SeverityWarning, File: "main.cog", Message: "unused"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := buf.String(), "warning: unused\n--> main.cog\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	d := &Diagnostic{
+		Severity: SeverityWarning,
+		Code:     CodeSyntax,
+		File:     "main.cog",
+		Span:     Span{Start: Pos{Ln: 1, Col: 2}, End: Pos{Ln: 1, Col: 3}},
+		Message:  "message",
+	}
+
+	var buf bytes.Buffer
+
+	if err := WriteJSON(&buf, []*Diagnostic{d}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*Diagnostic
+
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0].Severity != SeverityWarning || got[0].Span != d.Span || got[0].Code != CodeSyntax {
+		t.Errorf("got %+v, want %+v", got, d)
+	}
+
+	if !bytes.Contains(buf.Bytes(), []byte(`"severity": "warning"`)) {
+		t.Errorf("severity is not encoded by name: %s", buf.String())
+	}
+
+	// No diagnostics are encoded as an empty array.
+	buf.Reset()
+
+	if err := WriteJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("got %q, want empty array", got)
+	}
+}
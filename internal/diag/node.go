@@ -0,0 +1,101 @@
+package diag
+
+import (
+	"reflect"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+)
+
+// At returns an error diagnostic about node in file, spanning the source of
+// node from its start to the end of its last token.
+func At(file *ast.File, node ast.Node, code Code, msg string) *Diagnostic {
+	start := Start(node)
+	start.Col = max(start.Col, 1)
+
+	d := &Diagnostic{
+		Severity: SeverityError,
+		Code:     code,
+		Span:     Span{Start: start, End: end(node, start)},
+		Message:  msg,
+	}
+
+	if file != nil {
+		d.File = file.Name
+	}
+
+	return d
+}
+
+// Start returns the position where the source of node starts. Declarations
+// are positioned at their assignment operator, but start at the identifier.
+func Start(node ast.Node) Pos {
+	if decl, ok := node.(*ast.Declaration); ok && decl.Assignment.Identifier.Token.Ln > 0 {
+		return Pos{Ln: int(decl.Assignment.Identifier.Token.Ln), Col: int(decl.Assignment.Identifier.Token.Col)}
+	}
+
+	ln, col := node.Pos()
+
+	return Pos{Ln: int(ln), Col: int(col)}
+}
+
+// end returns the position after the last token of node, or after the start
+// if node has no tokens past it. Identifiers are left out, except node itself
+// and declared identifiers, as a reference shares the identifier of the
+// declaration it refers to, and so its position.
+func end(node ast.Node, start Pos) Pos {
+	last := Pos{Ln: start.Ln, Col: start.Col + 1}
+
+	var declared *ast.Identifier
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Declaration:
+			declared = n.Assignment.Identifier
+		case *ast.Identifier:
+			if n != node && n != declared {
+				return true
+			}
+		}
+
+		for _, t := range nodeTokens(n) {
+			if t.Ln == 0 || before(Pos{Ln: int(t.Ln), Col: int(t.Col)}, start) {
+				continue
+			}
+
+			if after := (Pos{Ln: int(t.Ln), Col: int(t.Col) + t.Width()}); before(last, after) {
+				last = after
+			}
+		}
+
+		return true
+	})
+
+	return last
+}
+
+var tokenType = reflect.TypeFor[tokens.Token]()
+
+// nodeTokens returns the tokens that node holds in its fields.
+func nodeTokens(node ast.Node) []tokens.Token {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	v = v.Elem()
+
+	var toks []tokens.Token
+
+	for i := range v.NumField() {
+		if f := v.Field(i); f.Type() == tokenType && v.Type().Field(i).IsExported() {
+			toks = append(toks, f.Interface().(tokens.Token))
+		}
+	}
+
+	return toks
+}
+
+func before(a, b Pos) bool {
+	return a.Ln < b.Ln || a.Ln == b.Ln && a.Col < b.Col
+}
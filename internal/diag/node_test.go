@@ -0,0 +1,39 @@
+package diag_test
+
+import (
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/parsetest"
+)
+
+func TestAt(t *testing.T) {
+	t.Parallel()
+
+	f := parsetest.Parse(t, `y := 2
+x := 1 + y * 300`)
+
+	tests := []struct {
+		node ast.Node
+		want diag.Span
+	}{
+		{
+			// The reference to y does not extend the span to its declaration.
+			node: f.Statements[1],
+			want: diag.Span{Start: diag.Pos{Ln: 4, Col: 1}, End: diag.Pos{Ln: 4, Col: 17}},
+		},
+		{
+			node: f.Statements[1].(*ast.Declaration).Assignment.Expression,
+			want: diag.Span{Start: diag.Pos{Ln: 4, Col: 8}, End: diag.Pos{Ln: 4, Col: 17}},
+		},
+	}
+
+	for _, tt := range tests {
+		d := diag.At(f, tt.node, diag.CodeSyntax, "msg")
+
+		if d.File != "main.cog" || d.Span != tt.want {
+			t.Errorf("At(%s) = %s %+v, want main.cog %+v", tt.node, d.File, d.Span, tt.want)
+		}
+	}
+}
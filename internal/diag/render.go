@@ -0,0 +1,125 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render writes the diagnostic for humans: the message, its position, the
+// source line with a caret under the span, the notes and the suggested fix.
+// src is the content of the file of the diagnostic, the snippet is left out
+// if it is nil.
+//
+//	error[P002]: undefined identifier "cout"
+//	 --> main.cog:4:5
+//	  |
+//	4 |     cout = 1
+//	  |     ^^^^
+//	  = help: did you mean "count"?
+func Render(w io.Writer, d *Diagnostic, src []byte) error {
+	var out strings.Builder
+
+	out.WriteString(d.Severity.String())
+
+	if d.Code != "" {
+		out.WriteString("[" + string(d.Code) + "]")
+	}
+
+	out.WriteString(": " + d.Message + "\n")
+
+	line, ok := sourceLine(src, d.Span.Start.Ln)
+
+	// The gutter fits the line number of the snippet.
+	gutter := strings.Repeat(" ", len(strconv.Itoa(d.Span.Start.Ln)))
+	if !ok {
+		gutter = ""
+	}
+
+	if pos := d.Position(); pos != "" {
+		fmt.Fprintf(&out, "%s--> %s\n", gutter, pos)
+	}
+
+	if ok {
+		start := d.Span.Start.Col
+		width := 1
+
+		if end := d.Span.End; end.Ln == d.Span.Start.Ln && end.Col > start {
+			width = end.Col - start
+		} else if end.Ln > d.Span.Start.Ln {
+			width = max(utf8.RuneCountInString(line)-start+1, 1)
+		}
+
+		fmt.Fprintf(&out, "%s |\n", gutter)
+		fmt.Fprintf(&out, "%d | %s\n", d.Span.Start.Ln, line)
+		fmt.Fprintf(&out, "%s | %s%s\n", gutter, indent(line, start), strings.Repeat("^", width))
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(&out, "%s = note: %s\n", gutter, note.String())
+	}
+
+	if d.Fix != nil {
+		fmt.Fprintf(&out, "%s = help: %s\n", gutter, d.Fix.Message)
+	}
+
+	_, err := io.WriteString(w, out.String())
+
+	return err
+}
+
+// WriteJSON writes the diagnostics as a JSON array.
+func WriteJSON(w io.Writer, diags []*Diagnostic) error {
+	if diags == nil {
+		diags = []*Diagnostic{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+
+	return enc.Encode(diags)
+}
+
+// sourceLine returns a line of src by one-based line number.
+func sourceLine(src []byte, ln int) (string, bool) {
+	if src == nil || ln < 1 {
+		return "", false
+	}
+
+	for i := 1; i < ln; i++ {
+		_, rest, found := bytes.Cut(src, []byte("\n"))
+		if !found {
+			return "", false
+		}
+
+		src = rest
+	}
+
+	line, _, _ := bytes.Cut(src, []byte("\n"))
+
+	return strings.TrimSuffix(string(line), "\r"), true
+}
+
+// indent returns the whitespace lining up with column col of line. Tabs are
+// kept, so the caret lines up however wide the terminal renders them.
+func indent(line string, col int) string {
+	var out strings.Builder
+
+	for i, r := range []rune(line) {
+		if i >= col-1 {
+			break
+		}
+
+		if r == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,729 @@
+// Package format prints Cog source in its canonical layout.
+//
+// The layout is driven by the syntax tree: it tells blocks from literals,
+// operators from type brackets, and finds switch cases and labels. The text of
+// every token is kept from the source, so formatting only changes whitespace
+// and trailing commas, and never what a program means. Comments are kept in
+// place, a comment on the line of a statement stays on that line.
+//
+// The canonical layout indents with tabs, puts every statement of a block on
+// its own line, keeps at most one blank line between statements, and spaces
+// operators, declarations (x : T = v, x := v) and assignments. Literals keep
+// their source lines, a literal spread over lines gets a trailing comma, and
+// the values of struct literals and enums are aligned.
+package format
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/tokens"
+)
+
+// Source formats src, the source of the parsed file.
+func Source(ctx context.Context, file *ast.File, src []byte) ([]byte, error) {
+	toks, err := lexer.NewLexer(bytes.NewReader(src)).Parse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the end of file.
+	toks = toks[:len(toks)-1]
+
+	p := &printer{
+		toks:  toks,
+		text:  make([]string, len(toks)),
+		kinds: make([]kind, len(toks)),
+		match: make([]int, len(toks)),
+		hints: newHints(file),
+		label: -1,
+	}
+
+	starts := lineStarts(src)
+
+	for i, t := range toks {
+		p.text[i] = tokenText(t, src, starts)
+	}
+
+	p.classify()
+	p.print()
+
+	out := p.bytes()
+
+	// Formatting must not change the tokens, other than adding commas.
+	if err := sameTokens(ctx, toks, out); err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", file.Name, err)
+	}
+
+	return out, nil
+}
+
+// kind is the role of a token in the layout.
+type kind uint8
+
+const (
+	kindNone kind = iota
+
+	// Brackets, the opening and closing bracket have the same kind.
+	kindBlock    // {} around statements
+	kindCases    // {} around the cases of a switch or match
+	kindFields   // {} around struct or interface fields, () around imports or exported fields
+	kindValues   // {} around the values of an enum or error
+	kindList     // literals, arguments and indexes
+	kindParams   // () around the parameters of a procedure type
+	kindTypeList // [] of a slice or array type
+
+	// Operators.
+	kindBinary
+	kindPrefix
+	kindSuffix
+	kindShift // second < or > of a shift operator
+	kindAngle // <> around type arguments and parameters
+)
+
+// isLines reports whether the contents of a bracket are separated by lines.
+func (k kind) isLines() bool {
+	return k == kindBlock || k == kindCases || k == kindFields
+}
+
+// group is an open bracket.
+type group struct {
+	open   int  // index of the opening bracket, -1 for the file
+	indent int  // indent of the line of the opening bracket
+	broken bool // whether the contents start on a new line
+	inCase bool // whether a case is waiting for its colon
+}
+
+// line is a line of output.
+type line struct {
+	indent  int
+	text    strings.Builder
+	tokens  int
+	group   *group
+	align   int // offset of the operator aligned with the lines around, -1 if none
+	comment int // offset of the comment at the end of the line, -1 if none
+}
+
+type printer struct {
+	toks  []tokens.Token
+	text  []string // source text of the tokens
+	kinds []kind
+	match []int // index of the matching bracket
+	hints *hints
+
+	lines     []*line
+	stack     []*group
+	needBreak bool // whether the next token starts a line
+	label     int  // index of the colon of a label
+}
+
+// classify finds the kind of every bracket and operator.
+func (p *printer) classify() {
+	index := make(map[position]int, len(p.toks))
+	for i, t := range p.toks {
+		index[positionOf(t)] = i
+	}
+
+	for _, pos := range p.hints.bodies {
+		i, ok := index[pos]
+		if !ok {
+			continue
+		}
+
+		if p.toks[i].Type == tokens.Case || p.toks[i].Type == tokens.Default {
+			// The body opens before the first case.
+			i--
+			for i > 0 && p.toks[i].Type == tokens.Comment {
+				i--
+			}
+		}
+
+		for i < len(p.toks) && p.toks[i].Type != tokens.LBrace {
+			i++
+		}
+
+		if i < len(p.toks) {
+			p.kinds[i] = kindCases
+		}
+	}
+
+	var brackets, angles []int
+
+	for i, t := range p.toks {
+		switch t.Type {
+		case tokens.LBrace:
+			if p.hints.blocks[positionOf(t)] {
+				p.kinds[i] = kindBlock
+			} else if p.kinds[i] == kindNone {
+				p.kinds[i] = kindList
+			}
+
+			brackets = append(brackets, i)
+		case tokens.LParen:
+			switch prev := p.prev(i); {
+			case prev == tokens.Import || prev == tokens.GoImport:
+				p.kinds[i] = kindFields
+			case prev == tokens.Export && len(brackets) > 0 && p.kinds[brackets[len(brackets)-1]] == kindFields:
+				p.kinds[i] = kindFields
+			case prev == tokens.Function || prev == tokens.Procedure:
+				p.kinds[i] = kindParams
+			case prev == tokens.GT && p.kinds[i-1] == kindAngle && isProcedure(p.prev(p.match[i-1])):
+				p.kinds[i] = kindParams
+			default:
+				p.kinds[i] = kindList
+			}
+
+			brackets = append(brackets, i)
+		case tokens.LBracket:
+			if p.operandEnd(i - 1) {
+				p.kinds[i] = kindList
+			} else {
+				p.kinds[i] = kindTypeList
+			}
+
+			brackets = append(brackets, i)
+		case tokens.RBrace, tokens.RParen, tokens.RBracket:
+			if len(brackets) == 0 {
+				continue
+			}
+
+			open := brackets[len(brackets)-1]
+			brackets = brackets[:len(brackets)-1]
+
+			p.kinds[i] = p.kinds[open]
+			p.match[i], p.match[open] = open, i
+		case tokens.Struct, tokens.Interface, tokens.Enum, tokens.Error:
+			p.markTypeBody(i)
+		case tokens.LT:
+			if k, ok := p.hints.operators[positionOf(t)]; ok {
+				p.kinds[i] = k
+				continue
+			}
+
+			p.kinds[i] = kindAngle
+			angles = append(angles, i)
+		case tokens.GT:
+			if k, ok := p.hints.operators[positionOf(t)]; ok {
+				p.kinds[i] = k
+				continue
+			}
+
+			if len(angles) == 0 {
+				p.kinds[i] = kindBinary
+				continue
+			}
+
+			open := angles[len(angles)-1]
+			angles = angles[:len(angles)-1]
+
+			p.kinds[i] = kindAngle
+			p.match[i], p.match[open] = open, i
+		case tokens.Question:
+			p.kinds[i] = kindSuffix
+		case tokens.Not:
+			if k, ok := p.hints.operators[positionOf(t)]; ok {
+				p.kinds[i] = k
+				continue
+			}
+
+			// Not in an expression: the result type T ! E, or an operator
+			// the tree does not hold.
+			switch {
+			case p.operandEnd(i-1) && i+1 < len(p.toks) && operandStart(p.toks[i+1].Type):
+				p.kinds[i] = kindBinary
+			case p.operandEnd(i - 1):
+				p.kinds[i] = kindSuffix
+			default:
+				p.kinds[i] = kindPrefix
+			}
+		case tokens.Plus, tokens.Minus, tokens.Asterisk, tokens.Divide, tokens.Percent, tokens.BitAnd,
+			tokens.BitXor, tokens.BitClear, tokens.BitOr, tokens.Pipe, tokens.Tilde, tokens.And, tokens.Or,
+			tokens.Equal, tokens.NotEqual, tokens.GTEqual, tokens.LTEqual:
+			if k, ok := p.hints.operators[positionOf(t)]; ok {
+				p.kinds[i] = k
+				continue
+			}
+
+			// In types, & is a reference, and ~ a constraint or match case.
+			if p.operandEnd(i - 1) {
+				p.kinds[i] = kindBinary
+			} else {
+				p.kinds[i] = kindPrefix
+			}
+		}
+	}
+}
+
+// markTypeBody marks the brace after the struct, interface, enum or error
+// keyword at i.
+func (p *printer) markTypeBody(i int) {
+	j := i + 1
+
+	if j < len(p.toks) && p.toks[j].Type == tokens.LT {
+		// Skip the type arguments.
+		depth := 0
+
+		for ; j < len(p.toks); j++ {
+			switch p.toks[j].Type {
+			case tokens.LT:
+				depth++
+			case tokens.GT:
+				depth--
+			}
+
+			if depth == 0 {
+				break
+			}
+		}
+
+		j++
+	}
+
+	if j >= len(p.toks) || p.toks[j].Type != tokens.LBrace {
+		return
+	}
+
+	if p.toks[i].Type == tokens.Struct || p.toks[i].Type == tokens.Interface {
+		p.kinds[j] = kindFields
+	} else {
+		p.kinds[j] = kindValues
+	}
+}
+
+// prev returns the type of the token before i.
+func (p *printer) prev(i int) tokens.Type {
+	if i <= 0 {
+		return 0
+	}
+
+	return p.toks[i-1].Type
+}
+
+// operandEnd reports whether the token at i ends an operand, or a type.
+func (p *printer) operandEnd(i int) bool {
+	if i < 0 {
+		return false
+	}
+
+	switch t := p.toks[i].Type; t {
+	case tokens.Identifier, tokens.StringLiteral, tokens.IntLiteral, tokens.FloatLiteral,
+		tokens.True, tokens.False, tokens.Builtin, tokens.Question:
+		return true
+	case tokens.RParen:
+		return p.kinds[i] != kindParams
+	case tokens.RBracket:
+		return p.kinds[i] != kindTypeList
+	case tokens.RBrace:
+		return p.kinds[i] == kindList
+	case tokens.Not:
+		return p.kinds[i] == kindSuffix
+	case tokens.GT:
+		return p.kinds[i] == kindAngle
+	default:
+		return isTypeKeyword(t)
+	}
+}
+
+// operandStart reports whether a token of type t starts an operand, or a
+// type.
+func operandStart(t tokens.Type) bool {
+	switch t {
+	case tokens.Identifier, tokens.LParen, tokens.LBracket, tokens.BitAnd, tokens.Builtin,
+		tokens.Map, tokens.Set, tokens.Struct, tokens.Interface, tokens.Function, tokens.Procedure:
+		return true
+	default:
+		return isTypeKeyword(t)
+	}
+}
+
+func isTypeKeyword(t tokens.Type) bool {
+	return t >= tokens.ASCII && t <= tokens.Complex128 ||
+		t >= tokens.Int && t <= tokens.Comparable ||
+		t == tokens.Bool || t == tokens.Any || t == tokens.Error || t == tokens.Signal || t == tokens.Iter
+}
+
+func isRange(t tokens.Type) bool {
+	return t == tokens.Range || t == tokens.RangeInclusive
+}
+
+func isProcedure(t tokens.Type) bool {
+	return t == tokens.Function || t == tokens.Procedure
+}
+
+func isOpening(t tokens.Type) bool {
+	return t == tokens.LBrace || t == tokens.LParen || t == tokens.LBracket
+}
+
+func isClosing(t tokens.Type) bool {
+	return t == tokens.RBrace || t == tokens.RParen || t == tokens.RBracket
+}
+
+// print lays out the tokens in lines.
+func (p *printer) print() {
+	p.stack = []*group{{open: -1, indent: -1, broken: true}}
+
+	for i, t := range p.toks {
+		g := p.stack[len(p.stack)-1]
+
+		srcBreak := i > 0 && t.Ln > p.endLn(i-1)
+
+		switch {
+		case i == 0:
+			p.newLine(0, g)
+		case isClosing(t.Type):
+			p.closing(i, g)
+		case t.Type == tokens.Comment && !srcBreak:
+			// A comment on the line of a statement stays there.
+			l := p.lines[len(p.lines)-1]
+			l.comment = l.text.Len()
+
+			p.write(" ")
+		case t.Type == tokens.Else && p.prev(i) == tokens.RBrace:
+			p.write(" ")
+		case p.needBreak || srcBreak && p.breaks(i, g):
+			if srcBreak && t.Ln > p.endLn(i-1)+1 && i-1 != g.open {
+				p.blankLine()
+			}
+
+			p.newLine(p.indent(i, g), g)
+		default:
+			if p.space(i, g) {
+				p.write(" ")
+			}
+		}
+
+		p.token(i, g)
+	}
+}
+
+// closing writes the line break before the closing bracket at i.
+func (p *printer) closing(i int, g *group) {
+	if p.match[i] == i-1 {
+		// Empty brackets stay together.
+		return
+	}
+
+	switch {
+	case p.kinds[i].isLines():
+		p.newLine(g.indent, g)
+	case g.broken && (p.toks[i-1].Type == tokens.Comma || p.toks[i].Type == tokens.RBrace):
+		if p.toks[i-1].Type != tokens.Comma {
+			p.write(",")
+		}
+
+		p.newLine(g.indent, g)
+	}
+}
+
+// breaks reports whether the line break in the source before the token at i
+// is kept.
+func (p *printer) breaks(i int, g *group) bool {
+	if p.toks[i].Type == tokens.Comment || g.open < 0 || p.kinds[g.open].isLines() {
+		return true
+	}
+
+	// In literals and arguments, lines break between values.
+	return i-1 == g.open || p.toks[i-1].Type == tokens.Comma || p.continues(i-1)
+}
+
+// continues reports whether the token at i continues the statement on the
+// next line.
+func (p *printer) continues(i int) bool {
+	switch p.toks[i].Type {
+	case tokens.Comma, tokens.Assign, tokens.Declaration:
+		return true
+	default:
+		return p.kinds[i] == kindBinary || p.kinds[i] == kindShift
+	}
+}
+
+// indent returns the indent of the line starting with the token at i.
+func (p *printer) indent(i int, g *group) int {
+	switch {
+	case p.hints.cases[positionOf(p.toks[i])] && g.open >= 0 && p.kinds[g.open] == kindCases:
+		return g.indent
+	case p.hints.labels[positionOf(p.toks[i])]:
+		return max(g.indent, 0)
+	case !p.needBreak && (g.open < 0 || p.kinds[g.open].isLines()) && p.continues(i-1):
+		return g.indent + 2
+	default:
+		return g.indent + 1
+	}
+}
+
+// space reports whether a space separates the token at i from the token
+// before it on the same line.
+func (p *printer) space(i int, g *group) bool {
+	prev, t := p.toks[i-1].Type, p.toks[i].Type
+
+	switch {
+	case isOpening(prev), p.kinds[i-1] == kindPrefix:
+		return false
+	case isClosing(t), t == tokens.Comma, t == tokens.Semicolon, t == tokens.Dot, prev == tokens.Dot:
+		return false
+	case p.kinds[i] == kindSuffix, p.kinds[i] == kindShift:
+		return false
+	case isRange(t), isRange(prev):
+		return false
+	case p.kinds[i] == kindAngle, p.kinds[i-1] == kindAngle && prev == tokens.LT:
+		return false
+	case t == tokens.Colon:
+		// Cases, labels and map keys are followed by a colon, declarations
+		// are spaced.
+		inMap := g.open >= 0 && p.kinds[g.open] == kindList && p.toks[g.open].Type == tokens.LBrace
+		return !g.inCase && i != p.label && !inMap
+	case t == tokens.LParen:
+		return !p.operandEnd(i-1) && !isProcedure(prev) || prev == tokens.RParen
+	case t == tokens.LBracket:
+		return !p.operandEnd(i - 1)
+	case t == tokens.LBrace:
+		// A typed literal follows its type.
+		return p.kinds[i] != kindList || !p.operandEnd(i-1)
+	case prev == tokens.RBracket && p.kinds[i-1] == kindTypeList:
+		return false
+	}
+
+	return true
+}
+
+// token writes the token at i, and updates the open brackets.
+func (p *printer) token(i int, g *group) {
+	t := p.toks[i]
+	l := p.lines[len(p.lines)-1]
+
+	// The values of struct literals and enums are aligned.
+	if (t.Type == tokens.Assign || t.Type == tokens.Declaration) && l.tokens == 1 && l.group == g &&
+		p.toks[i-1].Type == tokens.Identifier && g.open >= 0 &&
+		(p.kinds[g.open] == kindValues || p.kinds[g.open] == kindList && p.toks[g.open].Type == tokens.LBrace) {
+		l.align = l.text.Len()
+	}
+
+	p.write(p.text[i])
+	l.tokens++
+
+	p.needBreak = false
+
+	switch {
+	case isOpening(t.Type):
+		next := i + 1
+		empty := next < len(p.toks) && p.match[i] == next
+
+		ng := &group{open: i, indent: l.indent}
+		if p.kinds[i].isLines() {
+			ng.broken = !empty
+		} else {
+			ng.broken = !empty && next < len(p.toks) && p.toks[next].Ln > t.Ln
+		}
+
+		p.stack = append(p.stack, ng)
+		p.needBreak = ng.broken
+	case isClosing(t.Type):
+		if len(p.stack) > 1 {
+			p.stack = p.stack[:len(p.stack)-1]
+		}
+	case t.Type == tokens.Comment:
+		p.needBreak = strings.HasPrefix(p.text[i], "//")
+	case t.Type == tokens.Colon && (g.inCase || i == p.label):
+		g.inCase = false
+		p.needBreak = true
+	case p.hints.cases[positionOf(t)] && g.open >= 0 && p.kinds[g.open] == kindCases:
+		g.inCase = true
+	case p.hints.labels[positionOf(t)]:
+		p.label = i + 1
+	}
+}
+
+func (p *printer) write(s string) {
+	p.lines[len(p.lines)-1].text.WriteString(s)
+}
+
+func (p *printer) newLine(indent int, g *group) {
+	p.lines = append(p.lines, &line{indent: indent, group: g, align: -1, comment: -1})
+}
+
+func (p *printer) blankLine() {
+	if len(p.lines) > 0 && p.lines[len(p.lines)-1].tokens > 0 {
+		p.lines = append(p.lines, &line{align: -1, comment: -1})
+	}
+}
+
+// endLn returns the line on which the token at i ends.
+func (p *printer) endLn(i int) uint32 {
+	return p.toks[i].Ln + uint32(strings.Count(p.text[i], "\n"))
+}
+
+// bytes aligns the lines and returns the output.
+func (p *printer) bytes() []byte {
+	align(p.lines, func(l *line) *int { return &l.align }, func(a, b *line) bool {
+		return a.group == b.group
+	})
+
+	align(p.lines, func(l *line) *int { return &l.comment }, func(a, b *line) bool {
+		return a.indent == b.indent
+	})
+
+	var out bytes.Buffer
+
+	for _, l := range p.lines {
+		if l.tokens > 0 {
+			out.WriteString(strings.Repeat("\t", l.indent))
+			out.WriteString(l.text.String())
+		}
+
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes()
+}
+
+// align pads consecutive lines with an offset in the same column, for lines
+// that are the same.
+func align(lines []*line, offset func(*line) *int, same func(a, b *line) bool) {
+	for start := 0; start < len(lines); {
+		end := start + 1
+
+		if *offset(lines[start]) < 0 {
+			start = end
+			continue
+		}
+
+		width := *offset(lines[start])
+
+		for end < len(lines) && *offset(lines[end]) >= 0 && same(lines[start], lines[end]) {
+			width = max(width, *offset(lines[end]))
+			end++
+		}
+
+		for _, l := range lines[start:end] {
+			at := *offset(l)
+			pad := width - at
+
+			text := l.text.String()
+			l.text.Reset()
+			l.text.WriteString(text[:at] + strings.Repeat(" ", pad) + text[at:])
+
+			// Offsets after the padding move along.
+			if l.align > at {
+				l.align += pad
+			}
+
+			if l.comment > at {
+				l.comment += pad
+			}
+		}
+
+		start = end
+	}
+}
+
+// lineStarts returns the offsets of the lines of src.
+func lineStarts(src []byte) []int {
+	starts := []int{0}
+
+	for i, b := range src {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+
+	return starts
+}
+
+// tokenText returns the source text of a token.
+func tokenText(t tokens.Token, src []byte, starts []int) string {
+	switch t.Type {
+	case tokens.Identifier, tokens.IntLiteral, tokens.FloatLiteral:
+		return t.Literal
+	case tokens.Builtin:
+		return "@" + t.Literal
+	case tokens.Comment:
+		if strings.HasPrefix(t.Literal, "//") {
+			return strings.TrimRight(t.Literal, " \t\r")
+		}
+
+		return t.Literal
+	case tokens.StringLiteral:
+		// The lexer drops the quotes, the source has the quoting.
+		return stringText(src[offset(t, src, starts):])
+	default:
+		return t.Type.String()
+	}
+}
+
+// offset returns the offset of a token in src.
+func offset(t tokens.Token, src []byte, starts []int) int {
+	off := starts[t.Ln-1]
+
+	for range int(t.Col) - 1 {
+		_, size := utf8.DecodeRune(src[off:])
+		off += size
+	}
+
+	return off
+}
+
+// stringText returns the string literal at the start of src.
+func stringText(src []byte) string {
+	quote := src[0]
+
+	for i := 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return string(src[:i+1])
+		}
+	}
+
+	return string(src)
+}
+
+// tokenLiteral returns the literal of t as it is formatted: comments lose
+// their trailing whitespace.
+func tokenLiteral(t tokens.Token) string {
+	if t.Type == tokens.Comment {
+		return strings.TrimRight(t.Literal, " \t\r")
+	}
+
+	return t.Literal
+}
+
+// sameTokens checks that the formatted source out has the tokens toks, other
+// than commas before closing braces.
+func sameTokens(ctx context.Context, toks []tokens.Token, out []byte) error {
+	got, err := lexer.NewLexer(bytes.NewReader(out)).Parse(ctx)
+	if err != nil {
+		return err
+	}
+
+	got = got[:len(got)-1]
+
+	var i int
+
+	for j, t := range got {
+		if i < len(toks) && t.Type == toks[i].Type && t.Literal == tokenLiteral(toks[i]) {
+			i++
+			continue
+		}
+
+		if t.Type == tokens.Comma && j+1 < len(got) && got[j+1].Type == tokens.RBrace {
+			continue
+		}
+
+		return fmt.Errorf("token %q changed at %d:%d", t.Type, t.Ln, t.Col)
+	}
+
+	if i != len(toks) {
+		return errors.New("tokens dropped")
+	}
+
+	return nil
+}
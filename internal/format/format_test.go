@@ -0,0 +1,381 @@
+package format
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/project"
+)
+
+func formatSource(t *testing.T, src string) string {
+	t.Helper()
+
+	toks, err := lexer.NewLexer(strings.NewReader(src)).Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lexer error: %v", err)
+	}
+
+	p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "test.cog")
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	f, err := p.Parse(t.Context(), "test.cog")
+	if err != nil {
+		t.Fatalf("parser error: %v", err)
+	}
+
+	out, err := Source(t.Context(), f, []byte(src))
+	if err != nil {
+		t.Fatalf("format error: %v", err)
+	}
+
+	return string(out)
+}
+
+func TestSource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "declarations",
+			src: `package main
+x:int64=1
+y  :=  2
+main : proc() = {
+    var z :  utf8?  = "z"
+  z = "zz"
+}
+`,
+			want: `package main
+x : int64 = 1
+y := 2
+main : proc() = {
+	var z : utf8? = "z"
+	z = "zz"
+}
+`,
+		},
+		{
+			name: "operators",
+			src: `package main
+
+main : proc() = {
+	a := -1+2*(3-4)
+	b := !true&&a<5
+	c := &a
+	if (a==1)!=b {}
+	r : int64!E = 1
+	if !r? { @print(r!) }
+}
+
+E ~ error<utf8> { Bad := "bad" }
+`,
+			want: `package main
+
+main : proc() = {
+	a := -1 + 2 * (3 - 4)
+	b := !true && a < 5
+	c := &a
+	if (a == 1) != b {}
+	r : int64 ! E = 1
+	if !r? {
+		@print(r!)
+	}
+}
+
+E ~ error<utf8> {Bad := "bad"}
+`,
+		},
+		{
+			name: "bitwise_operators",
+			src: `package main
+
+main : proc() = {
+	a : uint64 = 7%4|||1<<3
+	b := a&15^a>>2&^1
+	m := @map<utf8, set<uint64>>()
+}
+`,
+			want: `package main
+
+main : proc() = {
+	a : uint64 = 7 % 4 ||| 1 << 3
+	b := a & 15 ^ a >> 2 &^ 1
+	m := @map<utf8, set<uint64>>()
+}
+`,
+		},
+		{
+			name: "types",
+			src: `package main
+
+List<T~any> ~ []T
+Pair ~ map<utf8,set<int8>>
+
+id : func<T ~ any>( x : T ) T = {
+	return x
+}
+
+main : proc() = {
+	xs := [ ]int8 { 1, 2 }
+	@print(xs [0])
+	ys := @slice < int32 > (3)
+	@print(id<utf8>("x"))
+	@print(ys)
+}
+`,
+			want: `package main
+
+List<T ~ any> ~ []T
+Pair ~ map<utf8, set<int8>>
+
+id : func<T ~ any>(x : T) T = {
+	return x
+}
+
+main : proc() = {
+	xs := []int8{1, 2}
+	@print(xs[0])
+	ys := @slice<int32>(3)
+	@print(id<utf8>("x"))
+	@print(ys)
+}
+`,
+		},
+		{
+			name: "alignment",
+			src: `package main
+
+Status ~ enum<utf8> {
+	Open := "open",
+	Closed := "closed"
+}
+
+point ~ struct {
+	export (
+		x : float64
+		longer : float64
+	)
+}
+
+main : proc() = {
+	p : point = {
+		x = 1.0,
+		longer = 2.0
+	}
+	@print(p)
+}
+`,
+			want: `package main
+
+Status ~ enum<utf8> {
+	Open   := "open",
+	Closed := "closed",
+}
+
+point ~ struct {
+	export (
+		x : float64
+		longer : float64
+	)
+}
+
+main : proc() = {
+	p : point = {
+		x      = 1.0,
+		longer = 2.0,
+	}
+	@print(p)
+}
+`,
+		},
+		{
+			name: "comments",
+			src: `package main
+
+
+// Doc comment.
+main : proc() = {
+
+	a := 1   // inline
+	bb := 22    // aligned
+
+	/* block */
+	@print(a)
+	@print(bb)
+	// last
+}
+`,
+			want: `package main
+
+// Doc comment.
+main : proc() = {
+	a := 1   // inline
+	bb := 22 // aligned
+
+	/* block */
+	@print(a)
+	@print(bb)
+	// last
+}
+`,
+		},
+		{
+			name: "control_flow",
+			src: `package main
+
+main : proc() = {
+	var x := 1
+outer: for {
+	switch x {
+	case 1: break outer
+	default:
+		x = 2
+	}
+}
+	if x == 2 { @print(x) } else { @print("no") }
+}
+`,
+			want: `package main
+
+main : proc() = {
+	var x := 1
+outer:
+	for {
+		switch x {
+		case 1:
+			break outer
+		default:
+			x = 2
+		}
+	}
+	if x == 2 {
+		@print(x)
+	} else {
+		@print("no")
+	}
+}
+`,
+		},
+		{
+			name: "async",
+			src: `package main
+
+square : proc(x : int64) int64 = { return x*x }
+
+main : proc() = {
+	s:=async square( 2 )
+	t : signal<int64> = async square(3)
+	@print(@await( s )+@await(t))
+	@print("done ")
+}
+`,
+			want: `package main
+
+square : proc(x : int64) int64 = {
+	return x * x
+}
+
+main : proc() = {
+	s := async square(2)
+	t : signal<int64> = async square(3)
+	@print(@await(s) + @await(t))
+	@print("done ")
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := formatSource(t, tt.src)
+			if got != tt.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			// The formatted source is canonical.
+			if again := formatSource(t, got); again != got {
+				t.Errorf("formatting again changed the source:\n%s", again)
+			}
+		})
+	}
+}
+
+// TestExamples formats the example package and script, and checks that
+// formatting them again changes nothing.
+func TestExamples(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("..", "..", "example")
+
+	formatted := formatDir(t, dir, nil)
+	if len(formatted) == 0 {
+		t.Fatal("no files formatted")
+	}
+
+	for path, src := range formatDir(t, dir, formatted) {
+		if !bytes.Equal(src, formatted[path]) {
+			t.Errorf("formatting %s again changed it:\n%s", path, src)
+		}
+	}
+}
+
+// formatDir formats the package and scripts in dir, with the contents of
+// overlay replacing the files on disk. It returns the formatted files by path.
+func formatDir(t *testing.T, dir string, overlay map[string][]byte) map[string][]byte {
+	t.Helper()
+
+	loader := &project.Loader{Overlay: overlay}
+
+	paths, err := loader.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := loader.Load(t.Context(), dir, paths)
+	if err != nil {
+		t.Fatalf("loading %s: %v", dir, err)
+	}
+
+	files := pkg.Files
+
+	for _, script := range project.Scripts(dir) {
+		pkg, err := loader.LoadScript(t.Context(), dir, script)
+		if err != nil {
+			t.Fatalf("loading %s: %v", script, err)
+		}
+
+		files = append(files, pkg.Files...)
+	}
+
+	out := make(map[string][]byte, len(files))
+
+	for _, f := range files {
+		src, ok := overlay[f.Path]
+		if !ok {
+			src, err = os.ReadFile(f.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		out[f.Path], err = Source(t.Context(), f.AST, src)
+		if err != nil {
+			t.Fatalf("formatting %s: %v", f.Path, err)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,222 @@
+package format
+
+import (
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+)
+
+// position is the position of a token in the source.
+type position struct {
+	ln  uint32
+	col uint16
+}
+
+func positionOf(t tokens.Token) position {
+	return position{ln: t.Ln, col: t.Col}
+}
+
+// hints holds the layout found in the syntax tree, by token position.
+type hints struct {
+	blocks    map[position]bool // opening braces of blocks
+	operators map[position]kind // binary, prefix and suffix operators
+	cases     map[position]bool // case and default keywords
+	labels    map[position]bool // labels of statements
+	bodies    []position        // first case, or keyword, of every switch and match
+}
+
+func newHints(file *ast.File) *hints {
+	h := &hints{
+		blocks:    make(map[position]bool),
+		operators: make(map[position]kind),
+		cases:     make(map[position]bool),
+		labels:    make(map[position]bool),
+	}
+
+	for _, stmt := range file.Statements {
+		h.statement(stmt)
+	}
+
+	return h
+}
+
+func (h *hints) block(b *ast.Block) {
+	if b == nil {
+		return
+	}
+
+	h.blocks[positionOf(b.Start)] = true
+
+	for _, stmt := range b.Statements {
+		h.statement(stmt)
+	}
+}
+
+func (h *hints) label(l *ast.Label) {
+	if l != nil {
+		h.labels[positionOf(l.Token)] = true
+	}
+}
+
+func (h *hints) body(keyword tokens.Token, first ...tokens.Token) {
+	for _, t := range first {
+		if t.Ln > 0 {
+			h.bodies = append(h.bodies, positionOf(t))
+			return
+		}
+	}
+
+	h.bodies = append(h.bodies, positionOf(keyword))
+}
+
+func (h *hints) statements(stmts []ast.Statement) {
+	for _, stmt := range stmts {
+		h.statement(stmt)
+	}
+}
+
+func (h *hints) statement(stmt ast.Statement) {
+	switch s := stmt.(type) {
+	case *ast.Assignment:
+		h.expression(s.Expression)
+	case *ast.Block:
+		h.block(s)
+	case *ast.Declaration:
+		h.expression(s.Assignment.Expression)
+	case *ast.ExpressionStatement:
+		h.expression(s.Expression)
+	case *ast.ForStatement:
+		h.label(s.Label)
+		h.expression(s.Range)
+		h.block(s.Loop)
+	case *ast.IfStatement:
+		h.label(s.Label)
+		h.expression(s.Condition)
+		h.block(s.Consequence)
+		h.block(s.Alternative)
+	case *ast.Match:
+		var first, def tokens.Token
+
+		h.expression(s.Subject)
+
+		for i, c := range s.Cases {
+			if i == 0 {
+				first = c.Token
+			}
+
+			h.cases[positionOf(c.Token)] = true
+			h.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			def = s.Default.Token
+			h.cases[positionOf(def)] = true
+			h.statements(s.Default.Body)
+		}
+
+		h.body(s.Token, first, def)
+	case *ast.Method:
+		h.statement(s.Declaration)
+	case *ast.Return:
+		for _, value := range s.Values {
+			h.expression(value)
+		}
+	case *ast.Switch:
+		var first, def tokens.Token
+
+		h.label(s.Label)
+
+		for i, c := range s.Cases {
+			if i == 0 {
+				first = c.Token
+			}
+
+			h.cases[positionOf(c.Token)] = true
+			h.expression(c.Condition)
+			h.statements(c.Body)
+		}
+
+		if s.Default != nil {
+			def = s.Default.Token
+			h.cases[positionOf(def)] = true
+			h.statements(s.Default.Body)
+		}
+
+		h.body(s.Token, first, def)
+	}
+}
+
+func (h *hints) expressions(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		h.expression(expr)
+	}
+}
+
+func (h *hints) expression(expr ast.Expression) {
+	switch e := expr.(type) {
+	case *ast.ArrayLiteral:
+		h.expressions(e.Values)
+	case *ast.Async:
+		h.expression(e.Call)
+	case *ast.Builtin:
+		h.expressions(e.Arguments)
+	case *ast.Call:
+		h.expression(e.Expression)
+		h.expressions(e.Arguments)
+	case *ast.EitherLiteral:
+		h.expression(e.Value)
+	case *ast.FormatString:
+		for _, part := range e.Parts {
+			// Values written between the braces are inside the string token.
+			if part.Value != nil && !part.Inline {
+				h.expression(part.Value)
+			}
+		}
+	case *ast.GoCallExpression:
+		h.expressions(e.Arguments)
+	case *ast.Index:
+		h.expression(e.Identifier)
+		h.expression(e.Index)
+	case *ast.Infix:
+		h.operators[positionOf(e.Operator)] = kindBinary
+
+		if e.Operator.Type == tokens.ShiftLeft || e.Operator.Type == tokens.ShiftRight {
+			// A shift operator is two tokens, the second follows the first.
+			h.operators[position{ln: e.Operator.Ln, col: e.Operator.Col + 1}] = kindShift
+		}
+
+		h.expression(e.Left)
+		h.expression(e.Right)
+	case *ast.MapLiteral:
+		for _, pair := range e.Pairs {
+			h.expression(pair.Key)
+			h.expression(pair.Value)
+		}
+	case *ast.Prefix:
+		h.operators[positionOf(e.Operator)] = kindPrefix
+		h.expression(e.Right)
+	case *ast.Propagate:
+		h.operators[positionOf(e.Operator)] = kindSuffix
+		h.expression(e.Left)
+	case *ast.ProcedureLiteral:
+		h.block(e.Body)
+	case *ast.ResultLiteral:
+		h.expression(e.Value)
+	case *ast.Selector:
+		h.expression(e.Expression)
+	case *ast.SetLiteral:
+		h.expressions(e.Values)
+	case *ast.SliceLiteral:
+		h.expressions(e.Values)
+	case *ast.StructLiteral:
+		for _, field := range e.Values {
+			h.expression(field.Value)
+		}
+	case *ast.Suffix:
+		h.operators[positionOf(e.Operator)] = kindSuffix
+		h.expression(e.Left)
+	case *ast.TupleLiteral:
+		h.expressions(e.Values)
+	case *ast.UnionLiteral:
+		h.expression(e.Value)
+	}
+}
@@ -7,12 +7,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/format"
 	"github.com/samborkent/cog/internal/lexer"
 	"github.com/samborkent/cog/internal/parser"
 	"github.com/samborkent/cog/internal/tokens"
@@ -23,6 +25,19 @@ import (
 func transpileSource(t *testing.T, src string) string {
 	t.Helper()
 
+	f := parseSource(t, src)
+
+	code := transpileFile(t, f)
+
+	checkFormat(t, f, src, code)
+
+	return code
+}
+
+// parseSource runs the lexer and parser.
+func parseSource(t *testing.T, src string) *ast.File {
+	t.Helper()
+
 	l := lexer.NewLexer(strings.NewReader(src))
 
 	toks, err := l.Parse(t.Context())
@@ -40,6 +55,13 @@ func transpileSource(t *testing.T, src string) string {
 		t.Fatalf("parser parse error: %v", err)
 	}
 
+	return f
+}
+
+// transpileFile transpiles a parsed file and returns generated go source.
+func transpileFile(t *testing.T, f *ast.File) string {
+	t.Helper()
+
 	tr := transpiler.NewTranspiler([]*ast.File{f})
 
 	gofile, err := tr.Transpile()
@@ -56,6 +78,45 @@ func transpileSource(t *testing.T, src string) string {
 	return buf.String()
 }
 
+// checkFormat checks that formatting src generates the same go source code,
+// and that formatting the formatted source changes nothing.
+func checkFormat(t *testing.T, f *ast.File, src, code string) {
+	t.Helper()
+
+	formatted, err := format.Source(t.Context(), f, []byte(src))
+	if err != nil {
+		t.Fatalf("format error: %v", err)
+	}
+
+	formattedFile := parseSource(t, string(formatted))
+
+	if got := transpileFile(t, formattedFile); codeLines(got) != codeLines(code) {
+		t.Fatalf("formatting changed the generated code, formatted source:\n%s", formatted)
+	}
+
+	again, err := format.Source(t.Context(), formattedFile, formatted)
+	if err != nil {
+		t.Fatalf("format error: %v", err)
+	}
+
+	if !bytes.Equal(again, formatted) {
+		t.Fatalf("formatting is not stable, formatted source:\n%s\nformatted again:\n%s", formatted, again)
+	}
+}
+
+// codeLines returns the sorted lines of generated go source, without the
+// //line directives, which change with the layout of the Cog source. The lines
+// are sorted as imports are generated in no fixed order.
+func codeLines(code string) string {
+	lines := slices.DeleteFunc(strings.Split(code, "\n"), func(line string) bool {
+		return strings.HasPrefix(line, "//line ")
+	})
+
+	slices.Sort(lines)
+
+	return strings.Join(lines, "\n")
+}
+
 // runGenerated compiles and runs generated Go code, returning its output.
 // projectRoot returns the absolute path to the module root (the directory containing go.mod).
 func projectRoot(t *testing.T) string {
@@ -430,6 +491,35 @@ main : proc() = {}
 	}
 }
 
+func TestFuncWithSideEffectsShouldError(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+log : func(x : int64) int64 = {
+	@print(x)
+	return x
+}
+
+double : func(x : int64) int64 = {
+	return log(x) * 2
+}
+
+main : proc() = {
+	@print(double(1))
+}
+`
+
+	_, err := tryTranspile(t.Context(), src)
+	if err == nil {
+		t.Fatalf("expected transpile error for func with side effects, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "double -> log -> @print") {
+		t.Fatalf("expected error with call chain, got: %v", err)
+	}
+}
+
 func TestMainAsIntShouldError(t *testing.T) {
 	t.Parallel()
 
@@ -765,6 +855,138 @@ main : proc() = {
 	}
 }
 
+func TestGoValuesAndTypes(t *testing.T) {
+	src := `package main
+
+goimport (
+    "bytes"
+    "fmt"
+    "math"
+    "time"
+)
+
+limit : int64 = @go.math.MaxInt64
+
+main : proc() = {
+    var buf : @go.bytes.Buffer
+    @go.fmt.Fprint(&buf, "buffered")
+    @go.fmt.Println(&buf)
+    @print(limit)
+    d := @go.time.Second * 2
+    @print(d)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	for _, want := range []string{"buffered", "9223372036854775807", "2000000000"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestGoGenericCall(t *testing.T) {
+	src := `package main
+
+goimport (
+    "slices"
+)
+
+main : proc() = {
+    xs : []int64 = {3, 1, 2}
+    @print(@go.slices.Contains(xs, 1))
+    @print(@go.slices.Max(xs))
+    ys : []int8 = {4, 5}
+    @print(@go.slices.Index(ys, 5))
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	if want := "true\n3\n1\n"; out != want {
+		t.Fatalf("got output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestGoAdapters(t *testing.T) {
+	src := `package main
+
+goimport (
+    "math/big"
+    "os"
+    "strconv"
+    "strings"
+)
+
+main : proc() = {
+    parsed := @go.strconv.Atoi("42")
+    if parsed? {
+        @print(parsed)
+    }
+
+    failed := @go.strconv.Atoi("x")
+    if !failed? {
+        @print("parse failed")
+    }
+
+    missing := @go.os.Remove("does-not-exist")
+    if missing? {
+        @print("remove failed")
+    }
+
+    shout : ascii ! error = @go.strings.ToUpper("shout")
+    if shout? {
+        @print(@go.strings.ToLower(shout))
+    }
+
+    accent : ascii ! error = @go.strings.ToUpper("café")
+    if !accent? {
+        @print("not ascii")
+    }
+
+    count : int64 = 2
+    @print(@go.strings.Repeat("ab", count))
+
+    huge : int128 ! error = @go.big.NewInt(-7)
+    if huge? {
+        @print(@go.big.Jacobi(huge, @go.big.NewInt(3)))
+    }
+
+    overflow : uint128 ! error = @go.big.NewInt(-1)
+    if !overflow? {
+        @print("uint128 overflow")
+    }
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	for _, want := range []string{"42", "parse failed", "remove failed", "shout", "not ascii", "abab", "-1", "uint128 overflow"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output, got:\n%s\ncode:\n%s", want, out, code)
+		}
+	}
+}
+
 func TestEnumBeforeStructType(t *testing.T) {
 	src := `package main
 
@@ -1346,7 +1568,7 @@ main : proc() = {
 func TestGenericFunctionCallInferred(t *testing.T) {
 	src := `package main
 
-genFunc : func<T ~ any>(x : T) = {
+genFunc : proc<T ~ any>(x : T) = {
 	@print(x)
 }
 
@@ -1376,7 +1598,7 @@ main : proc() = {
 func TestGenericFunctionCallExplicit(t *testing.T) {
 	src := `package main
 
-genFunc : func<T ~ any>(x : T) = {
+genFunc : proc<T ~ any>(x : T) = {
 	@print(x)
 }
 
@@ -1423,3 +1645,1229 @@ main : proc() = {
 
 	mustContain(t, out, "returned")
 }
+
+func TestGenericFunctionMonomorphized(t *testing.T) {
+	src := `package main
+
+process : func<T ~ int>(x : T) int64 = {
+	match x {
+	case int32:
+		return @cast<int64>(x) + 1
+	case int64:
+		return x * 2
+	default:
+		return 0
+	}
+}
+
+combine : proc<A ~ int, B ~ string>(a : A, b : B) = {
+	match a {
+	case int32:
+		@print("int32")
+	default:
+		@print("other")
+	}
+
+	match b {
+	case utf8:
+		@print(b)
+	default:
+		@print("ascii")
+	}
+}
+
+main : proc() = {
+	b : int32 = 10
+	d : int16 = 5
+	@print(process(42) + process(b) + process(d))
+	combine(b, "first")
+	combine(d, "second")
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "func process_int32(x int32) int64 {")
+	mustContain(t, code, "func process_int64(x int64) int64 {")
+	mustContain(t, code, "func process[T interface {")
+	mustContain(t, code, "process_int64(42) + process_int32(b) + process[int16](d)")
+	mustContain(t, code, "func combine_int32_utf8(ctx go_context.Context, a int32, b string) {")
+	mustContain(t, code, "combine_A_utf8[int16](ctx, d, \"second\")")
+	mustNotContain(t, code, "switch")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	mustContain(t, out, "95\nint32\nfirst\nother\nsecond")
+}
+
+func TestGenericFunctionNumberFallback(t *testing.T) {
+	src := `package main
+
+describe : func<T ~ number>(x : T) utf8 = {
+	match x {
+	case int64:
+		return "int64"
+	default:
+		return "number"
+	}
+}
+
+main : proc() = {
+	f : float32 = 1.5
+	u : uint16 = 2
+	@print(describe(1), describe(f), describe(u))
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	mustContain(t, out, "int64 number number")
+}
+
+func TestSumTypes(t *testing.T) {
+	src := `package main
+
+Circle ~ struct {
+	r : float64
+}
+
+Rect ~ struct {
+	w : float64
+	h : float64
+}
+
+Shape ~ Circle | Rect | utf8
+
+area : func(s : Shape) float64 = {
+	match v := s {
+	case Circle:
+		return 3.0 * v.r * v.r
+	case Rect:
+		return v.w * v.h
+	case utf8:
+		return 0.0
+	}
+}
+
+isRect : func(s : Shape) bool = {
+	match s {
+	case Rect:
+		return true
+	default:
+		return false
+	}
+}
+
+main : proc() = {
+	c : Circle = {r = 2.0}
+	r : Rect = {w = 2.0, h = 3.0}
+	s : Shape = c
+	@print(area(s))
+	@print(area(r))
+	@print(area("none"))
+	@print(isRect(r))
+	@print(isRect(s))
+	@print(s)
+	label : Shape = "label"
+	@print(label)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "switch s.tag {")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	mustContain(t, out, "12\n6\n0\ntrue\nfalse\n{r = 2}\nlabel")
+}
+
+func TestErrorPropagationAndPayloads(t *testing.T) {
+	src := `package main
+
+goimport (
+	"errors"
+	"strconv"
+)
+
+MyError ~ error<utf8> {
+	NotFound(key : utf8) := "not found",
+	Timeout := "timeout",
+	BadNumber(text : utf8, cause : error) := "bad number",
+}
+
+lookup : func(key : utf8) int64 ! MyError = {
+	if key == "a" {
+		return 1
+	}
+
+	if key == "t" {
+		return MyError.Timeout
+	}
+
+	return MyError.NotFound(key)
+}
+
+double : func(key : utf8) int64 ! MyError = {
+	x := lookup(key)!?
+	return x * 2
+}
+
+parse : func(text : utf8) int64 ! MyError = {
+	n := @go.strconv.Atoi(text)
+	if !n? {
+		return MyError.BadNumber(text, n!)
+	}
+
+	return n
+}
+
+total : func(a : utf8, b : utf8) int64 ! error = {
+	return parse(a)!? + parse(b)!?
+}
+
+main : proc() = {
+	r := double("a")
+	if r? {
+		@print(r)
+	}
+
+	s := double("b")
+	if !s? {
+		e := s!
+		@print(e)
+		@print(e.key)
+		@print(e == MyError.NotFound(""))
+		@print(e == MyError.Timeout)
+	}
+
+	u := total("1", "x")
+	if !u? {
+		err := u!
+		@print(err)
+		@print(@go.errors.Is(err, @go.strconv.ErrSyntax))
+		@print(@go.errors.Is(err, MyError.BadNumber("", err)))
+		@print(@go.errors.Is(err, MyError.Timeout))
+	}
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "func (e _MyErrorError) Unwrap() error {")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	mustContain(t, out, `2
+not found (key = "b")
+b
+true
+false
+bad number (text = "x"): strconv.Atoi: parsing "x": invalid syntax
+true
+true
+false`)
+}
+
+func TestInterfaceErrors(t *testing.T) {
+	src := `package main
+
+goimport (
+	"errors"
+	"strconv"
+)
+
+Failure ~ interface {
+	Error : func() utf8
+}
+
+parse : func(text : utf8) int64 ! ParseError = {
+	if text == "" {
+		return ParseError{text = text}
+	}
+
+	return 1
+}
+
+ParseError ~ struct {
+	text : utf8
+}
+
+ParseError.Error : func() utf8 = {
+	return "cannot parse empty text"
+}
+
+atoi : func(text : utf8) int64 ! Failure = {
+	n := @go.strconv.Atoi(text)!?
+	return n
+}
+
+total : func(a : utf8, b : utf8) int64 ! Failure = {
+	return parse(a)!? + atoi(b)!?
+}
+
+main : proc() = {
+	r := total("a", "2")
+	if r? {
+		@print(r)
+	}
+
+	s := total("", "2")
+	if !s? {
+		e := s!
+		@print(e.Error())
+	}
+
+	u := total("a", "x")
+	if !u? {
+		err := u!
+		@print(err.Error())
+		@print(@go.errors.Is(err, @go.strconv.ErrSyntax))
+	}
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "func (e _ParseError) Error() string {")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	mustContain(t, out, `3
+cannot parse empty text
+strconv.Atoi: parsing "x": invalid syntax
+true`)
+}
+
+func TestAsyncAwait(t *testing.T) {
+	src := `package main
+
+square : proc(x : int64) int64 = {
+	return x * x
+}
+
+greet : proc(name : utf8, suffix? : utf8 = "!") = {
+	@print("hello " + name + suffix)
+}
+
+identity : proc<T ~ any>(x : T) T = {
+	return x
+}
+
+first : proc(s : signal<int64>) int64 = {
+	return @await(s)
+}
+
+main : proc() = {
+	var n := 3
+	s := async square(n)
+	n = 4
+	@print(first(s))
+
+	g := async greet("async")
+	@await(g)
+
+	id : signal<utf8> = async identity("generic")
+	@print(@await(id))
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "cog.Async(ctx, func(ctx go_context.Context) int64 {")
+	mustContain(t, code, "*cog.Future[cog.Void]")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	// The argument is evaluated before n is reassigned.
+	mustContain(t, out, "9")
+	mustContain(t, out, "hello async!")
+	mustContain(t, out, "generic")
+}
+
+func TestASCIIMapAndSet(t *testing.T) {
+	src := `package main
+
+Name ~ ascii
+
+main : proc() = {
+	var ages : map<Name, int64> = {
+		"ada": 36,
+		"alan": 41,
+	}
+
+	key : ascii = "ada"
+	@print(ages[key])
+	@print(ages["alan"])
+
+	@delete(ages, key)
+	@print(@len(ages))
+
+	var words : map<ascii, int64> = {
+		"one": 1,
+	}
+
+	for v, k in words {
+		@print(@len(k) + 100)
+		@print(v)
+	}
+
+	var seen : set<ascii> = {"x", "yy"}
+	@delete(seen, "x")
+
+	for v in seen {
+		@print(@len(v) + 200)
+	}
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "cog.ASCIIMap[_Name, int64]")
+	mustContain(t, code, "cog.ASCIISet[cog.ASCII]")
+	mustContain(t, code, "ages[string(key)]")
+	mustContain(t, code, "range words.All()")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	for _, want := range []string{"36", "41", "1", "103", "202"} {
+		mustContain(t, out, want)
+	}
+}
+
+func TestIntegerOperators(t *testing.T) {
+	src := `package main
+
+Counter ~ struct {
+	n : int64
+}
+
+main : proc() = {
+	var h : uint64 = 14695981039346656037
+	h = h ^ 97
+	h = h * 1099511628211
+	@print(h % 1000)
+
+	shift : uint8 = 4
+	packed : uint32 = 3 << shift ||| 5
+	@print(packed)
+	@print(packed >> 4 & 3)
+	@print(packed &^ 1)
+
+	x : int64 = -17
+	@print(x % 5)
+	@print(x >> 1)
+
+	big : int128 = -12
+	@print(big >> 2)
+	@print(big % 5)
+	@print(big << 100 >> 100)
+
+	var wide : uint128 = 1
+	wide = wide << 100 ||| 6
+	@print(wide >> 99)
+	@print(wide & 7 ^ 1)
+	@print(wide &^ 2 % 10)
+}
+
+limit := 3
+&Counter.Show : proc() = {
+	@print(limit & 7)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "uint32(3)<<shift | 5")
+	mustContain(t, code, "cog.Int128Rsh(big, uint(2))")
+	mustContain(t, code, "cog.Uint128AndNot(wide,")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	want := []string{"996", "53", "3", "52", "-2", "-9", "-3", "-2", "-12", "2", "7", "0"}
+	if got := strings.Fields(out); !slices.Equal(got, want) {
+		t.Errorf("output = %v, want %v", got, want)
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	src := `package main
+
+main : proc() = {
+	mask : uint8 = 0xF0
+	@print(mask)
+
+	perm : uint16 = 0o755
+	@print(perm)
+
+	flags : uint64 = 0b1010_0101
+	@print(flags)
+
+	million : int64 = 1_000_000
+	@print(million)
+
+	low : int8 = -128
+	@print(low)
+
+	huge : uint128 = 0xFFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF
+	@print(huge >> 124)
+
+	min : int128 = -0x8000_0000_0000_0000_0000_0000_0000_0000
+	@print(min >> 124)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	want := []string{"240", "493", "165", "1000000", "-128", "15", "-8"}
+	if got := strings.Fields(out); !slices.Equal(got, want) {
+		t.Errorf("output = %v, want %v", got, want)
+	}
+}
+
+func TestPrintConstantExpression(t *testing.T) {
+	src := `package main
+
+main : proc() = {
+	@print(12 + 10)
+	@print("{1 + 2}")
+	@print(-(4 * 2), 1.5 + 2.0, 3 > 2)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	if want := "22\n3\n-8 3.5 true\n"; out != want {
+		t.Fatalf("got output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestFormattedPrint(t *testing.T) {
+	src := `package main
+
+Status ~ enum<utf8> {
+	Open := "open",
+}
+
+Pair ~ (utf8, int64)
+
+Point ~ struct {
+	x : int64
+	name : ascii
+	tag : utf8?
+}
+
+DivError ~ error<utf8> {
+	Zero := "division by zero",
+}
+
+divide : func(a : int64, b : int64) int64 ! DivError = {
+	if b == 0 {
+		return DivError.Zero
+	}
+
+	return a / b
+}
+
+main : proc() = {
+	x : int64 = 42
+	y := 3.14159
+	@print("x = {x}, y = {y:.2f}")
+	@print("{x:>6}|{x:<6}|{x:06}|{x:x}|{x:X}|{x:b}|{x:o}")
+	@print("{} and {:e}", "positional", 1500.0)
+	@print(x, y, "words", true)
+
+	a : ascii = "ascii"
+	big : int128 = -170141183460469231731687303715884105728
+	half : float16 = 0.5
+	@print(a, big, half)
+	@print("{big:x} {a:q}")
+
+	p : Pair = {"pair", 7}
+	@print(p)
+	@print(Status.Open)
+
+	pt : Point = {x = 1, name = "pt"}
+	@print(pt)
+
+	xs := []int64{1, 2, 3}
+	@print("{xs} has {@len(xs)} elements")
+
+	@print(divide(6, 3), divide(1, 0))
+
+	var maybe : uint8?
+	maybe = 5
+	if maybe? {
+		@print("maybe = {maybe:03}")
+	}
+
+	msg := @format("{{{x}}}")
+	@print(msg)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	if strings.Contains(code, "reflect") {
+		t.Error("generated code formats values with reflection")
+	}
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	want := []string{
+		"x = 42, y = 3.14",
+		"    42|42    |000042|2a|2A|101010|52",
+		"positional and 1.5e+03",
+		"42 3.14159 words true",
+		"ascii -170141183460469231731687303715884105728 0.5",
+		"-80000000000000000000000000000000 \"ascii\"",
+		`("pair", 7)`,
+		"open",
+		`{x = 1, name = "pt", tag = none}`,
+		"[1, 2, 3] has 3 elements",
+		"2 !division by zero",
+		"maybe = 005",
+		"{42}",
+	}
+	if got := strings.Split(strings.TrimSuffix(out, "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestIterators(t *testing.T) {
+	src := `package main
+
+Tree ~ struct {
+	values : []int64
+}
+
+(t : Tree).All : func() iter<int64, uint64> = {
+	return {
+		var i : uint64 = 0
+		for v in t.values {
+			if !yield(v * 10, i) {
+				return
+			}
+			i = i + 1
+		}
+	}
+}
+
+count : proc(yield : func(v : int64) bool) = {
+	for i in 1..=3 {
+		if !yield(i) {
+			return
+		}
+	}
+}
+
+main : proc() = {
+	for i in 0..4 {
+		@print(i)
+	}
+
+	for i in 10..0 by -3 {
+		@print(i)
+	}
+
+	n : uint8 = 255
+	for i in 250..=n by 2 {
+		@print(i)
+	}
+
+	for i in n..250 {
+		@print(i)
+	}
+
+	evens : iter<int64> = 0..10 by 4
+	for v in evens {
+		@print("even {v}")
+	}
+
+	for v in count {
+		@print("count {v}")
+	}
+
+	tree : Tree = {values = []int64{1, 2}}
+	for v, i in tree {
+		@print("tree {i} {v}")
+	}
+
+	squares : iter<int64> = {
+		for i in 1..100 {
+			if i * i > 10 || !yield(i * i) {
+				return
+			}
+		}
+	}
+
+	for s in squares {
+		@print("square {s}")
+	}
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "builtin.Range[int64](0, 4, 1, false)")
+	mustContain(t, code, "go_iter.Seq2[int64, uint64]")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	want := []string{
+		"0", "1", "2", "3",
+		"10", "7", "4", "1",
+		"250", "252", "254",
+		"even 0", "even 4", "even 8",
+		"count 1", "count 2", "count 3",
+		"tree 0 10", "tree 1 20",
+		"square 1", "square 4", "square 9",
+	}
+	if got := strings.Split(strings.TrimSuffix(out, "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestRangeShouldError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"float", "0.5..2.5"},
+		{"mixed_types", "a..b"},
+		{"zero_step", "0..10 by 0"},
+		{"negative_unsigned_step", "b..0 by -1"},
+		{"int128", "c..c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := `package main
+
+main : proc() = {
+	a : int64 = 1
+	b : uint8 = 2
+	c : int128 = 3
+	for i in ` + tt.expr + ` {
+		@print(i)
+	}
+}`
+
+			l := lexer.NewLexer(strings.NewReader(src))
+
+			toks, err := l.Parse(t.Context())
+			if err != nil {
+				t.Fatalf("lexer error: %v", err)
+			}
+
+			p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "")
+			if err != nil {
+				t.Fatalf("parser init error: %v", err)
+			}
+
+			if _, err := p.Parse(t.Context(), ""); err == nil {
+				t.Fatal("expected parse error, got nil")
+			}
+		})
+	}
+}
+
+func TestAsyncFuncShouldError(t *testing.T) {
+	t.Parallel()
+
+	src := `package main
+
+square : func(x : int64) int64 = {
+	return x * x
+}
+
+main : proc() = {
+	s := async square(3)
+	@print(@await(s))
+}
+`
+
+	_, err := tryTranspile(t.Context(), src)
+	if err == nil {
+		t.Fatalf("expected parse error for func called async, got nil")
+	}
+
+	mustContain(t, err.Error(), "cannot be called async")
+}
+
+func TestCompileTimeEvaluation(t *testing.T) {
+	src := `package main
+
+comp size : uint64 = 4 * 2
+
+comp factorial : func(n : uint64) uint64 = {
+	if n <= 1 {
+		return 1
+	}
+
+	return n * factorial(n - 1)
+}
+
+comp fib : func(n : uint64) uint64 = {
+	var a : uint64 = 0
+	var b : uint64 = 1
+
+	for i in 0..n {
+		next := a + b + i - i
+		a = b
+		b = next
+	}
+
+	return a
+}
+
+comp clamp : func(x : int64, max? : int64 = 10) int64 = {
+	switch {
+	case x > max:
+		return max
+	case x < 0:
+		return 0
+	default:
+		return x
+	}
+}
+
+comp greeting : utf8 = "hello" + ", " + "world"
+
+comp huge : int128 = 1 << 100
+
+comp half : float32 = 1.0 / 2.0
+
+main : proc() = {
+	comp local := factorial(5) + 1
+	values : [size / 2]int64 = {1, 2, 3, 4}
+	var table : [fib(6)]int64
+
+	@print(factorial(10))
+	@print(fib(90))
+	@print(clamp(42))
+	@print(clamp(-3, 5))
+	@print(local)
+	@print(greeting)
+	@print(huge)
+	@print(half)
+	@print(values)
+	@print(@len(table))
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	mustContain(t, code, "const greeting string = \"hello, world\"")
+	mustContain(t, code, "[8]int64")
+	mustContain(t, code, "const local uint64 = 121")
+	mustContain(t, code, "var huge cog.Int128")
+	mustContain(t, code, "[4]int64")
+	mustContain(t, code, "builtin.Uint(uint64(3628800))")
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s\ncode:\n%s", err, out, code)
+	}
+
+	want := []string{
+		"3628800",
+		"2880067194370816120",
+		"10",
+		"0",
+		"121",
+		"hello, world",
+		"1267650600228229401496703205376",
+		"0.5",
+		"[1, 2, 3, 4]",
+		"8",
+	}
+	if got := strings.Split(strings.TrimSuffix(out, "\n"), "\n"); !slices.Equal(got, want) {
+		t.Errorf("output:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCompileTimeEvaluationShouldError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "non_comp_argument",
+			src: `comp square : func(x : int64) int64 = {
+	return x * x
+}
+
+main : proc() = {
+	x := 3
+	@print(square(x))
+}`,
+			want: `13:2: "x" is not comp`,
+		},
+		{
+			name: "overflow",
+			src: `comp base : uint8 = 200
+comp x : uint8 = base + 100
+
+main : proc() = {
+	@print(x)
+}`,
+			want: "8:6: constant 300 overflows uint8",
+		},
+		{
+			name: "division_by_zero",
+			src: `comp half : func(x : int64) int64 = {
+	return x / (x - x)
+}
+
+comp h : int64 = half(4)
+
+main : proc() = {
+	@print(h)
+}`,
+			want: "division by zero",
+		},
+		{
+			name: "call_depth",
+			src: `comp down : func(n : int64) int64 = {
+	return down(n + 1)
+}
+
+main : proc() = {
+	@print(down(0))
+}`,
+			want: "exceeds the maximum call depth of 256",
+		},
+		{
+			name: "steps",
+			src: `comp spin : func() int64 = {
+	var i : int64 = 0
+	for {
+		i = i + 0
+	}
+	return i
+}
+
+main : proc() = {
+	@print(spin())
+}`,
+			want: "compile-time evaluation exceeds 1048576 steps",
+		},
+		{
+			name: "print",
+			src: `comp loud : func(x : int64) int64 = {
+	@print(x)
+	return x
+}
+
+main : proc() = {
+	@print(loud(1))
+}`,
+			want: `8:2: comp func "loud" cannot call @print`,
+		},
+		{
+			name: "go_call",
+			src: `comp upper : func(s : utf8) utf8 = {
+	return @go.strings.ToUpper(s)
+}
+
+main : proc() = {
+	@print(upper("a"))
+}`,
+			want: `comp func "upper" cannot call Go function @go.strings.ToUpper`,
+		},
+		{
+			name: "dyn",
+			src: `dyn level : int64 = 1
+
+comp get : func() int64 = {
+	return level
+}
+
+main : proc() = {
+	@print(get())
+}`,
+			want: `comp func "get" cannot use dynamically scoped variable "level"`,
+		},
+		{
+			name: "proc_call",
+			src: `tick : proc() int64 = {
+	return 1
+}
+
+comp get : func() int64 = {
+	return tick()
+}
+
+main : proc() = {
+	@print(get())
+}`,
+			want: `comp func "get" cannot call proc tick`,
+		},
+		{
+			name: "non_comp_func_call",
+			src: `one : func() int64 = {
+	return 1
+}
+
+comp get : func() int64 = {
+	return one()
+}
+
+main : proc() = {
+	@print(get())
+}`,
+			want: `comp func "get" can only call comp funcs, and one is not comp`,
+		},
+		{
+			name: "cycle",
+			src: `comp a : int64 = b + 1
+comp b : int64 = a + 1
+
+main : proc() = {
+	@print(a)
+}`,
+			want: `initialization cycle`,
+		},
+		{
+			name: "array_length",
+			src: `comp n : uint64 = 2
+
+main : proc() = {
+	values : [n]int64 = {1, 2, 3}
+	@print(values)
+}`,
+			want: "array literal has 3 values, but the array length is 2",
+		},
+		{
+			name: "nested_call",
+			src: `comp inverse : func(x : int64) int64 = {
+	return 1 / x
+}
+
+comp twice : func(x : int64) int64 = {
+	return inverse(x) * 2
+}
+
+main : proc() = {
+	@print(twice(0))
+}`,
+			want: "8:2: division by zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tryTranspile(t.Context(), "package main\n\ngoimport (\n\t\"strings\"\n)\n\n"+tt.src)
+			if err == nil {
+				t.Fatal("expected compile-time evaluation error, got nil")
+			}
+
+			mustContain(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestImmutableSliceIsCopied(t *testing.T) {
+	src := `package main
+
+goimport (
+	"sort"
+)
+
+main : proc() = {
+	xs : []float64 = {3.0, 1.0, 2.0}
+	var ys := xs
+	@go.sort.Float64s(ys)
+	@print(xs)
+	@print(ys)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	if want := "[3, 1, 2]\n[1, 2, 3]\n"; out != want {
+		t.Fatalf("got output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestImmutableASCIIIsCopied(t *testing.T) {
+	src := `package main
+
+goimport (
+	"crypto/rand"
+)
+
+main : proc() = {
+	a : ascii = "aaaaaaaa"
+	@go.rand.Read(a)
+	@print(a)
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	if want := "aaaaaaaa\n"; out != want {
+		t.Fatalf("got output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestCopyBuiltin(t *testing.T) {
+	src := `package main
+
+goimport (
+	"sort"
+)
+
+Bag ~ struct {
+	name : utf8
+	items : []float64
+	tags : map<utf8, []utf8>
+}
+
+main : proc() = {
+	var bag : Bag = Bag{name = "a", items = {2.0, 1.0}, tags = {"x": {"y"}}}
+	other := @copy(bag)
+	@go.sort.Float64s(bag.items)
+	@print(bag.items)
+	@print(other.items)
+	bags : []Bag = {bag}
+	var copies := bags
+	@print(@len(copies))
+}`
+
+	code := transpileSource(t, src)
+
+	t.Parallel()
+
+	out, err := runGenerated(t, code)
+	if err != nil {
+		t.Fatalf("running generated program failed: %v\noutput:\n%s", err, out)
+	}
+
+	if want := "[1, 2]\n[2, 1]\n1\n"; out != want {
+		t.Fatalf("got output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestMutabilityShouldError(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "delete",
+			src: `main : proc() = {
+	ages : map<utf8, int64> = {"ada": 36}
+	@delete(ages, "ada")
+}`,
+			want: `cannot delete from immutable variable "ages"`,
+		},
+		{
+			name: "mutable_method",
+			src: `Counter ~ struct {
+	count : int64
+}
+
+(var c : &Counter).Inc : proc() = {
+	c.count = c.count + 1
+}
+
+main : proc() = {
+	c : Counter = Counter{count = 0}
+	c.Inc()
+}`,
+			want: `method "Inc" changes its receiver, so it cannot be called on immutable variable "c"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tryTranspile(t.Context(), "package main\n\n"+tt.src)
+			if err == nil {
+				t.Fatal("expected mutability error, got nil")
+			}
+
+			mustContain(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -7,13 +7,16 @@ import (
 	"io"
 	"strings"
 	"text/scanner"
+	"unicode/utf8"
 
+	"github.com/samborkent/cog/internal/diag"
 	"github.com/samborkent/cog/internal/tokens"
 )
 
 type Lexer struct {
-	scan    scanner.Scanner
-	fileID  uint16
+	scan   scanner.Scanner
+	fileID uint16
+	path   string
 }
 
 func NewLexer(r io.Reader) *Lexer {
@@ -21,6 +24,12 @@ func NewLexer(r io.Reader) *Lexer {
 }
 
 func NewLexerWithFileID(r io.Reader, fileID uint16) *Lexer {
+	return NewFileLexer(r, "", fileID)
+}
+
+// NewFileLexer creates a lexer for the file at path, which is named in the
+// diagnostics.
+func NewFileLexer(r io.Reader, path string, fileID uint16) *Lexer {
 	var s scanner.Scanner
 	s.Init(r)
 	s.Mode = (scanner.GoTokens | scanner.ScanInts) &^ scanner.SkipComments
@@ -28,6 +37,7 @@ func NewLexerWithFileID(r io.Reader, fileID uint16) *Lexer {
 	return &Lexer{
 		scan:   s,
 		fileID: fileID,
+		path:   path,
 	}
 }
 
@@ -36,6 +46,16 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 
 	var errs []error
 
+	// The scanner reports its errors here instead of on stderr.
+	s.Error = func(s *scanner.Scanner, msg string) {
+		pos := s.Position
+		if !pos.IsValid() {
+			pos = s.Pos()
+		}
+
+		errs = append(errs, l.error(pos, diag.CodeInvalidText, msg, s.TokenText()))
+	}
+
 	// TODO: determine appropriate pre-allocation size, or guess number of tokens based on file size.
 	toks := make([]tokens.Token, 0, 1024)
 
@@ -44,15 +64,18 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 		col uint16
 	)
 
-	for tok := s.Scan(); tok != scanner.EOF; tok = s.Scan() {
-		if ctx.Err() != nil {
+	for {
+		errCount := s.ErrorCount
+
+		tok := s.Scan()
+		if tok == scanner.EOF || ctx.Err() != nil {
 			break
 		}
 
 		txt := s.TokenText()
 
-		if s.ErrorCount > 0 {
-			errs = append(errs, fmt.Errorf("\tln %d, col %d: scanner error: %s", s.Line, s.Column, txt))
+		if s.ErrorCount > errCount {
+			// The error is reported by s.Error.
 			continue
 		}
 
@@ -98,9 +121,14 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 					s.Next()
 				}
 			case tokens.BitAnd:
-				if s.Peek() == '&' {
+				switch s.Peek() {
+				case '&':
 					t.Type = tokens.And
 
+					s.Next()
+				case '^':
+					t.Type = tokens.BitClear
+
 					s.Next()
 				}
 			case tokens.Pipe:
@@ -108,6 +136,18 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 					t.Type = tokens.Or
 
 					s.Next()
+
+					if s.Peek() == '|' {
+						t.Type = tokens.BitOr
+
+						s.Next()
+					}
+				}
+			case tokens.Dot:
+				if s.Peek() == '.' {
+					s.Next()
+
+					t.Type = rangeOperator(&s)
 				}
 			case tokens.Builtin:
 				t.Type = tokens.Builtin
@@ -132,8 +172,25 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 			t.Type = tokens.IntLiteral
 			t.Literal = txt
 		case scanner.Float:
-			t.Type = tokens.FloatLiteral
-			t.Literal = txt
+			if strings.HasSuffix(txt, ".") && s.Peek() == '.' {
+				// The scanner reads 0..4 as the float 0. followed by .4, or
+				// 0.. as 0. followed by a dot.
+				t.Type = tokens.IntLiteral
+				t.Literal = strings.TrimSuffix(txt, ".")
+				toks = append(toks, t)
+
+				s.Next()
+
+				t = tokens.Token{
+					Type:   rangeOperator(&s),
+					Ln:     t.Ln,
+					Col:    t.Col + uint16(len(t.Literal)), //nolint:gosec // G115: integer overflow conversion
+					FileID: l.fileID,
+				}
+			} else {
+				t.Type = tokens.FloatLiteral
+				t.Literal = txt
+			}
 		case scanner.String:
 			t.Type = tokens.StringLiteral
 			t.Literal = strings.Trim(txt, `"`)
@@ -149,7 +206,7 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 				t.Literal = txt
 			}
 		default:
-			errs = append(errs, fmt.Errorf("\tln %d, col %d: unknown token: %s", s.Line, s.Column, txt))
+			errs = append(errs, l.error(s.Position, diag.CodeUnknownToken, "unknown token: "+txt, txt))
 			continue
 		}
 
@@ -170,3 +227,30 @@ func (l *Lexer) Parse(ctx context.Context) ([]tokens.Token, error) {
 
 	return append(toks, eof), nil
 }
+
+// rangeOperator returns the range operator of which s has read the two dots.
+func rangeOperator(s *scanner.Scanner) tokens.Type {
+	if s.Peek() == '=' {
+		s.Next()
+
+		return tokens.RangeInclusive
+	}
+
+	return tokens.Range
+}
+
+// error returns the diagnostic for the source text txt at pos.
+func (l *Lexer) error(pos scanner.Position, code diag.Code, msg, txt string) *diag.Diagnostic {
+	start := diag.Pos{Ln: pos.Line, Col: pos.Column}
+
+	return &diag.Diagnostic{
+		Severity: diag.SeverityError,
+		Code:     code,
+		File:     l.path,
+		Span: diag.Span{
+			Start: start,
+			End:   diag.Pos{Ln: start.Ln, Col: start.Col + max(utf8.RuneCountInString(txt), 1)},
+		},
+		Message: msg,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/samborkent/cog/internal/diag"
 	"github.com/samborkent/cog/internal/tokens"
 )
 
@@ -56,6 +57,7 @@ func TestSingleCharTokens(t *testing.T) {
 		{"-", tokens.Minus},
 		{"*", tokens.Asterisk},
 		{"/", tokens.Divide},
+		{"%", tokens.Percent},
 		{"?", tokens.Question},
 		{"~", tokens.Tilde},
 		{"^", tokens.BitXor},
@@ -88,6 +90,10 @@ func TestMultiCharTokens(t *testing.T) {
 		{"declaration", ":=", tokens.Declaration},
 		{"and", "&&", tokens.And},
 		{"or", "||", tokens.Or},
+		{"bit_clear", "&^", tokens.BitClear},
+		{"bit_or", "|||", tokens.BitOr},
+		{"range", "..", tokens.Range},
+		{"range_inclusive", "..=", tokens.RangeInclusive},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +123,7 @@ func TestSingleVsMultiCharDisambiguation(t *testing.T) {
 		{"colon_alone", ": x", tokens.Colon},
 		{"bitand_alone", "& x", tokens.BitAnd},
 		{"pipe_alone", "| x", tokens.Pipe},
+		{"or_alone", "|| x", tokens.Or},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +148,11 @@ func TestIntLiteral(t *testing.T) {
 		{"0", "0"},
 		{"42", "42"},
 		{"1000000", "1000000"},
+		{"1_000_000", "1_000_000"},
+		{"0xFF", "0xFF"},
+		{"0x_dead_beef", "0x_dead_beef"},
+		{"0b1010", "0b1010"},
+		{"0o755", "0o755"},
 	}
 
 	for _, tt := range tests {
@@ -169,6 +181,7 @@ func TestFloatLiteral(t *testing.T) {
 		{"3.14", "3.14"},
 		{"0.5", "0.5"},
 		{"1.0e10", "1.0e10"},
+		{"1_000.5", "1_000.5"},
 	}
 
 	for _, tt := range tests {
@@ -300,6 +313,7 @@ func TestTypeKeywords(t *testing.T) {
 		{"complex32", tokens.Complex32},
 		{"complex64", tokens.Complex64},
 		{"complex128", tokens.Complex128},
+		{"iter", tokens.Iter},
 	}
 
 	for _, tt := range tests {
@@ -504,6 +518,30 @@ func TestMultipleTokenSequence(t *testing.T) {
 	}
 }
 
+func TestRangeSequence(t *testing.T) {
+	t.Parallel()
+
+	toks := lex(t, "0..4 1..=n")
+
+	expected := []tokens.Type{
+		tokens.IntLiteral, tokens.Range, tokens.IntLiteral,
+		tokens.IntLiteral, tokens.RangeInclusive, tokens.Identifier, tokens.EOF,
+	}
+	if len(toks) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(expected), len(toks), toks)
+	}
+
+	for i, exp := range expected {
+		if toks[i].Type != exp {
+			t.Errorf("token %d: expected %s, got %s", i, exp, toks[i].Type)
+		}
+	}
+
+	if toks[0].Literal != "0" || toks[1].Col != 2 {
+		t.Errorf("expected IntLiteral '0' followed by .. at column 2, got %q and column %d", toks[0].Literal, toks[1].Col)
+	}
+}
+
 func TestDeclarationSequence(t *testing.T) {
 	t.Parallel()
 
@@ -565,6 +603,30 @@ func TestContextCancellation(t *testing.T) {
 	}
 }
 
+func TestScannerError(t *testing.T) {
+	t.Parallel()
+
+	l := NewFileLexer(strings.NewReader("x := \"a\\qb\"\ny := 1\nz := 2"), "main.cog", 0)
+
+	_, err := l.Parse(t.Context())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	diags := diag.All(err)
+	if len(diags) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diags), err)
+	}
+
+	if diags[0].Code != diag.CodeInvalidText {
+		t.Errorf("got code %s, want %s", diags[0].Code, diag.CodeInvalidText)
+	}
+
+	if got, want := diags[0].Error(), "main.cog:1:6: invalid char escape"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
 func TestLineAndColumnTracking(t *testing.T) {
 	t.Parallel()
 
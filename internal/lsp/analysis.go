@@ -0,0 +1,318 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/project"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// snapshot is the analysis of the package containing a document, based on
+// the open documents at the time.
+type snapshot struct {
+	path string           // path of the document
+	pkg  *project.Package // nil if the package could not be loaded
+	file *project.File    // the document in pkg, nil if not found
+	err  error            // error loading the package
+
+	overlay map[string][]byte
+	lines   map[string][]string
+}
+
+// analyze loads the package containing the document at path, the same way
+// the compiler does: scripts on their own, and .cog files together with the
+// other files in their directory. Test files are included.
+func (s *Server) analyze(ctx context.Context, path string) *snapshot {
+	snap := &snapshot{
+		path:    path,
+		overlay: s.docs,
+		lines:   make(map[string][]string),
+	}
+
+	loader := &project.Loader{Debug: s.debug, Tests: true, Overlay: s.docs}
+	root := filepath.Dir(path)
+
+	func() {
+		// The parser panics on some invalid input, which must not stop the server.
+		defer func() {
+			if r := recover(); r != nil {
+				snap.pkg = nil
+				snap.err = fmt.Errorf("internal parser error: %v", r)
+			}
+		}()
+
+		if strings.HasSuffix(path, ".cogs") {
+			snap.pkg, snap.err = loader.LoadScript(ctx, root, path)
+			return
+		}
+
+		files, err := loader.Files(root)
+		if err != nil {
+			snap.err = err
+			return
+		}
+
+		snap.pkg, snap.err = loader.Load(ctx, root, files)
+	}()
+
+	if snap.pkg != nil {
+		for _, file := range snap.pkg.Files {
+			if file.Path == path {
+				snap.file = file
+			}
+		}
+	}
+
+	return snap
+}
+
+// diagnostics returns the diagnostics of the package by path, including the
+// diagnostics in imported packages. Files without errors have no diagnostics,
+// which clears earlier ones.
+func (snap *snapshot) diagnostics() map[string][]Diagnostic {
+	diags := map[string][]Diagnostic{snap.path: {}}
+
+	if snap.pkg != nil {
+		for _, file := range snap.pkg.Files {
+			diags[file.Path] = []Diagnostic{}
+		}
+	}
+
+	for _, d := range diag.All(snap.err) {
+		// Errors without a file, such as unreadable files, are shown at the
+		// start of the document.
+		path := d.File
+		if path == "" {
+			path = snap.path
+		}
+
+		diags[path] = append(diags[path], snap.diagnostic(path, d))
+	}
+
+	return diags
+}
+
+// diagnostic converts a compiler diagnostic in the file at path.
+func (snap *snapshot) diagnostic(path string, d *diag.Diagnostic) Diagnostic {
+	severity := DiagnosticSeverityError
+
+	switch d.Severity {
+	case diag.SeverityWarning:
+		severity = DiagnosticSeverityWarning
+	case diag.SeverityNote:
+		severity = DiagnosticSeverityInformation
+	}
+
+	msg := d.Message
+	for _, note := range d.Notes {
+		msg += "\nnote: " + note.String()
+	}
+
+	if d.Fix != nil {
+		msg += "\nhelp: " + d.Fix.Message
+	}
+
+	var rng Range
+
+	if start := d.Span.Start; start.IsValid() {
+		length := 1
+		if end := d.Span.End; end.Ln == start.Ln && end.Col > start.Col {
+			length = end.Col - start.Col
+		}
+
+		rng = snap.span(path, uint32(start.Ln), start.Col, length) //nolint:gosec // G115: line numbers are positive
+	}
+
+	return Diagnostic{
+		Range:    rng,
+		Severity: severity,
+		Code:     string(d.Code),
+		Source:   "cog",
+		Message:  msg,
+	}
+}
+
+// line returns a line of the file at path, by zero-based line number.
+func (snap *snapshot) line(path string, line int) string {
+	lns, ok := snap.lines[path]
+	if !ok {
+		src, ok := snap.overlay[path]
+		if !ok {
+			src, _ = os.ReadFile(path)
+		}
+
+		lns = lines(string(src))
+		snap.lines[path] = lns
+	}
+
+	if line < 0 || line >= len(lns) {
+		return ""
+	}
+
+	return lns[line]
+}
+
+// span returns the range of length runes at a one-based line and column.
+func (snap *snapshot) span(path string, ln uint32, col, length int) Range {
+	if ln == 0 {
+		return Range{}
+	}
+
+	line := snap.line(path, int(ln)-1)
+
+	return Range{
+		Start: Position{Line: int(ln) - 1, Character: utf16Column(line, col)},
+		End:   Position{Line: int(ln) - 1, Character: utf16Column(line, col+length)},
+	}
+}
+
+// tokenRange returns the range of an identifier token in the file at path.
+func (snap *snapshot) tokenRange(path string, tok tokens.Token) Range {
+	return snap.span(path, tok.Ln, int(tok.Col), max(runeLen(tok.Literal), 1))
+}
+
+// identifierAt returns the identifier token of the document at pos.
+func (snap *snapshot) identifierAt(pos Position) (tokens.Token, bool) {
+	if snap.file == nil {
+		return tokens.Token{}, false
+	}
+
+	col := runeColumn(snap.line(snap.path, pos.Line), pos.Character)
+
+	for _, tok := range snap.file.Tokens {
+		if tok.Type != tokens.Identifier || int(tok.Ln) != pos.Line+1 {
+			continue
+		}
+
+		if int(tok.Col) <= col && col <= int(tok.Col)+runeLen(tok.Literal) {
+			return tok, true
+		}
+	}
+
+	return tokens.Token{}, false
+}
+
+// reference returns the symbol used at pos.
+func (snap *snapshot) reference(pos Position) (parser.Reference, bool) {
+	tok, ok := snap.identifierAt(pos)
+	if !ok {
+		return parser.Reference{}, false
+	}
+
+	return snap.pkg.Symbols.ResolveReference(tok)
+}
+
+// declarationPath returns the path of the file declaring the symbol of ref.
+func (snap *snapshot) declarationPath(ref parser.Reference) (string, bool) {
+	pkg := snap.pkg
+	if ref.Import != "" {
+		pkg = snap.pkg.Imports[ref.Import]
+	}
+
+	fileID := int(ref.Symbol.Identifier.Token.FileID)
+	if pkg == nil || fileID >= len(pkg.Files) {
+		return "", false
+	}
+
+	return pkg.Files[fileID].Path, true
+}
+
+// hover returns the declaration of the symbol used at pos.
+func (snap *snapshot) hover(pos Position) *Hover {
+	tok, ok := snap.identifierAt(pos)
+	if !ok {
+		return nil
+	}
+
+	ref, ok := snap.pkg.Symbols.ResolveReference(tok)
+	if !ok {
+		return nil
+	}
+
+	var qualifier string
+
+	if ref.Import != "" {
+		if imported, ok := snap.pkg.Imports[ref.Import]; ok {
+			qualifier = imported.Name
+		}
+	}
+
+	decl := declaration(ref.Symbol, qualifier)
+
+	rng := snap.tokenRange(snap.path, tok)
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```cog\n" + decl + "\n```"},
+		Range:    &rng,
+	}
+}
+
+// definition returns the location of the declaration of the symbol used at
+// pos.
+func (snap *snapshot) definition(pos Position) *Location {
+	ref, ok := snap.reference(pos)
+	if !ok || ref.Symbol.Identifier.Token.Ln == 0 {
+		return nil
+	}
+
+	path, ok := snap.declarationPath(ref)
+	if !ok {
+		return nil
+	}
+
+	return &Location{
+		URI:   uriFromPath(path),
+		Range: snap.tokenRange(path, ref.Symbol.Identifier.Token),
+	}
+}
+
+// declaration formats the declaration of a symbol in Cog syntax. Symbols of
+// imported packages are qualified by the package name.
+func declaration(sym parser.Symbol, qualifier string) string {
+	ident := sym.Identifier
+
+	name := ident.Name
+	if qualifier != "" {
+		name = qualifier + "." + name
+	}
+
+	var out strings.Builder
+
+	if ident.Exported && qualifier == "" {
+		out.WriteString("export ")
+	}
+
+	switch {
+	case sym.Scope == parser.EnumScope, sym.Scope == parser.StructScope && ident.Qualifier != ast.QualifierMethod:
+		// Enum values and fields are declared in their type.
+	case ident.Qualifier == ast.QualifierType:
+		out.WriteString(name + " ~ " + typeString(ident.ValueType.Underlying()))
+		return out.String()
+	case ident.Qualifier == ast.QualifierVariable:
+		out.WriteString("var ")
+	case ident.Qualifier == ast.QualifierDynamic:
+		out.WriteString("dyn ")
+	case ident.Qualifier == ast.QualifierCompileTime:
+		out.WriteString("comp ")
+	}
+
+	out.WriteString(name + " : " + typeString(ident.ValueType))
+
+	return out.String()
+}
+
+func typeString(typ types.Type) string {
+	if typ == nil {
+		return types.None.String()
+	}
+
+	return typ.String()
+}
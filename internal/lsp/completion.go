@@ -0,0 +1,218 @@
+package lsp
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// completion returns the completions at pos:
+//   - builtins after @;
+//   - exported symbols after the name of an imported package and a dot;
+//   - fields, methods and enum values after a value or type and a dot;
+//   - otherwise the globals, imported packages and the locals declared
+//     before pos.
+func (snap *snapshot) completion(pos Position) []CompletionItem {
+	line := []rune(snap.line(snap.path, pos.Line))
+	col := min(runeColumn(string(line), pos.Character)-1, len(line))
+
+	// Start of the word being completed.
+	start := col
+	for start > 0 && isIdentRune(line[start-1]) {
+		start--
+	}
+
+	var items []CompletionItem
+
+	switch {
+	case start > 0 && line[start-1] == '@':
+		for _, name := range append(parser.BuiltinNames(), "go") {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionKindKeyword, Detail: "@" + name})
+		}
+	case snap.pkg == nil || snap.file == nil:
+	case start > 0 && line[start-1] == '.':
+		// The operand of the selector, which ends before the dot.
+		end := start - 1
+		begin := end
+
+		for begin > 0 && isIdentRune(line[begin-1]) {
+			begin--
+		}
+
+		if begin == end {
+			return nil
+		}
+
+		items = snap.selectorCompletion(string(line[begin:end]), tokens.Token{
+			Type:    tokens.Identifier,
+			Literal: string(line[begin:end]),
+			FileID:  snap.file.ID,
+			Ln:      uint32(pos.Line + 1), //nolint:gosec // G115: line numbers are positive
+			Col:     uint16(begin + 1),    //nolint:gosec // G115: columns fit the lexer positions
+		})
+	default:
+		items = snap.scopeCompletion(pos)
+	}
+
+	slices.SortFunc(items, func(a, b CompletionItem) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+
+	return items
+}
+
+// selectorCompletion returns the members of the operand of a selector.
+func (snap *snapshot) selectorCompletion(name string, tok tokens.Token) []CompletionItem {
+	var items []CompletionItem
+
+	if imp, ok := snap.pkg.Symbols.ResolveCogImport(name); ok {
+		for exportName, sym := range imp.Exports {
+			items = append(items, symbolCompletion(exportName, sym))
+		}
+
+		return items
+	}
+
+	// The operand is resolved by the parser, even if the selector is not
+	// complete yet. Globals are found by name otherwise.
+	ref, ok := snap.pkg.Symbols.ResolveReference(tok)
+	if !ok {
+		sym, found := snap.pkg.Symbols.Resolve(name)
+		if !found {
+			return nil
+		}
+
+		ref = parser.Reference{Symbol: sym}
+	}
+
+	typeName := typeString(ref.Symbol.Type())
+	if ref.Symbol.Identifier.Qualifier == ast.QualifierType {
+		typeName = ref.Symbol.Identifier.Name
+	}
+
+	// Members of imported types are declared in the imported package.
+	tables := []*parser.SymbolTable{snap.pkg.Symbols}
+	for _, imported := range snap.pkg.Imports {
+		tables = append(tables, imported.Symbols)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, table := range tables {
+		table.ForEachField(typeName, func(name string, sym parser.Symbol) {
+			if seen[name] {
+				return
+			}
+
+			seen[name] = true
+
+			items = append(items, symbolCompletion(name, sym))
+		})
+	}
+
+	return items
+}
+
+// scopeCompletion returns the globals and imported packages, and the locals
+// declared in the enclosing global declaration before pos.
+func (snap *snapshot) scopeCompletion(pos Position) []CompletionItem {
+	var items []CompletionItem
+
+	snap.pkg.Symbols.ForEachGlobal(func(name string, sym parser.Symbol) {
+		if name != "_" {
+			items = append(items, symbolCompletion(name, sym))
+		}
+	})
+
+	for name := range snap.pkg.Symbols.CogImports() {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionKindModule, Detail: "import"})
+	}
+
+	ln := uint32(pos.Line + 1) //nolint:gosec // G115: line numbers are positive
+	col := runeColumn(snap.line(snap.path, pos.Line), pos.Character)
+	start := snap.enclosingDeclaration(ln)
+
+	seen := make(map[string]bool)
+
+	for _, tok := range snap.file.Tokens {
+		if tok.Ln < start || tok.Ln > ln || tok.Ln == ln && int(tok.Col) >= col {
+			continue
+		}
+
+		ref, ok := snap.pkg.Symbols.ResolveReference(tok)
+		if !ok || ref.Symbol.Scope != parser.LocalScope || seen[tok.Literal] {
+			continue
+		}
+
+		// Only declarations: their token is the token of the identifier.
+		if decl := ref.Symbol.Identifier.Token; decl.Ln != tok.Ln || decl.Col != tok.Col {
+			continue
+		}
+
+		seen[tok.Literal] = true
+
+		items = append(items, symbolCompletion(tok.Literal, ref.Symbol))
+	}
+
+	return items
+}
+
+// enclosingDeclaration returns the line of the global statement containing
+// line ln.
+func (snap *snapshot) enclosingDeclaration(ln uint32) uint32 {
+	var start uint32
+
+	if snap.file.AST == nil {
+		return start
+	}
+
+	for _, stmt := range snap.file.AST.Statements {
+		if stmtLn, _ := stmt.Pos(); stmtLn > 0 && stmtLn <= ln {
+			start = stmtLn
+		}
+	}
+
+	return start
+}
+
+func symbolCompletion(name string, sym parser.Symbol) CompletionItem {
+	item := CompletionItem{
+		Label:  name,
+		Detail: typeString(sym.Type()),
+	}
+
+	ident := sym.Identifier
+
+	switch {
+	case sym.Scope == parser.EnumScope:
+		item.Kind = CompletionKindEnumMember
+	case ident.Qualifier == ast.QualifierMethod:
+		item.Kind = CompletionKindMethod
+	case sym.Scope == parser.StructScope:
+		item.Kind = CompletionKindField
+	case ident.Qualifier == ast.QualifierType:
+		item.Kind = CompletionKindClass
+		if ident.ValueType.Kind() == types.StructKind {
+			item.Kind = CompletionKindStruct
+		}
+
+		item.Detail = declaration(sym, "")
+	case ident.ValueType != nil && ident.ValueType.Kind() == types.ProcedureKind:
+		item.Kind = CompletionKindFunction
+	case ident.Qualifier == ast.QualifierImmutable:
+		item.Kind = CompletionKindConstant
+	default:
+		item.Kind = CompletionKindVariable
+	}
+
+	return item
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,77 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// pathFromURI returns the file path of a file URI.
+func pathFromURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "file" {
+		return "", &rpcError{Code: codeInvalidParams, Message: "unsupported URI scheme: " + uri}
+	}
+
+	return filepath.Clean(filepath.FromSlash(u.Path)), nil
+}
+
+// uriFromPath returns the file URI of a file path.
+func uriFromPath(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// lines splits a document in lines.
+func lines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// utf16Column converts a one-based column in runes, as reported by the lexer,
+// to a zero-based column in UTF-16 code units.
+func utf16Column(line string, col int) int {
+	char := 0
+
+	for i, r := range []rune(line) {
+		if i >= col-1 {
+			break
+		}
+
+		char += utf16Len(r)
+	}
+
+	return char
+}
+
+// runeColumn converts a zero-based column in UTF-16 code units to a one-based
+// column in runes.
+func runeColumn(line string, char int) int {
+	col := 1
+
+	for _, r := range line {
+		if char <= 0 {
+			break
+		}
+
+		char -= utf16Len(r)
+		col++
+	}
+
+	return col
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
+
+func runeLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import "testing"
+
+func TestColumns(t *testing.T) {
+	t.Parallel()
+
+	// The lexer counts runes, the protocol counts UTF-16 code units.
+	line := `s := "é😀" + x`
+
+	tests := []struct {
+		col  int // one-based, in runes
+		char int // zero-based, in UTF-16 code units
+	}{
+		{col: 1, char: 0},
+		{col: 7, char: 6},   // é
+		{col: 8, char: 7},   // 😀, two code units
+		{col: 9, char: 9},   // "
+		{col: 13, char: 13}, // x
+		{col: 14, char: 14}, // end of line
+	}
+
+	for _, tt := range tests {
+		if got := utf16Column(line, tt.col); got != tt.char {
+			t.Errorf("utf16Column(%d) = %d, want %d", tt.col, got, tt.char)
+		}
+
+		if got := runeColumn(line, tt.char); got != tt.col {
+			t.Errorf("runeColumn(%d) = %d, want %d", tt.char, got, tt.col)
+		}
+	}
+}
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	uri := uriFromPath("/home/user/my project/main.cog")
+	if uri != "file:///home/user/my%20project/main.cog" {
+		t.Errorf("uriFromPath: got %q", uri)
+	}
+
+	path, err := pathFromURI(uri)
+	if err != nil || path != "/home/user/my project/main.cog" {
+		t.Errorf("pathFromURI(%q) = %q, %v", uri, path, err)
+	}
+
+	if _, err := pathFromURI("untitled:Untitled-1"); err == nil {
+		t.Error("pathFromURI: expected error for non-file URI")
+	}
+}
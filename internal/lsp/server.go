@@ -0,0 +1,252 @@
+// Package lsp implements a language server for Cog. It serves diagnostics,
+// hover, go-to-definition, completion and document symbols, based on the
+// parser and its symbol tables.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// errExitWithoutShutdown is returned when the client exits the server before
+// shutting it down.
+var errExitWithoutShutdown = errors.New("exit without shutdown")
+
+// Server is a language server. It handles one message at a time.
+type Server struct {
+	debug bool
+
+	conn     *conn
+	docs     map[string][]byte // text of the open documents by path
+	shutdown bool
+}
+
+// NewServer creates a language server. Debug enables the debug mode of the
+// parser.
+func NewServer(debug bool) *Server {
+	return &Server{
+		debug: debug,
+		docs:  make(map[string][]byte),
+	}
+}
+
+// Serve reads requests from r and writes responses to w, until the client
+// exits or r is closed.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		content, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var msg message
+
+		if err := json.Unmarshal(content, &msg); err != nil {
+			if err := s.conn.reply(nil, nil, &rpcError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+
+			return nil
+		}
+
+		result, err := s.handle(ctx, &msg)
+
+		// Notifications have no response.
+		if msg.ID == nil {
+			continue
+		}
+
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(ctx context.Context, msg *message) (any, error) {
+	if s.shutdown && msg.Method != "shutdown" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return &InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: TextDocumentSyncOptions{
+					OpenClose: true,
+					Change:    TextDocumentSyncKindFull,
+					Save:      true,
+				},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				CompletionProvider: CompletionOptions{
+					TriggerCharacters: []string{".", "@"},
+				},
+				DocumentSymbolProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "cog"},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		params, err := decode[DidOpenTextDocumentParams](msg.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, s.update(ctx, params.TextDocument.URI, []byte(params.TextDocument.Text))
+	case "textDocument/didChange":
+		params, err := decode[DidChangeTextDocumentParams](msg.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+
+		// Documents are synchronized in full, so the last change holds the text.
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+
+		return nil, s.update(ctx, params.TextDocument.URI, []byte(text))
+	case "textDocument/didClose":
+		params, err := decode[DidCloseTextDocumentParams](msg.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, s.update(ctx, params.TextDocument.URI, nil)
+	case "textDocument/didSave":
+		params, err := decode[DidSaveTextDocumentParams](msg.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		path, err := pathFromURI(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, s.publishDiagnostics(ctx, path)
+	case "textDocument/hover":
+		return withSnapshot(ctx, s, msg.Params, func(snap *snapshot, pos Position) *Hover {
+			return snap.hover(pos)
+		})
+	case "textDocument/definition":
+		return withSnapshot(ctx, s, msg.Params, func(snap *snapshot, pos Position) *Location {
+			return snap.definition(pos)
+		})
+	case "textDocument/completion":
+		return withSnapshot(ctx, s, msg.Params, func(snap *snapshot, pos Position) *CompletionList {
+			return &CompletionList{Items: snap.completion(pos)}
+		})
+	case "textDocument/documentSymbol":
+		params, err := decode[DocumentSymbolParams](msg.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		path, err := pathFromURI(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return s.analyze(ctx, path).documentSymbols(), nil
+	default:
+		if msg.ID == nil {
+			// Unknown notifications are ignored.
+			return nil, nil
+		}
+
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+	}
+}
+
+// update sets the text of an open document, or closes it if text is nil, and
+// publishes the diagnostics of its package.
+func (s *Server) update(ctx context.Context, uri string, text []byte) error {
+	path, err := pathFromURI(uri)
+	if err != nil {
+		return err
+	}
+
+	if text == nil {
+		delete(s.docs, path)
+	} else {
+		s.docs[path] = text
+	}
+
+	return s.publishDiagnostics(ctx, path)
+}
+
+// publishDiagnostics publishes the diagnostics of the package containing the
+// document at path.
+func (s *Server) publishDiagnostics(ctx context.Context, path string) error {
+	diags := s.analyze(ctx, path).diagnostics()
+
+	for _, file := range slices.Sorted(maps.Keys(diags)) {
+		err := s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         uriFromPath(file),
+			Diagnostics: diags[file],
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withSnapshot analyzes the document of a request at a position, and calls
+// fn with the analysis.
+func withSnapshot[T any](ctx context.Context, s *Server, raw json.RawMessage, fn func(*snapshot, Position) *T) (any, error) {
+	params, err := decode[TextDocumentPositionParams](raw)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := pathFromURI(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := s.analyze(ctx, path)
+	if snap.pkg == nil {
+		return nil, nil
+	}
+
+	return fn(snap, params.Position), nil
+}
+
+func decode[T any](raw json.RawMessage) (T, error) {
+	var params T
+
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return params, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+
+	return params, nil
+}
@@ -0,0 +1,533 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testClient is a language client talking to a server in-process.
+type testClient struct {
+	t    *testing.T
+	conn *conn
+	msgs chan json.RawMessage
+	done chan error
+
+	mu     sync.Mutex
+	id     int
+	diags  map[string][]Diagnostic // latest published diagnostics by URI
+	closed bool
+}
+
+func newTestClient(t *testing.T) *testClient {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	c := &testClient{
+		t:     t,
+		conn:  newConn(clientReader, clientWriter),
+		msgs:  make(chan json.RawMessage, 64),
+		done:  make(chan error, 1),
+		diags: make(map[string][]Diagnostic),
+	}
+
+	go func() {
+		// The test context is canceled before the cleanup shuts the server down.
+		err := NewServer(false).Serve(context.Background(), serverReader, serverWriter)
+		_ = serverReader.Close()
+		_ = serverWriter.Close()
+		c.done <- err
+	}()
+
+	go func() {
+		defer close(c.msgs)
+
+		for {
+			content, err := c.conn.read()
+			if err != nil {
+				return
+			}
+
+			c.msgs <- content
+		}
+	}()
+
+	t.Cleanup(func() {
+		if !c.closed {
+			c.call("shutdown", nil, nil)
+			c.notify("exit", nil)
+
+			if err := <-c.done; err != nil {
+				t.Errorf("Serve: %v", err)
+			}
+		}
+	})
+
+	c.call("initialize", map[string]any{}, nil)
+	c.notify("initialized", map[string]any{})
+
+	return c
+}
+
+func (c *testClient) notify(method string, params any) {
+	c.t.Helper()
+
+	if err := c.conn.notify(method, params); err != nil {
+		c.t.Fatalf("notify %s: %v", method, err)
+	}
+}
+
+// call sends a request and decodes its result into result. Notifications
+// received before the response are recorded.
+func (c *testClient) call(method string, params, result any) *rpcError {
+	c.t.Helper()
+
+	c.id++
+	id := json.RawMessage(strconv.Itoa(c.id))
+
+	err := c.conn.write(map[string]any{"jsonrpc": "2.0", "id": &id, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatalf("call %s: %v", method, err)
+	}
+
+	for content := range c.msgs {
+		var msg struct {
+			ID     *json.RawMessage `json:"id"`
+			Method string           `json:"method"`
+			Params json.RawMessage  `json:"params"`
+			Result json.RawMessage  `json:"result"`
+			Error  *rpcError        `json:"error"`
+		}
+
+		if err := json.Unmarshal(content, &msg); err != nil {
+			c.t.Fatalf("decoding %s: %v", content, err)
+		}
+
+		if msg.ID == nil {
+			if msg.Method == "textDocument/publishDiagnostics" {
+				var params PublishDiagnosticsParams
+				if err := json.Unmarshal(msg.Params, &params); err != nil {
+					c.t.Fatal(err)
+				}
+
+				c.mu.Lock()
+				c.diags[params.URI] = params.Diagnostics
+				c.mu.Unlock()
+			}
+
+			continue
+		}
+
+		if string(*msg.ID) != string(id) {
+			c.t.Fatalf("got response to %s, want %s", *msg.ID, id)
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("decoding result of %s: %v", method, err)
+			}
+		}
+
+		return nil
+	}
+
+	c.t.Fatalf("call %s: connection closed", method)
+
+	return nil
+}
+
+func (c *testClient) open(path, text string) {
+	c.t.Helper()
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uriFromPath(path), LanguageID: "cog", Version: 1, Text: text},
+	})
+}
+
+func (c *testClient) change(path, text string) {
+	c.t.Helper()
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uriFromPath(path)},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+}
+
+// diagnostics returns the latest diagnostics published for path. Messages
+// are handled in order, so the response to a request follows the
+// diagnostics published before it.
+func (c *testClient) diagnostics(path string) []Diagnostic {
+	c.t.Helper()
+
+	c.call("textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: uriFromPath(path)},
+	}, nil)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.diags[uriFromPath(path)]
+}
+
+func (c *testClient) position(method, path string, pos Position, result any) {
+	c.t.Helper()
+
+	if err := c.call(method, TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uriFromPath(path)},
+		Position:     pos,
+	}, result); err != nil {
+		c.t.Fatalf("%s: %v", method, err)
+	}
+}
+
+const (
+	geomSource = `package geom
+
+export Point ~ struct {
+    export (
+        x : float64
+        y : float64
+    )
+}
+
+export Origin : Point = {
+    x = 0.0,
+    y = 0.0,
+}
+
+export Distance : func(a : Point, b : Point) float64 = {
+    dx := a.x - b.x
+    dy := a.y - b.y
+    return dx * dx + dy * dy
+}
+`
+
+	mainSource = `package main
+
+import (
+    "geom"
+)
+
+Status ~ enum<utf8> {
+    Open := "open",
+    Closed := "closed",
+}
+
+Pair ~ struct {
+    left : int64
+    right : int64
+}
+
+main : proc() = {
+    p := geom.Origin
+    d := geom.Distance(p, p)
+    s := Status.Open
+    pair : Pair = {
+        left = 1,
+        right = 2,
+    }
+    @print(d)
+    @print(s)
+    @print(pair.left)
+}
+`
+)
+
+// writeProject writes a main package importing the geom package, and
+// returns the path of the main file.
+func writeProject(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(dir, "geom"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "geom", "geom.cog"), []byte(geomSource), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "main.cog")
+
+	if err := os.WriteFile(path, []byte(mainSource), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+// positionOf returns the position of the n-th occurrence of substr in the
+// main source, offset by delta characters.
+func positionOf(t *testing.T, substr string, n, delta int) Position {
+	t.Helper()
+
+	for i, line := range lines(mainSource) {
+		for col := 0; ; {
+			j := strings.Index(line[col:], substr)
+			if j < 0 {
+				break
+			}
+
+			if n == 0 {
+				return Position{Line: i, Character: col + j + delta}
+			}
+
+			n--
+			col += j + 1
+		}
+	}
+
+	t.Fatalf("%q not found", substr)
+
+	return Position{}
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t)
+
+	if err := c.call("cog/unknown", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method: got %v, want method not found", err)
+	}
+
+	c.call("shutdown", nil, nil)
+
+	if err := c.call("textDocument/hover", nil, nil); err == nil || err.Code != codeInvalidRequest {
+		t.Errorf("request after shutdown: got %v, want invalid request", err)
+	}
+
+	c.notify("exit", nil)
+	c.closed = true
+
+	if err := <-c.done; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t)
+	c.notify("exit", nil)
+	c.closed = true
+
+	if err := <-c.done; !errors.Is(err, errExitWithoutShutdown) {
+		t.Errorf("Serve: got %v, want %v", err, errExitWithoutShutdown)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	path := writeProject(t)
+	c := newTestClient(t)
+
+	c.open(path, mainSource)
+
+	if diags := c.diagnostics(path); len(diags) != 0 {
+		t.Fatalf("got diagnostics for valid source: %v", diags)
+	}
+
+	c.change(path, strings.Replace(mainSource, "@print(d)", "@print(e)", 1))
+
+	diags := c.diagnostics(path)
+	if len(diags) == 0 {
+		t.Fatal("got no diagnostics for undefined identifier")
+	}
+
+	want := Range{
+		Start: positionOf(t, "@print(d)", 0, len("@print(")),
+		End:   positionOf(t, "@print(d)", 0, len("@print(d")),
+	}
+
+	if diags[0].Range != want || diags[0].Code != "P002" || !strings.HasPrefix(diags[0].Message, `undefined identifier "e"`) {
+		t.Errorf("got %+v, want undefined identifier at %+v", diags[0], want)
+	}
+
+	// The suggested fix is part of the message.
+	if !strings.Contains(diags[0].Message, `did you mean "d"?`) {
+		t.Errorf("got message %q, want suggestion", diags[0].Message)
+	}
+
+	// Fixing the error clears the diagnostics.
+	c.change(path, mainSource)
+
+	if diags := c.diagnostics(path); len(diags) != 0 {
+		t.Errorf("got diagnostics after fix: %v", diags)
+	}
+
+	// Errors in imported packages are reported in their file.
+	geomPath := filepath.Join(filepath.Dir(path), "geom", "geom.cog")
+	c.open(geomPath, strings.Replace(geomSource, "dx * dx", "dz * dx", 1))
+	c.change(path, mainSource)
+
+	if diags := c.diagnostics(path); len(diags) != 0 {
+		t.Errorf("got diagnostics for valid importing file: %v", diags)
+	}
+
+	diags = c.diagnostics(geomPath)
+	if len(diags) == 0 || !strings.HasPrefix(diags[0].Message, `undefined identifier "dz"`) {
+		t.Errorf("got %+v, want error in imported package", diags)
+	}
+}
+
+func TestHover(t *testing.T) {
+	t.Parallel()
+
+	path := writeProject(t)
+	c := newTestClient(t)
+
+	c.open(path, mainSource)
+
+	tests := []struct {
+		name string
+		pos  Position
+		want string
+	}{
+		{name: "local", pos: positionOf(t, "(p, p)", 0, 1), want: "p : Point"},
+		{name: "import", pos: positionOf(t, "Distance", 0, 2), want: "geom.Distance : func(a : Point, b : Point) float64"},
+		{name: "type", pos: positionOf(t, "Status", 1, 0), want: "Status ~ enum<utf8> {\nOpen := (\"open\" : utf8),\nClosed := (\"closed\" : utf8),\n}"},
+		{name: "enum_value", pos: positionOf(t, "Open", 1, 0), want: "Open : utf8"},
+		{name: "field", pos: positionOf(t, "pair.left", 0, 5), want: "left : int64"},
+	}
+
+	for _, tt := range tests {
+		var hover *Hover
+
+		c.position("textDocument/hover", path, tt.pos, &hover)
+
+		if hover == nil {
+			t.Errorf("%s: got no hover", tt.name)
+			continue
+		}
+
+		if got := strings.TrimSuffix(strings.TrimPrefix(hover.Contents.Value, "```cog\n"), "\n```"); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	t.Parallel()
+
+	path := writeProject(t)
+	c := newTestClient(t)
+
+	c.open(path, mainSource)
+
+	var loc *Location
+
+	c.position("textDocument/definition", path, positionOf(t, "Distance", 0, 0), &loc)
+
+	want := Location{
+		URI:   uriFromPath(filepath.Join(filepath.Dir(path), "geom", "geom.cog")),
+		Range: Range{Start: Position{Line: 14, Character: 7}, End: Position{Line: 14, Character: 15}},
+	}
+
+	if loc == nil || *loc != want {
+		t.Errorf("imported: got %+v, want %+v", loc, want)
+	}
+
+	c.position("textDocument/definition", path, positionOf(t, "(p, p)", 0, 1), &loc)
+
+	want = Location{
+		URI:   uriFromPath(path),
+		Range: Range{Start: positionOf(t, "p :=", 0, 0), End: positionOf(t, "p :=", 0, 1)},
+	}
+
+	if loc == nil || *loc != want {
+		t.Errorf("local: got %+v, want %+v", loc, want)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	t.Parallel()
+
+	path := writeProject(t)
+	c := newTestClient(t)
+
+	tests := []struct {
+		name   string
+		insert string // text inserted before @print(d)
+		want   []string
+	}{
+		{name: "builtin", insert: "@", want: []string{"await", "cast", "copy", "delete", "format", "go", "if", "len", "map", "print", "ref", "set", "slice"}},
+		{name: "field", insert: "pair.", want: []string{"left", "right"}},
+		{name: "imported_field", insert: "p.", want: []string{"x", "y"}},
+		{name: "enum", insert: "Status.", want: []string{"Closed", "Open"}},
+		{name: "import", insert: "geom.", want: []string{"Distance", "Origin", "Point"}},
+		{name: "scope", insert: "", want: []string{"Pair", "Status", "d", "geom", "main", "p", "pair", "s"}},
+	}
+
+	for _, tt := range tests {
+		text := strings.Replace(mainSource, "    @print(d)", "    "+tt.insert+"\n    @print(d)", 1)
+		c.open(path, text)
+
+		pos := positionOf(t, "@print(d)", 0, 0)
+		pos.Character += len(tt.insert)
+
+		var list CompletionList
+
+		c.position("textDocument/completion", path, pos, &list)
+
+		var got []string
+		for _, item := range list.Items {
+			got = append(got, item.Label)
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	t.Parallel()
+
+	path := writeProject(t)
+	c := newTestClient(t)
+
+	var symbols []DocumentSymbol
+
+	c.call("textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: uriFromPath(path)},
+	}, &symbols)
+
+	want := []struct {
+		name       string
+		kind       SymbolKind
+		start, end int // lines
+	}{
+		{name: "Status", kind: SymbolKindEnum, start: 6, end: 11},
+		{name: "Pair", kind: SymbolKindStruct, start: 11, end: 16},
+		{name: "main", kind: SymbolKindFunction, start: 16, end: 27},
+	}
+
+	if len(symbols) != len(want) {
+		t.Fatalf("got %d symbols, want %d: %+v", len(symbols), len(want), symbols)
+	}
+
+	for i, got := range symbols {
+		if got.Name != want[i].name || got.Kind != want[i].kind ||
+			got.Range.Start.Line != want[i].start || got.Range.End.Line != want[i].end ||
+			got.SelectionRange.Start != (Position{Line: want[i].start}) {
+			t.Errorf("got %+v, want %s on lines %d to %d", got, want[i].name, want[i].start, want[i].end)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// documentSymbols returns the global declarations, types and methods of the
+// document. A symbol ranges up to the next global statement.
+func (snap *snapshot) documentSymbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+
+	if snap.file == nil || snap.file.AST == nil {
+		return symbols
+	}
+
+	stmts := snap.file.AST.Statements
+
+	for i, stmt := range stmts {
+		var (
+			ident *ast.Identifier
+			kind  SymbolKind
+			name  string
+		)
+
+		switch stmt := stmt.(type) {
+		case *ast.Declaration:
+			ident = stmt.Assignment.Identifier
+			name = ident.Name
+			kind = declarationKind(ident)
+		case *ast.Type:
+			ident = stmt.Identifier
+			name = ident.Name
+			kind = typeKind(ident.ValueType)
+		case *ast.Method:
+			ident = stmt.Declaration.Assignment.Identifier
+			name = typeString(stmt.Type) + "." + ident.Name
+			kind = SymbolKindMethod
+		default:
+			continue
+		}
+
+		selection := snap.tokenRange(snap.path, ident.Token)
+
+		start, _ := stmt.Pos()
+		rng := Range{Start: Position{Line: int(start) - 1}, End: selection.End}
+
+		if start == 0 || start > ident.Token.Ln {
+			rng.Start = selection.Start
+		}
+
+		// The statement ends where the next one begins, or at the end of the file.
+		if n := len(snap.file.Tokens); n > 0 {
+			rng.End = Position{Line: int(snap.file.Tokens[n-1].Ln)}
+		}
+
+		for _, next := range stmts[i+1:] {
+			if nextLn, _ := next.Pos(); nextLn > ident.Token.Ln {
+				rng.End = Position{Line: int(nextLn) - 1}
+				break
+			}
+		}
+
+		// Type declarations are too long for the detail.
+		detail := typeString(ident.ValueType)
+		if ident.Qualifier == ast.QualifierType {
+			detail = ""
+		}
+
+		symbols = append(symbols, DocumentSymbol{
+			Name:           name,
+			Detail:         detail,
+			Kind:           kind,
+			Range:          rng,
+			SelectionRange: selection,
+		})
+	}
+
+	return symbols
+}
+
+func declarationKind(ident *ast.Identifier) SymbolKind {
+	switch {
+	case ident.ValueType != nil && ident.ValueType.Kind() == types.ProcedureKind:
+		return SymbolKindFunction
+	case ident.Qualifier == ast.QualifierImmutable, ident.Qualifier == ast.QualifierCompileTime:
+		return SymbolKindConstant
+	default:
+		return SymbolKindVariable
+	}
+}
+
+func typeKind(typ types.Type) SymbolKind {
+	if typ == nil {
+		return SymbolKindClass
+	}
+
+	switch typ.Kind() {
+	case types.StructKind:
+		return SymbolKindStruct
+	case types.EnumKind, types.ErrorKind:
+		return SymbolKindEnum
+	default:
+		return SymbolKindClass
+	}
+}
@@ -0,0 +1,248 @@
+// Package mutability checks that immutable variables are not changed, also not
+// through the slices, maps, sets and references they contain, and wraps the
+// values that must be copied to keep them that way in @copy.
+//
+// A value of a shared type is copied when it is given to a variable of other
+// mutability than the variable it comes from: an immutable value given to a
+// var, or a var value given to an immutable variable, as either could then
+// change the value the other sees. Values passed to Go are copied as if given
+// to a var, unless Go takes them as strings, and arguments of async calls as
+// if given to an immutable variable, as the caller keeps running.
+package mutability
+
+import (
+	"errors"
+	"fmt"
+	gotypes "go/types"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// Check reports the changes to immutable variables in files, which make up one
+// package, and wraps the expressions that must be copied in @copy.
+// Copies are not wrapped again, so files can be checked more than once.
+func Check(files []*ast.File) error {
+	c := &checker{}
+
+	for _, file := range files {
+		c.file = file
+		ast.Inspect(file, c.visit)
+	}
+
+	return errors.Join(c.errs...)
+}
+
+type checker struct {
+	file *ast.File
+	errs []error
+}
+
+func (c *checker) visit(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.Assignment:
+		if n.Identifier.Name != "_" && n.Expression != nil {
+			c.own(&n.Expression, isVar(n.Identifier))
+		}
+	case *ast.Async:
+		c.ownAll(n.Call.Arguments, false)
+	case *ast.GoCallExpression:
+		for i := range n.Arguments {
+			// A value passed to Go as a string is copied by the conversion.
+			if passedAsString(n.Signature, i) {
+				continue
+			}
+
+			c.own(&n.Arguments[i], true)
+		}
+	case *ast.Builtin:
+		if n.Name == "delete" {
+			if root, ok := base(n.Arguments[0]).(*ast.Identifier); ok && !isVar(root) {
+				c.report(n, fmt.Sprintf("cannot delete from immutable variable %q", root.Name))
+			}
+		}
+	case *ast.Call:
+		sel, ok := n.Expression.(*ast.Selector)
+		if !ok || !changesReceiver(sel) {
+			break
+		}
+
+		if root, ok := base(sel.Expression).(*ast.Identifier); ok && !isVar(root) {
+			c.report(sel, fmt.Sprintf("method %q changes its receiver, so it cannot be called on immutable variable %q", sel.Field.Name, root.Name))
+		}
+	}
+
+	return true
+}
+
+// own wraps the expression in slot in @copy if it may share memory with a
+// variable of other mutability than the variable it is given to, of which
+// mutable tells if it is var. The values of literals are owned one by one.
+func (c *checker) own(slot *ast.Expression, mutable bool) {
+	expr := *slot
+	if !types.IsShared(expr.Type()) {
+		return
+	}
+
+	switch e := base(expr).(type) {
+	case *ast.Identifier:
+		if e.Qualifier != ast.QualifierType && isVar(e) != mutable {
+			*slot = copyOf(expr)
+		}
+	case *ast.Call:
+		// A call may return a global or one of its arguments. Globals are
+		// immutable, so the result of a call is only shared with a var if it
+		// is passed one.
+		if mutable || passesVar(e) {
+			*slot = copyOf(expr)
+		}
+	case *ast.Builtin:
+		if e.Name == "if" {
+			c.ownAll(e.Arguments[1:], mutable)
+		}
+	case *ast.ArrayLiteral:
+		c.ownAll(e.Values, mutable)
+	case *ast.SliceLiteral:
+		c.ownAll(e.Values, mutable)
+	case *ast.SetLiteral:
+		c.ownAll(e.Values, mutable)
+	case *ast.TupleLiteral:
+		c.ownAll(e.Values, mutable)
+	case *ast.MapLiteral:
+		for _, pair := range e.Pairs {
+			c.own(&pair.Key, mutable)
+			c.own(&pair.Value, mutable)
+		}
+	case *ast.StructLiteral:
+		for _, field := range e.Values {
+			c.own(&field.Value, mutable)
+		}
+	case *ast.EitherLiteral:
+		c.own(&e.Value, mutable)
+	case *ast.UnionLiteral:
+		c.own(&e.Value, mutable)
+	case *ast.ResultLiteral:
+		c.own(&e.Value, mutable)
+	}
+}
+
+func (c *checker) ownAll(exprs []ast.Expression, mutable bool) {
+	for i := range exprs {
+		c.own(&exprs[i], mutable)
+	}
+}
+
+func (c *checker) report(node ast.Node, msg string) {
+	c.errs = append(c.errs, diag.At(c.file, node, diag.CodeImmutable, msg))
+}
+
+// base returns the expression that the fields and elements selected by expr
+// are part of.
+func base(expr ast.Expression) ast.Expression {
+	for {
+		switch e := expr.(type) {
+		case *ast.Selector:
+			if e.Field.Qualifier == ast.QualifierMethod {
+				return expr
+			}
+
+			expr = e.Expression
+		case *ast.Index:
+			expr = e.Identifier
+		default:
+			return expr
+		}
+	}
+}
+
+// passesVar reports whether call is passed a value of a shared type that is
+// part of a var, as an argument or as the receiver of a method.
+func passesVar(call *ast.Call) bool {
+	args := call.Arguments
+
+	if sel, ok := call.Expression.(*ast.Selector); ok && sel.Field.Qualifier == ast.QualifierMethod {
+		args = append([]ast.Expression{sel.Expression}, args...)
+	}
+
+	for _, arg := range args {
+		if root, ok := base(arg).(*ast.Identifier); ok && isVar(root) && types.IsShared(arg.Type()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// passedAsString reports whether argument i of a call to a Go function of
+// signature sig is passed as a string.
+func passedAsString(sig *gotypes.Signature, i int) bool {
+	if sig == nil || sig.Params().Len() == 0 {
+		return false
+	}
+
+	params := sig.Params()
+	param := params.At(min(i, params.Len()-1)).Type()
+
+	if sig.Variadic() && i >= params.Len()-1 {
+		param = param.(*gotypes.Slice).Elem()
+	}
+
+	basic, ok := param.Underlying().(*gotypes.Basic)
+
+	return ok && basic.Info()&gotypes.IsString != 0
+}
+
+// changesReceiver reports whether sel selects a method declared with a var
+// reference receiver.
+func changesReceiver(sel *ast.Selector) bool {
+	if sel.Field.Qualifier != ast.QualifierMethod {
+		return false
+	}
+
+	typ := sel.Expression.Type()
+	if ref, ok := typ.(*types.Reference); ok {
+		typ = ref.Value
+	}
+
+	s, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+
+	method := s.Method(sel.Field.Name)
+
+	return method != nil && method.Mutable
+}
+
+func isVar(ident *ast.Identifier) bool {
+	return ident.Qualifier == ast.QualifierVariable
+}
+
+// copyOf returns @copy(expr), as if it was written in the source.
+func copyOf(expr ast.Expression) *ast.Builtin {
+	ln, col := expr.Pos()
+
+	return &ast.Builtin{
+		Token:      tokens.Token{Type: tokens.Builtin, Literal: "copy", Ln: ln, Col: col},
+		Name:       "copy",
+		Arguments:  []ast.Expression{expr},
+		ReturnType: valueType(expr),
+	}
+}
+
+// valueType returns the type of expr, or the value type if expr is an option
+// or result identifier, which the transpiler unwraps to its value.
+func valueType(expr ast.Expression) types.Type {
+	if ident, ok := expr.(*ast.Identifier); ok {
+		switch v := ident.ValueType.(type) {
+		case *types.Option:
+			return v.Value
+		case *types.Result:
+			return v.Value
+		}
+	}
+
+	return expr.Type()
+}
@@ -0,0 +1,227 @@
+package mutability_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/mutability"
+	"github.com/samborkent/cog/internal/parsetest"
+)
+
+// copies returns the expressions of file that are copied.
+func copies(file *ast.File) []string {
+	var got []string
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if b, ok := node.(*ast.Builtin); ok && b.Name == "copy" {
+			got = append(got, b.Arguments[0].String())
+		}
+
+		return true
+	})
+
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		src  string
+		want []string
+	}{
+		"basic": {
+			src: `main : proc() = {
+	x : int64 = 1
+	var y := x
+	@print(y)
+}`,
+		},
+		"same_mutability": {
+			src: `main : proc() = {
+	xs : []int64 = {1, 2}
+	ys := xs
+	var zs : []int64 = {3}
+	var ws := zs
+	@print(ys, ws)
+}`,
+		},
+		"immutable_to_var": {
+			src: `main : proc() = {
+	xs : []int64 = {1, 2}
+	var ys := xs
+	@print(xs, ys)
+}`,
+			want: []string{"xs"},
+		},
+		"var_to_immutable": {
+			src: `main : proc() = {
+	var xs : []int64 = {1, 2}
+	ys := xs
+	@print(xs, ys)
+}`,
+			want: []string{"xs"},
+		},
+		"literal": {
+			src: `Bag ~ struct {
+	items : []int64
+}
+
+main : proc() = {
+	var xs : []int64 = {1, 2}
+	bag : Bag = Bag{items = xs}
+	@print(bag)
+}`,
+			want: []string{"xs"},
+		},
+		"call": {
+			src: `table : []int64 = {1, 2}
+
+get : func() []int64 = {
+	return table
+}
+
+main : proc() = {
+	xs := get()
+	var ys := get()
+	@print(xs, ys)
+}`,
+			want: []string{"get()"},
+		},
+		"field_assignment": {
+			src: `Bag ~ struct {
+	items : []int64
+}
+
+(var b : &Bag).Set : proc(xs : []int64) = {
+	b.items = xs
+}`,
+			want: []string{"xs"},
+		},
+		"go_call": {
+			src: `goimport (
+	"sort"
+)
+
+main : proc() = {
+	var xs : []float64 = {2.0, 1.0}
+	ys : []float64 = {2.0, 1.0}
+	@go.sort.Float64s(xs)
+	@go.sort.Float64s(ys)
+}`,
+			want: []string{"ys"},
+		},
+		"go_call_ascii": {
+			src: `goimport (
+	"crypto/rand"
+)
+
+main : proc() = {
+	a : ascii = "aaaa"
+	@go.rand.Read(a)
+	@print(a)
+}`,
+			want: []string{"a"},
+		},
+		"go_call_ascii_string": {
+			src: `goimport (
+	"strings"
+)
+
+main : proc() = {
+	a : ascii = "aaaa"
+	@print(@go.strings.ToUpper(a))
+}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			file := parsetest.Parse(t, tt.src)
+
+			if err := mutability.Check([]*ast.File{file}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := copies(file); !slices.Equal(got, tt.want) {
+				t.Errorf("got copies %q, want %q", got, tt.want)
+			}
+
+			// Copies are not copied again.
+			if err := mutability.Check([]*ast.File{file}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := copies(file); !slices.Equal(got, tt.want) {
+				t.Errorf("got copies %q after second check, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckShouldError(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		src  string
+		want string
+	}{
+		"delete": {
+			src: `main : proc() = {
+	ages : map<utf8, int64> = {"ada": 36}
+	@delete(ages, "ada")
+}`,
+			want: `main.cog:5:2: cannot delete from immutable variable "ages"`,
+		},
+		"delete_field": {
+			src: `Bag ~ struct {
+	seen : set<int64>
+}
+
+main : proc() = {
+	bag : Bag = Bag{seen = {1}}
+	@delete(bag.seen, 1)
+}`,
+			want: `main.cog:9:2: cannot delete from immutable variable "bag"`,
+		},
+		"mutable_method": {
+			src: `Counter ~ struct {
+	count : int64
+}
+
+(var c : &Counter).Inc : proc() = {
+	c.count = c.count + 1
+}
+
+main : proc() = {
+	c : Counter = Counter{count = 0}
+	c.Inc()
+}`,
+			want: `main.cog:13:3: method "Inc" changes its receiver, so it cannot be called on immutable variable "c"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := mutability.Check([]*ast.File{parsetest.Parse(t, tt.src)})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			diags := diag.All(err)
+			if len(diags) == 0 {
+				t.Fatalf("got no diagnostics: %v", err)
+			}
+
+			if diags[0].Code != diag.CodeImmutable {
+				t.Errorf("got code %s, want %s", diags[0].Code, diag.CodeImmutable)
+			}
+
+			if got := diags[0].Error(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,12 +11,14 @@ import (
 func (p *Parser) parseAssignment(ctx context.Context, ident *ast.Identifier) *ast.Assignment {
 	symbol, ok := p.symbols.Resolve(ident.Name)
 	if !ok {
-		p.error(p.prev(), "unknown identifier", "parseAssignment")
+		p.undefined(ident.Token, "parseAssignment")
 		return nil
 	}
 
+	p.symbols.addReference(ident.Token, symbol)
+
 	switch symbol.Identifier.Qualifier {
-	case ast.QualifierImmutable:
+	case ast.QualifierImmutable, ast.QualifierCompileTime:
 		p.error(p.prev(), "cannot reassign a constant", "parseAssignment")
 
 		// Skip until next line.
@@ -72,3 +74,34 @@ func (p *Parser) parseAssignment(ctx context.Context, ident *ast.Identifier) *as
 
 	return node
 }
+
+// parseFieldAssignment parses the assignment to the field selected by selector
+// of the variable ident, which is named by the selector.
+func (p *Parser) parseFieldAssignment(ctx context.Context, ident *ast.Identifier, selector *ast.Selector) *ast.Assignment {
+	node := &ast.Assignment{
+		Token: p.this(),
+		Identifier: &ast.Identifier{
+			Token:     ident.Token,
+			Name:      selector.String(),
+			ValueType: selector.Type(),
+			Qualifier: ast.QualifierVariable,
+		},
+		Field: selector,
+	}
+
+	p.advance("parseFieldAssignment") // consume '='
+
+	expr := p.expression(ctx, selector.Type())
+	if expr == nil {
+		return nil
+	}
+
+	if !types.Equal(selector.Type(), expr.Type()) && !types.AssignableTo(expr.Type(), selector.Type()) {
+		p.error(node.Token, fmt.Sprintf("type mismatch: cannot assign %q to field of type %q", expr.Type(), selector.Type()), "parseFieldAssignment")
+		return nil
+	}
+
+	node.Expression = expr
+
+	return node
+}
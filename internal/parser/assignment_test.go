@@ -35,6 +35,28 @@ main : proc() = {
 }`)
 	})
 
+	t.Run("field", func(t *testing.T) {
+		t.Parallel()
+		_ = parse(t, `package p
+Counter ~ struct {
+	count : int64
+}
+(var c : &Counter).Reset : proc() = {
+	c.count = 0
+}`)
+	})
+
+	t.Run("field_type_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+Counter ~ struct {
+	count : int64
+}
+(var c : &Counter).Reset : proc() = {
+	c.count = "zero"
+}`)
+	})
+
 	t.Run("result_reassignment_clears_checked_state", func(t *testing.T) {
 		t.Parallel()
 		parseShouldError(t, `package p
@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// parseAsync parses an async procedure call: async proc(...). Its value is a
+// signal handle, which @await resolves to the return value of the procedure.
+func (p *Parser) parseAsync(ctx context.Context) *ast.Async {
+	token := p.this()
+	p.advance("parseAsync async") // consume async
+
+	if !p.scriptMode && p.symbols.Outer == nil {
+		p.error(token, "async calls are only allowed in procedure bodies", "parseAsync")
+		return nil
+	}
+
+	if p.inFunction {
+		p.error(token, "func cannot make async calls, because it cannot have side-effects", "parseAsync")
+		return nil
+	}
+
+	callToken := p.this()
+
+	if callToken.Type != tokens.Identifier {
+		p.error(callToken, "expected procedure call after async", "parseAsync")
+		return nil
+	}
+
+	expr := p.primary(ctx, types.None)
+	if expr == nil {
+		return nil
+	}
+
+	call, ok := expr.(*ast.Call)
+	if !ok {
+		p.error(callToken, "expected procedure call after async", "parseAsync")
+		return nil
+	}
+
+	procType, ok := call.Expression.Type().(*types.Procedure)
+	if !ok {
+		p.error(callToken, fmt.Sprintf("cannot call %q async: not a procedure", call.Expression), "parseAsync")
+		return nil
+	}
+
+	if procType.Function {
+		p.error(callToken, fmt.Sprintf("func %q cannot be called async", call.Expression), "parseAsync")
+		return nil
+	}
+
+	signalType := &types.Signal{Value: call.ReturnType}
+	if types.IsNone(signalType.Value) {
+		signalType.Value = nil
+	}
+
+	return &ast.Async{
+		Token:      token,
+		Call:       call,
+		SignalType: signalType,
+	}
+}
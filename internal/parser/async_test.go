@@ -0,0 +1,136 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+)
+
+func TestParseAsync(t *testing.T) {
+	t.Parallel()
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+square : proc(x : int64) int64 = {
+	return x * x
+}
+main : proc() = {
+	s := async square(2)
+	@print(@await(s))
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		decl, ok := body.Statements[0].(*ast.Declaration)
+		if !ok {
+			t.Fatalf("expected declaration, got %T", body.Statements[0])
+		}
+
+		async, ok := decl.Assignment.Expression.(*ast.Async)
+		if !ok {
+			t.Fatalf("expected async expression, got %T", decl.Assignment.Expression)
+		}
+
+		if got := async.Type().String(); got != "signal<int64>" {
+			t.Errorf("async type = %q, want %q", got, "signal<int64>")
+		}
+	})
+
+	t.Run("statement", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+work : proc() = {
+	@print("work")
+}
+main : proc() = {
+	async work()
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		stmt, ok := body.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("expected expression statement, got %T", body.Statements[0])
+		}
+
+		if got := stmt.Expression.Type().String(); got != "signal" {
+			t.Errorf("async type = %q, want %q", got, "signal")
+		}
+	})
+
+	t.Run("signal_type", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+square : proc(x : int64) int64 = {
+	return x * x
+}
+wait : proc(s : signal<int64>) int64 = {
+	return @await(s)
+}
+main : proc() = {
+	s : signal<int64> = async square(2)
+	@print(wait(s))
+}`)
+	})
+
+	t.Run("func_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+square : func(x : int64) int64 = {
+	return x * x
+}
+main : proc() = {
+	s := async square(2)
+}`)
+	})
+
+	t.Run("in_func_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+work : proc() = {}
+pure : func() int64 = {
+	async work()
+	return 1
+}
+main : proc() = {}`)
+	})
+
+	t.Run("global_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+square : proc(x : int64) int64 = {
+	return x * x
+}
+s := async square(2)
+main : proc() = {}`)
+	})
+
+	t.Run("not_a_call_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	x := 1
+	s := async x
+}`)
+	})
+
+	t.Run("await_non_signal_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	x := 1
+	@print(@await(x))
+}`)
+	})
+}
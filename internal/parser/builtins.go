@@ -3,6 +3,8 @@ package parser
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/tokens"
@@ -11,6 +13,213 @@ import (
 
 type BuiltinParser func(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin
 
+func (p *Parser) builtinParsers() map[string]BuiltinParser {
+	return map[string]BuiltinParser{
+		"await":  p.parseBuiltinAwait,
+		"cast":   p.parseBuiltinCast,
+		"copy":   p.parseBuiltinCopy,
+		"delete": p.parseBuiltinDelete,
+		"format": p.parseBuiltinFormat,
+		"if":     p.parseBuiltinIf,
+		"len":    p.parseBuiltinLen,
+		"map":    p.parseBuiltinMap,
+		"print":  p.parseBuiltinPrint,
+		"ref":    p.parseBuiltinRef,
+		"set":    p.parseBuiltinSet,
+		"slice":  p.parseBuiltinSlice,
+	}
+}
+
+// BuiltinNames returns the sorted names of the builtins, without the @ prefix.
+func BuiltinNames() []string {
+	return slices.Sorted(maps.Keys((&Parser{}).builtinParsers()))
+}
+
+func (p *Parser) parseBuiltinAwait(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after @await", "parseBuiltinAwait")
+		return nil
+	}
+
+	p.advance("parseBuiltinAwait (") // consume (
+
+	if p.this().Type == tokens.RParen {
+		p.error(p.this(), "expected signal argument in @await", "parseBuiltinAwait")
+		return nil
+	}
+
+	argToken := p.this()
+
+	arg := p.expression(ctx, types.None)
+	if arg == nil {
+		return nil
+	}
+
+	signalType, ok := arg.Type().Underlying().(*types.Signal)
+	if !ok {
+		p.error(argToken, fmt.Sprintf("@await expects a signal, got %q", arg.Type()), "parseBuiltinAwait")
+		return nil
+	}
+
+	var returnType types.Type = types.None
+
+	if signalType.Value != nil {
+		returnType = signalType.Value
+
+		if tokenType.Kind() != types.Invalid && !types.Equal(returnType, tokenType) {
+			p.error(argToken, fmt.Sprintf("@await returns %q, expected %q", returnType, tokenType), "parseBuiltinAwait")
+			return nil
+		}
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after argument in @await", "parseBuiltinAwait")
+		return nil
+	}
+
+	p.advance("parseBuiltinAwait )") // consume ')'
+
+	return &ast.Builtin{
+		Token:      t,
+		Name:       "await",
+		Arguments:  []ast.Expression{arg},
+		ReturnType: returnType,
+	}
+}
+
+// parseBuiltinCopy parses @copy(x), which returns a deep copy of x that shares
+// no memory with it.
+func (p *Parser) parseBuiltinCopy(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after @copy", "parseBuiltinCopy")
+		return nil
+	}
+
+	p.advance("parseBuiltinCopy (") // consume (
+
+	if p.this().Type == tokens.RParen {
+		p.error(p.this(), "expected argument in @copy", "parseBuiltinCopy")
+		return nil
+	}
+
+	argToken := p.this()
+
+	arg := p.expression(ctx, tokenType)
+	if arg == nil {
+		return nil
+	}
+
+	if tokenType.Kind() != types.Invalid && !types.Equal(arg.Type(), tokenType) && !types.AssignableTo(arg.Type(), tokenType) {
+		p.error(argToken, fmt.Sprintf("@copy returns %q, expected %q", arg.Type(), tokenType), "parseBuiltinCopy")
+		return nil
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after argument in @copy", "parseBuiltinCopy")
+		return nil
+	}
+
+	p.advance("parseBuiltinCopy )") // consume ')'
+
+	return &ast.Builtin{
+		Token:      t,
+		Name:       "copy",
+		Arguments:  []ast.Expression{arg},
+		ReturnType: arg.Type(),
+	}
+}
+
+// parseBuiltinDelete parses @delete(m, key), which removes key from map or set
+// m.
+func (p *Parser) parseBuiltinDelete(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
+	if p.inFunction {
+		p.error(t, "func cannot call @delete, because it cannot have side-effects", "parseBuiltinDelete")
+		return nil
+	}
+
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after @delete", "parseBuiltinDelete")
+		return nil
+	}
+
+	p.advance("parseBuiltinDelete (") // consume (
+
+	containerToken := p.this()
+
+	container := p.expression(ctx, types.None)
+	if container == nil {
+		return nil
+	}
+
+	var keyType types.Type
+
+	switch c := container.Type().Underlying().(type) {
+	case *types.Map:
+		keyType = c.Key
+	case *types.Set:
+		keyType = c.Element
+	default:
+		p.error(containerToken, fmt.Sprintf("@delete expects a map or set, got %q", container.Type()), "parseBuiltinDelete")
+		return nil
+	}
+
+	if p.this().Type != tokens.Comma {
+		p.error(p.this(), "expected ',' after map in @delete", "parseBuiltinDelete")
+		return nil
+	}
+
+	p.advance("parseBuiltinDelete ,") // consume ,
+
+	keyToken := p.this()
+
+	key := p.expression(ctx, keyType)
+	if key == nil {
+		return nil
+	}
+
+	if !types.Equal(key.Type(), keyType) && !types.AssignableTo(key.Type(), keyType) {
+		p.error(keyToken, fmt.Sprintf("@delete key of type %q, expected %q", key.Type(), keyType), "parseBuiltinDelete")
+		return nil
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after arguments in @delete", "parseBuiltinDelete")
+		return nil
+	}
+
+	p.advance("parseBuiltinDelete )") // consume ')'
+
+	return &ast.Builtin{
+		Token:      t,
+		Name:       "delete",
+		Arguments:  []ast.Expression{container, key},
+		ReturnType: types.None,
+	}
+}
+
+// parseBuiltinFormat parses @format(args...), which formats its arguments
+// like @print, and returns the result as utf8.
+func (p *Parser) parseBuiltinFormat(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
+	returnType := types.Basics[types.UTF8]
+
+	if tokenType.Kind() != types.Invalid && !types.Equal(returnType, tokenType) {
+		p.error(t, fmt.Sprintf("@format returns %q, expected %q", returnType, tokenType), "parseBuiltinFormat")
+		return nil
+	}
+
+	args := p.parseFormatArguments(ctx, "format")
+	if args == nil {
+		return nil
+	}
+
+	return &ast.Builtin{
+		Token:      t,
+		Name:       "format",
+		ReturnType: returnType,
+		Arguments:  args,
+	}
+}
+
 func (p *Parser) parseBuiltinIf(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
 	var typArgs []types.Type
 
@@ -98,6 +307,52 @@ func (p *Parser) parseBuiltinIf(ctx context.Context, t tokens.Token, tokenType t
 	}
 }
 
+// parseBuiltinLen parses @len(x), the number of elements of a string, array,
+// slice, map or set.
+func (p *Parser) parseBuiltinLen(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
+	returnType := types.Basics[types.Uint64]
+
+	if tokenType.Kind() != types.Invalid && !types.Equal(returnType, tokenType) {
+		p.error(t, fmt.Sprintf("@len returns %q, expected %q", returnType, tokenType), "parseBuiltinLen")
+		return nil
+	}
+
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after @len", "parseBuiltinLen")
+		return nil
+	}
+
+	p.advance("parseBuiltinLen (") // consume (
+
+	argToken := p.this()
+
+	arg := p.expression(ctx, types.None)
+	if arg == nil {
+		return nil
+	}
+
+	switch kind := arg.Type().Kind(); {
+	case types.IsString(arg.Type()), kind == types.ArrayKind, kind == types.SliceKind, kind == types.MapKind, kind == types.SetKind:
+	default:
+		p.error(argToken, fmt.Sprintf("@len expects a string, array, slice, map or set, got %q", arg.Type()), "parseBuiltinLen")
+		return nil
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after argument in @len", "parseBuiltinLen")
+		return nil
+	}
+
+	p.advance("parseBuiltinLen )") // consume ')'
+
+	return &ast.Builtin{
+		Token:      t,
+		Name:       "len",
+		Arguments:  []ast.Expression{arg},
+		ReturnType: returnType,
+	}
+}
+
 func (p *Parser) parseBuiltinMap(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
 	if tokenType.Kind() != types.Invalid && tokenType.Kind() != types.MapKind {
 		// If type is supplied, check if it's a map.
@@ -174,37 +429,16 @@ func (p *Parser) parseBuiltinMap(ctx context.Context, t tokens.Token, tokenType
 }
 
 func (p *Parser) parseBuiltinPrint(ctx context.Context, t tokens.Token, tokenType types.Type) *ast.Builtin {
-	if p.this().Type != tokens.LParen {
-		p.error(p.this(), "expected '(' after @print", "parsePrint")
-		return nil
-	}
-
-	p.advance("parsePrint (") // consume (
-
-	if p.this().Type == tokens.RParen {
-		p.error(p.this(), "expected argument in @print", "parsePrint")
-		return nil
-	}
-
-	arg := p.expression(ctx, tokenType)
-	if arg == nil {
-		return nil
-	}
-
-	// TODO: implement string formatting
-
-	if p.this().Type != tokens.RParen {
-		p.error(p.this(), "expected ')' after argument in @print", "parsePrint")
+	args := p.parseFormatArguments(ctx, "print")
+	if args == nil {
 		return nil
 	}
 
-	p.advance("parsePrint )") // consume ')'
-
 	return &ast.Builtin{
 		Token:      t,
 		Name:       "print",
 		ReturnType: types.None,
-		Arguments:  []ast.Expression{arg},
+		Arguments:  args,
 	}
 }
 
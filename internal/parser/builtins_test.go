@@ -16,6 +16,98 @@ main : proc() = {
 			t.Fatal("expected statements")
 		}
 	})
+
+	t.Run("multiple_args", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	x := 1
+	@print("x", x, true)
+}`)
+	})
+
+	t.Run("format_string", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	x : int64 = 1
+	y := 2.5
+	@print("x = {x:>4}, y = {y:.2f}, {{literal}}")
+	@print("{} + {:x} = {x + 1:06d}", y, x)
+	s := "abc"
+	@print("len {@len(s)}")
+}`)
+	})
+
+	errors := []struct {
+		name string
+		body string
+	}{
+		{"no_args", `@print()`},
+		{"unclosed_brace", `@print("x = {x")`},
+		{"unmatched_brace", `@print("x = }")`},
+		{"undefined_identifier", `@print("{z}")`},
+		{"invalid_expression", `@print("{x +}")`},
+		{"missing_argument", `@print("{} {}", x)`},
+		{"extra_argument", `@print("{x}", x)`},
+		{"invalid_spec", `@print("{x:!}")`},
+		{"missing_precision", `@print("{y:.f}")`},
+		{"integer_verb_on_float", `@print("{y:x}")`},
+		{"float_verb_on_integer", `@print("{x:f}")`},
+		{"precision_on_integer", `@print("{x:.2}")`},
+		{"quote_on_integer", `@print("{x:q}")`},
+		{"zero_pad_on_string", `@print("{s:05}")`},
+	}
+
+	for _, tt := range errors {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parseShouldError(t, `package p
+main : proc() = {
+	x : int64 = 1
+	y := 2.5
+	s := "s"
+	`+tt.body+`
+}`)
+		})
+	}
+}
+
+func TestParseBuiltinFormat(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	x : uint8 = 255
+	s : utf8 = @format("{x:08b}")
+	@print(s)
+}`)
+	})
+
+	t.Run("in_func", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+hex : func(x : int64) utf8 = {
+	return @format("{x:x}")
+}
+main : proc() = {}`)
+	})
+
+	t.Run("not_assignable", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	x : int64 = @format("{}", 1)
+}`)
+	})
 }
 
 func TestParseBuiltinIf(t *testing.T) {
@@ -351,3 +443,91 @@ main : proc() = {
 }`)
 	})
 }
+
+func TestParseBuiltinLen(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	m : map<ascii, int64> = {"a": 1}
+	n : uint64 = @len(m)
+	@print(n + @len("hello"))
+}`)
+	})
+
+	t.Run("not_a_container_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	x := 1
+	@print(@len(x))
+}`)
+	})
+}
+
+func TestParseBuiltinDelete(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	var m : map<ascii, int64> = {"a": 1}
+	@delete(m, "a")
+	var s : set<int64> = {1, 2}
+	@delete(s, 1)
+}`)
+	})
+
+	t.Run("key_type_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	var s : set<int64> = {1, 2}
+	@delete(s, true)
+}`)
+	})
+
+	t.Run("in_func_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+clear : func(m : map<utf8, int64>) int64 = {
+	@delete(m, "a")
+	return 0
+}
+main : proc() = {}`)
+	})
+}
+
+func TestParseBuiltinCopy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	xs : []int64 = {1, 2}
+	var ys : []int64 = @copy(xs)
+	@print(ys)
+}`)
+	})
+
+	t.Run("type_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	xs : []int64 = {1, 2}
+	ys : []utf8 = @copy(xs)
+	@print(ys)
+}`)
+	})
+}
@@ -27,7 +27,7 @@ func (p *Parser) parseTypedDeclaration(ctx context.Context, ident *ast.Identifie
 func (p *Parser) parseDeclaration(ctx context.Context, ident *ast.Identifier) *ast.Declaration {
 	symbol, ok := p.symbols.Resolve(ident.Name)
 	if ok && symbol.Scope != ScanScope && ident.Qualifier != ast.QualifierMethod {
-		p.error(ident.Token, "cannot redeclare variable", "parseDeclaration")
+		p.redeclared(ident.Token, "variable", "parseDeclaration")
 		return nil
 	}
 
@@ -50,10 +50,18 @@ func (p *Parser) parseDeclaration(ctx context.Context, ident *ast.Identifier) *a
 		},
 	}
 
+	if ident.Qualifier == ast.QualifierCompileTime {
+		p.checkCompProcedure(ident)
+	}
+
 	if !p.match(tokens.Assign, tokens.Declaration) {
-		if ident.Qualifier == ast.QualifierImmutable {
+		switch ident.Qualifier {
+		case ast.QualifierImmutable:
 			p.error(p.this(), "immutable declarations must be initialized", "parseDeclaration")
 			return nil
+		case ast.QualifierCompileTime:
+			p.error(p.this(), "comp declarations must be initialized", "parseDeclaration")
+			return nil
 		}
 
 		// Uninitialized variable
@@ -95,6 +103,24 @@ func (p *Parser) parseDeclaration(ctx context.Context, ident *ast.Identifier) *a
 	return node
 }
 
+// checkCompProcedure checks that a comp declaration of a procedure can be
+// evaluated at compile time: only a func with a return value can.
+func (p *Parser) checkCompProcedure(ident *ast.Identifier) {
+	procType, ok := ident.ValueType.(*types.Procedure)
+	if !ok {
+		return
+	}
+
+	switch {
+	case !procType.Function:
+		p.error(ident.Token, "a proc cannot be comp, only a func can be evaluated at compile time", "checkCompProcedure")
+	case procType.ReturnType == nil:
+		p.error(ident.Token, "comp func must have a return type", "checkCompProcedure")
+	case len(procType.TypeParams) > 0:
+		p.error(ident.Token, "comp func cannot have type parameters", "checkCompProcedure")
+	}
+}
+
 // resultExprState checks whether an expression assigned to a result type
 // is a valid value or error variant and returns the corresponding check state.
 // Returns (state, true) if the expression matches a variant, or (0, false)
@@ -110,16 +136,26 @@ func resultExprState(resolved *types.Result, expr ast.Expression) (checkState, b
 		return checkValue, true
 	}
 
+	// Other errors, like structs with an Error method, are the error variant
+	// if the error type of the result accepts them.
+	if types.IsError(exprType) && types.ErrorAssignableTo(exprType, resolved.Error) {
+		return checkError, true
+	}
+
 	return 0, false
 }
 
 // wrapResultLiteral wraps an expression in a ResultLiteral for assignment
 // to a result-typed variable. It determines whether the expression is the
-// error or value variant based on the expression type's kind.
-// Returns nil if the expression type doesn't match either variant.
+// error or value variant like resultExprState.
 func wrapResultLiteral(tok tokens.Token, resultType types.Type, expr ast.Expression) *ast.ResultLiteral {
 	isError := expr.Type().Kind() == types.ErrorKind
 
+	if resolved, ok := resultType.Underlying().(*types.Result); ok {
+		state, _ := resultExprState(resolved, expr)
+		isError = state == checkError
+	}
+
 	return &ast.ResultLiteral{
 		Token:      tok,
 		ResultType: resultType,
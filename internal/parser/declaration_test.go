@@ -138,3 +138,137 @@ main : proc() = {}`)
 		}
 	})
 }
+
+func TestParseCompDeclaration(t *testing.T) {
+	t.Parallel()
+
+	t.Run("value", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+comp size : uint64 = 4 * 2
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 0)
+		if d.Assignment.Identifier.Qualifier != ast.QualifierCompileTime {
+			t.Errorf("expected QualifierCompileTime, got %d", d.Assignment.Identifier.Qualifier)
+		}
+
+		if d.Assignment.Identifier.ValueType.Kind() != types.Uint64 {
+			t.Errorf("expected Uint64, got %s", d.Assignment.Identifier.ValueType.Kind())
+		}
+	})
+
+	t.Run("func", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+comp square : func(x : int64) int64 = {
+	return x * x
+}
+main : proc() = {
+	@print(square(3))
+}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 0)
+		if d.Assignment.Identifier.Qualifier != ast.QualifierCompileTime {
+			t.Errorf("expected QualifierCompileTime, got %d", d.Assignment.Identifier.Qualifier)
+		}
+	})
+
+	t.Run("local", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+main : proc() = {
+	comp n := 3
+	@print(n)
+}`)
+		d := stmtAs[*ast.Declaration](t, f, 0)
+
+		proc, ok := d.Assignment.Expression.(*ast.ProcedureLiteral)
+		if !ok {
+			t.Fatalf("expected ProcedureLiteral, got %T", d.Assignment.Expression)
+		}
+
+		compDecl, ok := proc.Body.Statements[0].(*ast.Declaration)
+		if !ok {
+			t.Fatalf("expected Declaration, got %T", proc.Body.Statements[0])
+		}
+
+		if compDecl.Assignment.Identifier.Qualifier != ast.QualifierCompileTime {
+			t.Errorf("expected QualifierCompileTime, got %d", compDecl.Assignment.Identifier.Qualifier)
+		}
+	})
+
+	t.Run("func_array_length", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+comp square : func(x : uint64) uint64 = {
+	return x * x
+}
+grid : [square(3)]int64 = {}
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 1)
+
+		array, ok := d.Assignment.Identifier.ValueType.(*types.Array)
+		if !ok {
+			t.Fatalf("expected Array, got %T", d.Assignment.Identifier.ValueType)
+		}
+
+		if _, ok := array.Length.(*ast.Call); !ok {
+			t.Errorf("expected call as array length, got %T", array.Length)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+export comp limit : int32 = 10
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 0)
+		if !d.Assignment.Identifier.Exported || d.Assignment.Identifier.Qualifier != ast.QualifierCompileTime {
+			t.Errorf("expected exported comp declaration, got exported %t, qualifier %d", d.Assignment.Identifier.Exported, d.Assignment.Identifier.Qualifier)
+		}
+	})
+
+	for name, src := range map[string]string{
+		"uninitialized": `package p
+comp n : int64
+main : proc() = {}`,
+		"reassign": `package p
+main : proc() = {
+	comp n := 1
+	n = 2
+}`,
+		"proc": `package p
+comp tick : proc() int64 = {
+	return 1
+}
+main : proc() = {}`,
+		"no_return_type": `package p
+comp nothing : func(x : int64) = {
+	@print(x)
+}
+main : proc() = {}`,
+		"type_parameters": `package p
+comp id : func<T ~ any>(x : T) T = {
+	return x
+}
+main : proc() = {}`,
+		"func_array_length_not_comp": `package p
+square : func(x : uint64) uint64 = {
+	return x * x
+}
+grid : [square(3)]int64 = {}
+main : proc() = {}`,
+		"not_declaration": `package p
+main : proc() = {
+	comp @print(1)
+}`,
+	} {
+		t.Run(name+"_error", func(t *testing.T) {
+			t.Parallel()
+			parseShouldError(t, src)
+		})
+	}
+}
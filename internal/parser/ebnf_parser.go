@@ -8,20 +8,150 @@ import (
 	f16 "github.com/x448/float16"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/comptime"
 	"github.com/samborkent/cog/internal/tokens"
 	"github.com/samborkent/cog/internal/types"
 )
 
 func (p *Parser) expression(ctx context.Context, typeToken types.Type) ast.Expression {
+	var iterType *types.Iterator
+	if typeToken != nil {
+		iterType, _ = typeToken.Underlying().(*types.Iterator)
+	}
+
+	if iterType != nil && p.this().Type != tokens.LBrace {
+		// The operands of a range are integers, an iterator value needs no
+		// expected type. Braces are an iterator literal.
+		typeToken = types.None
+	}
+
+	start := p.this()
+
+	expr := p.index(ctx, typeToken)
+
+	// Constant expressions are folded, so their result is range-checked like a
+	// literal.
+	if err := comptime.Constant(expr); err != nil {
+		p.error(start, err.Error(), "expression")
+		return nil
+	}
+
+	if expr != nil && p.match(tokens.Range, tokens.RangeInclusive) {
+		return p.rangeExpression(ctx, expr, iterType)
+	}
+
+	return expr
+}
+
+// rangeExpression parses the rest of the range expression starting with start:
+// ..end or ..=end, followed by an optional step: 0..10 by 2.
+func (p *Parser) rangeExpression(ctx context.Context, start ast.Expression, expected *types.Iterator) ast.Expression {
+	node := &ast.Range{
+		Operator: p.this(),
+		Start:    start,
+	}
+
+	p.advance("rangeExpression operator") // consume .. or ..=
+
+	node.End = p.index(ctx, types.None)
+	if node.End == nil {
+		return nil
+	}
+
+	// by is not a keyword, but no expression is followed by an identifier.
+	if p.this().Type == tokens.Identifier && p.this().Literal == "by" {
+		p.advance("rangeExpression by") // consume by
+
+		node.Step = p.index(ctx, types.None)
+		if node.Step == nil {
+			return nil
+		}
+	}
+
+	// The integer type comes from the expected type, or else from the first
+	// operand that is not an untyped literal.
+	var elemType types.Type
+
+	switch {
+	case expected != nil:
+		elemType = expected.Value
+	case !isUntypedInt(node.Start):
+		elemType = node.Start.Type()
+	case !isUntypedInt(node.End):
+		elemType = node.End.Type()
+	default:
+		elemType = node.Start.Type()
+	}
+
+	if !types.IsFixed(elemType) || elemType.Kind() == types.Int128 || elemType.Kind() == types.Uint128 {
+		p.error(node.Operator, fmt.Sprintf("range requires an integer type of at most 64 bits, got %q", elemType), "rangeExpression")
+		return nil
+	}
+
+	node.Start = convertUntypedInt(node.Start, elemType)
+	node.End = convertUntypedInt(node.End, elemType)
+
+	operands := []ast.Expression{node.Start, node.End}
+
+	if node.Step != nil {
+		// A step that is only known at run time panics if it is zero.
+		if lit, ok := node.Step.(*ast.Int64Literal); ok && lit.Value == 0 {
+			p.error(node.Operator, "range step cannot be zero", "rangeExpression")
+			return nil
+		}
+
+		if lit, ok := node.Step.(*ast.Int64Literal); ok && lit.Value < 0 && !types.IsSigned(elemType) {
+			p.error(node.Operator, fmt.Sprintf("range step of %q cannot be negative, only signed ranges count down", elemType), "rangeExpression")
+			return nil
+		}
+
+		node.Step = convertUntypedInt(node.Step, elemType)
+		operands = append(operands, node.Step)
+	}
+
+	for _, operand := range operands {
+		if !types.Equal(operand.Type(), elemType) {
+			p.error(node.Operator, fmt.Sprintf("range operand %s has type %q, expected %q", operand, operand.Type(), elemType), "rangeExpression")
+			return nil
+		}
+	}
+
+	return node
+}
+
+// isUntypedInt reports whether expr is an integer literal without an expected
+// type, which defaults to int64.
+func isUntypedInt(expr ast.Expression) bool {
+	_, ok := expr.(*ast.Int64Literal)
+	return ok
+}
+
+// convertUntypedInt converts an untyped integer literal to typ, if it fits.
+func convertUntypedInt(expr ast.Expression, typ types.Type) ast.Expression {
+	if !isUntypedInt(expr) {
+		return expr
+	}
+
+	infix := &ast.Infix{
+		Left:  &ast.Identifier{ValueType: typ},
+		Right: expr,
+	}
+	infix.EqualizeLiteralTypes()
+
+	return infix.Right
+}
+
+// index parses an expression followed by any number of indexes: xs[i][j].
+func (p *Parser) index(ctx context.Context, typeToken types.Type) ast.Expression {
 	expr := p.boolean(ctx, typeToken)
 
 	for p.match(tokens.LBracket) {
-		if ctx.Err() != nil || expr == nil {
+		if ctx.Err() != nil || expr == nil || p.opaque(expr, p.this()) {
 			return nil
 		}
 
 		operator := p.this()
-		p.advance("expression index operator") // consume operator
+		p.advance("index operator") // consume operator
 		index := p.boolean(ctx, types.None)
 
 		expr = &ast.Index{
@@ -31,11 +161,11 @@ func (p *Parser) expression(ctx context.Context, typeToken types.Type) ast.Expre
 		}
 
 		if p.this().Type != tokens.RBracket {
-			p.error(p.this(), "expected ] after index expression", "expression")
+			p.error(p.this(), "expected ] after index expression", "index")
 			return nil
 		}
 
-		p.advance("expression ]") // consume ]
+		p.advance("index ]") // consume ]
 	}
 
 	if expr != nil {
@@ -53,6 +183,10 @@ func (p *Parser) boolean(ctx context.Context, typeToken types.Type) ast.Expressi
 			return nil
 		}
 
+		if p.opaque(expr, p.this()) {
+			return nil
+		}
+
 		if !types.IsBool(expr.Type()) {
 			p.error(p.this(), "operator requires bool type", "boolean")
 			return nil
@@ -60,8 +194,17 @@ func (p *Parser) boolean(ctx context.Context, typeToken types.Type) ast.Expressi
 
 		operator := p.this()
 		p.advance("boolean operator") // consume operator
+
+		prevNoPropagation := p.noPropagation
+		p.noPropagation = "the right operand of " + operator.Type.String()
+
 		right := p.equality(ctx, types.Basics[types.Bool])
 
+		p.noPropagation = prevNoPropagation
+		if p.opaque(right, operator) {
+			return nil
+		}
+
 		expr = &ast.Infix{
 			Operator: operator,
 			Left:     expr,
@@ -84,9 +227,16 @@ func (p *Parser) equality(ctx context.Context, typeToken types.Type) ast.Express
 			return nil
 		}
 
+		if p.opaque(expr, p.this()) {
+			return nil
+		}
+
 		operator := p.this()
 		p.advance("equality operator") // consume operator
 		right := p.comparison(ctx, types.None)
+		if right == nil || p.opaque(right, operator) {
+			return nil
+		}
 
 		infix := &ast.Infix{
 			Operator: operator,
@@ -116,6 +266,10 @@ func (p *Parser) comparison(ctx context.Context, typeToken types.Type) ast.Expre
 			return nil
 		}
 
+		if p.opaque(expr, p.this()) {
+			return nil
+		}
+
 		if !types.IsNumber(expr.Type()) {
 			p.error(p.this(), "operator requires numeric type", "comparison")
 			return nil
@@ -124,6 +278,9 @@ func (p *Parser) comparison(ctx context.Context, typeToken types.Type) ast.Expre
 		operator := p.this()
 		p.advance("comparison operator") // consume operator
 		right := p.term(ctx, types.None)
+		if right == nil || p.opaque(right, operator) {
+			return nil
+		}
 
 		infix := &ast.Infix{
 			Operator: operator,
@@ -148,30 +305,45 @@ func (p *Parser) comparison(ctx context.Context, typeToken types.Type) ast.Expre
 func (p *Parser) term(ctx context.Context, typeToken types.Type) ast.Expression {
 	expr := p.factor(ctx, typeToken)
 
-	for p.match(tokens.Minus, tokens.Plus) {
+	for p.match(tokens.Minus, tokens.Plus, tokens.BitOr, tokens.BitXor) {
 		if ctx.Err() != nil || expr == nil {
 			return nil
 		}
 
-		// TODO: this is a hack due to lack of known Go typing at compile time, figure out a better solution.
-		if expr.Type() != types.None {
-			if p.this().Type == tokens.Plus {
-				if !types.IsSummable(expr.Type()) {
-					p.error(p.this(), fmt.Sprintf("operator requires numeric or string type, got %q", expr.Type()), "term")
-					return nil
-				}
-			} else {
-				// Minus
-				if !types.IsNumber(expr.Type()) {
-					p.error(p.this(), fmt.Sprintf("operator requires numeric type, got %q", expr.Type()), "term")
-					return nil
-				}
+		if p.opaque(expr, p.this()) {
+			return nil
+		}
+
+		switch p.this().Type {
+		case tokens.Plus:
+			if !types.IsSummable(expr.Type()) {
+				p.error(p.this(), fmt.Sprintf("operator requires numeric or string type, got %q", expr.Type()), "term")
+				return nil
+			}
+		case tokens.Minus:
+			if !types.IsNumber(expr.Type()) {
+				p.error(p.this(), fmt.Sprintf("operator requires numeric type, got %q", expr.Type()), "term")
+				return nil
+			}
+		default:
+			// Bitwise or, xor
+			if !types.IsFixed(expr.Type()) {
+				p.error(p.this(), fmt.Sprintf("operator requires integer type, got %q", expr.Type()), "term")
+				return nil
 			}
 		}
 
 		operator := p.this()
 		p.advance("term operator") // consume operator
 		right := p.factor(ctx, expr.Type())
+		if right == nil || p.opaque(right, operator) {
+			return nil
+		}
+
+		if isBitwise(operator.Type) && !types.IsFixed(right.Type()) {
+			p.error(operator, fmt.Sprintf("operator requires integer type, got %q", right.Type()), "term")
+			return nil
+		}
 
 		expr = &ast.Infix{
 			Operator: operator,
@@ -190,19 +362,67 @@ func (p *Parser) term(ctx context.Context, typeToken types.Type) ast.Expression
 func (p *Parser) factor(ctx context.Context, typeToken types.Type) ast.Expression {
 	expr := p.unary(ctx, typeToken)
 
-	for p.match(tokens.Asterisk, tokens.Divide) {
+	for {
 		if ctx.Err() != nil || expr == nil {
 			return nil
 		}
 
-		if !types.IsNumber(expr.Type()) {
-			p.error(p.this(), "operator requires numeric type", "factor")
+		operator, ok := p.factorOperator()
+		if !ok {
+			break
+		}
+
+		if p.opaque(expr, operator) {
 			return nil
 		}
 
-		operator := p.this()
+		switch operator.Type {
+		case tokens.Asterisk, tokens.Divide:
+			if !types.IsNumber(expr.Type()) {
+				p.error(operator, "operator requires numeric type", "factor")
+				return nil
+			}
+		default:
+			// Remainder, bitwise and, bit clear, shifts
+			if !types.IsFixed(expr.Type()) {
+				p.error(operator, fmt.Sprintf("operator requires integer type, got %q", expr.Type()), "factor")
+				return nil
+			}
+		}
+
 		p.advance("factor operator") // consume operator
-		right := p.unary(ctx, expr.Type())
+
+		shift := operator.Type == tokens.ShiftLeft || operator.Type == tokens.ShiftRight
+
+		rightType := expr.Type()
+
+		if shift {
+			p.advance("factor shift operator") // consume second < or >
+
+			// The shift count need not have the type of the shifted value.
+			rightType = types.None
+		}
+
+		right := p.unary(ctx, rightType)
+		if right == nil || p.opaque(right, operator) {
+			return nil
+		}
+
+		if lit, ok := right.(*ast.Int64Literal); ok && shift && lit.Value >= 0 {
+			// An untyped shift count literal is unsigned.
+			right = &ast.Uint64Literal{Token: lit.Token, Value: uint64(lit.Value)}
+		}
+
+		switch {
+		case shift:
+			if !types.IsUint(right.Type()) || right.Type().Kind() == types.Uint128 {
+				p.error(operator, fmt.Sprintf("shift count requires unsigned integer type of at most 64 bits, got %q", right.Type()), "factor")
+				return nil
+			}
+		case isBitwise(operator.Type) && !types.IsFixed(right.Type()):
+			p.error(operator, fmt.Sprintf("operator requires integer type, got %q", right.Type()), "factor")
+			return nil
+		}
 
 		expr = &ast.Infix{
 			Operator: operator,
@@ -218,7 +438,59 @@ func (p *Parser) factor(ctx context.Context, typeToken types.Type) ast.Expressio
 	return nil
 }
 
+// factorOperator returns the factor operator at the current token. A shift
+// operator is two adjacent < or > tokens, which are not combined by the lexer
+// as >> also closes nested type arguments. A binary & must be on the line of
+// its left operand: at the start of a line, & starts a reference method
+// declaration.
+func (p *Parser) factorOperator() (tokens.Token, bool) {
+	operator := p.this()
+
+	switch operator.Type {
+	case tokens.Asterisk, tokens.Divide, tokens.Percent, tokens.BitClear:
+		return operator, true
+	case tokens.BitAnd:
+		return operator, operator.Ln == p.prev().Ln
+	case tokens.LT, tokens.GT:
+		next := p.next()
+		if next.Type != operator.Type || next.Ln != operator.Ln || next.Col != operator.Col+1 {
+			return operator, false
+		}
+
+		if operator.Type == tokens.LT {
+			operator.Type = tokens.ShiftLeft
+		} else {
+			operator.Type = tokens.ShiftRight
+		}
+
+		return operator, true
+	default:
+		return operator, false
+	}
+}
+
+// isBitwise reports whether an infix operator of type t only applies to
+// integers.
+func isBitwise(t tokens.Type) bool {
+	switch t {
+	case tokens.Percent, tokens.BitAnd, tokens.BitOr, tokens.BitXor, tokens.BitClear,
+		tokens.ShiftLeft, tokens.ShiftRight:
+		return true
+	default:
+		return false
+	}
+}
+
 func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression {
+	if p.this().Type == tokens.Async {
+		node := p.parseAsync(ctx)
+		if node == nil {
+			return nil
+		}
+
+		return node
+	}
+
 	if p.match(tokens.Not, tokens.Minus, tokens.BitAnd) {
 		// Previous operator is stored, to disallow double references.
 		prevOperator := p.prev()
@@ -229,6 +501,18 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 		operator := p.this()
 		p.advance("unary operator") // consume operator
 
+		if operator.Type == tokens.Minus && p.match(tokens.IntLiteral, tokens.FloatLiteral) {
+			// A negative number is a single literal, so its range includes
+			// the sign: -128 is an int8. The token is restored, as globals
+			// are parsed twice.
+			i, lit := p.i, p.this()
+			p.tokens[i].Literal = "-" + lit.Literal
+
+			defer func() { p.tokens[i] = lit }()
+
+			return p.unary(ctx, typeToken)
+		}
+
 		exprType := typeToken
 
 		if operator.Type == tokens.BitAnd {
@@ -251,7 +535,7 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 		}
 
 		right := p.unary(ctx, exprType)
-		if right == nil {
+		if right == nil || p.opaque(right, operator) {
 			return nil
 		}
 
@@ -269,6 +553,12 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 		}
 	}
 
+	if p.this().Type == tokens.Identifier && p.next().Type == tokens.Not {
+		// The expected type is that of the error, or propagated value, of
+		// the result.
+		typeToken = types.None
+	}
+
 	if (typeToken == nil || typeToken == types.None) && p.this().Type == tokens.Identifier {
 		// TODO: get rid of double lookup for identifiers
 		symbol, ok := p.symbols.Resolve(p.this().Literal)
@@ -276,7 +566,7 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 			// If this is an imported package name, skip the type pre-lookup;
 			// primary() will handle it via parsePkgSelector.
 			if _, isImport := p.symbols.ResolveCogImport(p.this().Literal); !isImport {
-				p.error(p.this(), "undefined identifier", "primary")
+				p.undefined(p.this(), "primary")
 				return nil
 			}
 		} else {
@@ -309,6 +599,10 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 		token := p.this()
 		p.advance("unary !") // consume !
 
+		if p.this().Type == tokens.Question {
+			return p.parsePropagate(token, node)
+		}
+
 		if typeToken.Kind() != types.ResultKind {
 			p.error(token, "! operator requires result type", "unary")
 			return nil
@@ -343,6 +637,9 @@ func (p *Parser) unary(ctx context.Context, typeToken types.Type) ast.Expression
 
 func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expression {
 	if typeToken != nil {
+		// The expected type, before resolving aliases.
+		expected := typeToken
+
 		aliasType, ok := typeToken.(*types.Alias)
 		if ok && !aliasType.IsTypeParam() {
 			typeToken = aliasType.Underlying()
@@ -393,6 +690,43 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 				Value:      expr,
 				IsRight:    isRight,
 			}
+		case types.UnionKind:
+			// Handle sum type literal.
+			unionType, ok := typeToken.(*types.Union)
+			if !ok {
+				p.error(p.this(), "unable to assert union type", "primary")
+				return nil
+			}
+
+			if unionType.Name != "" {
+				break
+			}
+
+			token := p.this()
+
+			// Infer variant.
+			expr := p.primary(ctx, types.None)
+			if expr == nil {
+				return nil
+			}
+
+			// A value of the sum type itself needs no literal.
+			if types.Equal(expr.Type(), unionType) {
+				return expr
+			}
+
+			index := unionType.Index(expr.Type())
+			if index < 0 {
+				p.error(p.this(), fmt.Sprintf("expression of type %q is not a variant of sum type %q", expr.Type().String(), unionType.String()), "primary")
+				return nil
+			}
+
+			return &ast.UnionLiteral{
+				Token:     token,
+				UnionType: expected,
+				Value:     expr,
+				Index:     index,
+			}
 		}
 	}
 
@@ -413,7 +747,20 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 		t := p.this()
 
 		if t.Literal == "go" {
-			return p.parseGoCallExpression(ctx)
+			expr := p.parseGoExpression(ctx)
+			if expr == nil {
+				return nil
+			}
+
+			if !p.adaptGoValue(expr, typeToken) {
+				return nil
+			}
+
+			if call, ok := expr.(*ast.GoCallExpression); ok && !p.checkGoCallValue(call) {
+				return nil
+			}
+
+			return expr
 		}
 
 		p.advance("primary builtin") // consume @
@@ -477,11 +824,13 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 				return p.parsePkgSelector(ctx, imp)
 			}
 
-			p.error(p.this(), "undefined identifier", "primary")
+			p.undefined(p.this(), "primary")
 
 			return nil
 		}
 
+		p.symbols.addReference(p.this(), symbol)
+
 		p.advance("primary identifier") // consume identifier
 
 		if symbol.Identifier.Qualifier == ast.QualifierType && p.this().Type == tokens.LBrace {
@@ -574,6 +923,10 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 				return nil
 			}
 
+			if kind == types.ErrorKind && symbol.Identifier.Qualifier != ast.QualifierType {
+				return p.parseErrorPayloadSelector(symbol.Identifier, symbolType)
+			}
+
 			// Selector expression
 			selector := p.this()
 
@@ -605,7 +958,12 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 					return nil
 				}
 
-				field.Identifier.Token = p.this()
+				p.symbols.addReference(p.this(), field)
+
+				// The field identifier is shared by all uses, so the use gets a copy.
+				fieldIdent := *field.Identifier
+				fieldIdent.Token = p.this()
+				field.Identifier = &fieldIdent
 
 				p.advance("primary identifier field") // consume field identifier
 
@@ -613,12 +971,30 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 				// type can be inferred downstream.  For struct fields, preserve the
 				// original field type (e.g. float64) so arithmetic works correctly.
 				if field.Scope == EnumScope {
-					field.Identifier.ValueType = &types.Alias{
+					alias := &types.Alias{
 						Name:     symbol.Identifier.Name,
 						Derived:  symbol.Type(),
 						Exported: symbol.Identifier.Exported,
 						Global:   symbol.Identifier.Global,
 					}
+
+					field.Identifier.ValueType = alias
+
+					// An error variant with a payload is constructed by a call.
+					if errType, ok := symbol.Type().(*types.Error); ok {
+						if val := errType.Value(field.Identifier.Name); val != nil && len(val.Params) > 0 {
+							if p.this().Type != tokens.LParen {
+								p.error(p.prev(), fmt.Sprintf("error variant %q requires arguments", field.Identifier.Name), "primary")
+								return nil
+							}
+
+							field.Identifier.ValueType = &types.Procedure{
+								Function:   true,
+								Parameters: val.Params,
+								ReturnType: alias,
+							}
+						}
+					}
 				}
 
 				if selected != nil {
@@ -734,7 +1110,8 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 
 			return expr
 		case *types.Array:
-			// TODO: see if it's possible to evaluate array length
+			// The length is evaluated, and the number of values checked
+			// against it, by the comptime package.
 			arrayLiteral := &ast.ArrayLiteral{
 				Token:     p.this(),
 				ArrayType: t,
@@ -821,6 +1198,17 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 			p.advance("primary map }") // consume }
 
 			return mapLiteral
+		case *types.Iterator:
+			// An iterator literal is the body of the procedure that passes the
+			// values to yield.
+			literal, ok := p.primary(ctx, t.Procedure()).(*ast.ProcedureLiteral)
+			if !ok {
+				return nil
+			}
+
+			literal.ProcedureType = t
+
+			return literal
 		case *types.Procedure:
 			procLiteral := &ast.ProcedureLiteral{
 				ProcedureType: t,
@@ -865,12 +1253,12 @@ func (p *Parser) primary(ctx context.Context, typeToken types.Type) ast.Expressi
 			}
 
 			// Track the return type for result-aware return parsing.
-			prevReturnType := p.currentReturnType
-			p.currentReturnType = t.ReturnType
+			prevReturnType, prevInFunction, prevNoPropagation := p.currentReturnType, p.inFunction, p.noPropagation
+			p.currentReturnType, p.inFunction, p.noPropagation = t.ReturnType, t.Function, ""
 
 			body := p.parseBlockStatement(ctx)
 
-			p.currentReturnType = prevReturnType
+			p.currentReturnType, p.inFunction, p.noPropagation = prevReturnType, prevInFunction, prevNoPropagation
 
 			if len(t.Parameters) > 0 {
 				// Leave parameter scope
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
 )
 
 func TestExpression(t *testing.T) {
@@ -29,15 +30,34 @@ main : proc() = {}`)
 	t.Run("prefix", func(t *testing.T) {
 		t.Parallel()
 		f := parse(t, `package p
-x := -1
+y := 1
+x := -y
 main : proc() = {}`)
 
-		d := stmtAs[*ast.Declaration](t, f, 0)
+		d := stmtAs[*ast.Declaration](t, f, 1)
 		if _, ok := d.Assignment.Expression.(*ast.Prefix); !ok {
 			t.Errorf("expected Prefix expression, got %T", d.Assignment.Expression)
 		}
 	})
 
+	t.Run("negative_literal", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+x := -1
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 0)
+
+		lit, ok := d.Assignment.Expression.(*ast.Int64Literal)
+		if !ok {
+			t.Fatalf("expected Int64Literal expression, got %T", d.Assignment.Expression)
+		}
+
+		if lit.Value != -1 {
+			t.Errorf("expected value -1, got %d", lit.Value)
+		}
+	})
+
 	t.Run("comparison", func(t *testing.T) {
 		t.Parallel()
 
@@ -68,6 +88,207 @@ main : proc() = {
 	})
 }
 
+func TestIntegerOperators(t *testing.T) {
+	t.Parallel()
+
+	t.Run("precedence", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+x : uint32 = 1 ||| 2 << 3 & 7 ^ 5 % 2 &^ 1
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 0)
+
+		want := "(((1 : uint32) ||| (((2 : uint32) << (3 : uint64)) & (7 : uint32))) ^ " +
+			"(((5 : uint32) % (2 : uint32)) &^ (1 : uint32)))"
+		if got := d.Assignment.Expression.String(); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	})
+
+	t.Run("shift_count", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+n : uint8 = 3
+x : int64 = -8 >> n
+y : int128 = 1 << 100
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 2)
+
+		infix, ok := d.Assignment.Expression.(*ast.Infix)
+		if !ok {
+			t.Fatalf("expected Infix expression, got %T", d.Assignment.Expression)
+		}
+
+		if infix.Type().String() != "int128" || infix.Right.Type().String() != "uint64" {
+			t.Errorf("got %s << %s, want int128 << uint64", infix.Type(), infix.Right.Type())
+		}
+	})
+
+	t.Run("nested_type_arguments", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	m := @map<utf8, set<int64>>()
+	@print(@len(m) >> 1)
+}`)
+	})
+
+	t.Run("reference_method_after_expression", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+T ~ struct {
+	n : int64
+}
+mask := 3
+&T.Masked : func() int64 = {
+	return mask & 2
+}
+main : proc() = {}`)
+
+		d := stmtAs[*ast.Declaration](t, f, 1)
+		if _, ok := d.Assignment.Expression.(*ast.Infix); ok {
+			t.Error("& on the next line was parsed as bitwise and")
+		}
+
+		stmtAs[*ast.Method](t, f, 2)
+	})
+
+	for name, src := range map[string]string{
+		"float_remainder": `x := 1.5 % 2.0`,
+		"string_or":       `x := "a" ||| "b"`,
+		"float_operand":   `x := 1 & 2.0`,
+		"signed_count":    "n : int64 = 2\nx := 1 << n",
+		"negative_count":  `x := 1 >> -1`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parseShouldError(t, "package p\n"+src+"\nmain : proc() = {}")
+		})
+	}
+}
+
+func TestNumericLiterals(t *testing.T) {
+	t.Parallel()
+
+	f := parse(t, `package p
+a : uint8 = 0xFF
+b : uint16 = 0b1010_1010
+c : uint32 = 0o755
+d : int64 = 1_000_000
+e : int8 = -128
+g : int128 = -0x8000_0000_0000_0000_0000_0000_0000_0000
+h : uint128 = 0xFFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF_FFFF
+i : float64 = 0x10
+main : proc() = {}`)
+
+	for i, want := range []string{
+		"(255 : uint8)",
+		"(170 : uint16)",
+		"(493 : uint32)",
+		"(1000000 : int64)",
+		"(-128 : int8)",
+		"(-0x80000000000000000000000000000000 : int128)",
+		"(340282366920938463463374607431768211455 : uint128)",
+		"(16 : float64)",
+	} {
+		d := stmtAs[*ast.Declaration](t, f, i)
+		if got := d.Assignment.Expression.String(); got != want {
+			t.Errorf("declaration %d: got %s, want %s", i, got, want)
+		}
+	}
+
+	for name, src := range map[string]string{
+		"uint8_overflow":   `x : uint8 = 256`,
+		"hex_overflow":     `x : int16 = 0x8000`,
+		"negative_uint":    `x : uint8 = -1`,
+		"int8_underflow":   `x : int8 = -129`,
+		"int64_overflow":   `x := 9_223_372_036_854_775_808`,
+		"uint128_overflow": `x : uint128 = 0x1_0000_0000_0000_0000_0000_0000_0000_0000`,
+		"legacy_octal":     `x := 0755`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parseShouldError(t, "package p\n"+src+"\nmain : proc() = {}")
+		})
+	}
+}
+
+func TestConstantExpressions(t *testing.T) {
+	t.Parallel()
+
+	parse(t, `package p
+a : uint8 = 200 + 55
+b : int8 = -100 - 28
+c : uint8 = 1 << 7
+d : float16 = 60000.0 + 5000.0
+main : proc() = {}`)
+
+	for name, tt := range map[string]struct {
+		src  string
+		want string
+	}{
+		"uint8_overflow": {
+			src:  `x : uint8 = 200 + 100`,
+			want: "2:13: constant 300 overflows uint8 (range 0 to 255)",
+		},
+		"int8_underflow": {
+			src:  `x : int8 = -100 - 29`,
+			want: "2:12: constant -129 overflows int8 (range -128 to 127)",
+		},
+		"negated_min": {
+			src:  `x : int8 = -(-128)`,
+			want: "2:12: constant 128 overflows int8 (range -128 to 127)",
+		},
+		"shift_overflow": {
+			src:  `x : uint8 = 1 << 8`,
+			want: "2:13: constant 256 overflows uint8 (range 0 to 255)",
+		},
+		"float_overflow": {
+			src:  `x : float16 = 60000.0 * 2.0`,
+			want: "2:15: constant +Inf overflows float16",
+		},
+		"division_by_zero": {
+			src:  `x : int64 = 1 / (2 - 2)`,
+			want: "2:13: division by zero",
+		},
+		"argument": {
+			src:  "f : func(x : uint8) uint8 = {\n\treturn x\n}\ny := f(255 + 1)",
+			want: "5:8: constant 256 overflows uint8 (range 0 to 255)",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			toks, err := lexer.NewLexer(strings.NewReader("package p\n" + tt.src + "\nmain : proc() = {}")).Parse(t.Context())
+			if err != nil {
+				t.Fatalf("lex error: %v", err)
+			}
+
+			p, err := NewTestParser(t, toks, false)
+			if err != nil {
+				t.Fatalf("parser init error: %v", err)
+			}
+
+			_, err = p.Parse(t.Context(), "test.cog")
+			if err == nil {
+				t.Fatal("expected parse error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
 func TestStructLiteralWithTypeAlias(t *testing.T) {
 	t.Parallel()
 
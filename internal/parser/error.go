@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// parsePropagate parses r!?, the value of result r, which returns the error of
+// r from the enclosing function instead. The error type of the enclosing
+// result must be that of r, the Go error interface, or an interface that the
+// error of r implements.
+func (p *Parser) parsePropagate(operator tokens.Token, left ast.Expression) ast.Expression {
+	p.advance("parsePropagate ?") // consume ?
+
+	if p.noPropagation != "" {
+		p.error(operator, "cannot propagate an error in "+p.noPropagation, "parsePropagate")
+		return nil
+	}
+
+	result, ok := left.Type().Underlying().(*types.Result)
+	if !ok {
+		p.error(operator, "!? operator requires result type", "parsePropagate")
+		return nil
+	}
+
+	var returnType *types.Result
+
+	if p.currentReturnType != nil {
+		returnType, _ = p.currentReturnType.Underlying().(*types.Result)
+	}
+
+	enclosing := "procedure"
+	if p.inFunction {
+		enclosing = "function"
+	}
+
+	if returnType == nil {
+		p.error(operator, "cannot propagate an error from a "+enclosing+" that does not return a result", "parsePropagate")
+		return nil
+	}
+
+	if !types.ErrorAssignableTo(result.Error, returnType.Error) {
+		p.error(operator, fmt.Sprintf("cannot propagate error of type %q from a %s returning error type %q", result.Error, enclosing, returnType.Error), "parsePropagate")
+		return nil
+	}
+
+	return &ast.Propagate{
+		Operator:   operator,
+		Left:       left,
+		ReturnType: p.currentReturnType,
+	}
+}
+
+// parseErrorPayloadSelector parses the selection of a payload field of the
+// error value ident. A variant without the field has its zero value.
+func (p *Parser) parseErrorPayloadSelector(ident *ast.Identifier, typ types.Type) ast.Expression {
+	selector := p.this()
+	p.advance("parseErrorPayloadSelector .") // consume .
+
+	if p.this().Type != tokens.Identifier {
+		p.error(p.this(), "expected field identifier after . selector", "parseErrorPayloadSelector")
+		return nil
+	}
+
+	var param *types.Parameter
+
+	if errType, ok := typ.Underlying().(*types.Error); ok {
+		param = errType.Field(p.this().Literal)
+	}
+
+	if param == nil {
+		p.error(p.this(), fmt.Sprintf("undefined payload field %q for error %q", p.this().Literal, typ), "parseErrorPayloadSelector")
+		return nil
+	}
+
+	field := &ast.Identifier{
+		Token:     p.this(),
+		Name:      param.Name,
+		ValueType: param.Type,
+	}
+
+	p.advance("parseErrorPayloadSelector field") // consume field identifier
+
+	return &ast.Selector{
+		Token:      selector,
+		Expression: ident,
+		Field:      field,
+	}
+}
@@ -0,0 +1,352 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/types"
+)
+
+func TestParsePropagate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same_error", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! MyError = {
+	x := get()!?
+	return x * 2
+}
+main : proc() = {}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 2)
+		procLit := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+		assign := procLit.Body.Statements[0].(*ast.Declaration)
+
+		propagate, ok := assign.Assignment.Expression.(*ast.Propagate)
+		if !ok {
+			t.Fatalf("expected *ast.Propagate, got %T", assign.Assignment.Expression)
+		}
+
+		if propagate.Type().Kind() != types.Int64 {
+			t.Errorf("expected int64 value, got %s", propagate.Type())
+		}
+	})
+
+	t.Run("go_error", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! error = {
+	x : int64 = get()!?
+	return x * 2
+}
+main : proc() = {}`)
+	})
+
+	t.Run("identifier", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! MyError = {
+	r := get()
+	x : int64 = r!?
+	return x * 2
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_not_result", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { Fail }
+double : func(x : int64) int64 ! MyError = {
+	y := x!?
+	return y
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_no_result_return", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+main : proc() = {
+	x := get()!?
+	@print(x)
+}`)
+	})
+
+	t.Run("error_mismatched_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { Fail }
+OtherError ~ error { Bad }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! OtherError = {
+	x := get()!?
+	return x * 2
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_messages", func(t *testing.T) {
+		t.Parallel()
+
+		for name, tt := range map[string]struct {
+			src  string
+			want string
+		}{
+			"proc_no_result": {
+				src:  "main : proc() = {\n\tx := get()!?\n\t@print(x)\n}",
+				want: "7:12: cannot propagate an error from a procedure that does not return a result",
+			},
+			"func_no_result": {
+				src:  "g : func() int64 = {\n\tx := get()!?\n\treturn x\n}\nmain : proc() = {}",
+				want: "7:12: cannot propagate an error from a function that does not return a result",
+			},
+			"func_mismatched_error": {
+				src:  "OtherError ~ error { Bad }\ng : func() int64 ! OtherError = {\n\tx := get()!?\n\treturn x\n}\nmain : proc() = {}",
+				want: `8:12: cannot propagate error of type "MyError" from a function returning error type "OtherError"`,
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				src := "package p\nMyError ~ error { Fail }\nget : func() int64 ! MyError = {\n\treturn 1\n}\n" + tt.src
+
+				toks, err := lexer.NewLexer(strings.NewReader(src)).Parse(t.Context())
+				if err != nil {
+					t.Fatalf("lex error: %v", err)
+				}
+
+				p, err := NewTestParser(t, toks, false)
+				if err != nil {
+					t.Fatalf("parser init error: %v", err)
+				}
+
+				_, err = p.Parse(t.Context(), "test.cog")
+				if err == nil {
+					t.Fatal("expected parse error, got nil")
+				}
+
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("got error %q, want it to contain %q", err, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("error_conditional_operand", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { Fail }
+ok : func() bool ! MyError = {
+	return true
+}
+both : func(a : bool) bool ! MyError = {
+	return a && ok()!?
+}
+main : proc() = {}`)
+	})
+}
+
+func TestParseErrorPayload(t *testing.T) {
+	t.Parallel()
+
+	t.Run("construct_and_select", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+MyError ~ error { NotFound(key : utf8), Timeout }
+find : func(key : utf8) int64 ! MyError = {
+	return MyError.NotFound(key)
+}
+main : proc() = {
+	r := find("k")
+	if !r? {
+		e := r!
+		@print(e.key)
+	}
+}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 1)
+		procLit := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+		ret := procLit.Body.Statements[0].(*ast.Return)
+
+		result, ok := ret.Values[0].(*ast.ResultLiteral)
+		if !ok || !result.IsError {
+			t.Fatalf("expected error result literal, got %T", ret.Values[0])
+		}
+
+		if _, ok := result.Value.(*ast.Call); !ok {
+			t.Errorf("expected variant constructor call, got %T", result.Value)
+		}
+	})
+
+	t.Run("error_missing_arguments", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { NotFound(key : utf8) }
+find : func() int64 ! MyError = {
+	return MyError.NotFound
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_undefined_field", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+MyError ~ error { NotFound(key : utf8) }
+find : func() int64 ! MyError = {
+	return 1
+}
+main : proc() = {
+	r := find()
+	if !r? {
+		e := r!
+		@print(e.name)
+	}
+}`)
+	})
+}
+
+func TestParseErrorInterface(t *testing.T) {
+	t.Parallel()
+
+	t.Run("struct_error", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+find : func(key : utf8) int64 ! NotFound = {
+	return NotFound{key = key}
+}
+NotFound ~ struct {
+	key : utf8
+}
+NotFound.Error : func() utf8 = {
+	return "not found"
+}
+main : proc() = {}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 0)
+		procLit := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+		ret := procLit.Body.Statements[0].(*ast.Return)
+
+		result, ok := ret.Values[0].(*ast.ResultLiteral)
+		if !ok || !result.IsError {
+			t.Fatalf("expected error result literal, got %T", ret.Values[0])
+		}
+	})
+
+	t.Run("interface_error", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+Failure ~ interface {
+	Error : func() utf8
+}
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return MyError.Fail
+}
+double : func() int64 ! Failure = {
+	x := get()!?
+	return x * 2
+}
+main : proc() = {
+	r := double()
+	if !r? {
+		e := r!
+		@print(e.Error())
+	}
+}`)
+	})
+
+	t.Run("go_error_to_interface", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+goimport (
+	"strconv"
+)
+Failure ~ interface {
+	Error : func() utf8
+}
+atoi : func(s : utf8) int64 ! Failure = {
+	return @go.strconv.Atoi(s)!?
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_no_error_method", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+NotFound ~ struct {
+	key : utf8
+}
+find : func(key : utf8) int64 ! NotFound = {
+	return 1
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_wrong_signature", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Failure ~ interface {
+	Error : func() int64
+}
+find : func() int64 ! Failure = {
+	return 1
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_not_implemented", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Failure ~ interface {
+	Error : func() utf8
+	Code : func() int64
+}
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return MyError.Fail
+}
+double : func() int64 ! Failure = {
+	x := get()!?
+	return x * 2
+}
+main : proc() = {}`)
+	})
+}
@@ -88,13 +88,28 @@ func (p *Parser) parseForStatement(ctx context.Context) *ast.ForStatement {
 			return nil
 		}
 
+		if call := p.iteratorMethod(expr); call != nil {
+			expr = call
+		}
+
 		if !types.IsIterator(expr.Type()) {
 			p.error(p.this(), "cannot iterate over type "+expr.Type().String(), "parseForStatement")
 			return nil
 		}
 
+		valueType, indexType := rangeTypes(expr.Type())
+
 		if valueVar != nil {
-			valueVar.ValueType = expr.Type()
+			valueVar.ValueType = valueType
+		}
+
+		if indexVar != nil {
+			if indexType == nil {
+				p.error(indexVar.Token, "cannot use index variable in loop over "+expr.Type().String(), "parseForStatement")
+				return nil
+			}
+
+			indexVar.ValueType = indexType
 		}
 
 		node.Range = expr
@@ -149,3 +164,65 @@ func (p *Parser) parseForStatement(ctx context.Context) *ast.ForStatement {
 
 	return node
 }
+
+// iteratorMethod returns the call of the All method of expr, if expr has a
+// user-defined type with an All method that returns an iterator. A value of
+// such a type is looped over with the iterator.
+func (p *Parser) iteratorMethod(expr ast.Expression) ast.Expression {
+	switch expr.(type) {
+	case *ast.Identifier, *ast.Selector:
+	default:
+		return nil
+	}
+
+	if _, ok := expr.Type().(*types.Alias); !ok {
+		return nil
+	}
+
+	method, ok := p.symbols.ResolveField(expr.Type().String(), "All")
+	if !ok || method.Identifier.Qualifier != ast.QualifierMethod {
+		return nil
+	}
+
+	procType, ok := method.Type().(*types.Procedure)
+	if !ok || len(procType.Parameters) > 0 || procType.ReturnType == nil ||
+		procType.ReturnType.Kind() != types.IteratorKind {
+		return nil
+	}
+
+	ln, col := expr.Pos()
+
+	// The method identifier is shared by all uses, so the use gets a copy.
+	field := *method.Identifier
+
+	return &ast.Call{
+		Expression: &ast.Selector{
+			Token:      tokens.Token{Type: tokens.Dot, Ln: ln, Col: col},
+			Expression: expr,
+			Field:      &field,
+		},
+		ReturnType: procType.ReturnType,
+	}
+}
+
+// rangeTypes returns the types of the value and index variables of a loop over
+// a value of type typ. The index type is nil for sets and iterators without
+// an index.
+func rangeTypes(typ types.Type) (value, index types.Type) {
+	if it, ok := types.IteratorOf(typ); ok {
+		return it.Value, it.Index
+	}
+
+	switch container := typ.Underlying().(type) {
+	case *types.Array:
+		return container.Element, types.Basics[types.Uint64]
+	case *types.Slice:
+		return container.Element, types.Basics[types.Uint64]
+	case *types.Map:
+		return container.Value, container.Key
+	case *types.Set:
+		return container.Element, nil
+	default:
+		return typ, types.Basics[types.Uint64]
+	}
+}
@@ -33,4 +33,151 @@ main : proc() = {
 			t.Fatal("expected statements")
 		}
 	})
+
+	t.Run("map_key_and_value", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	m : map<ascii, int64> = {"a": 1}
+	for v, k in m {
+		key : ascii = k
+		value : int64 = v
+		@print(key)
+		@print(value)
+	}
+}`)
+	})
+
+	t.Run("set_index_error", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+main : proc() = {
+	s : set<int64> = {1}
+	for v, i in s {
+		@print(v)
+	}
+}`)
+	})
+
+	t.Run("range", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	n : uint8 = 10
+	for i in 0..=n by 2 {
+		x : uint8 = i
+		@print(x)
+	}
+	for i in 10..0 by -1 {
+		@print(i)
+	}
+}`)
+	})
+
+	t.Run("iterator_variable", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+main : proc() = {
+	evens : iter<int64> = 0..10 by 2
+	pairs : iter<utf8, int64> = {
+		if !yield("a", 1) {
+			return
+		}
+	}
+	for v in evens {
+		@print(v)
+	}
+	for v, k in pairs {
+		key : int64 = k
+		@print(v)
+		@print(key)
+	}
+}`)
+	})
+
+	t.Run("yield_procedure", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+count : proc(yield : func(v : int64) bool) = {
+	for i in 0..3 {
+		if !yield(i) {
+			return
+		}
+	}
+}
+main : proc() = {
+	for v in count {
+		@print(v)
+	}
+}`)
+	})
+
+	t.Run("iterator_method", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+Bag ~ struct {
+	values : []int64
+}
+(b : Bag).All : func() iter<int64> = {
+	return {
+		for v in b.values {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+main : proc() = {
+	b : Bag = {values = []int64{1, 2}}
+	for v in b {
+		@print(v)
+	}
+}`)
+	})
+
+	for name, src := range map[string]string{
+		"range_float": `package p
+main : proc() = {
+	for i in 0.5..2.5 {
+		@print(i)
+	}
+}`,
+		"range_zero_step": `package p
+main : proc() = {
+	for i in 0..10 by 0 {
+		@print(i)
+	}
+}`,
+		"range_mixed_types": `package p
+main : proc() = {
+	a : int64 = 1
+	b : int32 = 2
+	for i in a..b {
+		@print(i)
+	}
+}`,
+		"iterator_index_error": `package p
+main : proc() = {
+	evens : iter<int64> = 0..10
+	for v, i in evens {
+		@print(v)
+	}
+}`,
+		"bare_return_with_result": `package p
+f : func() int64 = {
+	return
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			parseShouldError(t, src)
+		})
+	}
 }
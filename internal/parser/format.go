@@ -0,0 +1,283 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/tokens"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// parseFormatArguments parses the arguments of @print and @format. If the
+// first argument is a string literal with braces, it is a format string, and
+// its {} placeholders take the other arguments in order.
+func (p *Parser) parseFormatArguments(ctx context.Context, name string) []ast.Expression {
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after @"+name, "parseFormatArguments")
+		return nil
+	}
+
+	p.advance("parseFormatArguments (") // consume (
+
+	if p.this().Type == tokens.RParen {
+		p.error(p.this(), "expected argument in @"+name, "parseFormatArguments")
+		return nil
+	}
+
+	var (
+		format *ast.FormatString
+		args   []ast.Expression
+	)
+
+	for p.this().Type != tokens.RParen {
+		if len(args) > 0 {
+			if p.this().Type != tokens.Comma {
+				p.error(p.this(), "expected ',' or ')' after argument in @"+name, "parseFormatArguments")
+				return nil
+			}
+
+			p.advance("parseFormatArguments ,") // consume ,
+		}
+
+		if len(args) == 0 && p.this().Type == tokens.StringLiteral && strings.ContainsAny(p.this().Literal, "{}") &&
+			(p.next().Type == tokens.Comma || p.next().Type == tokens.RParen) {
+			format = p.parseFormatString(ctx, p.this())
+			if format == nil {
+				return nil
+			}
+
+			p.advance("parseFormatArguments format") // consume format string
+			args = append(args, format)
+
+			continue
+		}
+
+		arg := p.expression(ctx, types.None)
+		if arg == nil {
+			return nil
+		}
+
+		args = append(args, arg)
+	}
+
+	p.advance("parseFormatArguments )") // consume )
+
+	if format == nil {
+		return args
+	}
+
+	positional := args[1:]
+
+	for _, part := range format.Parts {
+		if part.Text != "" {
+			continue
+		}
+
+		if !part.Inline {
+			if len(positional) == 0 {
+				p.error(format.Token, fmt.Sprintf("@%s format string has more {} than arguments", name), "parseFormatArguments")
+				return nil
+			}
+
+			part.Value, positional = positional[0], positional[1:]
+		}
+
+		if err := checkFormatSpec(part.Spec, part.ValueType()); err != nil {
+			p.error(format.Token, err.Error(), "parseFormatArguments")
+			return nil
+		}
+	}
+
+	if len(positional) > 0 {
+		p.error(format.Token, fmt.Sprintf("@%s has %d arguments without a {} in the format string", name, len(positional)), "parseFormatArguments")
+		return nil
+	}
+
+	return []ast.Expression{format}
+}
+
+// parseFormatString parses the text and placeholders of format string t. The
+// values of {} placeholders are left empty.
+func (p *Parser) parseFormatString(ctx context.Context, t tokens.Token) *ast.FormatString {
+	format := &ast.FormatString{Token: t}
+
+	var text strings.Builder
+
+	lit := t.Literal
+
+	for i := 0; i < len(lit); i++ {
+		switch {
+		case strings.HasPrefix(lit[i:], "{{"), strings.HasPrefix(lit[i:], "}}"):
+			_ = text.WriteByte(lit[i])
+			i++
+		case lit[i] == '}':
+			p.error(t, "unmatched '}' in format string, use '}}' for a literal '}'", "parseFormatString")
+			return nil
+		case lit[i] == '{':
+			end := strings.IndexByte(lit[i:], '}')
+			if end < 0 {
+				p.error(t, "unclosed '{' in format string, use '{{' for a literal '{'", "parseFormatString")
+				return nil
+			}
+
+			if text.Len() > 0 {
+				format.Parts = append(format.Parts, &ast.FormatPart{Text: text.String()})
+				text.Reset()
+			}
+
+			part := &ast.FormatPart{Spec: ast.FormatSpec{Precision: -1}}
+
+			placeholder := lit[i+1 : i+end]
+
+			src, spec, hasSpec := strings.Cut(placeholder, ":")
+			if hasSpec {
+				parsed, err := parseFormatSpec(spec)
+				if err != nil {
+					p.error(t, err.Error(), "parseFormatString")
+					return nil
+				}
+
+				part.Spec = parsed
+			}
+
+			if strings.TrimSpace(src) != "" {
+				part.Value = p.parseEmbedded(ctx, t, i+1, src)
+				if part.Value == nil {
+					return nil
+				}
+
+				part.Inline = true
+			}
+
+			format.Parts = append(format.Parts, part)
+			i += end
+		default:
+			_ = text.WriteByte(lit[i])
+		}
+	}
+
+	if text.Len() > 0 {
+		format.Parts = append(format.Parts, &ast.FormatPart{Text: text.String()})
+	}
+
+	return format
+}
+
+// parseEmbedded parses src, the expression at offset in string literal t.
+func (p *Parser) parseEmbedded(ctx context.Context, t tokens.Token, offset int, src string) ast.Expression {
+	embedded, err := lexer.NewLexerWithFileID(strings.NewReader(src), t.FileID).Parse(ctx)
+	if err != nil {
+		p.error(t, fmt.Sprintf("invalid expression %q in format string", src), "parseEmbedded")
+		return nil
+	}
+
+	// Point the tokens into the string literal, after its opening quote.
+	for i := range embedded {
+		embedded[i].Ln = t.Ln
+		embedded[i].Col += t.Col + uint16(offset) //nolint:gosec // G115: integer overflow conversion
+	}
+
+	toks, i := p.tokens, p.i
+	p.tokens, p.i = embedded, 0
+
+	defer func() { p.tokens, p.i = toks, i }()
+
+	expr := p.expression(ctx, types.None)
+	if expr == nil {
+		return nil
+	}
+
+	if p.this().Type != tokens.EOF {
+		p.error(p.this(), fmt.Sprintf("unexpected %s in format string expression", p.this().Type), "parseEmbedded")
+		return nil
+	}
+
+	return expr
+}
+
+// parseFormatSpec parses the format of a value: [align][0][width][.precision][verb].
+func parseFormatSpec(s string) (ast.FormatSpec, error) {
+	spec := ast.FormatSpec{Precision: -1}
+	rest := s
+
+	if rest != "" && (rest[0] == '<' || rest[0] == '>') {
+		spec.Align, rest = rest[0], rest[1:]
+	}
+
+	if rest != "" && rest[0] == '0' {
+		spec.Zero, rest = true, rest[1:]
+	}
+
+	digits := func() (int, bool) {
+		n := 0
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+
+		if n == 0 {
+			return 0, false
+		}
+
+		v, err := strconv.Atoi(rest[:n])
+		rest = rest[n:]
+
+		return v, err == nil
+	}
+
+	if width, ok := digits(); ok {
+		spec.Width = width
+	}
+
+	if rest != "" && rest[0] == '.' {
+		rest = rest[1:]
+
+		precision, ok := digits()
+		if !ok {
+			return spec, fmt.Errorf("missing precision after '.' in format spec %q", s)
+		}
+
+		spec.Precision = precision
+	}
+
+	if len(rest) == 1 && strings.IndexByte("dboxXeEfgGq", rest[0]) >= 0 {
+		spec.Verb, rest = rest[0], ""
+	}
+
+	if rest != "" {
+		return spec, fmt.Errorf("invalid format spec %q, expected [<>][0][width][.precision][verb]", s)
+	}
+
+	return spec, nil
+}
+
+// checkFormatSpec reports whether spec can format a value of type typ.
+func checkFormatSpec(spec ast.FormatSpec, typ types.Type) error {
+	switch spec.Verb {
+	case 'd', 'b', 'o', 'x', 'X':
+		if !types.IsFixed(typ) {
+			return fmt.Errorf("format verb %c requires an integer, got %q", spec.Verb, typ)
+		}
+	case 'e', 'E', 'f', 'g', 'G':
+		if !types.IsFloat(typ) {
+			return fmt.Errorf("format verb %c requires a float, got %q", spec.Verb, typ)
+		}
+	case 'q':
+		if !types.IsString(typ) {
+			return fmt.Errorf("format verb q requires a string, got %q", typ)
+		}
+	}
+
+	if spec.Precision >= 0 && !types.IsFloat(typ) {
+		return fmt.Errorf("format precision requires a float, got %q", typ)
+	}
+
+	if spec.Zero && !types.IsReal(typ) {
+		return fmt.Errorf("zero padding requires a number, got %q", typ)
+	}
+
+	return nil
+}
@@ -43,6 +43,9 @@ func (p *Parser) FindGlobals(ctx context.Context) {
 	// Pre-register all type names so forward references can be resolved.
 	p.preRegisterTypeNames(ctx)
 
+	p.scanning = true
+	defer func() { p.scanning = false }()
+
 tokenLoop:
 	for p.this().Type != tokens.EOF {
 		exported := false
@@ -62,10 +65,17 @@ tokenLoop:
 
 		qualifier := ast.QualifierImmutable
 
+		// A method with a var reference receiver can change its receiver.
+		mutable := false
+
 		switch p.this().Type {
 		case tokens.BitAnd:
 			// Reference receiver method.
 			p.advance("findGlobals &") // consume &
+		case tokens.Comp:
+			qualifier = ast.QualifierCompileTime
+
+			p.advance("findGlobals comp") // consume comp
 		case tokens.Dynamic:
 			qualifier = ast.QualifierDynamic
 
@@ -74,7 +84,8 @@ tokenLoop:
 			// Receiver variable: (f : Type) or (var f : &Type)
 			p.advance("findGlobals (") // consume (
 
-			if p.this().Type == tokens.Variable {
+			variable := p.this().Type == tokens.Variable
+			if variable {
 				p.advance("findGlobals var") // consume var
 			}
 
@@ -85,6 +96,7 @@ tokenLoop:
 			}
 
 			if p.this().Type == tokens.BitAnd {
+				mutable = variable
 				p.advance("findGlobals &") // consume &
 			}
 		case tokens.Variable:
@@ -95,11 +107,7 @@ tokenLoop:
 
 		switch p.this().Type {
 		case tokens.GoImport:
-			p.advance("findGlobals goimport") // consume goimport
-
-			if p.this().Type == tokens.LParen {
-				p.skipGrouped(ctx)
-			}
+			p.parseGoImport() // load Go packages so @go types resolve during the scan
 		case tokens.Import:
 			p.parseImport() // process imports during global scan
 		case tokens.Identifier:
@@ -107,7 +115,7 @@ tokenLoop:
 			case tokens.Colon, tokens.Declaration:
 				p.findGlobalDecl(ctx, exported, qualifier)
 			case tokens.Dot, tokens.RParen:
-				p.findGlobalMethod(ctx, exported)
+				p.findGlobalMethod(ctx, exported, mutable)
 			case tokens.Tilde:
 				p.findGlobalType(ctx, exported)
 			case tokens.LT:
@@ -154,11 +162,7 @@ func (p *Parser) findScriptImports(ctx context.Context) {
 		case tokens.Import:
 			p.parseImport()
 		case tokens.GoImport:
-			p.advance("findScriptImports goimport") // consume goimport
-
-			if p.this().Type == tokens.LParen {
-				p.skipGrouped(ctx)
-			}
+			p.parseGoImport()
 		default:
 			p.advance("findScriptImports")
 		}
@@ -238,7 +242,7 @@ func (p *Parser) findGlobalDecl(ctx context.Context, exported bool, qualifier as
 	_, ok := p.symbols.Resolve(p.this().Literal)
 	if ok {
 		// Report redeclare error and advance past the identifier to avoid an infinite loop
-		p.error(p.this(), "cannot redeclare variable", "findGlobalDecl")
+		p.redeclared(p.this(), "variable", "findGlobalDecl")
 		p.advance("findGlobalDecl redeclare") // consume identifier to make progress
 
 		return
@@ -336,7 +340,7 @@ func (p *Parser) findGlobalType(ctx context.Context, exported bool) {
 			preRegistered = true
 			ident = existing.Identifier
 		} else {
-			p.error(p.this(), "cannot redeclare type", "findGlobalType")
+			p.redeclared(p.this(), "type", "findGlobalType")
 			return
 		}
 	}
@@ -550,7 +554,7 @@ func (p *Parser) skipGrouped(ctx context.Context) {
 	}
 }
 
-func (p *Parser) findGlobalMethod(ctx context.Context, exported bool) {
+func (p *Parser) findGlobalMethod(ctx context.Context, exported, mutable bool) {
 	// Parse method declaration: Type.Method : proc() = ...
 	// Current token is the receiver type name.
 	receiverName := p.this().Literal
@@ -620,6 +624,7 @@ func (p *Parser) findGlobalMethod(ctx context.Context, exported bool) {
 		method := &types.Method{
 			Name:      methodName,
 			Procedure: procType,
+			Mutable:   mutable,
 		}
 
 		switch v := sym.Identifier.ValueType.(type) {
@@ -1,7 +1,21 @@
 package parser
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"go/constant"
+	"go/importer"
+	"go/token"
+	gotypes "go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/tokens"
@@ -29,8 +43,15 @@ func (p *Parser) parseGoImport() *ast.GoImport {
 			return nil
 		}
 
-		_, ok := p.symbols.ResolveGoImport(p.this().Literal)
+		existing, ok := p.symbols.ResolveGoImport(path.Base(p.this().Literal))
 		if ok {
+			if existing.Token.FileID == p.this().FileID &&
+				existing.Token.Ln == p.this().Ln && existing.Token.Col == p.this().Col {
+				// Already registered during FindGlobals; just record in the AST node.
+				node.Imports = append(node.Imports, existing)
+				continue
+			}
+
 			p.error(p.this(), "cannot redeclare Go imports", "parseGoImport")
 			return nil
 		}
@@ -40,8 +61,15 @@ func (p *Parser) parseGoImport() *ast.GoImport {
 			Name:  p.this().Literal,
 		}
 
+		pkg, err := loadGoPackage(filepath.Dir(p.filePath), ident.Name)
+		if err != nil {
+			p.error(p.this(), fmt.Sprintf("unable to load Go package %q: %v", ident.Name, err), "parseGoImport")
+			return nil
+		}
+
 		node.Imports = append(node.Imports, ident)
 		p.symbols.DefineGoImport(ident)
+		p.symbols.DefineGoPackage(path.Base(ident.Name), pkg)
 	}
 
 	p.advance("parseGoImport )") // consume ')'
@@ -49,69 +77,663 @@ func (p *Parser) parseGoImport() *ast.GoImport {
 	return node
 }
 
-func (p *Parser) parseGoCallExpression(ctx context.Context) *ast.GoCallExpression {
-	node := &ast.GoCallExpression{
-		Token: p.this(),
-	}
-
-	p.advance("parseGoCallExpression @go") // consume @go
+// parseGoSelector parses a `@go.pkg.Name` reference and returns the Go import
+// identifier together with the referenced Go object.
+func (p *Parser) parseGoSelector() (*ast.Identifier, gotypes.Object) {
+	p.advance("parseGoSelector @go") // consume @go
 
 	if p.this().Type != tokens.Dot {
-		p.error(p.this(), "expected '.' after @go", "parseGoCallExpression")
-		return nil
+		p.error(p.this(), "expected '.' after @go", "parseGoSelector")
+		return nil, nil
 	}
 
-	p.advance("parseGoCallExpression .") // consume .
+	p.advance("parseGoSelector .") // consume .
 
 	if p.this().Type != tokens.Identifier {
-		p.error(p.this(), "expected identifier after '.' in @go call", "parseGoCallExpression")
-		return nil
+		p.error(p.this(), "expected identifier after '.' in @go selector", "parseGoSelector")
+		return nil, nil
 	}
 
 	_, ok := p.symbols.ResolveGoImport(p.this().Literal)
 	if !ok {
-		p.error(p.this(), "undefined Go import", "parseGoCallExpression")
+		p.error(p.this(), "undefined Go import", "parseGoSelector")
+		return nil, nil
 	}
 
-	node.Import = &ast.Identifier{
+	pkg, ok := p.symbols.ResolveGoPackage(p.this().Literal)
+	if !ok {
+		p.error(p.this(), "Go import has no type information", "parseGoSelector")
+		return nil, nil
+	}
+
+	imp := &ast.Identifier{
 		Token: p.this(),
 		Name:  p.this().Literal,
 	}
 
-	p.advance("parseGoCallExpression import") // consume import identifier
+	p.advance("parseGoSelector import") // consume import identifier
 
 	if p.this().Type != tokens.Dot {
-		p.error(p.this(), "expected '.' after Go import", "parseGoCallExpression")
-		return nil
+		p.error(p.this(), "expected '.' after Go import", "parseGoSelector")
+		return nil, nil
 	}
 
-	p.advance("parseGoCallExpression import .") // consume .
+	p.advance("parseGoSelector import .") // consume .
 
 	if p.this().Type != tokens.Identifier {
-		p.error(p.this(), "expected call after '.' in Go import", "parseGoCallExpression")
+		p.error(p.this(), "expected identifier after '.' in Go import", "parseGoSelector")
+		return nil, nil
+	}
+
+	obj := pkg.Scope().Lookup(p.this().Literal)
+	if obj == nil || !obj.Exported() {
+		p.error(p.this(), fmt.Sprintf("undefined: %s.%s", imp.Name, p.this().Literal), "parseGoSelector")
+		return nil, nil
+	}
+
+	return imp, obj
+}
+
+// parseGoExpression parses a Go function call, constant or variable.
+func (p *Parser) parseGoExpression(ctx context.Context) ast.Expression {
+	t := p.this()
+
+	imp, obj := p.parseGoSelector()
+	if obj == nil {
+		return nil
+	}
+
+	switch obj := obj.(type) {
+	case *gotypes.Func:
+		call := p.parseGoCallExpression(ctx, t, imp, obj)
+		if call == nil {
+			return nil
+		}
+
+		return call
+	case *gotypes.Const, *gotypes.Var:
+		value := p.parseGoValue(t, imp, obj)
+		if value == nil {
+			return nil
+		}
+
+		return value
+	default:
+		p.error(p.this(), fmt.Sprintf("%s.%s is not an expression", imp.Name, obj.Name()), "parseGoExpression")
 		return nil
 	}
+}
 
-	callIdent := &ast.Identifier{
-		Token:     p.this(),
-		Name:      p.this().Literal,
-		ValueType: types.None, // TODO: figure out how to deal with Go types and type conversion
+func (p *Parser) parseGoValue(t tokens.Token, imp *ast.Identifier, obj gotypes.Object) *ast.GoValue {
+	valueType, err := goValueType(obj)
+	if err != nil {
+		p.error(p.this(), fmt.Sprintf("cannot use %s.%s: %v", imp.Name, obj.Name(), err), "parseGoValue")
+		return nil
 	}
 
-	p.advance("parseGoCallExpression import call") // consume call identifier
+	node := &ast.GoValue{
+		Token:  t,
+		Import: imp,
+		Identifier: &ast.Identifier{
+			Token:     p.this(),
+			Name:      obj.Name(),
+			ValueType: valueType,
+			Qualifier: ast.QualifierVariable,
+		},
+		GoType: obj.Type(),
+	}
 
-	// TODO: also support imported variables / constants
-	if p.this().Type != tokens.LParen {
-		p.error(p.this(), "expected '(' after call in Go import", "parseGoCallExpression")
+	if _, ok := obj.(*gotypes.Const); ok {
+		node.Identifier.Qualifier = ast.QualifierImmutable
+	}
+
+	p.advance("parseGoValue identifier") // consume identifier
+
+	if p.this().Type == tokens.LParen {
+		p.error(p.this(), fmt.Sprintf("%s.%s is not a function", imp.Name, obj.Name()), "parseGoValue")
 		return nil
 	}
 
-	node.CallIdentifier = callIdent
-	node.Arguments = p.parseCallArguments(ctx, nil)
+	return node
+}
+
+// goValueType returns the Cog type of a Go constant or variable. Untyped
+// integer constants which overflow int64 are typed as uint64.
+func goValueType(obj gotypes.Object) (types.Type, error) {
+	if c, ok := obj.(*gotypes.Const); ok {
+		if basic, ok := c.Type().(*gotypes.Basic); ok && basic.Kind() == gotypes.UntypedInt {
+			if _, exact := constant.Int64Val(c.Val()); !exact {
+				if _, exact := constant.Uint64Val(c.Val()); !exact {
+					return nil, fmt.Errorf("constant %s overflows uint64", c.Val())
+				}
+
+				return types.Basics[types.Uint64], nil
+			}
+		}
+	}
+
+	return types.FromGo(obj.Type())
+}
+
+// parseGoType parses a `@go.pkg.Type` type annotation.
+func (p *Parser) parseGoType() types.Type {
+	imp, obj := p.parseGoSelector()
+	if obj == nil {
+		return nil
+	}
 
+	typeName, ok := obj.(*gotypes.TypeName)
 	if !ok {
+		p.error(p.this(), fmt.Sprintf("%s.%s is not a type", imp.Name, obj.Name()), "parseGoType")
+		return nil
+	}
+
+	typ, err := types.FromGo(typeName.Type())
+	if err != nil {
+		p.error(p.this(), fmt.Sprintf("cannot use %s.%s: %v", imp.Name, obj.Name(), err), "parseGoType")
+		return nil
+	}
+
+	p.advance("parseGoType identifier") // consume identifier
+
+	return typ
+}
+
+func (p *Parser) parseGoCallExpression(
+	ctx context.Context,
+	t tokens.Token,
+	imp *ast.Identifier,
+	fn *gotypes.Func,
+) *ast.GoCallExpression {
+	node := &ast.GoCallExpression{
+		Token:  t,
+		Import: imp,
+		Path:   fn.Pkg().Path(),
+	}
+
+	node.CallIdentifier = &ast.Identifier{
+		Token: p.this(),
+		Name:  p.this().Literal,
+	}
+
+	p.advance("parseGoCallExpression call") // consume call identifier
+
+	if p.this().Type != tokens.LParen {
+		p.error(p.this(), "expected '(' after Go function", "parseGoCallExpression")
+		return nil
+	}
+
+	if !p.parseGoCallArguments(ctx, node, fn.Signature()) {
 		return nil
 	}
 
 	return node
 }
+
+// instantiateGoCall instantiates the generic signature sig with the type
+// arguments inferred from args. Type parameters are first inferred from the
+// arguments which are not literals, then from the core types of their
+// constraints, and last from literals, which Go gives their default type.
+// The number of arguments is checked first, as they are unified with the
+// parameters one by one.
+func instantiateGoCall(sig *gotypes.Signature, args []ast.Expression) (*gotypes.Signature, error) {
+	params := sig.Params().Len()
+
+	minArgs := params
+	if sig.Variadic() {
+		minArgs--
+	}
+
+	switch {
+	case len(args) < minArgs:
+		return nil, fmt.Errorf("not enough arguments: have %d, want %d", len(args), minArgs)
+	case !sig.Variadic() && len(args) > params:
+		return nil, fmt.Errorf("too many arguments: have %d, want %d", len(args), params)
+	}
+
+	inferred := make(map[*gotypes.TypeParam]gotypes.Type)
+
+	unifyArgs := func(literals bool) {
+		for i, arg := range args {
+			if ast.IsGoLiteral(arg) != literals {
+				continue
+			}
+
+			goArgType, err := types.ToGo(arg.Type())
+			if err != nil {
+				continue
+			}
+
+			unifyGo(goParam(sig, i), goArgType, inferred)
+		}
+	}
+
+	unifyArgs(false)
+
+	for range sig.TypeParams().Len() {
+		for tparam := range sig.TypeParams().TypeParams() {
+			if bound, ok := inferred[tparam]; ok {
+				if core := coreType(tparam); core != nil {
+					unifyGo(core, bound.Underlying(), inferred)
+				}
+			}
+		}
+	}
+
+	unifyArgs(true)
+
+	targs := make([]gotypes.Type, sig.TypeParams().Len())
+
+	for i := range targs {
+		tparam := sig.TypeParams().At(i)
+
+		targ, ok := inferred[tparam]
+		if !ok {
+			return nil, fmt.Errorf("cannot infer %s", tparam)
+		}
+
+		targs[i] = targ
+	}
+
+	inst, err := gotypes.Instantiate(nil, sig, targs, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return inst.(*gotypes.Signature), nil
+}
+
+// unifyGo infers the type parameters in param of which the type is given by
+// arg. Type parameters which are already inferred are kept.
+func unifyGo(param, arg gotypes.Type, inferred map[*gotypes.TypeParam]gotypes.Type) {
+	switch param := param.(type) {
+	case *gotypes.TypeParam:
+		if _, ok := inferred[param]; !ok {
+			inferred[param] = arg
+		}
+	case *gotypes.Slice:
+		if arg, ok := arg.Underlying().(*gotypes.Slice); ok {
+			unifyGo(param.Elem(), arg.Elem(), inferred)
+		}
+	case *gotypes.Array:
+		if arg, ok := arg.Underlying().(*gotypes.Array); ok {
+			unifyGo(param.Elem(), arg.Elem(), inferred)
+		}
+	case *gotypes.Map:
+		if arg, ok := arg.Underlying().(*gotypes.Map); ok {
+			unifyGo(param.Key(), arg.Key(), inferred)
+			unifyGo(param.Elem(), arg.Elem(), inferred)
+		}
+	case *gotypes.Pointer:
+		if arg, ok := arg.Underlying().(*gotypes.Pointer); ok {
+			unifyGo(param.Elem(), arg.Elem(), inferred)
+		}
+	}
+}
+
+// coreType returns the single type of which the constraint of tparam allows
+// the types with that underlying type, such as []E of S ~[]E, or nil.
+func coreType(tparam *gotypes.TypeParam) gotypes.Type {
+	iface, ok := tparam.Constraint().Underlying().(*gotypes.Interface)
+	if !ok || iface.NumEmbeddeds() != 1 {
+		return nil
+	}
+
+	union, ok := iface.EmbeddedType(0).(*gotypes.Union)
+	if !ok || union.Len() != 1 {
+		return nil
+	}
+
+	return union.Term(0).Type()
+}
+
+// goParam returns the Go type of the parameter of sig given argument i,
+// which is the element type for variadic arguments.
+func goParam(sig *gotypes.Signature, i int) gotypes.Type {
+	params := sig.Params()
+
+	if sig.Variadic() && i >= params.Len()-1 {
+		return params.At(params.Len() - 1).Type().(*gotypes.Slice).Elem()
+	}
+
+	return params.At(min(i, params.Len()-1)).Type()
+}
+
+// adaptGoValue retypes a Go value to the expected Cog result type when the
+// transpiler can adapt it, e.g. a Go string used as ascii ! error. The adapter
+// fails if the value does not fit, so the value is only adapted to a result.
+// It reports whether the value can be used as the expected type.
+func (p *Parser) adaptGoValue(expr ast.Expression, expected types.Type) bool {
+	if types.IsNone(expected) {
+		return true
+	}
+
+	var (
+		goType gotypes.Type
+		token  tokens.Token
+	)
+
+	switch n := expr.(type) {
+	case *ast.GoCallExpression:
+		if n.Signature.Results().Len() != 1 {
+			return true
+		}
+
+		goType, token = n.Signature.Results().At(0).Type(), n.CallIdentifier.Token
+	case *ast.GoValue:
+		goType, token = n.GoType, n.Identifier.Token
+	default:
+		return true
+	}
+
+	result, ok := expected.Underlying().(*types.Result)
+	if !ok || result.Error != types.GoError || !types.AdaptableFromGo(goType, result.Value) {
+		if types.AdaptableFromGo(goType, expected) {
+			p.error(token, fmt.Sprintf("Go value of type %q may not fit %q, use %s ! error", goType, expected, expected), "adaptGoValue")
+			return false
+		}
+
+		return true
+	}
+
+	switch n := expr.(type) {
+	case *ast.GoCallExpression:
+		n.ReturnType = expected
+	case *ast.GoValue:
+		n.Identifier.ValueType = expected
+	}
+
+	return true
+}
+
+// checkGoCallValue reports whether the result of a Go call can be used as a
+// single Cog value.
+func (p *Parser) checkGoCallValue(call *ast.GoCallExpression) bool {
+	name := call.Import.Name + "." + call.CallIdentifier.Name
+
+	switch {
+	case call.ReturnType == nil:
+		p.error(call.CallIdentifier.Token, fmt.Sprintf("%s (no value) used as value", name), "checkGoCallValue")
+		return false
+	case call.ReturnType.Kind() == types.TupleKind:
+		p.error(call.CallIdentifier.Token, fmt.Sprintf("multiple-value %s in single-value context", name), "checkGoCallValue")
+		return false
+	case types.IsNone(call.ReturnType):
+		p.error(call.CallIdentifier.Token, fmt.Sprintf("unsupported Go type %q returned by %s", call.Signature.Results(), name), "checkGoCallValue")
+		return false
+	}
+
+	return true
+}
+
+// parseGoCallArguments parses the arguments of a Go call and checks them
+// against the Go signature of the callee. The type arguments of a generic
+// callee are inferred from the arguments, so these are parsed without an
+// expected type, after which literals are given the type of their parameter.
+func (p *Parser) parseGoCallArguments(ctx context.Context, node *ast.GoCallExpression, sig *gotypes.Signature) bool {
+	callIdent := node.CallIdentifier
+	name := node.Import.Name + "." + callIdent.Name
+	generic := sig.TypeParams().Len() > 0
+
+	var procType *types.Procedure
+
+	if !generic {
+		var err error
+
+		procType, err = types.FromGoSignature(sig)
+		if err != nil {
+			p.error(callIdent.Token, fmt.Sprintf("cannot call %s: %v", name, err), "parseGoCallArguments")
+			return false
+		}
+	}
+
+	p.advance("parseGoCallArguments (") // consume '('
+
+	args := []ast.Expression{}
+	starts := []int{} // token index of each argument
+
+	for i := 0; p.this().Type != tokens.RParen && p.this().Type != tokens.EOF; i++ {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		var expectedType types.Type = types.None
+
+		if !generic {
+			paramIndex := i
+			if sig.Variadic() && paramIndex >= len(procType.Parameters)-1 {
+				paramIndex = len(procType.Parameters) - 1
+			}
+
+			if paramIndex >= len(procType.Parameters) {
+				p.error(p.this(), fmt.Sprintf("too many arguments in call to %s", callIdent.Name), "parseGoCallArguments")
+				return false
+			}
+
+			paramType := goParamType(procType, sig, i)
+
+			// Let the argument infer its own type, adapters are inserted by the
+			// transpiler where the Go parameter type differs.
+			if paramType.Kind() != types.AnyKind && p.this().Type != tokens.Identifier {
+				expectedType = paramType
+			}
+		}
+
+		starts = append(starts, p.i)
+
+		arg := p.expression(ctx, expectedType)
+		if arg == nil {
+			return false
+		}
+
+		args = append(args, arg)
+
+		if p.this().Type == tokens.Comma {
+			p.advance("parseGoCallArguments ,") // consume ','
+		}
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after function call arguments", "parseGoCallArguments")
+		return false
+	}
+
+	if generic {
+		inst, err := instantiateGoCall(sig, args)
+		if err != nil {
+			p.error(callIdent.Token, fmt.Sprintf("cannot call %s: %v", name, err), "parseGoCallArguments")
+			return false
+		}
+
+		procType, err = types.FromGoSignature(inst)
+		if err != nil {
+			p.error(callIdent.Token, fmt.Sprintf("cannot call %s: %v", name, err), "parseGoCallArguments")
+			return false
+		}
+
+		sig = inst
+	}
+
+	minArgs := len(procType.Parameters)
+	if sig.Variadic() {
+		minArgs--
+	}
+
+	if len(args) < minArgs {
+		p.error(p.this(), fmt.Sprintf("not enough arguments in call to %s: have %d, want %d", callIdent.Name, len(args), minArgs), "parseGoCallArguments")
+		return false
+	}
+
+	for i := range args {
+		paramType := goParamType(procType, sig, i)
+
+		if generic && ast.IsGoLiteral(args[i]) {
+			// Literals were parsed with their default type, as Go gives untyped
+			// constants, so they are parsed again as the instantiated type.
+			if args[i] = p.retypeGoLiteral(starts[i], args[i], paramType); args[i] == nil {
+				return false
+			}
+		}
+
+		if !p.goAssignable(args[i], paramType, goParam(sig, i)) {
+			p.error(p.tokens[starts[i]], fmt.Sprintf("cannot use %q as %q in argument to %s", args[i].Type(), goParam(sig, i), callIdent.Name), "parseGoCallArguments")
+			return false
+		}
+	}
+
+	p.advance("parseGoCallArguments )") // consume ')'
+
+	callIdent.ValueType = procType
+	node.Arguments = args
+	node.ReturnType = procType.ReturnType
+	node.Signature = sig
+
+	return true
+}
+
+// goParamType returns the Cog type of the parameter of procType, the Cog view
+// of sig, given argument i, which is the element type for variadic arguments.
+func goParamType(procType *types.Procedure, sig *gotypes.Signature, i int) types.Type {
+	params := procType.Parameters
+	paramType := params[min(i, len(params)-1)].Type
+
+	if sig.Variadic() && i >= len(params)-1 {
+		// Variadic arguments are passed one by one.
+		paramType = paramType.(*types.Slice).Element
+	}
+
+	return paramType
+}
+
+// retypeGoLiteral parses the literal arg at token index start again as typ, if
+// it is a basic type other than that of arg.
+func (p *Parser) retypeGoLiteral(start int, arg ast.Expression, typ types.Type) ast.Expression {
+	if _, ok := typ.Underlying().(*types.Basic); !ok || types.Equal(arg.Type(), typ) {
+		return arg
+	}
+
+	i := p.i
+	p.i = start
+
+	defer func() { p.i = i }()
+
+	return p.parseLiteral(typ)
+}
+
+// goAssignable reports whether a Cog argument can be passed to a Go parameter.
+// Literals are emitted as untyped Go constants, so they only need to match the
+// Cog view of the parameter; other values must be assignable in Go or have an
+// adapter.
+func (p *Parser) goAssignable(arg ast.Expression, paramType types.Type, goParamType gotypes.Type) bool {
+	if ast.IsGoLiteral(arg) {
+		return types.AssignableTo(arg.Type(), paramType)
+	}
+
+	return types.AdaptableToGo(checkedValueType(arg), goParamType)
+}
+
+// checkedValueType returns the type of arg, or the value type if arg is a
+// result identifier, which denotes its value once checked.
+func checkedValueType(arg ast.Expression) types.Type {
+	if _, ok := arg.(*ast.Identifier); ok {
+		if result, ok := arg.Type().Underlying().(*types.Result); ok {
+			return result.Value
+		}
+	}
+
+	return arg.Type()
+}
+
+// goPackages caches the type information of Go packages, per directory from
+// which they are loaded, as the module of that directory selects the versions
+// of the packages outside the standard library.
+var goPackages = struct {
+	sync.Mutex
+
+	dirs map[string]*goLoader
+}{
+	dirs: make(map[string]*goLoader),
+}
+
+// goLoader loads Go packages as seen from the module of a directory.
+type goLoader struct {
+	dir      string
+	importer gotypes.Importer
+	exports  map[string]string // export data file by import path
+	pkgs     map[string]*gotypes.Package
+}
+
+// loadGoPackage loads the type information of a Go package from its export
+// data, as built by `go list -export` for the module of dir.
+func loadGoPackage(dir, path string) (*gotypes.Package, error) {
+	goPackages.Lock()
+	defer goPackages.Unlock()
+
+	l, ok := goPackages.dirs[dir]
+	if !ok {
+		l = &goLoader{
+			dir:     dir,
+			exports: make(map[string]string),
+			pkgs:    make(map[string]*gotypes.Package),
+		}
+		l.importer = importer.ForCompiler(token.NewFileSet(), "gc", l.lookup)
+		goPackages.dirs[dir] = l
+	}
+
+	if pkg, ok := l.pkgs[path]; ok {
+		return pkg, nil
+	}
+
+	if _, ok := l.exports[path]; !ok {
+		if err := l.list(path); err != nil {
+			return nil, err
+		}
+	}
+
+	pkg, err := l.importer.Import(path)
+	if err != nil {
+		return nil, err
+	}
+
+	l.pkgs[path] = pkg
+
+	return pkg, nil
+}
+
+// list records the export data files of the package at path and of its
+// dependencies, which `go list` builds if needed.
+func (l *goLoader) list(path string) error {
+	cmd := exec.Command("go", "list", "-export", "-deps", "-f", "{{.ImportPath}}\t{{.Export}}", "--", path)
+	cmd.Dir = l.dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.New(msg)
+		}
+
+		return err
+	}
+
+	for line := range strings.Lines(string(out)) {
+		importPath, export, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if export != "" {
+			l.exports[importPath] = export
+		}
+	}
+
+	return nil
+}
+
+// lookup opens the export data of the package at path for the importer.
+func (l *goLoader) lookup(path string) (io.ReadCloser, error) {
+	export, ok := l.exports[path]
+	if !ok {
+		return nil, fmt.Errorf("no export data for %q", path)
+	}
+
+	return os.Open(export)
+}
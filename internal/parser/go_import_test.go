@@ -1,6 +1,11 @@
 package parser_test
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/types"
+)
 
 func TestParseGoImport(t *testing.T) {
 	t.Parallel()
@@ -17,6 +22,52 @@ main : proc() = {}`)
 			t.Fatal("expected at least goimport + main")
 		}
 	})
+
+	t.Run("module_package", func(t *testing.T) {
+		t.Parallel()
+
+		// Packages outside the standard library are loaded from the module of
+		// the source, here the Cog module, which requires golang.org/x/mod.
+		f := parse(t, `package p
+goimport (
+	"golang.org/x/mod/semver"
+)
+main : proc() = {
+	ok := @go.semver.IsValid("v1.2.3")
+	@print(ok)
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		decl, ok := body.Statements[0].(*ast.Declaration)
+		if !ok {
+			t.Fatalf("expected declaration, got %T", body.Statements[0])
+		}
+
+		if got := decl.Assignment.Identifier.ValueType; got != types.Basics[types.Bool] {
+			t.Errorf("expected bool, got %s", got)
+		}
+	})
+
+	t.Run("duplicate_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+	"strings"
+)
+main : proc() = {}`)
+	})
+
+	t.Run("unknown_package_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"does/not/exist"
+)
+main : proc() = {}`)
+	})
 }
 
 func TestParseGoCallExpression(t *testing.T) {
@@ -38,6 +89,204 @@ main : proc() = {
 		}
 	})
 
+	t.Run("return_type", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	x := @go.strings.Repeat("ab", 3) + "c"
+	n : int64 = @go.strings.Count(x, "a")
+	@print(n)
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		decl, ok := body.Statements[0].(*ast.Declaration)
+		if !ok {
+			t.Fatalf("expected declaration, got %T", body.Statements[0])
+		}
+
+		if decl.Assignment.Identifier.ValueType != types.Basics[types.UTF8] {
+			t.Errorf("expected utf8, got %s", decl.Assignment.Identifier.ValueType)
+		}
+	})
+
+	t.Run("variadic", func(t *testing.T) {
+		t.Parallel()
+		parse(t, `package p
+goimport (
+	"path"
+)
+main : proc() = {
+	x := @go.path.Join("a", "b", "c")
+	@print(x)
+}`)
+	})
+
+	t.Run("generic", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"slices"
+)
+main : proc() = {
+	xs : []int64 = {3, 1, 2}
+	found := @go.slices.Contains(xs, 1)
+	i := @go.slices.Index(xs, 2)
+	@print(found, i)
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		for i, want := range []types.Type{types.Basics[types.Bool], types.Basics[types.Int64]} {
+			decl, ok := body.Statements[i+1].(*ast.Declaration)
+			if !ok {
+				t.Fatalf("expected declaration, got %T", body.Statements[i+1])
+			}
+
+			if decl.Assignment.Identifier.ValueType != want {
+				t.Errorf("expected %s, got %s", want, decl.Assignment.Identifier.ValueType)
+			}
+		}
+	})
+
+	t.Run("generic_literal", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"slices"
+)
+main : proc() = {
+	xs : []int8 = {3, 1, 2}
+	found := @go.slices.Contains(xs, 1)
+	@print(found)
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		decl, ok := body.Statements[1].(*ast.Declaration)
+		if !ok {
+			t.Fatalf("expected declaration, got %T", body.Statements[1])
+		}
+
+		call := decl.Assignment.Expression.(*ast.GoCallExpression)
+		if _, ok := call.Arguments[1].(*ast.Int8Literal); !ok {
+			t.Errorf("expected int8 literal argument, got %T", call.Arguments[1])
+		}
+	})
+
+	t.Run("generic_literal_overflow_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"slices"
+)
+main : proc() = {
+	xs : []int8 = {3, 1, 2}
+	found := @go.slices.Contains(xs, 300)
+}`)
+	})
+
+	t.Run("generic_too_many_arguments_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"reflect"
+)
+main : proc() = {
+	x := @go.reflect.TypeFor(1)
+}`)
+	})
+
+	t.Run("generic_not_enough_arguments_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"slices"
+)
+main : proc() = {
+	xs : []int64 = {3, 1, 2}
+	found := @go.slices.Contains(xs)
+}`)
+	})
+
+	t.Run("generic_argument_type_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"slices"
+)
+main : proc() = {
+	xs : []int64 = {3, 1, 2}
+	found := @go.slices.Contains(xs, "a")
+}`)
+	})
+
+	t.Run("undefined_function_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	x := @go.strings.Upper("hello")
+}`)
+	})
+
+	t.Run("not_enough_arguments_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	x := @go.strings.Repeat("ab")
+}`)
+	})
+
+	t.Run("too_many_arguments_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	x := @go.strings.ToUpper("a", "b")
+}`)
+	})
+
+	t.Run("argument_type_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	n : int64 = 3
+	x := @go.strings.ToUpper(n)
+}`)
+	})
+
+	t.Run("go_int_argument_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	n : int32 = 3
+	x := @go.strings.Repeat("ab", n)
+}`)
+	})
+
 	t.Run("undefined_import_error", func(t *testing.T) {
 		t.Parallel()
 		parseShouldError(t, `package p
@@ -46,3 +295,230 @@ main : proc() = {
 }`)
 	})
 }
+
+func TestParseGoValue(t *testing.T) {
+	t.Parallel()
+
+	t.Run("constants_and_variables", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"math"
+	"os"
+)
+main : proc() = {
+	a := @go.math.MaxInt64
+	b := @go.math.MaxUint64
+	c := @go.math.Pi
+	d := @go.os.Args
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		want := []string{"int64", "uint64", "float64", "[]utf8"}
+		for i, w := range want {
+			decl := body.Statements[i].(*ast.Declaration)
+			if got := decl.Assignment.Identifier.ValueType.String(); got != w {
+				t.Errorf("statement %d: expected %s, got %s", i, w, got)
+			}
+		}
+	})
+
+	t.Run("type_is_not_a_value_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"bytes"
+)
+main : proc() = {
+	x := @go.bytes.Buffer
+}`)
+	})
+
+	t.Run("value_is_not_callable_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"math"
+)
+main : proc() = {
+	x := @go.math.Pi()
+}`)
+	})
+
+	t.Run("no_value_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"time"
+)
+main : proc() = {
+	x := @go.time.Sleep(1)
+}`)
+	})
+
+	t.Run("multiple_value_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	x := @go.strings.Cut("a=b", "=")
+}`)
+	})
+
+	t.Run("call_statement", func(t *testing.T) {
+		t.Parallel()
+		parse(t, `package p
+goimport (
+	"fmt"
+)
+main : proc() = {
+	@go.fmt.Println("hello")
+}`)
+	})
+}
+
+func TestParseGoType(t *testing.T) {
+	t.Parallel()
+
+	t.Run("opaque", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"bytes"
+)
+write : proc(buf : &@go.bytes.Buffer) = {}
+main : proc() = {}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 1)
+		proc := decl.Assignment.Identifier.ValueType.(*types.Procedure)
+
+		ref, ok := proc.Parameters[0].Type.(*types.Reference)
+		if !ok || ref.Value.Kind() != types.GoKind {
+			t.Errorf("expected reference to Go type, got %s", proc.Parameters[0].Type)
+		}
+	})
+
+	t.Run("basic_underlying", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"time"
+)
+main : proc() = {
+	var d : @go.time.Duration = 5
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		decl := body.Statements[0].(*ast.Declaration)
+		if decl.Assignment.Identifier.ValueType != types.Basics[types.Int64] {
+			t.Errorf("expected int64, got %s", decl.Assignment.Identifier.ValueType)
+		}
+	})
+
+	t.Run("not_a_type_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+x : @go.strings.ToUpper
+main : proc() = {}`)
+	})
+}
+
+func TestParseGoAdapters(t *testing.T) {
+	t.Parallel()
+
+	t.Run("result_types", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+goimport (
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+main : proc() = {
+	a := @go.strconv.Atoi("1")
+	b := @go.os.Remove("file")
+	c := @go.big.NewInt(1)
+	d : ascii ! error = @go.strings.ToUpper("a")
+	e : int128 ! error = @go.big.NewInt(1)
+	f : uint128 ! error = @go.big.NewInt(1)
+}`)
+
+		main := stmtAs[*ast.Declaration](t, f, 1)
+		body := main.Assignment.Expression.(*ast.ProcedureLiteral).Body
+
+		want := []string{"int64 ! error", "error?", "&@go.big.Int?", "ascii ! error", "int128 ! error", "uint128 ! error"}
+		for i, w := range want {
+			decl := body.Statements[i].(*ast.Declaration)
+			if got := decl.Assignment.Expression.Type().String(); got != w {
+				t.Errorf("statement %d: expected %s, got %s", i, w, got)
+			}
+		}
+	})
+
+	t.Run("adapted_arguments", func(t *testing.T) {
+		t.Parallel()
+		parse(t, `package p
+goimport (
+	"math/big"
+	"strings"
+)
+main : proc() = {
+	s : ascii = "abc"
+	n : int128 = 7
+	x := @go.strings.ToUpper(s)
+	y := @go.big.Jacobi(n, @go.big.NewInt(3))
+}`)
+	})
+
+	t.Run("checked_result_argument", func(t *testing.T) {
+		t.Parallel()
+		parse(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	s : ascii ! error = @go.strings.ToUpper("a")
+	if s? {
+		x := @go.strings.ToLower(s)
+	}
+}`)
+	})
+
+	t.Run("adapted_value_error", func(t *testing.T) {
+		t.Parallel()
+
+		for _, src := range []string{
+			`s : ascii = @go.strings.ToUpper("a")`,
+			`n : int128 = @go.big.NewInt(1)`,
+			`n : uint128 = @go.big.NewInt(1)`,
+		} {
+			parseShouldError(t, "package p\ngoimport (\n\t\"math/big\"\n\t\"strings\"\n)\nmain : proc() = {\n\t"+src+"\n}")
+		}
+	})
+
+	t.Run("unadaptable_argument_error", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+goimport (
+	"strings"
+)
+main : proc() = {
+	n : int32 = 2
+	x := @go.strings.Repeat("ab", n)
+}`)
+	})
+}
@@ -63,11 +63,12 @@ func (p *Parser) parseImport() *ast.Import {
 		node.Imports = append(node.Imports, ident)
 
 		// Register the import in the symbol table.
-		// Exports will be populated later by the driver (cmd/main.go)
+		// Exports will be populated later by the project loader
 		// after the imported package has been parsed.
 		p.symbols.DefineCogImport(&CogImport{
 			Path:    importPath,
 			Name:    pkgName,
+			Token:   p.this(),
 			Exports: make(map[string]Symbol),
 		})
 	}
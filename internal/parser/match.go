@@ -3,6 +3,7 @@ package parser
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/tokens"
@@ -38,18 +39,35 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 
 	isEither := subjectType.Kind() == types.EitherKind
 
-	var isGeneric bool
+	// The sum type of a match on its variants.
+	var sum *types.Union
 
-	if !isEither {
+	if subjectType.Kind() == types.UnionKind {
+		if u, ok := subjectType.Underlying().(*types.Union); ok && u.Name == "" {
+			sum = u
+		}
+	}
+
+	// The type parameter of a generic match, nil for an either or sum type
+	// match.
+	var param *types.Alias
+
+	if !isEither && sum == nil {
 		if tp, ok := subjectType.(*types.Alias); ok && tp.IsTypeParam() {
 			if tp.Constraint != nil && (tp.Constraint.Kind() == types.UnionKind || tp.Constraint.Kind() == types.AnyKind) {
-				isGeneric = true
+				param = tp
 			}
 		}
 	}
 
-	if !isEither && !isGeneric {
-		p.error(p.this(), fmt.Sprintf("match subject must be an either type or a generic type parameter bounded by a union or any, got %s", subjectType.String()), "parseMatch")
+	// A value of the type parameter is narrowed to the case type.
+	subject, _ := node.Subject.(*ast.Identifier)
+	if param == nil {
+		subject = nil
+	}
+
+	if !isEither && sum == nil && param == nil {
+		p.error(p.this(), fmt.Sprintf("match subject must be an either type, a sum type or a generic type parameter bounded by a union or any, got %s", subjectType.String()), "parseMatch")
 		return nil
 	}
 
@@ -81,6 +99,14 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 
 		caseNode.MatchType = caseType
 
+		if param != nil && !p.checkMatchCase(node, caseNode, param) {
+			return nil
+		}
+
+		if sum != nil && !p.checkVariantCase(node, caseNode, sum) {
+			return nil
+		}
+
 		if p.this().Type != tokens.Colon {
 			p.error(p.this(), "expected ':' after case type", "parseMatch")
 			return nil
@@ -91,8 +117,16 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 		p.symbols = NewEnclosedSymbolTable(p.symbols)
 
 		if node.Binding != nil {
-			node.Binding.ValueType = caseType
-			p.symbols.Define(node.Binding)
+			// Each case binds a value of its own type.
+			binding := *node.Binding
+			binding.ValueType = caseType
+			p.symbols.Define(&binding)
+		}
+
+		if subject != nil {
+			narrowed := *subject
+			narrowed.ValueType = caseType
+			p.symbols.Define(&narrowed)
 		}
 
 		for !p.match(tokens.Case, tokens.Default, tokens.RBrace, tokens.EOF) {
@@ -137,8 +171,19 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 
 		if node.Binding != nil {
 			// In default case, binding variable takes the original subject type
-			node.Binding.ValueType = subjectType
-			p.symbols.Define(node.Binding)
+			binding := *node.Binding
+			binding.ValueType = subjectType
+			p.symbols.Define(&binding)
+		}
+
+		if param != nil {
+			// The type is not narrowed, so values of the type parameter
+			// can be used as the constraint allows.
+			if p.matchDefaults == nil {
+				p.matchDefaults = make(map[string]int)
+			}
+
+			p.matchDefaults[param.Name]++
 		}
 
 		for !p.match(tokens.RBrace, tokens.EOF) {
@@ -162,6 +207,10 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 
 		p.symbols = p.symbols.Outer
 
+		if param != nil {
+			p.matchDefaults[param.Name]--
+		}
+
 		node.Default = defaultNode
 	}
 
@@ -172,5 +221,112 @@ func (p *Parser) parseMatch(ctx context.Context) *ast.Match {
 
 	p.advance("parseMatch }") // consume }
 
+	if param != nil && node.Default == nil && !p.checkExhaustive(node, param) {
+		return nil
+	}
+
+	if sum != nil && node.Default == nil {
+		if missing := missingCases(node, sum.Variants); len(missing) > 0 {
+			p.error(node.Token, fmt.Sprintf("match on sum type %q is not exhaustive, missing cases: %s",
+				subjectType, strings.Join(missing, ", ")), "parseMatch")
+
+			return nil
+		}
+	}
+
 	return node
 }
+
+// checkMatchCase checks that the type of a case of a match on a type
+// parameter satisfies its constraint, and is not matched by an earlier case.
+func (p *Parser) checkMatchCase(node *ast.Match, caseNode *ast.MatchCase, param *types.Alias) bool {
+	if !param.SatisfiedBy(caseNode.MatchType) {
+		p.error(caseNode.Token, fmt.Sprintf("case type %q does not satisfy constraint %q of type parameter %q",
+			caseNode.MatchType, param.ConstraintString(), param.Name), "checkMatchCase")
+
+		return false
+	}
+
+	for _, c := range node.Cases {
+		if c.Tilde == caseNode.Tilde && c.Matches(caseNode.MatchType) {
+			p.error(caseNode.Token, fmt.Sprintf("duplicate case %q in match", caseNode.MatchType), "checkMatchCase")
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkExhaustive checks that a match on a type parameter without a default
+// case has a case for every type of its constraint.
+func (p *Parser) checkExhaustive(node *ast.Match, param *types.Alias) bool {
+	constraintTypes, ok := param.ConstraintTypes()
+	if !ok {
+		p.error(node.Token, fmt.Sprintf("match on type parameter %q needs a default case, as its constraint %q is not a finite set of types",
+			param.Name, param.ConstraintString()), "checkExhaustive")
+
+		return false
+	}
+
+	if missing := missingCases(node, constraintTypes); len(missing) > 0 {
+		p.error(node.Token, fmt.Sprintf("match on type parameter %q is not exhaustive, missing cases: %s",
+			param.Name, strings.Join(missing, ", ")), "checkExhaustive")
+
+		return false
+	}
+
+	return true
+}
+
+// checkVariantCase checks that the type of a case of a match on a sum type is
+// one of its variants, and is not matched by an earlier case.
+func (p *Parser) checkVariantCase(node *ast.Match, caseNode *ast.MatchCase, sum *types.Union) bool {
+	if caseNode.Tilde {
+		p.error(caseNode.Token, "cannot match underlying type in match on sum type", "checkVariantCase")
+		return false
+	}
+
+	if sum.Index(caseNode.MatchType) < 0 {
+		p.error(caseNode.Token, fmt.Sprintf("case type %q is not a variant of sum type %q", caseNode.MatchType, sum), "checkVariantCase")
+		return false
+	}
+
+	if node.Case(caseNode.MatchType) != nil {
+		p.error(caseNode.Token, fmt.Sprintf("duplicate case %q in match", caseNode.MatchType), "checkVariantCase")
+		return false
+	}
+
+	return true
+}
+
+// missingCases returns the types of typs without a case in node.
+func missingCases(node *ast.Match, typs []types.Type) []string {
+	var missing []string
+
+	for _, typ := range typs {
+		if node.Case(typ) == nil {
+			missing = append(missing, typ.String())
+		}
+	}
+
+	return missing
+}
+
+// opaque reports whether expr is a value of a type parameter, outside the
+// default case of a match on it, and reports the error. Until a match narrows
+// its type, the value can only be passed on: its operations depend on the
+// concrete type.
+func (p *Parser) opaque(expr ast.Expression, tok tokens.Token) bool {
+	if expr == nil {
+		return false
+	}
+
+	tp, ok := expr.Type().(*types.Alias)
+	if !ok || !tp.IsTypeParam() || p.matchDefaults[tp.Name] > 0 {
+		return false
+	}
+
+	p.error(tok, fmt.Sprintf("cannot use generic parameter %q before type match", expr), "opaque")
+
+	return true
+}
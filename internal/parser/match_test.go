@@ -154,6 +154,237 @@ main : proc() = {
 }`)
 	})
 
+	t.Run("narrowed_subject", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+double : func<T ~ int32 | utf8>(x : T) int64 = {
+	match x {
+	case int32:
+		y : int32 = x * 2
+		return @cast<int64>(y)
+	case utf8:
+		return 0
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("default_context", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+double : func<T ~ int>(x : T) T = {
+	match x {
+	case int64:
+		return x * 2
+	default:
+		y : T = x
+		return y
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_opaque_before_match", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+same : func<T ~ int>(x : T) bool = {
+	y := x == x
+
+	match x {
+	default:
+		return y
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_opaque_after_match", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+positive : func<T ~ int>(x : T) bool = {
+	match x {
+	default:
+		@print("")
+	}
+
+	return x > 0
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_case_not_in_constraint", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+show : func<T ~ int>(x : T) = {
+	match x {
+	case utf8:
+		@print(x)
+	default:
+		@print("other")
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_duplicate_case", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+show : func<T ~ int32 | utf8>(x : T) = {
+	match x {
+	case int32:
+		@print(x)
+	case int32:
+		@print(x)
+	case utf8:
+		@print(x)
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_not_exhaustive", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+show : func<T ~ int32 | utf8>(x : T) = {
+	match x {
+	case int32:
+		@print(x)
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_any_without_default", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+show : func<T ~ any>(x : T) = {
+	match x {
+	case int64:
+		@print(x)
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("sum_type", func(t *testing.T) {
+		t.Parallel()
+
+		f := parse(t, `package p
+Shape ~ int32 | float64 | utf8
+show : func(s : Shape) = {
+	match v := s {
+	case int32:
+		@print(v + 1)
+	case float64:
+		@print(v * 2.0)
+	case utf8:
+		@print(v + "!")
+	}
+}
+main : proc() = {
+	n : int32 = 1
+	show(n)
+}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 1)
+		procLit := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+		matchStmt := procLit.Body.Statements[0].(*ast.Match)
+
+		if len(matchStmt.Cases) != 3 {
+			t.Fatalf("expected 3 cases, got %d", len(matchStmt.Cases))
+		}
+	})
+
+	t.Run("sum_type_default", func(t *testing.T) {
+		t.Parallel()
+
+		parse(t, `package p
+Shape ~ int32 | float64 | utf8
+show : func(s : Shape) = {
+	match s {
+	case utf8:
+		@print("utf8")
+	default:
+		@print("number")
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_sum_type_not_exhaustive", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Shape ~ int32 | float64 | utf8
+show : func(s : Shape) = {
+	match s {
+	case int32:
+		@print("int32")
+	case utf8:
+		@print("utf8")
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_sum_type_case_not_variant", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Shape ~ int32 | utf8
+show : func(s : Shape) = {
+	match s {
+	case int64:
+		@print("int64")
+	default:
+		@print("other")
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_sum_type_duplicate_case", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Shape ~ int32 | utf8
+show : func(s : Shape) = {
+	match s {
+	case int32:
+		@print("a")
+	case int32:
+		@print("b")
+	case utf8:
+		@print("c")
+	}
+}
+main : proc() = {}`)
+	})
+
+	t.Run("error_sum_type_tilde_case", func(t *testing.T) {
+		t.Parallel()
+
+		parseShouldError(t, `package p
+Shape ~ int32 | utf8
+show : func(s : Shape) = {
+	match s {
+	case ~int32:
+		@print("a")
+	default:
+		@print("b")
+	}
+}
+main : proc() = {}`)
+	})
+
 	t.Run("case_body_statements", func(t *testing.T) {
 		t.Parallel()
 
@@ -192,6 +423,8 @@ show : func<T ~ any>(x : T) = {
 		@print(x)
 	case utf8:
 		@print(x)
+	default:
+		@print("other")
 	}
 }
 main : proc() = {}`)
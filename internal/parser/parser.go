@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
 	"github.com/samborkent/cog/internal/tokens"
 	"github.com/samborkent/cog/internal/types"
 )
@@ -21,7 +23,11 @@ type Parser struct {
 	i                 int
 	debug             bool
 	scriptMode        bool
-	currentReturnType types.Type // return type of the enclosing procedure (for result wrapping)
+	scanning          bool           // set during FindGlobals, when methods and forward types are incomplete
+	currentReturnType types.Type     // return type of the enclosing procedure (for result wrapping)
+	inFunction        bool           // parsing the body of a func
+	noPropagation     string         // operand that is evaluated conditionally, so cannot propagate an error
+	matchDefaults     map[string]int // type parameters in the default case of a match on them
 	definedMethods    map[string]struct{}
 }
 
@@ -36,6 +42,7 @@ func NewParserWithSymbols(tokens []tokens.Token, symbols *SymbolTable, debug boo
 	p := &Parser{
 		tokens:         tokens,
 		symbols:        symbols,
+		filePath:       fileName,
 		Errs:           make([]error, 0),
 		debug:          debug,
 		definedMethods: make(map[string]struct{}),
@@ -82,16 +89,12 @@ func (p *Parser) ParseOnly(ctx context.Context, fileName string) (*ast.File, err
 	p.i = 0
 	p.Errs = make([]error, 0, len(p.Errs))
 
-	p.builtins = map[string]BuiltinParser{
-		"cast":  p.parseBuiltinCast,
-		"if":    p.parseBuiltinIf,
-		"map":   p.parseBuiltinMap,
-		"print": p.parseBuiltinPrint,
-		"ref":   p.parseBuiltinRef,
-		"set":   p.parseBuiltinSet,
-		"slice": p.parseBuiltinSlice,
+	if fileName != "" {
+		p.filePath = fileName
 	}
 
+	p.builtins = p.builtinParsers()
+
 	var pkg *ast.Package
 
 	if p.scriptMode {
@@ -135,7 +138,8 @@ tokenLoop:
 				Text:  p.this().Literal,
 			})
 			p.advance("Parse comment")
-		case tokens.Dynamic,
+		case tokens.Comp,
+			tokens.Dynamic,
 			tokens.Export,
 			tokens.Identifier,
 			tokens.Variable,
@@ -259,6 +263,7 @@ func (p *Parser) synchronize() {
 			tokens.Switch,
 			tokens.Return,
 			tokens.Export,
+			tokens.Comp,
 			tokens.Dynamic,
 			tokens.Variable,
 			tokens.GoImport,
@@ -273,28 +278,67 @@ func (p *Parser) synchronize() {
 	}
 }
 
+// error reports an error at token t. The scope names the parser function
+// reporting it, which is added as note in debug mode.
 func (p *Parser) error(t tokens.Token, msg string, scope ...string) {
-	if len(scope) > 0 {
-		p.Errs = append(p.Errs, fmt.Errorf("\t%s: %v: %s", p.stringToken(t), scope, msg))
-	} else {
-		p.Errs = append(p.Errs, fmt.Errorf("\t%s: %s", p.stringToken(t), msg))
+	p.Errs = append(p.Errs, p.diagnostic(t, diag.CodeSyntax, msg, scope...))
+}
+
+// undefined reports the use of an undeclared identifier at token t, and
+// suggests a declared identifier with a similar name.
+func (p *Parser) undefined(t tokens.Token, scope ...string) {
+	d := p.diagnostic(t, diag.CodeUndefined, fmt.Sprintf("undefined identifier %q", t.Literal), scope...)
+
+	if name, ok := p.symbols.Similar(t.Literal); ok {
+		d.Fix = &diag.Fix{
+			Message: fmt.Sprintf("did you mean %q?", name),
+			Span:    d.Span,
+			Text:    name,
+		}
 	}
+
+	p.Errs = append(p.Errs, d)
 }
 
-func (p *Parser) stringToken(t tokens.Token) string {
-	if t.Literal == "" {
-		return fmt.Sprintf("%s:\tln %d, col %d: %s",
-			p.filePath, t.Ln, t.Col, t.Type,
-		)
+// redeclared reports the redeclaration of an identifier at token t, and
+// points to the earlier declaration.
+func (p *Parser) redeclared(t tokens.Token, kind string, scope ...string) {
+	d := p.diagnostic(t, diag.CodeRedeclared, fmt.Sprintf("cannot redeclare %s %q", kind, t.Literal), scope...)
+
+	if sym, ok := p.symbols.Resolve(t.Literal); ok && sym.Identifier != nil && sym.Identifier.Token.Ln > 0 {
+		prev := sym.Identifier.Token
+
+		note := diag.Note{
+			Message: fmt.Sprintf("%q is declared in another file of the package", t.Literal),
+			Pos:     diag.Pos{Ln: int(prev.Ln), Col: int(prev.Col)},
+		}
+
+		if prev.FileID == t.FileID {
+			note.Message = fmt.Sprintf("%q is already declared", t.Literal)
+			note.File = p.filePath
+		}
+
+		d.Notes = append(d.Notes, note)
+	}
+
+	p.Errs = append(p.Errs, d)
+}
+
+// diagnostic returns a diagnostic spanning token t.
+func (p *Parser) diagnostic(t tokens.Token, code diag.Code, msg string, scope ...string) *diag.Diagnostic {
+	start := diag.Pos{Ln: int(t.Ln), Col: int(t.Col)}
+
+	d := &diag.Diagnostic{
+		Severity: diag.SeverityError,
+		Code:     code,
+		File:     p.filePath,
+		Span:     diag.Span{Start: start, End: diag.Pos{Ln: start.Ln, Col: start.Col + t.Width()}},
+		Message:  msg,
 	}
 
-	if t.Type == tokens.Builtin {
-		return fmt.Sprintf("%s:\tln %d, col %d: @%s",
-			p.filePath, t.Ln, t.Col, t.Literal,
-		)
+	if p.debug && len(scope) > 0 {
+		d.Notes = append(d.Notes, diag.Note{Message: "reported by " + strings.Join(scope, ", ")})
 	}
 
-	return fmt.Sprintf("%s:\tln %d, col %d: %s: %s",
-		p.filePath, t.Ln, t.Col, t.Type, t.Literal,
-	)
+	return d
 }
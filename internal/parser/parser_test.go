@@ -1,9 +1,12 @@
 package parser_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/lexer"
 	"github.com/samborkent/cog/internal/parser"
 	"github.com/samborkent/cog/internal/tokens"
 )
@@ -68,3 +71,64 @@ a := 2
 main : proc() = {}`)
 	})
 }
+
+func TestDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	src := `package p
+
+main : proc() = {
+	count := 1
+	count := 2
+	@print(cout)
+}`
+
+	toks, err := lexer.NewLexer(strings.NewReader(src)).Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lex error: %v", err)
+	}
+
+	p, err := NewTestParser(t, toks, false)
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	_, err = p.Parse(t.Context(), "test.cog")
+	if err == nil {
+		t.Fatal("expected parse error, got nil")
+	}
+
+	diags := make(map[diag.Code]*diag.Diagnostic)
+	for _, d := range diag.All(err) {
+		if _, ok := diags[d.Code]; !ok {
+			diags[d.Code] = d
+		}
+	}
+
+	redeclared, ok := diags[diag.CodeRedeclared]
+	if !ok {
+		t.Fatalf("expected redeclaration diagnostic, got %v", err)
+	}
+
+	wantSpan := diag.Span{Start: diag.Pos{Ln: 5, Col: 2}, End: diag.Pos{Ln: 5, Col: 7}}
+	if redeclared.File != "test.cog" || redeclared.Span != wantSpan {
+		t.Errorf("got %s %+v, want test.cog %+v", redeclared.File, redeclared.Span, wantSpan)
+	}
+
+	if len(redeclared.Notes) != 1 || !strings.Contains(redeclared.Notes[0].String(), "4:2") {
+		t.Errorf("got notes %q, want earlier declaration", redeclared.Notes)
+	}
+
+	undefined, ok := diags[diag.CodeUndefined]
+	if !ok {
+		t.Fatalf("expected undefined identifier diagnostic, got %v", err)
+	}
+
+	if undefined.Message != `undefined identifier "cout"` {
+		t.Errorf("got message %q", undefined.Message)
+	}
+
+	if undefined.Fix == nil || undefined.Fix.Text != "count" || undefined.Fix.Span != undefined.Span {
+		t.Errorf("got fix %+v, want count", undefined.Fix)
+	}
+}
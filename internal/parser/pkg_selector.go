@@ -37,6 +37,8 @@ func (p *Parser) parsePkgSelector(ctx context.Context, imp *CogImport) ast.Expre
 		return nil
 	}
 
+	p.symbols.addImportReference(p.this(), imp.Path, sym)
+
 	pkgIdent := &ast.Identifier{
 		Token:     pkgToken,
 		Name:      imp.Name,
@@ -60,14 +62,33 @@ func (p *Parser) parsePkgSelector(ctx context.Context, imp *CogImport) ast.Expre
 			return nil
 		}
 
+		args := p.parseCallArguments(ctx, procType)
+
+		if len(procType.TypeParams) > 0 {
+			typeArgs, returnType := p.inferTypeArgs(procType, args)
+			if typeArgs == nil {
+				return nil
+			}
+
+			return &ast.Call{
+				Expression: fieldIdent,
+				Package:    imp.Name,
+				Arguments:  args,
+				ReturnType: qualified(returnType, imp.Name),
+				TypeArgs:   typeArgs,
+			}
+		}
+
 		return &ast.Call{
 			Expression: fieldIdent,
 			Package:    imp.Name,
-			Arguments:  p.parseCallArguments(ctx, procType),
-			ReturnType: procType.ReturnType,
+			Arguments:  args,
+			ReturnType: qualified(procType.ReturnType, imp.Name),
 		}
 	}
 
+	fieldIdent.ValueType = qualified(fieldIdent.ValueType, imp.Name)
+
 	// Otherwise it's a value/type selector: pkg.Value
 	return &ast.Selector{
 		Token:      pkgToken,
@@ -75,3 +96,17 @@ func (p *Parser) parsePkgSelector(ctx context.Context, imp *CogImport) ast.Expre
 		Field:      fieldIdent,
 	}
 }
+
+// qualified returns typ as seen from the package importing pkg, which names
+// the types declared by pkg with the package name.
+func qualified(typ types.Type, pkg string) types.Type {
+	alias, ok := typ.(*types.Alias)
+	if !ok || alias.Package != "" || !alias.Global || alias.IsTypeParam() {
+		return typ
+	}
+
+	q := *alias
+	q.Package = pkg
+
+	return &q
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/tokens"
@@ -18,6 +19,18 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 		p.advance("parseStatement comment")
 
 		return node
+	case tokens.Async:
+		token := p.this()
+
+		node := p.parseAsync(ctx)
+		if node == nil {
+			return nil
+		}
+
+		return &ast.ExpressionStatement{
+			Token:      token,
+			Expression: node,
+		}
 	case tokens.BitAnd:
 		// Skip, get it with prev in identifier case.
 		p.advance("parseStatement ref") // consume &
@@ -49,6 +62,18 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 	case tokens.Builtin:
 		t := p.this()
 
+		if t.Literal == "go" {
+			expr := p.parseGoExpression(ctx)
+			if expr == nil {
+				return nil
+			}
+
+			return &ast.ExpressionStatement{
+				Token:      t,
+				Expression: expr,
+			}
+		}
+
 		p.advance("parseStatement builtin") // consume @
 
 		builtinParser, ok := p.builtins[t.Literal]
@@ -66,6 +91,16 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 			Token:      t,
 			Expression: node,
 		}
+	case tokens.Comp:
+		// Skip, get it with prev in identifier case.
+		p.advance("parseStatement comp") // consume comp
+
+		if p.this().Type != tokens.Identifier || (p.next().Type != tokens.Colon && p.next().Type != tokens.Declaration) {
+			p.error(p.this(), "expected declaration after comp", "parseStatement")
+			return nil
+		}
+
+		return p.parseStatement(ctx)
 	case tokens.Dynamic:
 		// Skip, get it with prev in identifier case.
 		p.advance("parseStatement dyn") // consume dyn
@@ -91,6 +126,14 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 
 		p.advance("parseStatement export") // consume export
 
+		qualifier := ast.QualifierImmutable
+
+		if p.this().Type == tokens.Comp {
+			qualifier = ast.QualifierCompileTime
+
+			p.advance("parseStatement export comp") // consume comp
+		}
+
 		var reference bool
 
 		switch p.this().Type {
@@ -106,7 +149,7 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 				Token:     p.this(),
 				Name:      p.this().Literal,
 				Exported:  true,
-				Qualifier: ast.QualifierImmutable,
+				Qualifier: qualifier,
 				Global:    true,
 			}
 
@@ -198,6 +241,8 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 				return nil
 			}
 
+			p.symbols.addReference(p.this(), typeSymbol)
+
 			receiverIdent.ValueType = &types.Alias{
 				Name:    typeSymbol.Identifier.Name,
 				Derived: typeSymbol.Identifier.ValueType,
@@ -241,6 +286,8 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 			qualifier = ast.QualifierVariable
 		case tokens.Dynamic:
 			qualifier = ast.QualifierDynamic
+		case tokens.Comp:
+			qualifier = ast.QualifierCompileTime
 		}
 
 		// Check if previous token was &, for reference method receiver.
@@ -372,10 +419,12 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 				// Resolve the receiver and check mutability.
 				symbol, ok := p.symbols.Resolve(ident.Name)
 				if !ok {
-					p.error(ident.Token, "unknown identifier", "parseStatement")
+					p.undefined(ident.Token, "parseStatement")
 					return nil
 				}
 
+				p.symbols.addReference(ident.Token, symbol)
+
 				if symbol.Identifier.Qualifier != ast.QualifierVariable {
 					p.error(ident.Token, "cannot assign to field of immutable receiver", "parseStatement")
 
@@ -386,14 +435,7 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 					return nil
 				}
 
-				// Build a selector identifier for the assignment.
-				selectorIdent := &ast.Identifier{
-					Token:     ident.Token,
-					Name:      ident.Name + "." + selector.Field.Name,
-					Qualifier: ast.QualifierVariable,
-				}
-
-				return p.parseAssignment(ctx, selectorIdent)
+				return p.parseFieldAssignment(ctx, ident, selector)
 			}
 
 			return &ast.ExpressionStatement{
@@ -476,6 +518,8 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 			return nil
 		}
 
+		p.symbols.addReference(p.this(), typeSymbol)
+
 		receiverIdent.ValueType = &types.Alias{
 			Name:    typeSymbol.Identifier.Name,
 			Derived: typeSymbol.Identifier.ValueType,
@@ -521,8 +565,24 @@ func (p *Parser) parseStatement(ctx context.Context) ast.Statement {
 			resultType, _ = p.currentReturnType.Underlying().(*types.Result)
 		}
 
+		// A bare return ends a procedure without return value early.
+		if p.this().Type == tokens.RBrace || p.this().Ln != node.Token.Ln {
+			if p.currentReturnType != nil {
+				p.error(node.Token, fmt.Sprintf("missing return value of type %q", p.currentReturnType), "parseStatement")
+				return nil
+			}
+
+			return node
+		}
+
+		// An iterator literal is only known by its expected type.
+		var typeToken types.Type = types.None
+		if p.currentReturnType != nil && p.currentReturnType.Kind() == types.IteratorKind {
+			typeToken = p.currentReturnType
+		}
+
 		for p.this().Type != tokens.EOF {
-			expr := p.expression(ctx, types.None)
+			expr := p.expression(ctx, typeToken)
 			if expr != nil {
 				// If the enclosing procedure returns a Result type, only wrap
 				// value/error variants. Returning a full result value must pass
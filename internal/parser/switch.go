@@ -36,7 +36,12 @@ func (p *Parser) parseBoolSwitch(ctx context.Context) *ast.Switch {
 
 		p.advance("parseBoolSwitch case") // consume case
 
+		prevNoPropagation := p.noPropagation
+		p.noPropagation = "a case condition"
+
 		expr := p.expression(ctx, types.None)
+
+		p.noPropagation = prevNoPropagation
 		if expr == nil {
 			p.error(p.this(), "unable to parse case expression", "parseBoolSwitch")
 			return nil
@@ -125,6 +130,8 @@ func (p *Parser) parseIdentSwitch(ctx context.Context) *ast.Switch {
 		return nil
 	}
 
+	p.symbols.addReference(p.this(), symbol)
+
 	node.Identifier = symbol.Identifier
 
 	p.advance("parseIdentSwitch") // consume identifier
@@ -143,7 +150,12 @@ func (p *Parser) parseIdentSwitch(ctx context.Context) *ast.Switch {
 
 		p.advance("parseIdentSwitch case") // consume case
 
+		prevNoPropagation := p.noPropagation
+		p.noPropagation = "a case condition"
+
 		cond := p.expression(ctx, symbol.Type())
+
+		p.noPropagation = prevNoPropagation
 		if cond == nil {
 			p.error(p.this(), "unable to parse case expression", "parseIdentSwitch")
 			return nil
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"fmt"
+	gotypes "go/types"
+	"path"
 
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/tokens"
@@ -31,9 +33,24 @@ var None = &ast.Identifier{
 type CogImport struct {
 	Path    string            // import path (e.g. "geom")
 	Name    string            // package name (last segment of path)
+	Token   tokens.Token      // import path in the source
 	Exports map[string]Symbol // exported symbols from the imported package
 }
 
+// Reference is the use of a symbol in the source. References are recorded
+// for tooling, such as the language server.
+type Reference struct {
+	Symbol Symbol
+	Import string // import path of the declaring package, empty for the package itself
+}
+
+// position is the source position of a token.
+type position struct {
+	fileID uint16
+	ln     uint32
+	col    uint16
+}
+
 // checkState tracks which accesses are safe for a checked option/result variable.
 type checkState uint8
 
@@ -47,9 +64,11 @@ type SymbolTable struct {
 
 	table      map[string]Symbol
 	goimports  map[string]*ast.Identifier
+	gopackages map[string]*gotypes.Package
 	cogimports map[string]*CogImport // key: package name
 	fields     map[string]map[string]Symbol
 	checked    map[string]checkState // option/result variables verified in this scope
+	references map[position]Reference
 }
 
 func NewSymbolTable() *SymbolTable {
@@ -63,9 +82,11 @@ func NewSymbolTable() *SymbolTable {
 	return &SymbolTable{
 		table:      table,
 		goimports:  make(map[string]*ast.Identifier),
+		gopackages: make(map[string]*gotypes.Package),
 		cogimports: make(map[string]*CogImport),
 		fields:     make(map[string]map[string]Symbol),
 		checked:    make(map[string]checkState),
+		references: make(map[position]Reference),
 	}
 }
 
@@ -73,7 +94,9 @@ func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
 	s := NewSymbolTable()
 	s.Outer = outer
 	s.goimports = outer.goimports
+	s.gopackages = outer.gopackages
 	s.cogimports = outer.cogimports
+	s.references = outer.references
 
 	return s
 }
@@ -97,6 +120,7 @@ func (s *SymbolTable) Define(ident *ast.Identifier) {
 	}
 
 	s.table[ident.Name] = symbol
+	s.addReference(ident.Token, symbol)
 
 	// TODO: investigate why this check was here
 	// if ident.Qualifier != ast.QualifierType {
@@ -147,6 +171,7 @@ func (s *SymbolTable) DefineMethod(receiver string, method *ast.Identifier) erro
 		Identifier: method,
 		Scope:      StructScope,
 	}
+	s.addReference(method.Token, s.fields[receiver][method.Name])
 
 	return nil
 }
@@ -165,6 +190,7 @@ func (s *SymbolTable) DefineEnumValue(selector string, field *ast.Identifier) {
 		Identifier: field,
 		Scope:      EnumScope,
 	}
+	s.addReference(field.Token, s.fields[selector][field.Name])
 }
 
 func (s *SymbolTable) DefineGlobal(ident *ast.Identifier) {
@@ -183,7 +209,8 @@ func (s *SymbolTable) DefineGoImport(ident *ast.Identifier) {
 		ident.ValueType = types.None
 	}
 
-	s.goimports[ident.Name] = ident
+	// Go imports are referenced by package name, e.g. @go.big for "math/big".
+	s.goimports[path.Base(ident.Name)] = ident
 }
 
 func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
@@ -216,11 +243,35 @@ func (s *SymbolTable) ResolveField(typeName, field string) (Symbol, bool) {
 	return symbol, ok
 }
 
+// ForEachField iterates over the fields, methods and enum values of a type.
+func (s *SymbolTable) ForEachField(typeName string, fn func(name string, sym Symbol)) {
+	fields, ok := s.fields[typeName]
+	if !ok && s.Outer != nil {
+		s.Outer.ForEachField(typeName, fn)
+		return
+	}
+
+	for name, sym := range fields {
+		fn(name, sym)
+	}
+}
+
 func (s *SymbolTable) ResolveGoImport(name string) (*ast.Identifier, bool) {
 	ident, ok := s.goimports[name]
 	return ident, ok
 }
 
+// DefineGoPackage stores the type information of a Go import.
+func (s *SymbolTable) DefineGoPackage(name string, pkg *gotypes.Package) {
+	s.gopackages[name] = pkg
+}
+
+// ResolveGoPackage returns the type information of a Go import.
+func (s *SymbolTable) ResolveGoPackage(name string) (*gotypes.Package, bool) {
+	pkg, ok := s.gopackages[name]
+	return pkg, ok
+}
+
 // ForEachGlobal iterates over all symbols in the root (global) table.
 func (s *SymbolTable) ForEachGlobal(fn func(name string, sym Symbol)) {
 	root := s
@@ -233,6 +284,60 @@ func (s *SymbolTable) ForEachGlobal(fn func(name string, sym Symbol)) {
 	}
 }
 
+// Similar returns the visible identifier closest to name, for suggestions
+// when name is undefined. Only identifiers differing in a few characters are
+// returned.
+func (s *SymbolTable) Similar(name string) (string, bool) {
+	var (
+		best     string
+		bestDist = max(len(name)/3, 1) + 1
+	)
+
+	for scope := s; scope != nil; scope = scope.Outer {
+		for candidate := range scope.table {
+			if candidate == "_" || candidate == name {
+				continue
+			}
+
+			dist := editDistance(name, candidate)
+			if dist < bestDist || dist == bestDist && candidate < best {
+				best, bestDist = candidate, dist
+			}
+		}
+	}
+
+	return best, best != ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range ra {
+		curr[0] = i + 1
+
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+
+			curr[j+1] = min(prev[j+1]+1, curr[j]+1, prev[j]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
 func (s *SymbolTable) Update(name string, t types.Type) {
 	if symbol, ok := s.table[name]; ok {
 		symbol.Identifier.ValueType = t
@@ -289,3 +394,28 @@ func (s *SymbolTable) IsErrorChecked(name string) bool {
 
 	return false
 }
+
+// addReference records the use of a symbol at tok. Synthesized tokens, which
+// have no position, are ignored.
+func (s *SymbolTable) addReference(tok tokens.Token, symbol Symbol) {
+	s.addImportReference(tok, "", symbol)
+}
+
+// addImportReference records the use of a symbol of an imported package at tok.
+func (s *SymbolTable) addImportReference(tok tokens.Token, importPath string, symbol Symbol) {
+	if tok.Ln == 0 || symbol.Identifier == nil {
+		return
+	}
+
+	s.references[position{fileID: tok.FileID, ln: tok.Ln, col: tok.Col}] = Reference{
+		Symbol: symbol,
+		Import: importPath,
+	}
+}
+
+// ResolveReference returns the symbol used at tok, if any. Declarations are
+// references to the symbol they declare.
+func (s *SymbolTable) ResolveReference(tok tokens.Token) (Reference, bool) {
+	ref, ok := s.references[position{fileID: tok.FileID, ln: tok.Ln, col: tok.Col}]
+	return ref, ok
+}
@@ -1,9 +1,11 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
 	"github.com/samborkent/cog/internal/tokens"
 	"github.com/samborkent/cog/internal/types"
 )
@@ -376,3 +378,62 @@ func TestSymbolType(t *testing.T) {
 		t.Errorf("Type() = %v, want bool", sym.Type())
 	}
 }
+
+func TestResolveReference(t *testing.T) {
+	t.Parallel()
+
+	l := lexer.NewLexer(strings.NewReader(`package p
+
+x : int64 = 1
+
+main : proc() = {
+	y := x
+	_ = y
+}`))
+
+	toks, err := l.Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lex error: %v", err)
+	}
+
+	s := NewSymbolTable()
+
+	p, err := NewParserWithSymbols(toks, s, false, "test.cog")
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	if _, err := p.Parse(t.Context(), "test.cog"); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	var uses []tokens.Token
+
+	for _, tok := range toks {
+		if tok.Type == tokens.Identifier && tok.Literal == "x" {
+			uses = append(uses, tok)
+		}
+	}
+
+	if len(uses) != 2 {
+		t.Fatalf("found %d x tokens, want 2", len(uses))
+	}
+
+	ref, ok := s.ResolveReference(uses[1])
+	if !ok {
+		t.Fatal("expected a reference for the use of x")
+	}
+
+	decl := ref.Symbol.Identifier.Token
+	if decl.Ln != uses[0].Ln || decl.Col != uses[0].Col {
+		t.Errorf("declaration at %d:%d, want %d:%d", decl.Ln, decl.Col, uses[0].Ln, uses[0].Col)
+	}
+
+	if ref.Symbol.Scope != GlobalScope {
+		t.Errorf("scope = %v, want GlobalScope", ref.Symbol.Scope)
+	}
+
+	if _, ok := s.ResolveReference(tokens.Token{Type: tokens.Identifier, Literal: "x"}); ok {
+		t.Error("expected no reference for a token without position")
+	}
+}
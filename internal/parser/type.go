@@ -30,7 +30,13 @@ func (p *Parser) parseCombinedType(ctx context.Context, exported, global bool) t
 
 		return p.parseErrorType(ctx, ident)
 	case tokens.Function, tokens.Procedure:
-		return p.parseProcedureType(ctx, exported, global)
+		procType := p.parseProcedureType(ctx, exported, global)
+		if procType == nil {
+			// Prevent a typed nil.
+			return nil
+		}
+
+		return procType
 	}
 
 	typ := p.parseType(ctx)
@@ -57,20 +63,18 @@ func (p *Parser) parseCombinedType(ctx context.Context, exported, global bool) t
 			Exported: exported,
 			Global:   global,
 		}
+	case tokens.Pipe:
+		// Sum type: A | B | C
+		return p.parseSumType(ctx, typ, exported, global)
 	case tokens.Not:
 		// Result type: T ! E
 		p.advance("parseCombinedType result !") // consume !
 
-		errorType := p.parseCombinedType(ctx, exported, global)
+		errorType := p.parseResultErrorType(ctx, exported, global)
 		if errorType == nil {
 			return nil
 		}
 
-		if errorType.Kind() != types.ErrorKind {
-			p.error(p.prev(), "result error type must be an error type", "parseCombinedType")
-			return nil
-		}
-
 		if typ.Kind() == types.ErrorKind {
 			p.error(p.prev(), "result value type cannot be an error type", "parseCombinedType")
 			return nil
@@ -85,14 +89,92 @@ func (p *Parser) parseCombinedType(ctx context.Context, exported, global bool) t
 	return typ
 }
 
+// parseResultErrorType parses the error type of a result after the !.
+// A bare error keyword is the Go error interface. Other error types are Cog
+// errors, and interfaces and structs with an Error : func() utf8 method.
+func (p *Parser) parseResultErrorType(ctx context.Context, exported, global bool) types.Type {
+	if p.this().Type == tokens.Error && p.next().Type != tokens.LBrace && p.next().Type != tokens.LT {
+		p.advance("parseResultErrorType error") // consume error
+		return types.GoError
+	}
+
+	errorType := p.parseCombinedType(ctx, exported, global)
+	if errorType == nil {
+		return nil
+	}
+
+	// The methods of a struct may be declared after its use, and resolving a
+	// forward type fixes it, so the error type is checked by the full parse.
+	if p.scanning {
+		return errorType
+	}
+
+	if !types.IsError(errorType) {
+		p.error(p.prev(), "result error type must be an error type", "parseResultErrorType")
+		return nil
+	}
+
+	return errorType
+}
+
+// parseSumType parses the variants of a sum type after its first variant.
+// Variants are concrete and distinct.
+func (p *Parser) parseSumType(ctx context.Context, first types.Type, exported, global bool) types.Type {
+	union := &types.Union{
+		Variants: []types.Type{first},
+		Exported: exported,
+		Global:   global,
+	}
+
+	for p.this().Type == tokens.Pipe {
+		p.advance("parseSumType |") // consume |
+
+		tok := p.this()
+
+		variant := p.parseType(ctx)
+		if variant == nil {
+			return nil
+		}
+
+		if union.Index(variant) >= 0 {
+			p.error(tok, fmt.Sprintf("duplicate variant %q in sum type", variant), "parseSumType")
+			return nil
+		}
+
+		union.Variants = append(union.Variants, variant)
+	}
+
+	if len(union.Variants) > types.UnionMaxVariants {
+		p.error(p.prev(), fmt.Sprintf("sum type has %d variants, at most %d are allowed", len(union.Variants), types.UnionMaxVariants), "parseSumType")
+		return nil
+	}
+
+	for _, variant := range union.Variants {
+		if alias, ok := variant.(*types.Alias); ok && types.IsNone(alias.Derived) {
+			// A named type declared later is not resolved yet.
+			continue
+		}
+
+		switch variant.Kind() {
+		case types.AnyKind, types.InterfaceKind, types.UnionKind:
+			p.error(p.prev(), fmt.Sprintf("sum type variant %q must be a concrete type", variant), "parseSumType")
+			return nil
+		}
+	}
+
+	return union
+}
+
 // canStartType reports whether the current token can begin a type expression.
 func (p *Parser) canStartType() bool {
 	switch p.this().Type {
-	case tokens.Interface, tokens.LBracket, tokens.LParen, tokens.Map, tokens.Set,
+	case tokens.Interface, tokens.Iter, tokens.LBracket, tokens.LParen, tokens.Map, tokens.Set, tokens.Signal,
 		tokens.Struct, tokens.BitAnd, tokens.Function, tokens.Procedure:
 		return true
 	case tokens.Identifier:
 		return true
+	case tokens.Builtin:
+		return p.this().Literal == "go"
 	}
 	// Check for built-in type keywords (int64, utf8, etc.).
 	_, ok := types.Lookup[p.this().Type]
@@ -168,7 +250,8 @@ func (p *Parser) parseType(ctx context.Context) types.Type {
 		case tokens.IntLiteral:
 		case tokens.Identifier:
 			symbol, ok := p.symbols.Resolve(p.this().Literal)
-			if ok && types.IsFixed(symbol.Identifier.ValueType) {
+			if ok && (types.IsFixed(symbol.Identifier.ValueType) || isCompFixedFunc(symbol.Identifier)) {
+				p.symbols.addReference(p.this(), symbol)
 				break
 			}
 
@@ -260,8 +343,82 @@ func (p *Parser) parseType(ctx context.Context) types.Type {
 		p.advance("parseType set >") // consume >
 
 		return &types.Set{Element: elemType}
+	case tokens.Signal:
+		p.advance("parseType signal") // consume signal
+
+		if p.this().Type != tokens.LT {
+			// Handle of a procedure without return value.
+			return &types.Signal{}
+		}
+
+		p.advance("parseType signal <") // consume <
+
+		valType := p.parseCombinedType(ctx, false, false)
+		if valType == nil {
+			return nil
+		}
+
+		if p.this().Type != tokens.GT {
+			p.error(p.this(), "expected > after signal value type", "parseType")
+			return nil
+		}
+
+		p.advance("parseType signal >") // consume >
+
+		return &types.Signal{Value: valType}
+	case tokens.Iter:
+		p.advance("parseType iter") // consume iter
+
+		if p.this().Type != tokens.LT {
+			p.error(p.this(), "expected < after iter type", "parseType")
+			return nil
+		}
+
+		p.advance("parseType iter <") // consume <
+
+		valType := p.parseType(ctx)
+		if valType == nil {
+			return nil
+		}
+
+		iterType := &types.Iterator{Value: valType}
+
+		if p.this().Type == tokens.Comma {
+			p.advance("parseType iter ,") // consume ,
+
+			iterType.Index = p.parseType(ctx)
+			if iterType.Index == nil {
+				return nil
+			}
+		}
+
+		if p.this().Type != tokens.GT {
+			p.error(p.this(), "expected > after iter value type", "parseType")
+			return nil
+		}
+
+		p.advance("parseType iter >") // consume >
+
+		return iterType
 	case tokens.Struct:
 		return p.parseStruct(ctx)
+	case tokens.Builtin:
+		if p.this().Literal != "go" {
+			break
+		}
+
+		typ := p.parseGoType()
+		if typ == nil {
+			return nil
+		}
+
+		if p.this().Type == tokens.Question {
+			p.advance("parseType ?") // consume ?
+
+			return &types.Option{Value: typ}
+		}
+
+		return typ
 	case tokens.BitAnd:
 		// Reference type parsing
 		p.advance("parseType &") // consume &
@@ -301,13 +458,18 @@ func (p *Parser) parseType(ctx context.Context) types.Type {
 					return nil
 				}
 
+				p.symbols.addImportReference(p.this(), imp.Path, sym)
+
 				ident := sym.Identifier
+
+				var alias *types.Alias
+
 				if types.IsNone(ident.ValueType) {
-					typ = types.NewForwardAlias(ident.Name, ident.Exported, ident.Global, func() types.Type {
+					alias = types.NewForwardAlias(ident.Name, ident.Exported, ident.Global, func() types.Type {
 						return ident.ValueType
 					})
 				} else {
-					typ = &types.Alias{
+					alias = &types.Alias{
 						Name:     ident.Name,
 						Derived:  ident.ValueType,
 						Exported: ident.Exported,
@@ -315,6 +477,9 @@ func (p *Parser) parseType(ctx context.Context) types.Type {
 					}
 				}
 
+				alias.Package = imp.Name
+				typ = alias
+
 				p.advance("parseType pkg type") // consume type name
 
 				if p.this().Type == tokens.Question {
@@ -339,6 +504,8 @@ func (p *Parser) parseType(ctx context.Context) types.Type {
 			return nil
 		}
 
+		p.symbols.addReference(p.this(), typeSymbol)
+
 		ident := typeSymbol.Identifier
 
 		// If the symbol is a type parameter (inside a generic alias body),
@@ -761,16 +928,11 @@ func (p *Parser) parseProcedureType(ctx context.Context, exported, global bool)
 	if p.this().Type == tokens.Not {
 		p.advance("parseProcedureType !") // consume !
 
-		errorType := p.parseCombinedType(ctx, exported, global)
+		errorType := p.parseResultErrorType(ctx, exported, global)
 		if errorType == nil {
 			return nil
 		}
 
-		if errorType.Kind() != types.ErrorKind {
-			p.error(p.prev(), "result error type must be an error type", "parseProcedureType")
-			return nil
-		}
-
 		if returnType.Kind() == types.ErrorKind {
 			p.error(p.prev(), "result value type cannot be an error type", "parseProcedureType")
 			return nil
@@ -934,6 +1096,15 @@ func (p *Parser) parseErrorType(ctx context.Context, ident *ast.Identifier) type
 
 		p.advance("parseErrorType identifier") // consume identifier
 
+		var params []*types.Parameter
+
+		if p.this().Type == tokens.LParen {
+			params = p.parseErrorPayload(ctx, typ)
+			if params == nil {
+				return nil
+			}
+		}
+
 		if typ.ValueType != nil {
 			// Typed error: require := value
 			if p.this().Type != tokens.Declaration {
@@ -946,8 +1117,9 @@ func (p *Parser) parseErrorType(ctx context.Context, ident *ast.Identifier) type
 			enumExpr := p.expression(ctx, typ.ValueType)
 			if enumExpr != nil {
 				typ.Values = append(typ.Values, &types.EnumValue{
-					Name:  valName,
-					Value: enumExpr,
+					Name:   valName,
+					Value:  enumExpr,
+					Params: params,
 				})
 			}
 		} else {
@@ -958,6 +1130,7 @@ func (p *Parser) parseErrorType(ctx context.Context, ident *ast.Identifier) type
 					Token: tokens.Token{Type: tokens.StringLiteral, Literal: valName},
 					Value: valName,
 				},
+				Params: params,
 			})
 		}
 
@@ -970,3 +1143,101 @@ func (p *Parser) parseErrorType(ctx context.Context, ident *ast.Identifier) type
 
 	return typ
 }
+
+// parseErrorPayload parses the payload parameters of an error variant. A
+// parameter name used by several variants names one payload field, so it must
+// have the same type in each. A variant wraps at most one cause, which is a
+// parameter of an error type.
+func (p *Parser) parseErrorPayload(ctx context.Context, typ *types.Error) []*types.Parameter {
+	p.advance("parseErrorPayload (") // consume (
+
+	params := []*types.Parameter{}
+
+	var cause *types.Parameter
+
+	for !p.match(tokens.RParen, tokens.EOF) {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if p.this().Type != tokens.Identifier {
+			p.error(p.this(), "expected payload parameter identifier", "parseErrorPayload")
+			return nil
+		}
+
+		nameToken := p.this()
+		param := &types.Parameter{
+			Name: nameToken.Literal,
+		}
+
+		p.advance("parseErrorPayload identifier") // consume identifier
+
+		if p.this().Type != tokens.Colon {
+			p.error(p.this(), "expected ':' after payload parameter identifier", "parseErrorPayload")
+			return nil
+		}
+
+		p.advance("parseErrorPayload :") // consume :
+
+		if p.this().Type == tokens.Error && p.next().Type != tokens.LBrace && p.next().Type != tokens.LT {
+			p.advance("parseErrorPayload error") // consume error
+			param.Type = types.GoError
+		} else {
+			param.Type = p.parseCombinedType(ctx, false, false)
+			if param.Type == nil {
+				p.error(p.this(), "unknown payload parameter type", "parseErrorPayload")
+				return nil
+			}
+		}
+
+		for _, prev := range params {
+			if prev.Name == param.Name {
+				p.error(nameToken, fmt.Sprintf("duplicate payload parameter %q", param.Name), "parseErrorPayload")
+				return nil
+			}
+		}
+
+		if field := typ.Field(param.Name); field != nil && !types.Equal(field.Type, param.Type) {
+			p.error(nameToken, fmt.Sprintf("payload parameter %q has type %q in another variant", param.Name, field.Type), "parseErrorPayload")
+			return nil
+		}
+
+		if types.IsError(param.Type) {
+			if cause != nil {
+				p.error(nameToken, fmt.Sprintf("error variant already wraps cause %q", cause.Name), "parseErrorPayload")
+				return nil
+			}
+
+			cause = param
+		}
+
+		params = append(params, param)
+
+		if p.this().Type == tokens.Comma {
+			p.advance("parseErrorPayload ,") // consume ,
+		}
+	}
+
+	if p.this().Type != tokens.RParen {
+		p.error(p.this(), "expected ')' after error payload", "parseErrorPayload")
+		return nil
+	}
+
+	p.advance("parseErrorPayload )") // consume )
+
+	if len(params) == 0 {
+		p.error(p.prev(), "error payload must have at least one parameter", "parseErrorPayload")
+		return nil
+	}
+
+	return params
+}
+
+// isCompFixedFunc reports whether ident is a comp func returning a fixed-point
+// number, of which a call can be an array length.
+func isCompFixedFunc(ident *ast.Identifier) bool {
+	fn, ok := ident.ValueType.(*types.Procedure)
+
+	return ok && fn.Function && ident.Qualifier == ast.QualifierCompileTime &&
+		fn.ReturnType != nil && types.IsFixed(fn.ReturnType)
+}
@@ -50,6 +50,37 @@ main : proc() = {}`)
 		}
 	})
 
+	t.Run("sum", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+Point ~ struct { x : float64 }
+Value ~ int32 | utf8 | Point
+main : proc() = {}`)
+
+		ta := stmtAs[*ast.Type](t, f, 1)
+		if ta.Alias.Kind() != types.UnionKind {
+			t.Errorf("expected UnionKind, got %s", ta.Alias.Kind())
+		}
+
+		if s := ta.Alias.Underlying().String(); s != "int32 | utf8 | Point" {
+			t.Errorf("expected %q, got %q", "int32 | utf8 | Point", s)
+		}
+	})
+
+	t.Run("sum_duplicate_variant", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+Value ~ int32 | utf8 | int32
+main : proc() = {}`)
+	})
+
+	t.Run("sum_variant_not_concrete", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+Value ~ int32 | interface {}
+main : proc() = {}`)
+	})
+
 	t.Run("option", func(t *testing.T) {
 		t.Parallel()
 		f := parse(t, `package p
@@ -211,6 +242,71 @@ main : proc() = {}`)
 		}
 	})
 
+	t.Run("payload_error", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+MyError ~ error<utf8> {
+	NotFound(key : utf8) := "not found",
+	Timeout := "timeout",
+	Wrapped(key : utf8, cause : error) := "wrapped",
+}
+main : proc() = {}`)
+
+		ta := stmtAs[*ast.Type](t, f, 0)
+
+		errType, ok := ta.Alias.(*types.Error)
+		if !ok {
+			t.Fatalf("expected *types.Error, got %T", ta.Alias)
+		}
+
+		if !errType.HasPayload() {
+			t.Error("expected error type with payload")
+		}
+
+		if cause := errType.Values[2].Cause(); cause == nil || cause.Type != types.GoError {
+			t.Errorf("expected Go error cause, got %v", cause)
+		}
+	})
+
+	t.Run("payload_duplicate_parameter", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+MyError ~ error { NotFound(key : utf8, key : utf8) }
+main : proc() = {}`)
+	})
+
+	t.Run("payload_field_type_mismatch", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+MyError ~ error { NotFound(key : utf8), Invalid(key : int64) }
+main : proc() = {}`)
+	})
+
+	t.Run("payload_two_causes", func(t *testing.T) {
+		t.Parallel()
+		parseShouldError(t, `package p
+Inner ~ error { Fail }
+MyError ~ error { Wrapped(inner : Inner, cause : error) }
+main : proc() = {}`)
+	})
+
+	t.Run("result_go_error", func(t *testing.T) {
+		t.Parallel()
+		f := parse(t, `package p
+parse : func(s : utf8) int64 ! error = {
+	return 1
+}
+main : proc() = {}`)
+
+		decl := stmtAs[*ast.Declaration](t, f, 0)
+		procType := decl.Assignment.Identifier.ValueType.(*types.Procedure)
+
+		result, ok := procType.ReturnType.(*types.Result)
+		if !ok || result.Error != types.GoError {
+			t.Errorf("expected result with Go error, got %s", procType.ReturnType)
+		}
+	})
+
 	t.Run("error_invalid_type_param", func(t *testing.T) {
 		t.Parallel()
 		parseShouldError(t, `package p
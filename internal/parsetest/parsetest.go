@@ -0,0 +1,37 @@
+// Package parsetest parses Cog source for the tests of the passes that run on
+// the syntax tree.
+package parsetest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/parser"
+)
+
+// Parse parses src as the body of file main.cog in package main, failing the
+// test if it does not parse.
+func Parse(t testing.TB, src string) *ast.File {
+	t.Helper()
+
+	toks, err := lexer.NewLexer(strings.NewReader("package main\n\n" + src)).Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lexer error: %v", err)
+	}
+
+	p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "")
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	f, err := p.Parse(t.Context(), "")
+	if err != nil {
+		t.Fatalf("parser error: %v", err)
+	}
+
+	f.Name = "main.cog"
+
+	return f
+}
@@ -0,0 +1,394 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/samborkent/cog/internal/diag"
+)
+
+// ModFile is the name of the manifest at the root of a Cog module.
+const ModFile = "cog.mod"
+
+// Module is a tree of Cog packages with a cog.mod manifest at its root:
+//
+//	module example.com/app
+//
+//	require (
+//		example.com/geom v1.2.0
+//	)
+//
+// Import paths that start with the path of a required module resolve to the
+// packages of that module.
+type Module struct {
+	Path     string     // module path, the prefix of the import paths of its packages
+	Version  string     // selected version, empty for the main module
+	Dir      string     // root directory, holding the cog.mod file
+	Requires []*Require // modules required by the cog.mod file
+}
+
+// Require is a module required by a cog.mod file.
+type Require struct {
+	Path    string
+	Version string
+	File    string // path of the cog.mod file
+	Ln      int    // line of the requirement
+}
+
+// versionPattern matches a semantic version, which Go also requires of the
+// module versions in go.mod.
+var versionPattern = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`)
+
+// ParseModFile parses the contents of the cog.mod file at file.
+func ParseModFile(file string, data []byte) (*Module, error) {
+	mod := &Module{Dir: filepath.Dir(file)}
+
+	var errs []error
+
+	inRequire := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for ln := 1; scanner.Scan(); ln++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if inRequire {
+			if len(fields) == 1 && fields[0] == ")" {
+				inRequire = false
+				continue
+			}
+
+			req, err := parseRequire(file, ln, fields)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			mod.Requires = append(mod.Requires, req)
+
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				errs = append(errs, modError(file, ln, "expected module path: module <path>"))
+				continue
+			}
+
+			if mod.Path != "" {
+				errs = append(errs, modError(file, ln, "module declared twice"))
+				continue
+			}
+
+			mod.Path = fields[1]
+		case "require":
+			if len(fields) == 2 && fields[1] == "(" {
+				inRequire = true
+				continue
+			}
+
+			req, err := parseRequire(file, ln, fields[1:])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			mod.Requires = append(mod.Requires, req)
+		default:
+			errs = append(errs, modError(file, ln, fmt.Sprintf("unknown directive %q", fields[0])))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if inRequire {
+		errs = append(errs, modError(file, 0, "missing ')' after require block"))
+	}
+
+	if mod.Path == "" {
+		errs = append(errs, modError(file, 0, "missing module declaration"))
+	}
+
+	return mod, errors.Join(errs...)
+}
+
+// parseRequire parses the path and version of a requirement.
+func parseRequire(file string, ln int, fields []string) (*Require, error) {
+	if len(fields) != 2 {
+		return nil, modError(file, ln, "expected module path and version: require <path> <version>")
+	}
+
+	if !isRemotePath(fields[0]) {
+		return nil, modError(file, ln, fmt.Sprintf("required module path %q must start with a domain name", fields[0]))
+	}
+
+	if !versionPattern.MatchString(fields[1]) {
+		return nil, modError(file, ln, fmt.Sprintf("invalid version %q of module %q: expected a semantic version like v1.2.3", fields[1], fields[0]))
+	}
+
+	return &Require{Path: fields[0], Version: fields[1], File: file, Ln: ln}, nil
+}
+
+// FindModule returns the module whose root is dir or its closest parent with
+// a cog.mod file. It returns nil if there is no such parent.
+func FindModule(dir string) (*Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		file := filepath.Join(dir, ModFile)
+
+		data, err := os.ReadFile(file)
+		if err == nil {
+			return ParseModFile(file, data)
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("reading %s: %w", ModFile, err)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+
+		dir = parent
+	}
+}
+
+// ModCache returns the directory of the module cache: $COGMODCACHE, or
+// cog/mod in the user cache directory. A module is found in the cache at
+// <path>@<version>.
+func ModCache() (string, error) {
+	if dir := os.Getenv("COGMODCACHE"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating module cache: %w", err)
+	}
+
+	return filepath.Join(dir, "cog", "mod"), nil
+}
+
+// loadModules finds the main module of the package in dir, and selects the
+// versions of the modules it requires, directly or through other modules. Like
+// Go's minimal version selection, the highest version required is selected.
+// Modules are found in the vendor directory of the main module, or else in
+// the module cache.
+func (l *Loader) loadModules(dir string) error {
+	if l.modsLoaded {
+		return nil
+	}
+
+	l.modsLoaded = true
+
+	main, err := FindModule(dir)
+	if main == nil {
+		return err
+	}
+
+	l.main = main
+	l.modules = make(map[string]*Module)
+
+	errs := []error{err}
+
+	cache, err := ModCache()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	selected := make(map[string]*Require)
+	visited := make(map[string]bool)
+	queue := main.Requires
+
+	for len(queue) > 0 {
+		req := queue[0]
+		queue = queue[1:]
+
+		if visited[req.Path+"@"+req.Version] {
+			continue
+		}
+
+		visited[req.Path+"@"+req.Version] = true
+
+		if prev, ok := selected[req.Path]; !ok || semver.Compare(req.Version, prev.Version) > 0 {
+			selected[req.Path] = req
+		}
+
+		// The requirements of a module that is not in the cache are unknown.
+		// This is only an error if its version is selected.
+		mod, err := readModule(moduleDir(main, cache, req))
+		if err != nil || mod == nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		queue = append(queue, mod.Requires...)
+	}
+
+	for modPath, req := range selected {
+		modDir := moduleDir(main, cache, req)
+
+		if info, err := os.Stat(modDir); err != nil || !info.IsDir() {
+			errs = append(errs, modError(req.File, req.Ln,
+				fmt.Sprintf("module %s@%s not found in %s or the module cache %s", req.Path, req.Version,
+					filepath.Join(main.Dir, "vendor"), cache)))
+
+			continue
+		}
+
+		mod, err := readModule(modDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if mod == nil {
+			mod = &Module{Dir: modDir}
+		} else if mod.Path != modPath {
+			errs = append(errs, modError(req.File, req.Ln,
+				fmt.Sprintf("module %s@%s declares its path as %q", req.Path, req.Version, mod.Path)))
+
+			continue
+		}
+
+		mod.Path = modPath
+		mod.Version = req.Version
+
+		l.modules[modPath] = mod
+	}
+
+	return errors.Join(errs...)
+}
+
+// moduleDir returns the directory of a required module: its directory in the
+// vendor directory of the main module if it exists, or else in the cache.
+func moduleDir(main *Module, cache string, req *Require) string {
+	vendored := filepath.Join(main.Dir, "vendor", filepath.FromSlash(req.Path))
+	if info, err := os.Stat(vendored); err == nil && info.IsDir() {
+		return vendored
+	}
+
+	return filepath.Join(cache, filepath.FromSlash(req.Path)+"@"+req.Version)
+}
+
+// readModule reads the cog.mod file in dir. It returns nil if there is none.
+func readModule(dir string) (*Module, error) {
+	file := filepath.Join(dir, ModFile)
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ModFile, err)
+	}
+
+	return ParseModFile(file, data)
+}
+
+// resolveImport returns the directory of the package at importPath, and the
+// module it belongs to. The package is imported by a package of mod, or of no
+// module if mod is nil. Import paths resolve in order to:
+//   - a package of a required module, if the path starts with its path;
+//   - a package of mod, if the path starts with its path;
+//   - a directory relative to root or its parents, see importDir.
+func (l *Loader) resolveImport(root string, mod *Module, importPath string) (string, *Module, error) {
+	var required *Module
+
+	for modPath, m := range l.modules {
+		if hasPathPrefix(importPath, modPath) && (required == nil || len(modPath) > len(required.Path)) {
+			required = m
+		}
+	}
+
+	switch {
+	case required != nil:
+		return modulePackageDir(required, importPath), required, nil
+	case mod != nil && hasPathPrefix(importPath, mod.Path):
+		return modulePackageDir(mod, importPath), mod, nil
+	case isRemotePath(importPath):
+		return "", nil, fmt.Errorf("no required module provides package %q: add its module to %s", importPath, ModFile)
+	}
+
+	// Without a module, the import paths are relative to the entry package.
+	top := root
+	if mod != nil {
+		top = mod.Dir
+	}
+
+	dir, err := importDir(root, top, importPath)
+
+	return dir, mod, err
+}
+
+// modulePackageDir returns the directory of the package at importPath in mod.
+func modulePackageDir(mod *Module, importPath string) string {
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, mod.Path), "/")
+
+	return filepath.Join(mod.Dir, filepath.FromSlash(rel))
+}
+
+// hasPathPrefix reports whether importPath is prefix, or a package below it.
+func hasPathPrefix(importPath, prefix string) bool {
+	return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+}
+
+// isRemotePath reports whether importPath starts with a domain name, like the
+// paths of modules shared between repositories.
+func isRemotePath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+
+	return strings.Contains(first, ".")
+}
+
+// relPath returns the import path of the package in dir of mod, relative to
+// the root of the module.
+func relPath(mod *Module, dir string) string {
+	rel, err := filepath.Rel(mod.Dir, dir)
+	if err != nil || rel == "." {
+		return ""
+	}
+
+	return path.Clean(filepath.ToSlash(rel))
+}
+
+// modError returns a diagnostic about line ln of the cog.mod file, or the
+// whole file if ln is 0.
+func modError(file string, ln int, msg string) *diag.Diagnostic {
+	d := &diag.Diagnostic{
+		Severity: diag.SeverityError,
+		Code:     diag.CodeModule,
+		File:     file,
+		Message:  msg,
+	}
+
+	if ln > 0 {
+		d.Span = diag.Span{Start: diag.Pos{Ln: ln, Col: 1}, End: diag.Pos{Ln: ln, Col: 1}}
+	}
+
+	return d
+}
@@ -0,0 +1,225 @@
+package project_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/samborkent/cog/internal/project"
+)
+
+func TestParseModFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("module", func(t *testing.T) {
+		t.Parallel()
+
+		mod, err := project.ParseModFile("/app/cog.mod", []byte(`// Shapes application.
+module example.com/app
+
+require example.com/geom v1.2.0
+
+require (
+    example.com/color v0.3.1-beta // palettes
+    example.com/units v2.0.0
+)
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if mod.Path != "example.com/app" {
+			t.Errorf("path = %q, want %q", mod.Path, "example.com/app")
+		}
+
+		if mod.Dir != "/app" {
+			t.Errorf("dir = %q, want %q", mod.Dir, "/app")
+		}
+
+		want := []string{"example.com/geom@v1.2.0", "example.com/color@v0.3.1-beta", "example.com/units@v2.0.0"}
+
+		if len(mod.Requires) != len(want) {
+			t.Fatalf("got %d requires, want %d", len(mod.Requires), len(want))
+		}
+
+		for i, req := range mod.Requires {
+			if got := req.Path + "@" + req.Version; got != want[i] {
+				t.Errorf("require %d = %q, want %q", i, got, want[i])
+			}
+		}
+
+		if mod.Requires[1].Ln != 7 {
+			t.Errorf("require line = %d, want 7", mod.Requires[1].Ln)
+		}
+	})
+
+	for name, src := range map[string]string{
+		"missing_module":  "require example.com/geom v1.2.0\n",
+		"invalid_version": "module app\n\nrequire example.com/geom 1.2\n",
+		"local_require":   "module app\n\nrequire geom v1.2.0\n",
+		"unclosed":        "module app\n\nrequire (\n    example.com/geom v1.2.0\n",
+		"unknown":         "module app\n\nreplace example.com/geom => ../geom\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := project.ParseModFile("cog.mod", []byte(src)); err == nil {
+				t.Errorf("expected error for %q", src)
+			}
+		})
+	}
+}
+
+const appSource = `package main
+
+import (
+    "example.com/geom"
+    "example.com/geom/shapes"
+)
+
+main : proc() = {
+    @print(geom.Scale + shapes.Area(1.0))
+}`
+
+// geomModule returns the files of module example.com/geom, in dir.
+func geomModule(dir, requires string) map[string]string {
+	return map[string]string{
+		dir + "/cog.mod": "module example.com/geom\n" + requires,
+		dir + "/geom.cog": `package geom
+
+export Scale := 2.0`,
+		dir + "/shapes/shapes.cog": `package shapes
+
+import (
+    "example.com/geom"
+)
+
+export Area : func(side : float64) float64 = {
+    return side * side * geom.Scale
+}`,
+	}
+}
+
+func TestLoadModules(t *testing.T) {
+	t.Run("vendor", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"cog.mod":  "module example.com/app\n\nrequire example.com/geom v1.2.0\n",
+			"main.cog": appSource,
+		})
+		writeFiles(t, dir, geomModule("vendor/example.com/geom", ""))
+
+		pkg, err := load(t, dir, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if pkg.Module == nil || pkg.Module.Path != "example.com/app" {
+			t.Fatalf("main module = %+v, want example.com/app", pkg.Module)
+		}
+
+		geom, shapes := pkg.Imports["example.com/geom"], pkg.Imports["example.com/geom/shapes"]
+		if geom == nil || shapes == nil {
+			t.Fatal("required module packages were not loaded")
+		}
+
+		if geom.Module.Version != "v1.2.0" {
+			t.Errorf("version = %q, want %q", geom.Module.Version, "v1.2.0")
+		}
+
+		if geom.ImportPath != "" || shapes.ImportPath != "shapes" {
+			t.Errorf("import paths = %q, %q, want %q, %q", geom.ImportPath, shapes.ImportPath, "", "shapes")
+		}
+
+		if shapes.Imports["example.com/geom"] != geom {
+			t.Error("package geom was loaded twice")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"cog.mod":  "module example.com/app\n",
+			"main.cog": appSource,
+		})
+
+		if _, err := load(t, dir, dir); err == nil {
+			t.Fatal("expected error for import without required module")
+		}
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		cache := t.TempDir()
+		t.Setenv("COGMODCACHE", cache)
+
+		// The app requires geom v1.2.0 and units v1.0.0, which requires geom
+		// v1.3.0: the highest version is selected.
+		writeFiles(t, cache, geomModule("example.com/geom@v1.2.0", ""))
+		writeFiles(t, cache, geomModule("example.com/geom@v1.3.0", ""))
+		writeFiles(t, cache, map[string]string{
+			"example.com/units@v1.0.0/cog.mod":   "module example.com/units\n\nrequire example.com/geom v1.3.0\n",
+			"example.com/units@v1.0.0/units.cog": "package units\n\nexport Meter := 1.0",
+		})
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"cog.mod":  "module example.com/app\n\nrequire (\n    example.com/geom v1.2.0\n    example.com/units v1.0.0\n)\n",
+			"main.cog": appSource,
+		})
+
+		pkg, err := load(t, dir, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		geom := pkg.Imports["example.com/geom"]
+		if geom == nil {
+			t.Fatal("package geom was not loaded")
+		}
+
+		if geom.Module.Version != "v1.3.0" {
+			t.Errorf("version = %q, want %q", geom.Module.Version, "v1.3.0")
+		}
+
+		if want := filepath.Join(cache, "example.com", "geom@v1.3.0"); geom.Module.Dir != want {
+			t.Errorf("dir = %q, want %q", geom.Module.Dir, want)
+		}
+	})
+
+	t.Run("prerelease", func(t *testing.T) {
+		cache := t.TempDir()
+		t.Setenv("COGMODCACHE", cache)
+
+		// Numeric identifiers of pre-releases compare as numbers, so rc.10 is
+		// selected over rc.2.
+		writeFiles(t, cache, geomModule("example.com/geom@v1.3.0-rc.2", ""))
+		writeFiles(t, cache, geomModule("example.com/geom@v1.3.0-rc.10", ""))
+		writeFiles(t, cache, map[string]string{
+			"example.com/units@v1.0.0/cog.mod":   "module example.com/units\n\nrequire example.com/geom v1.3.0-rc.10\n",
+			"example.com/units@v1.0.0/units.cog": "package units\n\nexport Meter := 1.0",
+		})
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"cog.mod":  "module example.com/app\n\nrequire (\n    example.com/geom v1.3.0-rc.2\n    example.com/units v1.0.0\n)\n",
+			"main.cog": appSource,
+		})
+
+		pkg, err := load(t, dir, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		geom := pkg.Imports["example.com/geom"]
+		if geom == nil {
+			t.Fatal("package geom was not loaded")
+		}
+
+		if geom.Module.Version != "v1.3.0-rc.10" {
+			t.Errorf("version = %q, want %q", geom.Module.Version, "v1.3.0-rc.10")
+		}
+	})
+}
@@ -0,0 +1,575 @@
+// Package project loads Cog packages: it lexes and parses the files of a
+// package, together with the Cog packages it imports.
+package project
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/tokens"
+)
+
+// Package is a parsed Cog package.
+type Package struct {
+	ImportPath string // import path relative to the module root (empty for the entry package)
+	Name       string // package name
+	Files      []*File
+	Symbols    *parser.SymbolTable
+	Imports    map[string]*Package // imported Cog packages by import path
+	Module     *Module             // module of the package, nil outside a module
+}
+
+// File is a source file of a package.
+type File struct {
+	Path   string
+	ID     uint16
+	Tokens []tokens.Token // nil if the file could not be lexed
+	AST    *ast.File      // nil if the file could not be parsed
+	Errs   []error        // lexer and parser errors
+}
+
+// Loader loads packages from the file system.
+type Loader struct {
+	Debug bool // enable debug parser mode
+	Tests bool // include _test.cog files
+
+	// Overlay holds file contents that replace the contents on disk, by path.
+	Overlay map[string][]byte
+
+	packages map[string]*Package // imported packages by directory, loaded once
+	loading  []importing         // packages whose imports are being loaded
+
+	main       *Module            // module of the entry package, nil outside a module
+	modules    map[string]*Module // selected versions of the required modules by path
+	modsLoaded bool
+}
+
+// importing is a package whose imports are being loaded.
+type importing struct {
+	dir  string // absolute directory of the package
+	path string // import path, or package name for the entry package
+}
+
+// IsTestFile reports whether a Cog file only contains tests.
+func IsTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.cog")
+}
+
+// Files resolves the input to a sorted list of .cog file paths.
+// If a single .cog or .cogs file is given, only that file is returned.
+// If a directory is given, all .cog files in that directory are returned.
+func (l *Loader) Files(input string) ([]string, error) {
+	input = filepath.Clean(input)
+
+	if _, ok := l.Overlay[input]; ok {
+		return []string{input}, nil
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %q: %w", input, err)
+	}
+
+	// Single file: return just that file.
+	if !info.IsDir() {
+		if !strings.HasSuffix(input, ".cog") && !strings.HasSuffix(input, ".cogs") {
+			return nil, errors.New("invalid file extension, must be .cog or .cogs")
+		}
+
+		return []string{input}, nil
+	}
+
+	// Directory: scan for all .cog files.
+	entries, err := os.ReadDir(input)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %q: %w", input, err)
+	}
+
+	files := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !l.isPackageFile(entry.Name()) {
+			continue
+		}
+
+		files = append(files, filepath.Join(input, entry.Name()))
+	}
+
+	// Files that only exist in the overlay.
+	for path := range l.Overlay {
+		if filepath.Dir(path) == input && l.isPackageFile(filepath.Base(path)) && !slices.Contains(files, path) {
+			files = append(files, path)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .cog files found in %q", input)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (l *Loader) isPackageFile(name string) bool {
+	return strings.HasSuffix(name, ".cog") && (l.Tests || !IsTestFile(name))
+}
+
+// Scripts finds all .cogs files in the given directory.
+func Scripts(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var scripts []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".cogs") {
+			continue
+		}
+
+		scripts = append(scripts, filepath.Join(dir, entry.Name()))
+	}
+
+	sort.Strings(scripts)
+
+	return scripts
+}
+
+// Load loads the package made up of files. Imported packages are resolved
+// relative to root.
+//
+// Load continues past errors, so the returned package holds everything that
+// could be parsed; the returned error joins all errors found.
+func (l *Loader) Load(ctx context.Context, root string, files []string) (*Package, error) {
+	pkg, parsers, errs := l.loadGlobals(ctx, files)
+	if pkg == nil {
+		return nil, errors.Join(errs...)
+	}
+
+	// A package that declares a main proc must be named "main".
+	if sym, hasMain := pkg.Symbols.Resolve("main"); hasMain && pkg.Name != "main" {
+		tok := sym.Identifier.Token
+
+		errs = append(errs, packageError(pkg.Files[tok.FileID].Path, tok,
+			fmt.Sprintf("package %q declares a main proc but is not named \"main\"", pkg.Name)))
+	}
+
+	errs = append(errs, l.loadModules(filepath.Dir(files[0])))
+	pkg.Module = l.main
+
+	errs = append(errs, l.loadEntryImports(ctx, root, filepath.Dir(files[0]), pkg)...)
+	errs = append(errs, l.parse(ctx, pkg, parsers)...)
+
+	return pkg, errors.Join(errs...)
+}
+
+// LoadScript loads a .cogs script file. Script files have no package
+// declaration, and belong to package main.
+func (l *Loader) LoadScript(ctx context.Context, root string, path string) (*Package, error) {
+	file := &File{Path: path}
+
+	pkg := &Package{
+		Name:    "main",
+		Files:   []*File{file},
+		Symbols: parser.NewSymbolTable(),
+		Imports: make(map[string]*Package),
+	}
+
+	toks, err := l.lex(ctx, path, 0)
+	if err != nil {
+		file.Errs = append(file.Errs, err)
+		return pkg, err
+	}
+
+	file.Tokens = toks
+
+	p, err := parser.NewScriptParserWithSymbols(toks, pkg.Symbols, l.Debug)
+	if err != nil {
+		file.Errs = append(file.Errs, err)
+		return pkg, err
+	}
+
+	p.FindGlobals(ctx)
+
+	errs := []error{l.loadModules(filepath.Dir(path))}
+	pkg.Module = l.main
+
+	errs = append(errs, l.loadEntryImports(ctx, root, path, pkg)...)
+	errs = append(errs, l.parse(ctx, pkg, []*parser.Parser{p})...)
+
+	return pkg, errors.Join(errs...)
+}
+
+// loadGlobals lexes the files, validates they declare the same package, and
+// finds the globals of the package. It returns a parser for every file, nil
+// for files that could not be lexed.
+func (l *Loader) loadGlobals(ctx context.Context, files []string) (*Package, []*parser.Parser, []error) {
+	if len(files) == 0 {
+		return nil, nil, []error{errors.New("no files in package")}
+	}
+
+	pkg := &Package{
+		Files:   make([]*File, len(files)),
+		Symbols: parser.NewSymbolTable(),
+		Imports: make(map[string]*Package),
+	}
+
+	var errs []error
+
+	for i, path := range files {
+		file := &File{Path: path, ID: uint16(i)}
+		pkg.Files[i] = file
+
+		toks, err := l.lex(ctx, path, file.ID)
+		if err != nil {
+			file.Errs = append(file.Errs, err)
+			errs = append(errs, err)
+
+			continue
+		}
+
+		file.Tokens = toks
+	}
+
+	// Modules in the module cache are in <path>@<version> directories.
+	dirName, _, _ := strings.Cut(filepath.Base(filepath.Dir(files[0])), "@")
+
+	for _, file := range pkg.Files {
+		if file.Tokens == nil {
+			continue
+		}
+
+		if len(file.Tokens) < 2 || file.Tokens[0].Type != tokens.Package {
+			err := packageError(file.Path, file.Tokens[0], "missing package declaration")
+			file.Errs = append(file.Errs, err)
+			errs = append(errs, err)
+
+			continue
+		}
+
+		name := file.Tokens[1].Literal
+
+		if pkg.Name == "" {
+			pkg.Name = name
+
+			if pkg.Name != "main" && dirName != "." && pkg.Name != dirName {
+				err := packageError(file.Path, file.Tokens[1], fmt.Sprintf("package %q does not match directory name %q", pkg.Name, dirName))
+				file.Errs = append(file.Errs, err)
+				errs = append(errs, err)
+			}
+		} else if name != pkg.Name {
+			err := packageError(file.Path, file.Tokens[1], fmt.Sprintf("declares package %q, but other files use %q", name, pkg.Name))
+			file.Errs = append(file.Errs, err)
+			errs = append(errs, err)
+		}
+	}
+
+	// FindGlobals on all files with a shared symbol table.
+	parsers := make([]*parser.Parser, len(pkg.Files))
+
+	for i, file := range pkg.Files {
+		if file.Tokens == nil {
+			continue
+		}
+
+		p, err := parser.NewParserWithSymbols(file.Tokens, pkg.Symbols, l.Debug, file.Path)
+		if err != nil {
+			file.Errs = append(file.Errs, err)
+			errs = append(errs, err)
+
+			continue
+		}
+
+		p.FindGlobals(ctx)
+		parsers[i] = p
+	}
+
+	return pkg, parsers, errs
+}
+
+// loadEntryImports loads the imports of the entry package, or script, at
+// path.
+func (l *Loader) loadEntryImports(ctx context.Context, root, path string, pkg *Package) []error {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	l.loading = append(l.loading, importing{dir: dir, path: pkg.Name})
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	return l.loadImports(ctx, root, pkg)
+}
+
+// loadImports loads the Cog packages imported by pkg, and the packages they
+// import in turn, and makes their exported symbols available to pkg. Every
+// package is loaded once, however many packages import it.
+func (l *Loader) loadImports(ctx context.Context, root string, pkg *Package) []error {
+	var errs []error
+
+	imports := slices.SortedFunc(maps.Values(pkg.Symbols.CogImports()), func(a, b *parser.CogImport) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	for _, imp := range imports {
+		dir, mod, err := l.resolveImport(root, pkg.Module, imp.Path)
+		if err != nil {
+			errs = append(errs, importError(pkg, imp, err.Error()))
+			continue
+		}
+
+		if i := slices.IndexFunc(l.loading, func(p importing) bool { return p.dir == dir }); i >= 0 {
+			cycle := make([]string, 0, len(l.loading)-i+1)
+			for _, p := range l.loading[i:] {
+				cycle = append(cycle, strconv.Quote(p.path))
+			}
+
+			cycle = append(cycle, strconv.Quote(imp.Path))
+
+			errs = append(errs, importError(pkg, imp, "import cycle: "+strings.Join(cycle, " -> ")))
+
+			continue
+		}
+
+		imported, err := l.loadImport(ctx, root, dir, imp.Path, mod)
+		if imported != nil {
+			pkg.Imports[imp.Path] = imported
+			populateImportExports(imp, imported.Symbols)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("compiling imported package %q: %w", imp.Path, err))
+		}
+	}
+
+	return errs
+}
+
+// loadImport loads the package in dir of module mod, imported as importPath,
+// together with its own imports. A package that was loaded before is returned as is; its
+// errors were reported when it was first loaded. Tests of imported packages
+// are not loaded.
+func (l *Loader) loadImport(ctx context.Context, root, dir, importPath string, mod *Module) (*Package, error) {
+	if pkg, ok := l.packages[dir]; ok {
+		return pkg, nil
+	}
+
+	imports := &Loader{Overlay: l.Overlay}
+
+	files, err := imports.Files(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, parsers, errs := l.loadGlobals(ctx, files)
+	if pkg == nil {
+		return nil, errors.Join(errs...)
+	}
+
+	pkg.ImportPath = importPath
+	pkg.Module = mod
+
+	// Packages imported by module path get their path relative to the module
+	// root, and the relative imports of a required module resolve against its
+	// root.
+	if mod != nil && hasPathPrefix(importPath, mod.Path) {
+		pkg.ImportPath = relPath(mod, dir)
+	}
+
+	if mod != nil && mod != l.main {
+		root = mod.Dir
+	}
+
+	if l.packages == nil {
+		l.packages = make(map[string]*Package)
+	}
+
+	l.packages[dir] = pkg
+
+	// Imported packages must not declare a main proc.
+	if sym, hasMain := pkg.Symbols.Resolve("main"); hasMain {
+		tok := sym.Identifier.Token
+
+		errs = append(errs, packageError(files[tok.FileID], tok,
+			fmt.Sprintf("imported package %q must not declare a main proc", pkg.Name)))
+	}
+
+	l.loading = append(l.loading, importing{dir: dir, path: importPath})
+	errs = append(errs, l.loadImports(ctx, root, pkg)...)
+	l.loading = l.loading[:len(l.loading)-1]
+
+	errs = append(errs, l.parse(ctx, pkg, parsers)...)
+
+	return pkg, errors.Join(errs...)
+}
+
+// importDir returns the absolute directory of the package at importPath.
+// Import paths are relative to the project root: root, or for a package in a
+// nested directory, the closest parent of root up to top that holds the
+// import path. Directories outside top are never searched.
+func importDir(root, top, importPath string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(importPath)) {
+		return "", fmt.Errorf("invalid import path %q", importPath)
+	}
+
+	dir, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	top, err = filepath.Abs(top)
+	if err != nil {
+		return "", err
+	}
+
+	// A root outside top is only searched itself.
+	if rel, err := filepath.Rel(top, dir); err != nil || !filepath.IsLocal(rel) && rel != "." {
+		top = dir
+	}
+
+	for {
+		candidate := filepath.Join(dir, filepath.FromSlash(importPath))
+
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+
+		if dir == top {
+			return "", fmt.Errorf("cannot find imported package %q in %q", importPath, top)
+		}
+
+		dir = filepath.Dir(dir)
+	}
+}
+
+// Deps returns the packages imported by p, directly or indirectly, each once.
+// Every package comes after the packages it imports, so they can be compiled
+// in order.
+func (p *Package) Deps() []*Package {
+	var deps []*Package
+
+	seen := make(map[*Package]bool)
+
+	var visit func(pkg *Package)
+
+	visit = func(pkg *Package) {
+		for _, path := range slices.Sorted(maps.Keys(pkg.Imports)) {
+			imported := pkg.Imports[path]
+			if seen[imported] {
+				continue
+			}
+
+			seen[imported] = true
+
+			visit(imported)
+
+			deps = append(deps, imported)
+		}
+	}
+
+	visit(p)
+
+	return deps
+}
+
+// parse runs the full parse of all files, after their globals and imports
+// have been resolved.
+func (l *Loader) parse(ctx context.Context, pkg *Package, parsers []*parser.Parser) []error {
+	var errs []error
+
+	for i, file := range pkg.Files {
+		if parsers[i] == nil {
+			continue
+		}
+
+		f, err := parsers[i].ParseOnly(ctx, file.Path)
+		if err != nil {
+			file.Errs = append(file.Errs, parsers[i].Errs...)
+			errs = append(errs, err)
+		}
+
+		file.AST = f
+	}
+
+	return errs
+}
+
+// lex lexes a single file and returns its token stream.
+func (l *Loader) lex(ctx context.Context, path string, fileID uint16) ([]tokens.Token, error) {
+	var r io.Reader
+
+	if src, ok := l.Overlay[path]; ok {
+		r = bytes.NewReader(src)
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening %q: %w", path, err)
+		}
+
+		defer func() { _ = file.Close() }()
+
+		r = file
+	}
+
+	toks, err := lexer.NewFileLexer(r, path, fileID).Parse(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lexing %q: %w", path, err)
+	}
+
+	return toks, nil
+}
+
+// packageError returns a diagnostic about the package of a file, at token
+// tok.
+func packageError(path string, tok tokens.Token, msg string) *diag.Diagnostic {
+	start := diag.Pos{Ln: int(tok.Ln), Col: int(tok.Col)}
+
+	return &diag.Diagnostic{
+		Severity: diag.SeverityError,
+		Code:     diag.CodePackage,
+		File:     path,
+		Span:     diag.Span{Start: start, End: diag.Pos{Ln: start.Ln, Col: start.Col + max(utf8.RuneCountInString(tok.Literal), 1)}},
+		Message:  msg,
+	}
+}
+
+// importError returns a diagnostic about the import imp of pkg.
+func importError(pkg *Package, imp *parser.CogImport, msg string) *diag.Diagnostic {
+	tok := imp.Token
+
+	var path string
+	if int(tok.FileID) < len(pkg.Files) {
+		path = pkg.Files[tok.FileID].Path
+	}
+
+	d := packageError(path, tok, msg)
+	d.Code = diag.CodeImport
+
+	return d
+}
+
+// populateImportExports fills a CogImport's Exports map from the imported package's symbol table.
+func populateImportExports(imp *parser.CogImport, symbols *parser.SymbolTable) {
+	symbols.ForEachGlobal(func(name string, sym parser.Symbol) {
+		if sym.Identifier.Exported {
+			imp.Exports[name] = sym
+		}
+	})
+}
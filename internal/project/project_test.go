@@ -0,0 +1,265 @@
+package project_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/project"
+)
+
+// writeFiles writes the files, by path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// load loads the package in dir, with import paths relative to root.
+func load(t *testing.T, root, dir string) (*project.Package, error) {
+	t.Helper()
+
+	loader := &project.Loader{}
+
+	files, err := loader.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return loader.Load(context.Background(), root, files)
+}
+
+func importPaths(pkgs []*project.Package) []string {
+	paths := make([]string, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		paths = append(paths, pkg.ImportPath)
+	}
+
+	return paths
+}
+
+func TestLoadImports(t *testing.T) {
+	t.Parallel()
+
+	t.Run("transitive", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"main.cog": `package main
+
+import (
+    "a"
+)
+
+main : proc() = {
+    @print(a.X)
+}`,
+			"a/a.cog": `package a
+
+import (
+    "b"
+)
+
+export X := b.Y + 1`,
+			"b/b.cog": `package b
+
+export Y := 1`,
+		})
+
+		pkg, err := load(t, dir, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := strings.Join(importPaths(pkg.Deps()), " "), "b a"; got != want {
+			t.Errorf("deps = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("diamond", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"main.cog": `package main
+
+import (
+    "a"
+    "b"
+)
+
+main : proc() = {
+    @print(a.X + b.Y)
+}`,
+			"a/a.cog": `package a
+
+import (
+    "c"
+)
+
+export X := c.Z`,
+			"b/b.cog": `package b
+
+import (
+    "c"
+)
+
+export Y := c.Z`,
+			"c/c.cog": `package c
+
+export Z := 1`,
+		})
+
+		pkg, err := load(t, dir, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := strings.Join(importPaths(pkg.Deps()), " "), "c a b"; got != want {
+			t.Errorf("deps = %q, want %q", got, want)
+		}
+
+		if pkg.Imports["a"].Imports["c"] != pkg.Imports["b"].Imports["c"] {
+			t.Error("package c was loaded twice")
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"main.cog": `package main
+
+import (
+    "a"
+)
+
+main : proc() = {
+    @print(a.X)
+}`,
+			"a/a.cog": `package a
+
+import (
+    "b"
+)
+
+export X := 1`,
+			"b/b.cog": `package b
+
+import (
+    "a"
+)
+
+export Y := 1`,
+		})
+
+		_, err := load(t, dir, dir)
+		if err == nil {
+			t.Fatal("expected import cycle error")
+		}
+
+		var d *diag.Diagnostic
+		if !errors.As(err, &d) {
+			t.Fatalf("expected diagnostic, got %v", err)
+		}
+
+		if d.Code != diag.CodeImport {
+			t.Errorf("code = %q, want %q", d.Code, diag.CodeImport)
+		}
+
+		if want := `import cycle: "a" -> "b" -> "a"`; !strings.Contains(d.Message, want) {
+			t.Errorf("message = %q, want %q", d.Message, want)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"cog.mod": "module app\n",
+			"geom/geom.cog": `package geom
+
+import (
+    "geom/metric"
+)
+
+export Tau := 2.0 * metric.Pi`,
+			"geom/metric/metric.cog": `package metric
+
+export Pi := 3.14`,
+		})
+
+		nested := filepath.Join(dir, "geom")
+
+		pkg, err := load(t, nested, nested)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := pkg.Imports["geom/metric"]; !ok {
+			t.Error("geom/metric was not loaded")
+		}
+	})
+
+	t.Run("outside_project", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"app/main.cog": `package main
+
+import (
+    "lib"
+)
+
+main : proc() = {
+    @print(lib.X)
+}`,
+			"lib/lib.cog": `package lib
+
+export X := 1`,
+		})
+
+		app := filepath.Join(dir, "app")
+
+		if _, err := load(t, app, app); err == nil {
+			t.Fatal("expected error for import outside the project")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{
+			"main.cog": `package main
+
+import (
+    "missing"
+)
+
+main : proc() = {}`,
+		})
+
+		if _, err := load(t, dir, dir); err == nil {
+			t.Fatal("expected error for missing import")
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package purity
+
+// pureGoFuncs are the Go functions without side effects that a func can call,
+// by import path and function name. Functions that mutate their arguments,
+// like slices.Sort, read global state, like time.Now, or call methods of their
+// arguments, like fmt.Sprint, are left out.
+var pureGoFuncs = map[string]bool{
+	"errors.Is":     true,
+	"errors.Join":   true,
+	"errors.New":    true,
+	"errors.Unwrap": true,
+
+	"math.Abs":         true,
+	"math.Acos":        true,
+	"math.Asin":        true,
+	"math.Atan":        true,
+	"math.Atan2":       true,
+	"math.Cbrt":        true,
+	"math.Ceil":        true,
+	"math.Copysign":    true,
+	"math.Cos":         true,
+	"math.Cosh":        true,
+	"math.Exp":         true,
+	"math.Exp2":        true,
+	"math.Floor":       true,
+	"math.Hypot":       true,
+	"math.Inf":         true,
+	"math.IsInf":       true,
+	"math.IsNaN":       true,
+	"math.Log":         true,
+	"math.Log10":       true,
+	"math.Log1p":       true,
+	"math.Log2":        true,
+	"math.Max":         true,
+	"math.Min":         true,
+	"math.Mod":         true,
+	"math.NaN":         true,
+	"math.Pow":         true,
+	"math.Pow10":       true,
+	"math.Remainder":   true,
+	"math.Round":       true,
+	"math.RoundToEven": true,
+	"math.Signbit":     true,
+	"math.Sin":         true,
+	"math.Sinh":        true,
+	"math.Sqrt":        true,
+	"math.Tan":         true,
+	"math.Tanh":        true,
+	"math.Trunc":       true,
+
+	"strconv.Atoi":        true,
+	"strconv.FormatBool":  true,
+	"strconv.FormatFloat": true,
+	"strconv.FormatInt":   true,
+	"strconv.FormatUint":  true,
+	"strconv.Itoa":        true,
+	"strconv.ParseBool":   true,
+	"strconv.ParseFloat":  true,
+	"strconv.ParseInt":    true,
+	"strconv.ParseUint":   true,
+	"strconv.Quote":       true,
+	"strconv.Unquote":     true,
+
+	"strings.Compare":      true,
+	"strings.Contains":     true,
+	"strings.ContainsAny":  true,
+	"strings.ContainsRune": true,
+	"strings.Count":        true,
+	"strings.Cut":          true,
+	"strings.CutPrefix":    true,
+	"strings.CutSuffix":    true,
+	"strings.EqualFold":    true,
+	"strings.Fields":       true,
+	"strings.HasPrefix":    true,
+	"strings.HasSuffix":    true,
+	"strings.Index":        true,
+	"strings.IndexAny":     true,
+	"strings.IndexByte":    true,
+	"strings.IndexRune":    true,
+	"strings.Join":         true,
+	"strings.LastIndex":    true,
+	"strings.Repeat":       true,
+	"strings.Replace":      true,
+	"strings.ReplaceAll":   true,
+	"strings.Split":        true,
+	"strings.SplitN":       true,
+	"strings.ToLower":      true,
+	"strings.ToTitle":      true,
+	"strings.ToUpper":      true,
+	"strings.Trim":         true,
+	"strings.TrimLeft":     true,
+	"strings.TrimPrefix":   true,
+	"strings.TrimRight":    true,
+	"strings.TrimSpace":    true,
+	"strings.TrimSuffix":   true,
+
+	"unicode.IsDigit":  true,
+	"unicode.IsLetter": true,
+	"unicode.IsLower":  true,
+	"unicode.IsNumber": true,
+	"unicode.IsPunct":  true,
+	"unicode.IsSpace":  true,
+	"unicode.IsUpper":  true,
+	"unicode.ToLower":  true,
+	"unicode.ToTitle":  true,
+	"unicode.ToUpper":  true,
+
+	"unicode/utf8.RuneCountInString": true,
+	"unicode/utf8.RuneLen":           true,
+	"unicode/utf8.ValidString":       true,
+}
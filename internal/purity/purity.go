@@ -0,0 +1,304 @@
+// Package purity checks that funcs have no side effects.
+//
+// A func cannot call procs, impure builtins or Go functions that are not
+// known to be pure, make async calls, or assign to variables it did not
+// declare, which includes writing through references. A call to a func of the
+// same package with side effects is reported with the call chain that leads
+// to the side effect.
+package purity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// impureBuiltins are the builtins with side effects.
+var impureBuiltins = map[string]bool{
+	"await":  true,
+	"delete": true,
+	"print":  true,
+}
+
+// Check reports the side effects of the funcs in files, which make up one
+// package.
+func Check(files []*ast.File) error {
+	c := &checker{
+		funcs:    make(map[string]*function),
+		literals: make(map[*ast.ProcedureLiteral]*function),
+	}
+
+	for _, file := range files {
+		for _, stmt := range file.Statements {
+			c.declare(file, stmt)
+		}
+	}
+
+	// Func literals are checked wherever they are, also in procs.
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			lit, ok := node.(*ast.ProcedureLiteral)
+			if !ok || !isFunc(lit) {
+				return true
+			}
+
+			fn, ok := c.literals[lit]
+			if !ok {
+				fn = &function{name: "func literal", file: file, literal: lit}
+				c.literals[lit] = fn
+			}
+
+			c.check(fn)
+
+			return true
+		})
+	}
+
+	return errors.Join(c.errs...)
+}
+
+type state uint8
+
+const (
+	unchecked state = iota
+	checking
+	checked
+)
+
+// function is a func, of which effect is the call chain to its first side
+// effect, or nil if it has none.
+type function struct {
+	name    string
+	file    *ast.File
+	literal *ast.ProcedureLiteral
+	state   state
+	effect  []string
+}
+
+type checker struct {
+	funcs    map[string]*function // global funcs and methods by name
+	literals map[*ast.ProcedureLiteral]*function
+	errs     []error
+}
+
+// declare registers the global func or method declared by stmt, so calls to
+// it can be followed.
+func (c *checker) declare(file *ast.File, stmt ast.Statement) {
+	var (
+		name string
+		decl *ast.Declaration
+	)
+
+	switch s := stmt.(type) {
+	case *ast.Declaration:
+		name, decl = s.Assignment.Identifier.Name, s
+	case *ast.Method:
+		name, decl = receiverName(s.Type)+"."+s.Declaration.Assignment.Identifier.Name, s.Declaration
+	default:
+		return
+	}
+
+	lit, ok := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+	if !ok || !isFunc(lit) {
+		return
+	}
+
+	fn := &function{name: name, file: file, literal: lit}
+
+	if decl.Assignment.Identifier.Qualifier == ast.QualifierCompileTime {
+		// Comp funcs are checked by the stricter compile-time evaluation.
+		fn.state = checked
+	}
+
+	c.funcs[name] = fn
+	c.literals[lit] = fn
+}
+
+// check reports the side effects in the body of fn, and returns the call
+// chain to its first side effect. A func that is being checked, because it
+// is called recursively, is assumed to have no side effects.
+func (c *checker) check(fn *function) []string {
+	if fn.state != unchecked {
+		return fn.effect
+	}
+
+	fn.state = checking
+
+	w := &walker{
+		c:      c,
+		fn:     fn,
+		locals: make(map[*ast.Identifier]bool),
+		names:  make(map[string]*ast.Identifier),
+	}
+
+	ast.Inspect(fn.literal.Body, w.visit)
+
+	fn.state = checked
+
+	return fn.effect
+}
+
+// callee returns the func of the package that expr calls, or nil if it is
+// not known.
+func (c *checker) callee(expr ast.Expression) *function {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		if e.Global {
+			return c.funcs[e.Name]
+		}
+	case *ast.Selector:
+		if e.Field.Qualifier == ast.QualifierMethod {
+			return c.funcs[receiverName(e.Expression.Type())+"."+e.Field.Name]
+		}
+	}
+
+	return nil
+}
+
+// walker walks the body of a func, of which locals are the variables it
+// declares, and names the same variables by name.
+type walker struct {
+	c      *checker
+	fn     *function
+	at     ast.Statement // statement being walked
+	locals map[*ast.Identifier]bool
+	names  map[string]*ast.Identifier
+}
+
+func (w *walker) visit(node ast.Node) bool {
+	if stmt, ok := node.(ast.Statement); ok {
+		w.at = stmt
+	}
+
+	switch n := node.(type) {
+	case *ast.ProcedureLiteral:
+		// Nested funcs are checked on their own, and procs can only be called.
+		return false
+	case *ast.Declaration:
+		w.declare(n.Assignment.Identifier)
+	case *ast.ForStatement:
+		w.declare(n.Value)
+		w.declare(n.Index)
+	case *ast.Match:
+		w.declare(n.Binding)
+	case *ast.Assignment:
+		w.assignment(n)
+	case *ast.Async:
+		w.report(n, fmt.Sprintf("func %q cannot make async calls", w.fn.name), "async "+n.Call.Expression.String())
+
+		// The call is reported as async.
+		return false
+	case *ast.Builtin:
+		if impureBuiltins[n.Name] {
+			w.report(n, fmt.Sprintf("func %q cannot call @%s", w.fn.name, n.Name), "@"+n.Name)
+		}
+	case *ast.GoCallExpression:
+		if !pureGoFuncs[n.Path+"."+n.CallIdentifier.Name] {
+			w.report(n, fmt.Sprintf("func %q cannot call %s, which is not known to be pure", w.fn.name, n), n.String())
+		}
+	case *ast.GoValue:
+		// Go variables are mutable global state, Go constants are not.
+		if n.Identifier.Qualifier != ast.QualifierImmutable {
+			w.report(n, fmt.Sprintf("func %q cannot read Go variable %s", w.fn.name, n), n.String())
+		}
+	case *ast.Call:
+		w.call(n)
+	}
+
+	return true
+}
+
+func (w *walker) declare(ident *ast.Identifier) {
+	if ident != nil {
+		w.locals[ident] = true
+		w.names[ident.Name] = ident
+	}
+}
+
+// assignment reports assignments to variables declared outside of the func,
+// and to fields through references.
+func (w *walker) assignment(n *ast.Assignment) {
+	name := n.Identifier.Name
+	if name == "_" || w.locals[n.Identifier] || n.Identifier.Qualifier == ast.QualifierDynamic {
+		// Assignments to dyn variables are rejected by the transpiler.
+		return
+	}
+
+	root, _, isField := strings.Cut(name, ".")
+	if !isField {
+		w.report(n, fmt.Sprintf("func %q cannot assign to variable %q, which it did not declare", w.fn.name, name), "assignment to "+name)
+		return
+	}
+
+	local, ok := w.names[root]
+
+	switch {
+	case !ok:
+		w.report(n, fmt.Sprintf("func %q cannot assign to field %q of variable %q, which it did not declare", w.fn.name, name, root), "assignment to "+name)
+	case local.ValueType != nil && local.ValueType.Kind() == types.ReferenceKind:
+		w.report(n, fmt.Sprintf("func %q cannot write through reference %q", w.fn.name, name), "assignment to "+name)
+	}
+}
+
+// call reports calls to procs, and to funcs of the package with side effects.
+// Calls are reported at their statement, as they have the position of the
+// declaration they refer to.
+func (w *walker) call(n *ast.Call) {
+	procType, ok := n.Expression.Type().Underlying().(*types.Procedure)
+	if !ok {
+		return
+	}
+
+	if !procType.Function {
+		w.report(w.at, fmt.Sprintf("func %q cannot call proc %q", w.fn.name, n.Expression), n.Expression.String())
+		return
+	}
+
+	if n.Package != "" {
+		// Funcs of other packages are checked in their own package.
+		return
+	}
+
+	callee := w.c.callee(n.Expression)
+	if callee == nil || callee == w.fn {
+		return
+	}
+
+	effect := w.c.check(callee)
+	if effect == nil {
+		return
+	}
+
+	chain := append([]string{callee.name}, effect...)
+
+	w.report(w.at, fmt.Sprintf("func %q cannot call %q, which has side effects: %s", w.fn.name, callee.name, strings.Join(append([]string{w.fn.name}, chain...), " -> ")), chain...)
+}
+
+// report reports the side effect at node, of which chain is the call chain
+// from the func.
+func (w *walker) report(node ast.Node, msg string, chain ...string) {
+	if w.fn.effect == nil {
+		w.fn.effect = chain
+	}
+
+	w.c.errs = append(w.c.errs, diag.At(w.fn.file, node, diag.CodeSideEffect, msg))
+}
+
+func isFunc(lit *ast.ProcedureLiteral) bool {
+	procType, ok := lit.ProcedureType.(*types.Procedure)
+	return ok && procType.Function
+}
+
+// receiverName returns the name of the type of a method receiver, which may be
+// a reference.
+func receiverName(typ types.Type) string {
+	if ref, ok := typ.(*types.Reference); ok {
+		typ = ref.Value
+	}
+
+	return typ.String()
+}
@@ -0,0 +1,255 @@
+package purity_test
+
+import (
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/parsetest"
+	"github.com/samborkent/cog/internal/purity"
+	"github.com/samborkent/cog/internal/types"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	for name, src := range map[string]string{
+		"locals": `sum : func(xs : []int64) int64 = {
+	var total : int64 = 0
+	for x in xs {
+		total = total + x
+	}
+	return total
+}`,
+		"pure_go": `goimport (
+	"strings"
+)
+
+upper : func(s : utf8) utf8 = {
+	return @go.strings.ToUpper(s)
+}`,
+		"go_constant": `goimport (
+	"math"
+)
+
+limit : func() int64 = {
+	return @go.math.MaxInt64
+}`,
+		"calls_func": `double : func(x : int64) int64 = {
+	return x * 2
+}
+
+quadruple : func(x : int64) int64 = {
+	return double(double(x))
+}`,
+		"recursive": `fib : func(n : int64) int64 = {
+	if n < 2 {
+		return n
+	}
+	return fib(n - 1) + fib(n - 2)
+}`,
+		"proc_side_effects": `log : proc(x : int64) = {
+	@print(x)
+}
+
+main : proc() = {
+	var count : int64 = 0
+	count = count + 1
+	log(count)
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := purity.Check([]*ast.File{parsetest.Parse(t, src)}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckShouldError(t *testing.T) {
+	t.Parallel()
+
+	for name, tt := range map[string]struct {
+		src  string
+		want string
+	}{
+		"print": {
+			src: `f : func(x : int64) int64 = {
+	@print(x)
+	return x
+}`,
+			want: `main.cog:4:2: func "f" cannot call @print`,
+		},
+		"proc_call": {
+			src: `log : proc(x : int64) = {
+	@print(x)
+}
+
+f : func(x : int64) int64 = {
+	log(x)
+	return x
+}`,
+			want: `main.cog:8:2: func "f" cannot call proc "log"`,
+		},
+		"impure_go": {
+			src: `goimport (
+	"os"
+)
+
+f : func() = {
+	@go.os.Exit(1)
+}`,
+			want: `main.cog:8:2: func "f" cannot call @go.os.Exit, which is not known to be pure`,
+		},
+		"generic_go": {
+			src: `goimport (
+	"slices"
+)
+
+f : func(xs : []int64) bool = {
+	return @go.slices.Contains(xs, 1)
+}`,
+			want: `main.cog:8:9: func "f" cannot call @go.slices.Contains, which is not known to be pure`,
+		},
+		"go_formatting": {
+			src: `goimport (
+	"fmt"
+)
+
+f : func(x : int64) utf8 = {
+	return @go.fmt.Sprint(x)
+}`,
+			want: `main.cog:8:9: func "f" cannot call @go.fmt.Sprint, which is not known to be pure`,
+		},
+		"go_variable": {
+			src: `goimport (
+	"os"
+)
+
+f : func() []utf8 = {
+	return @go.os.Args
+}`,
+			want: `main.cog:8:9: func "f" cannot read Go variable @go.os.Args`,
+		},
+		"indirect": {
+			src: `g : func(x : int64) int64 = {
+	@print(x)
+	return x
+}
+
+f : func(x : int64) int64 = {
+	return g(x) + 1
+}
+
+main : proc() = {
+	@print(f(1))
+}`,
+			want: `main.cog:9:2: func "f" cannot call "g", which has side effects: f -> g -> @print`,
+		},
+		"captured_variable": {
+			src: `main : proc() = {
+	var count : int64 = 0
+	inc : func() = {
+		count = count + 1
+	}
+	inc()
+}`,
+			want: `main.cog:6:9: func "func literal" cannot assign to variable "count", which it did not declare`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := purity.Check([]*ast.File{parsetest.Parse(t, tt.src)})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			diags := diag.All(err)
+			if len(diags) == 0 {
+				t.Fatalf("got no diagnostics: %v", err)
+			}
+
+			if diags[len(diags)-1].Code != diag.CodeSideEffect {
+				t.Errorf("got code %s, want %s", diags[len(diags)-1].Code, diag.CodeSideEffect)
+			}
+
+			if got := diags[len(diags)-1].Error(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCheckAsync checks a func making an async call, which the parser rejects,
+// so the func is made from a proc.
+func TestCheckAsync(t *testing.T) {
+	t.Parallel()
+
+	f := parsetest.Parse(t, `square : proc(x : int64) int64 = {
+	return x * x
+}
+
+f : proc(x : int64) int64 = {
+	s := async square(x)
+	return @await(s)
+}`)
+
+	decl, ok := f.Statements[1].(*ast.Declaration)
+	if !ok {
+		t.Fatalf("expected declaration, got %T", f.Statements[1])
+	}
+
+	decl.Assignment.Expression.(*ast.ProcedureLiteral).ProcedureType.(*types.Procedure).Function = true
+
+	err := purity.Check([]*ast.File{f})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	diags := diag.All(err)
+
+	// The called proc is not reported, as the call is reported as async.
+	want := []string{
+		`main.cog:8:7: func "f" cannot make async calls`,
+		`main.cog:9:9: func "f" cannot call @await`,
+	}
+
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), err)
+	}
+
+	for i, d := range diags {
+		if got := d.Error(); got != want[i] {
+			t.Errorf("diagnostic %d: got %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestCheckImportPath(t *testing.T) {
+	t.Parallel()
+
+	f := parsetest.Parse(t, `goimport (
+	"strings"
+)
+
+upper : func(s : utf8) utf8 = {
+	return @go.strings.ToUpper(s)
+}`)
+
+	// A package with the same name as a pure standard library package is not
+	// known to be pure.
+	ast.Inspect(f, func(node ast.Node) bool {
+		if call, ok := node.(*ast.GoCallExpression); ok {
+			call.Path = "example.com/strings"
+		}
+
+		return true
+	})
+
+	if err := purity.Check([]*ast.File{f}); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,138 @@
+package remap
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// lineColumns maps a Cog file and line to the Cog columns of the //line
+// directives for it, in ascending order.
+type lineColumns map[string]map[int][]int
+
+// column returns the Cog column of a Go position at col in the .cog file at
+// path: the column of the last directive for the line starting at or before
+// col. Go reports columns relative to the directive, so past the first token
+// of a statement they are not Cog columns. It returns 0 if the column is not
+// known.
+func (m *Mapper) column(path string, line, col int) int {
+	m.loadColumns.Do(func() {
+		m.columns = readColumns(m.dir)
+	})
+
+	cols := m.columns[path][line]
+
+	i, found := slices.BinarySearch(cols, col)
+	if found {
+		return col
+	}
+
+	if i == 0 {
+		return 0
+	}
+
+	return cols[i-1]
+}
+
+// readColumns collects the columns of the //line directives in the Go files
+// in dir.
+func readColumns(dir string) lineColumns {
+	columns := make(lineColumns)
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		columns.addFile(path)
+
+		return nil
+	})
+
+	for _, lines := range columns {
+		for line, cols := range lines {
+			slices.Sort(cols)
+			lines[line] = slices.Compact(cols)
+		}
+	}
+
+	return columns
+}
+
+// addFile adds the columns of the //line directives in a Go file.
+func (c lineColumns) addFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+
+	var directive string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if directive != "" {
+			c.add(filepath.Dir(path), directive, line)
+		}
+
+		directive, _ = strings.CutPrefix(line, "//line ")
+		if directive == line {
+			directive = ""
+		}
+	}
+}
+
+// add adds the column of a //line directive, given the Go line following it.
+// The transpiler subtracts the indentation of that line from the column, so
+// that Go reports the Cog column for its first token, which is added back.
+func (c lineColumns) add(dir, directive, next string) {
+	rest, colText, ok := cutLast(directive, ":")
+	if !ok {
+		return
+	}
+
+	name, lnText, ok := cutLast(rest, ":")
+	if !ok {
+		return
+	}
+
+	ln, err := strconv.Atoi(lnText)
+	if err != nil {
+		return
+	}
+
+	col, err := strconv.Atoi(colText)
+	if err != nil {
+		return
+	}
+
+	col += len(next) - len(strings.TrimLeft(next, " \t"))
+
+	name = filepath.FromSlash(name)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+
+	if c[name] == nil {
+		c[name] = make(map[int][]int)
+	}
+
+	c[name][ln] = append(c[name][ln], col)
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+
+	return s[:i], s[i+len(sep):], true
+}
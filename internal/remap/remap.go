@@ -0,0 +1,169 @@
+// Package remap rewrites the output of the Go toolchain and of transpiled
+// programs in terms of the Cog source: positions refer to .cog files, and Go
+// types and identifiers are replaced by their Cog counterparts.
+package remap
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// Mapper rewrites lines of Go output.
+type Mapper struct {
+	dir   string            // working directory of the Go command
+	cwd   string            // directory paths are shown relative to
+	names map[string]string // Go identifier → Cog identifier
+
+	loadColumns sync.Once
+	columns     lineColumns // read from the Go files in dir on first use
+}
+
+// New returns a mapper for the output of a Go command run in dir. Relative
+// paths in the output are resolved against dir.
+func New(dir string, names map[string]string) *Mapper {
+	cwd, err := os.Getwd()
+	if err != nil {
+		cwd = ""
+	}
+
+	return &Mapper{
+		dir:   dir,
+		cwd:   cwd,
+		names: names,
+	}
+}
+
+// Names returns the Go identifiers of the globals in the symbol table that
+// differ from their Cog identifiers, mapped to the Cog identifier.
+func Names(symbols *parser.SymbolTable, names map[string]string) map[string]string {
+	if names == nil {
+		names = make(map[string]string)
+	}
+
+	symbols.ForEachGlobal(func(name string, sym parser.Symbol) {
+		if name == "_" {
+			return
+		}
+
+		ident := sym.Identifier
+		goName := component.ConvertExport(name, ident.Exported, true)
+
+		if ident.Qualifier == ast.QualifierType {
+			switch ident.ValueType.Kind() {
+			case types.EnumKind:
+				goName += "Enum"
+			case types.ErrorKind:
+				goName += "Error"
+			}
+		}
+
+		if goName != name {
+			names[goName] = name
+		}
+	})
+
+	return names
+}
+
+var (
+	// cogPosition matches a position in a .cog file, as written by the Go
+	// compiler: path:line or path:line:column.
+	cogPosition = regexp.MustCompile(`([^\s:]*\.cogs?):(\d+)(:\d+)?`)
+
+	// goIdentifier matches Go identifiers, and selectors of Go packages
+	// imported by Cog code.
+	goIdentifier = regexp.MustCompile(`\bgo_(\w+)\.|[\pL_][\pL\pN_]*`)
+)
+
+// Line rewrites a diagnostic of the Go compiler or go vet. Lines without a
+// position in a .cog file are returned unchanged.
+func (m *Mapper) Line(line string) string {
+	loc := cogPosition.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+
+	return m.positions(line[:loc[1]]) + m.rewrite(m.positions(line[loc[1]:]))
+}
+
+// positions rewrites the paths of .cog positions in line to be relative to
+// the working directory, and their columns to those of the //line directives
+// they follow. Columns are dropped if no directive is known for them.
+func (m *Mapper) positions(line string) string {
+	return cogPosition.ReplaceAllStringFunc(line, func(pos string) string {
+		match := cogPosition.FindStringSubmatch(pos)
+		path := m.abs(match[1])
+
+		pos = m.rel(path) + ":" + match[2]
+
+		if match[3] == "" {
+			return pos
+		}
+
+		ln, _ := strconv.Atoi(match[2])
+		col, _ := strconv.Atoi(match[3][1:])
+
+		if col = m.column(path, ln, col); col > 0 {
+			pos += ":" + strconv.Itoa(col)
+		}
+
+		return pos
+	})
+}
+
+// path resolves a path written by the Go command, and returns it relative to
+// the working directory if it is inside it.
+func (m *Mapper) path(p string) string {
+	return m.rel(m.abs(p))
+}
+
+// abs resolves a path written by the Go command.
+func (m *Mapper) abs(p string) string {
+	p = filepath.FromSlash(p)
+
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(m.dir, p)
+	}
+
+	return p
+}
+
+// rel returns p relative to the working directory if it is inside it.
+func (m *Mapper) rel(p string) string {
+	if m.cwd == "" {
+		return p
+	}
+
+	rel, err := filepath.Rel(m.cwd, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return p
+	}
+
+	return rel
+}
+
+// rewrite replaces Go types and identifiers in a message by their Cog names.
+func (m *Mapper) rewrite(msg string) string {
+	msg = rewriteTypes(msg)
+
+	return goIdentifier.ReplaceAllStringFunc(msg, func(ident string) string {
+		if pkg, ok := strings.CutPrefix(ident, "go_"); ok && strings.HasSuffix(pkg, ".") {
+			return "@go." + pkg
+		}
+
+		if name, ok := m.names[ident]; ok {
+			return name
+		}
+
+		return ident
+	})
+}
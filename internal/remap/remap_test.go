@@ -0,0 +1,263 @@
+package remap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestMapper() *Mapper {
+	return &Mapper{
+		dir: "/cache/app",
+		cwd: "/home/user/app",
+		names: map[string]string{
+			"Add":             "add",
+			"_Helper":         "Helper",
+			"ParseErrorError": "ParseError",
+		},
+	}
+}
+
+func TestLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "no_cog_position",
+			in:   "main.go:3:1: cannot use x (variable of type string) as int value",
+			want: "main.go:3:1: cannot use x (variable of type string) as int value",
+		},
+		{
+			name: "relative_position",
+			in:   "../../../home/user/app/main.cog:4:6: declared and not used: x",
+			want: "main.cog:4: declared and not used: x",
+		},
+		{
+			name: "absolute_position_outside_cwd",
+			in:   "/src/lib/lib.cog:2: undefined: y",
+			want: "/src/lib/lib.cog:2: undefined: y",
+		},
+		{
+			name: "basic_types",
+			in:   "/home/user/app/main.cog:4:17: cannot use a (variable of type cog.ASCII) as string value",
+			want: "main.cog:4: cannot use a (variable of type ascii) as utf8 value",
+		},
+		{
+			name: "string_outside_type",
+			in:   "/home/user/app/main.cog:4:9: cannot convert \"a\" (untyped string constant) to type cog.Option[string]",
+			want: "main.cog:4: cannot convert \"a\" (untyped string constant) to type utf8?",
+		},
+		{
+			name: "go_map",
+			in:   "/home/user/app/main.cog:5:3: invalid operation: m == nil (mismatched types map[string][]cog.ASCII and untyped nil)",
+			want: "main.cog:5: invalid operation: m == nil (mismatched types map<utf8, []ascii> and untyped nil)",
+		},
+		{
+			name: "generic_types",
+			in:   "/home/user/app/main.cog:7:2: cannot use r (variable of struct type cog.Result[cog.Option[int64], ParseErrorError]) as int64 value",
+			want: "main.cog:7: cannot use r (variable of struct type int64? ! ParseError) as int64 value",
+		},
+		{
+			name: "identifiers",
+			in:   "/home/user/app/main.cog:9:2: _Helper redeclared in this block, Add not used",
+			want: "main.cog:9: Helper redeclared in this block, add not used",
+		},
+		{
+			name: "go_package",
+			in:   "/home/user/app/main.cog:3:7: undefined: go_strings.Reverse",
+			want: "main.cog:3: undefined: @go.strings.Reverse",
+		},
+		{
+			name: "path_not_rewritten",
+			in:   "/home/user/app/Add.cog:1:1: newline in string",
+			want: "Add.cog:1: newline in string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := newTestMapper().Line(tt.in); got != tt.want {
+				t.Errorf("Line(%q)\ngot:  %q\nwant: %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineColumns(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// The directive columns are aligned to the indentation of the next line.
+	gofile := "package main\n\n" +
+		"//line ../src/main.cog:3:1\n" +
+		"func main() {\n" +
+		"//line ../src/main.cog:4:4\n" +
+		"\tx := 1\n" +
+		"//line ../src/main.cog:4:14\n" +
+		"\ty := 2\n" +
+		"}\n"
+
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(gofile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(dir, nil)
+	m.cwd = filepath.Dir(dir)
+
+	tests := map[string]string{
+		// First token of a statement.
+		"../src/main.cog:4:5: declared and not used: x": "src/main.cog:4:5: declared and not used: x",
+		// Later token, reported relative to the directive.
+		"../src/main.cog:4:10: invalid operation": "src/main.cog:4:5: invalid operation",
+		"../src/main.cog:4:17: invalid operation": "src/main.cog:4:15: invalid operation",
+		// Before the first directive of the line.
+		"../src/main.cog:4:2: syntax error": "src/main.cog:4: syntax error",
+		// Line without directive.
+		"../src/main.cog:7:3: undefined: z": "src/main.cog:7: undefined: z",
+	}
+
+	for in, want := range tests {
+		if got := m.Line(in); got != want {
+			t.Errorf("Line(%q)\ngot:  %q\nwant: %q", in, got, want)
+		}
+	}
+}
+
+func TestRewriteTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"cog.Option[string]":                        "utf8?",
+		"cog.Set[cog.Int128]":                       "set<int128>",
+		"cog.Either[int8,cog.Float16]":              "int8 ^ float16",
+		"cog.ASCIIMap[cog.ASCII, []string]":         "map<ascii, []utf8>",
+		"cog.ASCIISet[cog.ASCII]":                   "set<ascii>",
+		"cog.Result[map[string]int64, string] here": "map<utf8, int64> ! utf8 here",
+		"of type map[cog.Set[string]]string)":       "of type map<set<utf8>, utf8>)",
+		"mycog.Option[int]":                         "mycog.Option[int]",
+		"cog.Option[int":                            "cog.Option[int",
+		"Box[string] of type []string":              "Box[utf8] of type []utf8",
+		"invalid string literal":                    "invalid string literal",
+		"stringer":                                  "stringer",
+	}
+
+	for in, want := range tests {
+		if got := rewriteTypes(in); got != want {
+			t.Errorf("rewriteTypes(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stack_trace", func(t *testing.T) {
+		t.Parallel()
+
+		in := `panic: runtime error: integer divide by zero
+
+goroutine 1 [running]:
+main.Add({0x4f1c28, 0xc000012345}, 0x1, 0x0)
+	/home/user/app/main.cog:5 +0x1d
+main._Helper(...)
+	/home/user/app/main.cog:9
+runtime.goexit({})
+	/usr/lib/go/src/runtime/asm_amd64.s:1700 +0x1
+created by main.main in goroutine 1
+	/home/user/app/main.cog:12 +0x25
+`
+
+		want := `panic: runtime error: integer divide by zero
+
+goroutine 1 [running]:
+main.add(...)
+	main.cog:5
+main.Helper(...)
+	main.cog:9
+runtime.goexit({})
+	/usr/lib/go/src/runtime/asm_amd64.s:1700 +0x1
+created by main.main in goroutine 1
+	main.cog:12
+`
+
+		var out strings.Builder
+
+		w := newTestMapper().Writer(&out)
+
+		// Write in chunks that split lines.
+		for chunk := range strings.SplitSeq(in, "+") {
+			if _, err := w.Write([]byte(chunk + "+")); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got := strings.TrimSuffix(out.String(), "+\n")
+		if got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("diagnostic_continuation", func(t *testing.T) {
+		t.Parallel()
+
+		in := "# app\n" +
+			"/home/user/app/main.cog:3:9: not enough arguments in call to Add\n" +
+			"\thave (string)\n" +
+			"\twant (string, cog.ASCII)\n" +
+			"\tstring is not a type\n"
+
+		want := "# app\n" +
+			"main.cog:3: not enough arguments in call to add\n" +
+			"\thave (utf8)\n" +
+			"\twant (utf8, ascii)\n" +
+			"\tstring is not a type\n"
+
+		var out strings.Builder
+
+		w := newTestMapper().Writer(&out)
+
+		if _, err := w.Write([]byte(in)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := out.String(); got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("unterminated_line", func(t *testing.T) {
+		t.Parallel()
+
+		var out strings.Builder
+
+		w := newTestMapper().Writer(&out)
+
+		if _, err := w.Write([]byte("main.Add(0x1)")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, want := out.String(), "main.Add(0x1)\n"; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package remap
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// genericTypes maps the generic types of the Cog runtime to a function
+// formatting their type arguments as Cog type.
+var genericTypes = map[string]func(args []string) string{
+	"cog.Option[": func(args []string) string {
+		return strings.Join(args, ", ") + "?"
+	},
+	"cog.Result[": func(args []string) string {
+		return strings.Join(args, " ! ")
+	},
+	"cog.Either[": func(args []string) string {
+		return strings.Join(args, " ^ ")
+	},
+	"cog.Set[": func(args []string) string {
+		return "set<" + strings.Join(args, ", ") + ">"
+	},
+	"cog.ASCIISet[": func(args []string) string {
+		return "set<" + strings.Join(args, ", ") + ">"
+	},
+	"cog.ASCIIMap[": func(args []string) string {
+		return "map<" + strings.Join(args, ", ") + ">"
+	},
+}
+
+var (
+	// cogBasics matches the runtime types of Cog basic types, which are named
+	// differently in Cog.
+	cogBasics = regexp.MustCompile(`\bcog\.(ASCII|Int128|Uint128|Float16|Complex32)\b`)
+
+	// goString matches the Go string type, which is utf8 in Cog. As the word
+	// is common in messages, it is only replaced in type positions.
+	goString = regexp.MustCompile(`\bstring\b`)
+
+	// typePosition matches the start of a type in a Go diagnostic: after
+	// "type", as in "(variable of type T)" and "to type T", after "as", as in
+	// "as T value", and the parameter lists of "have (T)" and "want (T)".
+	typePosition = regexp.MustCompile(`\btype |\bas |\b(?:have|want) \(`)
+
+	// typeArgumentList matches the start of the type arguments of a generic
+	// type or function.
+	typeArgumentList = regexp.MustCompile(`[\pL_][\pL\pN_]*\[`)
+)
+
+// rewriteTypes replaces the Go types of the Cog runtime in s by Cog types.
+func rewriteTypes(s string) string {
+	s = cogBasics.ReplaceAllStringFunc(expandGenerics(s), basicType)
+
+	var out strings.Builder
+
+	last := 0
+
+	for _, span := range typeSpans(s) {
+		if span[0] < last {
+			span[0] = last
+		}
+
+		if span[1] <= span[0] {
+			continue
+		}
+
+		out.WriteString(s[last:span[0]])
+		out.WriteString(goString.ReplaceAllString(s[span[0]:span[1]], "utf8"))
+
+		last = span[1]
+	}
+
+	out.WriteString(s[last:])
+
+	return out.String()
+}
+
+// rewriteType replaces the Go types of the Cog runtime in typ, which is a
+// type as a whole.
+func rewriteType(typ string) string {
+	typ = cogBasics.ReplaceAllStringFunc(expandGenerics(typ), basicType)
+
+	return goString.ReplaceAllString(typ, "utf8")
+}
+
+func basicType(typ string) string {
+	return strings.ToLower(strings.TrimPrefix(typ, "cog."))
+}
+
+// typeSpans returns the spans of s holding types, in ascending order.
+func typeSpans(s string) [][2]int {
+	var spans [][2]int
+
+	for _, loc := range typePosition.FindAllStringIndex(s, -1) {
+		start := loc[1]
+
+		switch {
+		case s[start-1] == '(':
+			// Parameter list, up to the closing parenthesis.
+			if _, end := typeArguments(s, start); end > 0 {
+				spans = append(spans, [2]int{start, end - 1})
+			}
+		case strings.HasPrefix(s[loc[0]:], "as "):
+			if end := typeEnd(s, start); strings.HasPrefix(s[end:], " value") {
+				spans = append(spans, [2]int{start, end})
+			}
+		default:
+			spans = append(spans, [2]int{start, typeEnd(s, start)})
+		}
+	}
+
+	for _, loc := range typeArgumentList.FindAllStringIndex(s, -1) {
+		if _, end := typeArguments(s, loc[1]); end > 0 {
+			spans = append(spans, [2]int{loc[1], end - 1})
+		}
+	}
+
+	slices.SortFunc(spans, func(a, b [2]int) int {
+		return a[0] - b[0]
+	})
+
+	return spans
+}
+
+// typeEnd returns the end of the type starting at s[start:]: the first space,
+// comma or unmatched closing bracket outside brackets.
+func typeEnd(s string, start int) int {
+	depth := 0
+
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{', '<':
+			depth++
+		case ']', ')', '}', '>':
+			if depth == 0 {
+				return i
+			}
+
+			depth--
+		case ' ', ',':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return len(s)
+}
+
+// expandGenerics replaces instantiations of the generic runtime types and Go
+// map types in s, including nested ones.
+func expandGenerics(s string) string {
+	var out strings.Builder
+
+	for i := 0; i < len(s); {
+		prefix, format := genericAt(s, i)
+		if format == nil {
+			out.WriteByte(s[i])
+			i++
+
+			continue
+		}
+
+		args, end := typeArguments(s, i+len(prefix))
+		if end < 0 {
+			// Unbalanced brackets, leave the rest as is.
+			out.WriteString(s[i:])
+			break
+		}
+
+		if prefix == goMap {
+			// The value type follows the key.
+			valueEnd := typeEnd(s, end)
+			args = append(args, s[end:valueEnd])
+			end = valueEnd
+		}
+
+		for j, arg := range args {
+			args[j] = rewriteType(arg)
+		}
+
+		out.WriteString(format(args))
+
+		i = end
+	}
+
+	return out.String()
+}
+
+// goMap is the prefix of Go map types.
+const goMap = "map["
+
+// genericAt returns the generic runtime type instantiated at s[i:], if any.
+func genericAt(s string, i int) (string, func([]string) string) {
+	// Do not match the suffix of another identifier.
+	if i > 0 && isIdentByte(s[i-1]) {
+		return "", nil
+	}
+
+	for prefix, format := range genericTypes {
+		if strings.HasPrefix(s[i:], prefix) {
+			return prefix, format
+		}
+	}
+
+	if strings.HasPrefix(s[i:], goMap) {
+		return goMap, genericTypes["cog.ASCIIMap["]
+	}
+
+	return "", nil
+}
+
+// typeArguments splits the type arguments starting at s[start:], up to the
+// closing bracket. It returns the index after the closing bracket, or -1 if
+// the brackets are not balanced.
+func typeArguments(s string, start int) ([]string, int) {
+	var args []string
+
+	depth := 0
+	argStart := start
+
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			if depth > 0 {
+				depth--
+				continue
+			}
+
+			return append(args, strings.TrimSpace(s[argStart:i])), i + 1
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[argStart:i]))
+				argStart = i + 1
+			}
+		}
+	}
+
+	return nil, -1
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b == '.' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
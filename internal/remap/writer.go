@@ -0,0 +1,164 @@
+package remap
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// frameLocation matches the location line of a stack frame in a .cog file.
+	frameLocation = regexp.MustCompile(`^\t([^\s:]*\.cogs?):(\d+)(?: \+0x[0-9a-f]+)?$`)
+
+	// frameFunction matches the function line of a stack frame.
+	frameFunction = regexp.MustCompile(`^(created by )?(\S+?)(\(.*\))?( in goroutine \d+)?$`)
+)
+
+// Writer rewrites the lines written to it, and passes them on. It handles
+// both diagnostics of the Go toolchain and panic stack traces.
+//
+// Lines are passed on once complete, and the function line of a stack frame
+// once its location is known. Close passes on the remainder.
+type Writer struct {
+	m *Mapper
+	w io.Writer
+
+	buf   []byte
+	frame string // function line of a stack frame, waiting for its location
+	diag  bool   // the previous line was a diagnostic in a .cog file
+}
+
+// Writer returns a writer rewriting the lines written to it before writing
+// them to w.
+func (m *Mapper) Writer(w io.Writer) *Writer {
+	return &Writer{m: m, w: w}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := string(w.buf[:i])
+		w.buf = w.buf[i+1:]
+
+		if err := w.line(line); err != nil {
+			return len(p), err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close writes any pending output. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if len(w.buf) > 0 {
+		line := string(w.buf)
+		w.buf = nil
+
+		if err := w.line(line); err != nil {
+			return err
+		}
+	}
+
+	return w.flushFrame()
+}
+
+func (w *Writer) line(line string) error {
+	if w.frame != "" {
+		if match := frameLocation.FindStringSubmatch(line); match != nil {
+			frame := w.m.frame(w.frame)
+			w.frame = ""
+
+			return w.write(frame + "\n\t" + w.m.path(match[1]) + ":" + match[2])
+		}
+
+		if err := w.flushFrame(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case isFrameFunction(line):
+		// Wait for the next line to tell whether the frame is in Cog code.
+		w.frame = line
+		w.diag = false
+
+		return nil
+	case cogPosition.MatchString(line):
+		w.diag = true
+		return w.write(w.m.Line(line))
+	case w.diag && strings.HasPrefix(line, "\t"):
+		// Continuation of a diagnostic, e.g. have and want of a call.
+		return w.write(w.m.rewrite(line))
+	default:
+		w.diag = false
+		return w.write(line)
+	}
+}
+
+func (w *Writer) flushFrame() error {
+	if w.frame == "" {
+		return nil
+	}
+
+	frame := w.frame
+	w.frame = ""
+
+	return w.write(frame)
+}
+
+func (w *Writer) write(line string) error {
+	_, err := io.WriteString(w.w, line+"\n")
+	return err
+}
+
+// isFrameFunction reports whether line may be the function line of a stack
+// frame: a qualified function call, or the goroutine creating it.
+func isFrameFunction(line string) bool {
+	if strings.HasPrefix(line, "created by ") {
+		return true
+	}
+
+	open := strings.IndexByte(line, '(')
+
+	return open > 0 && strings.HasSuffix(line, ")") && !strings.ContainsAny(line[:open], " \t") &&
+		strings.Contains(line[:open], ".")
+}
+
+// frame rewrites the function line of a stack frame in Cog code. Function
+// names are replaced by their Cog names, and the arguments, which are the
+// machine words of the Go function, are elided.
+func (m *Mapper) frame(line string) string {
+	match := frameFunction.FindStringSubmatch(line)
+	if match == nil {
+		return line
+	}
+
+	created, fn, args, goroutine := match[1], match[2], match[3], match[4]
+
+	// Leave the import path of the package as is.
+	pkgPath, name := "", fn
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		pkgPath, name = fn[:i+1], fn[i+1:]
+	}
+
+	name = goIdentifier.ReplaceAllStringFunc(name, func(ident string) string {
+		if cogName, ok := m.names[ident]; ok {
+			return cogName
+		}
+
+		return ident
+	})
+
+	if args != "" {
+		args = "(...)"
+	}
+
+	return created + pkgPath + name + args + goroutine
+}
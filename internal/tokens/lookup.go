@@ -9,6 +9,7 @@ var Runes = map[rune]Type{
 	'-': Minus,
 	'.': Dot,
 	'/': Divide,
+	'%': Percent,
 	':': Colon,
 	'<': LT,
 	'=': Assign,
@@ -33,6 +34,7 @@ var Keywords = map[string]Type{
 	Bool.String():       Bool,
 	Break.String():      Break,
 	Case.String():       Case,
+	Comp.String():       Comp,
 	Comparable.String(): Comparable,
 	Complex32.String():  Complex32,
 	Complex64.String():  Complex64,
@@ -60,6 +62,7 @@ var Keywords = map[string]Type{
 	Int64.String():      Int64,
 	Int128.String():     Int128,
 	Interface.String():  Interface,
+	Iter.String():       Iter,
 	Map.String():        Map,
 	Match.String():      Match,
 	Number.String():     Number,
@@ -69,6 +72,7 @@ var Keywords = map[string]Type{
 	Return.String():     Return,
 	Select.String():     Select,
 	Set.String():        Set,
+	Signal.String():     Signal,
 	Signed.String():     Signed,
 	String.String():     String,
 	Struct.String():     Struct,
@@ -1,6 +1,9 @@
 package tokens
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf8"
+)
 
 type Token struct {
 	Type    Type
@@ -10,20 +13,41 @@ type Token struct {
 	Col     uint16
 }
 
+// String formats the token with its line and column. The file is left out,
+// as a token does not know its path; diagnostics name the file.
 func (t Token) String() string {
 	if t.Literal == "" {
-		return fmt.Sprintf("file %d, ln %d, col %d: %s",
-			t.FileID, t.Ln, t.Col, t.Type,
+		return fmt.Sprintf("%d:%d: %s",
+			t.Ln, t.Col, t.Type,
 		)
 	}
 
 	if t.Type == Builtin {
-		return fmt.Sprintf("file %d, ln %d, col %d: @%s",
-			t.FileID, t.Ln, t.Col, t.Literal,
+		return fmt.Sprintf("%d:%d: @%s",
+			t.Ln, t.Col, t.Literal,
 		)
 	}
 
-	return fmt.Sprintf("file %d, ln %d, col %d: %s: %s",
-		t.FileID, t.Ln, t.Col, t.Type, t.Literal,
+	return fmt.Sprintf("%d:%d: %s: %s",
+		t.Ln, t.Col, t.Type, t.Literal,
 	)
 }
+
+// Width returns the number of runes of the source text of the token.
+func (t Token) Width() int {
+	switch t.Type {
+	case EOF:
+		return 1
+	case StringLiteral:
+		// The quotes are not part of the literal.
+		return utf8.RuneCountInString(t.Literal) + 2
+	case Builtin:
+		return utf8.RuneCountInString(t.Literal) + 1
+	}
+
+	if t.Literal != "" {
+		return utf8.RuneCountInString(t.Literal)
+	}
+
+	return max(utf8.RuneCountInString(t.Type.String()), 1)
+}
@@ -17,7 +17,8 @@ const (
 	Plus                      // +
 	Minus                     // -
 	Asterisk                  // *
-	Divide                    // -
+	Divide                    // /
+	Percent                   // %
 	Question                  // ?
 	Tilde                     // ~
 	Pipe                      // |
@@ -34,9 +35,20 @@ const (
 	Declaration // :=
 	BitAnd      // &
 	BitXor      // ^
+	BitClear    // &^
+	BitOr       // |||
 	And         // &&
 	Or          // ||
 
+	// Range operators
+	Range          // ..
+	RangeInclusive // ..=
+
+	// Shift operators, two adjacent < or > tokens combined by the parser, as
+	// > also closes type arguments.
+	ShiftLeft  // <<
+	ShiftRight // >>
+
 	// Literals
 	Identifier
 	Bool
@@ -96,6 +108,8 @@ const (
 	Enum
 	Map
 	Set
+	Signal
+	Iter
 	Interface
 
 	// Type interface
@@ -119,6 +133,7 @@ const (
 	// Type qualifiers
 	Variable // var
 	Dynamic  // dyn
+	Comp     // comp
 
 	// Comment
 	Comment
@@ -156,6 +171,8 @@ func (t Type) String() string {
 		return "*"
 	case Divide:
 		return "/"
+	case Percent:
+		return "%"
 	case Question:
 		return "?"
 	case Tilde:
@@ -184,10 +201,22 @@ func (t Type) String() string {
 		return "&"
 	case BitXor:
 		return "^"
+	case BitClear:
+		return "&^"
+	case BitOr:
+		return "|||"
 	case And:
 		return "&&"
 	case Or:
 		return "||"
+	case Range:
+		return ".."
+	case RangeInclusive:
+		return "..="
+	case ShiftLeft:
+		return "<<"
+	case ShiftRight:
+		return ">>"
 	case Identifier:
 		return "identifier"
 	case Bool:
@@ -282,6 +311,10 @@ func (t Type) String() string {
 		return "map"
 	case Set:
 		return "set"
+	case Signal:
+		return "signal"
+	case Iter:
+		return "iter"
 	case Interface:
 		return "interface"
 	case Int:
@@ -316,6 +349,8 @@ func (t Type) String() string {
 		return "var"
 	case Dynamic:
 		return "dyn"
+	case Comp:
+		return "comp"
 	case Comment:
 		return "comment"
 	case EOF:
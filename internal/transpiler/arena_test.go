@@ -171,9 +171,8 @@ main : proc() = {}
 compute : func(n : int64) int64 = {
 	xs := @slice<int64>(n)
 	ys := @slice<int64>(n)
-	@print(xs)
-	@print(ys)
-	return n
+	size := @len(xs) + @len(ys)
+	return @cast<int64>(size)
 }`)
 		mustContain(t, got, "cog.NewArena()")
 		mustContain(t, got, "cog.MakeSlice[int64]")
@@ -0,0 +1,41 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// isASCIIKeyed reports whether typ is a map with ascii keys or a set of ascii
+// values, which convert to cog.ASCIIMap and cog.ASCIISet.
+func isASCIIKeyed(typ types.Type) bool {
+	switch container := typ.Underlying().(type) {
+	case *types.Map:
+		return container.Key.Kind() == types.ASCII
+	case *types.Set:
+		return container.Element.Kind() == types.ASCII
+	default:
+		return false
+	}
+}
+
+// convertASCIIKey converts an ascii expression to its key in a cog.ASCIIMap or
+// cog.ASCIISet. Literals convert to string literals.
+func (t *Transpiler) convertASCIIKey(key ast.Expression) (goast.Expr, error) {
+	switch lit := key.(type) {
+	case *ast.ASCIILiteral:
+		return component.UTF8Lit(string(lit.Value)), nil
+	case *ast.UTF8Literal:
+		return component.UTF8Lit(lit.Value), nil
+	}
+
+	expr, err := t.convertExpr(key)
+	if err != nil {
+		return nil, fmt.Errorf("converting ascii key: %w", err)
+	}
+
+	return component.ASCIIKey(expr), nil
+}
@@ -0,0 +1,121 @@
+package transpiler
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	"strconv"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// convertAsync converts an async procedure call. The procedure runs in a
+// goroutine with a child of the caller's context, so cancelling main cancels
+// it as well. The arguments are bound to parameters of a wrapping function, so
+// they are evaluated by the caller, like the arguments of a go statement:
+//
+//	func(_arg0 A) *cog.Future[T] {
+//		return cog.Async(ctx, func(ctx context.Context) T { return proc(ctx, _arg0) })
+//	}(arg)
+func (t *Transpiler) convertAsync(node *ast.Async) (goast.Expr, error) {
+	procType, ok := node.Call.Expression.Type().(*types.Procedure)
+	if !ok {
+		return nil, fmt.Errorf("failed to assert procedure type for async call %q", node.Call.Expression)
+	}
+
+	expr, err := t.convertExpr(node.Call)
+	if err != nil {
+		return nil, fmt.Errorf("converting async call: %w", err)
+	}
+
+	call, ok := expr.(*goast.CallExpr)
+	if !ok {
+		return nil, errors.New("async call did not convert to a call expression")
+	}
+
+	var valueType goast.Expr
+
+	if node.SignalType.Value != nil {
+		valueType, err = t.convertType(node.SignalType.Value)
+		if err != nil {
+			return nil, fmt.Errorf("converting async return type: %w", err)
+		}
+	}
+
+	var parent goast.Expr = component.ContextBackground
+
+	if t.currentFileNeedsContext() {
+		if err := t.symbols.MarkUsed("ctx"); err != nil {
+			return nil, err
+		}
+
+		parent = component.ContextVar
+	}
+
+	t.addCogImport()
+	t.addStdLibImport("context")
+
+	if len(node.Call.Arguments) == 0 {
+		return component.Async(parent, valueType, call), nil
+	}
+
+	// Type arguments of a generic procedure, to find the parameter types.
+	typeArgs := make(map[string]types.Type, len(node.Call.TypeArgs))
+	for i, ta := range node.Call.TypeArgs {
+		if i < len(procType.TypeParams) {
+			typeArgs[procType.TypeParams[i].Name] = ta
+		}
+	}
+
+	// The explicit arguments come after the context, and before the defaults.
+	offset := len(call.Args) - len(procType.Parameters)
+
+	params := make([]*goast.Field, 0, len(node.Call.Arguments))
+	args := make([]goast.Expr, 0, len(node.Call.Arguments))
+
+	for i := range node.Call.Arguments {
+		typ := procType.Parameters[i].Type
+		if len(typeArgs) > 0 {
+			typ = types.SubstituteType(typ, typeArgs)
+		}
+
+		paramType, err := t.convertType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("converting async parameter %d type: %w", i, err)
+		}
+
+		ident := &goast.Ident{Name: "_arg" + strconv.Itoa(i)}
+
+		params = append(params, &goast.Field{
+			Names: []*goast.Ident{ident},
+			Type:  paramType,
+		})
+		args = append(args, call.Args[offset+i])
+
+		call.Args[offset+i] = ident
+	}
+
+	signalType, err := t.convertType(node.SignalType)
+	if err != nil {
+		return nil, fmt.Errorf("converting signal type: %w", err)
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.FuncLit{
+			Type: &goast.FuncType{
+				Params: &goast.FieldList{List: params},
+				Results: &goast.FieldList{List: []*goast.Field{
+					{Type: signalType},
+				}},
+			},
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.ReturnStmt{Results: []goast.Expr{
+					component.Async(parent, valueType, call),
+				}},
+			}},
+		},
+		Args: args,
+	}, nil
+}
@@ -14,17 +14,72 @@ import (
 type Builtins string
 
 const (
-	BuiltinCast  Builtins = "cast"
-	BuiltinIf    Builtins = "if"
-	BuiltinMap   Builtins = "map"
-	BuiltinPrint Builtins = "print"
-	BuiltinRef   Builtins = "ref"
-	BuiltinSet   Builtins = "set"
-	BuiltinSlice Builtins = "slice"
+	BuiltinAwait  Builtins = "await"
+	BuiltinCast   Builtins = "cast"
+	BuiltinCopy   Builtins = "copy"
+	BuiltinDelete Builtins = "delete"
+	BuiltinFormat Builtins = "format"
+	BuiltinIf     Builtins = "if"
+	BuiltinLen    Builtins = "len"
+	BuiltinMap    Builtins = "map"
+	BuiltinPrint  Builtins = "print"
+	BuiltinRef    Builtins = "ref"
+	BuiltinSet    Builtins = "set"
+	BuiltinSlice  Builtins = "slice"
 )
 
 func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 	switch Builtins(node.Name) {
+	case BuiltinAwait:
+		if len(node.Arguments) != 1 {
+			return nil, fmt.Errorf("wrong number of arguments, got %d", len(node.Arguments))
+		}
+
+		signal, err := t.convertExpr(node.Arguments[0])
+		if err != nil {
+			return nil, fmt.Errorf("converting @await builtin signal: %w", err)
+		}
+
+		return &goast.CallExpr{
+			Fun: &goast.SelectorExpr{
+				X:   signal,
+				Sel: &goast.Ident{Name: "Await"},
+			},
+		}, nil
+	case BuiltinDelete:
+		if len(node.Arguments) != 2 {
+			return nil, fmt.Errorf("@delete expects 2 arguments, got %d", len(node.Arguments))
+		}
+
+		container, err := t.convertExpr(node.Arguments[0])
+		if err != nil {
+			return nil, fmt.Errorf("converting @delete builtin map argument: %w", err)
+		}
+
+		var key goast.Expr
+
+		if isASCIIKeyed(node.Arguments[0].Type()) {
+			key, err = t.convertASCIIKey(node.Arguments[1])
+		} else {
+			key, err = t.convertExpr(node.Arguments[1])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("converting @delete builtin key argument: %w", err)
+		}
+
+		return component.BuiltinDelete(container, key), nil
+	case BuiltinLen:
+		if len(node.Arguments) != 1 {
+			return nil, fmt.Errorf("@len expects 1 argument, got %d", len(node.Arguments))
+		}
+
+		arg, err := t.convertExpr(node.Arguments[0])
+		if err != nil {
+			return nil, fmt.Errorf("converting @len builtin argument: %w", err)
+		}
+
+		return component.BuiltinLen(arg), nil
 	case BuiltinIf:
 		if len(node.Arguments) == 0 || len(node.Arguments) > 3 {
 			return nil, fmt.Errorf("wrong number of arguments, got %d", len(node.Arguments))
@@ -87,14 +142,9 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 			return nil, fmt.Errorf("@map expects at most 1 argument, got %d", len(node.Arguments))
 		}
 
-		keyType, err := t.convertType(node.TypeArguments[0])
-		if err != nil {
-			return nil, fmt.Errorf("converting @map builtin key type: %w", err)
-		}
-
-		valueType, err := t.convertType(node.TypeArguments[1])
+		mapType, err := t.convertType(&types.Map{Key: node.TypeArguments[0], Value: node.TypeArguments[1]})
 		if err != nil {
-			return nil, fmt.Errorf("converting @map builtin value type: %w", err)
+			return nil, fmt.Errorf("converting @map builtin map type: %w", err)
 		}
 
 		var capacity goast.Expr
@@ -105,39 +155,26 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 				return nil, fmt.Errorf("converting @map builtin capacity argument: %w", err)
 			}
 
-			switch node.Arguments[0].(type) {
-			case *ast.Prefix:
+			if isNegative(node.Arguments[0]) {
 				return nil, errors.New("@map capacity must be positive")
 			}
 		}
 
-		return component.BuiltinMap(keyType, valueType, capacity), nil
-	case BuiltinPrint:
-		if len(node.Arguments) != 1 {
-			return nil, fmt.Errorf("print expects 1 argument, got %d", len(node.Arguments))
-		}
-
-		arg, err := t.convertExpr(node.Arguments[0])
+		return component.BuiltinMap(mapType, capacity), nil
+	case BuiltinFormat:
+		str, err := t.convertFormat(node.Arguments)
 		if err != nil {
-			return nil, fmt.Errorf("converting print argument: %w", err)
+			return nil, fmt.Errorf("converting @format arguments: %w", err)
 		}
 
-		// Print underlying value of enum/error instead of enum itself.
-		if node.Arguments[0].Type().Kind() == types.EnumKind || node.Arguments[0].Type().Kind() == types.ErrorKind {
-			enumType, ok := node.Arguments[0].Type().(*types.Alias)
-			if !ok {
-				return nil, fmt.Errorf("unable to cast enum to alias for @print argument")
-			}
-
-			arg = &goast.IndexExpr{
-				X:     &goast.Ident{Name: component.ConvertExport(enumType.Name, enumType.Exported, enumType.Global)},
-				Index: arg,
-			}
+		return str, nil
+	case BuiltinPrint:
+		str, err := t.convertFormat(node.Arguments)
+		if err != nil {
+			return nil, fmt.Errorf("converting @print arguments: %w", err)
 		}
 
-		t.addBuiltinImport()
-
-		return component.BuiltinPrint(arg), nil
+		return component.BuiltinPrint(str), nil
 	case BuiltinRef:
 		if len(node.TypeArguments) != 1 {
 			return nil, fmt.Errorf("@ref expects 1 type argument, got %d", len(node.TypeArguments))
@@ -162,13 +199,11 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 			return nil, fmt.Errorf("@set expects at most 1 argument, got %d", len(node.Arguments))
 		}
 
-		keyType, err := t.convertType(node.TypeArguments[0])
+		setType, err := t.convertType(&types.Set{Element: node.TypeArguments[0]})
 		if err != nil {
-			return nil, fmt.Errorf("converting @set builtin key type: %w", err)
+			return nil, fmt.Errorf("converting @set builtin set type: %w", err)
 		}
 
-		t.addCogImport()
-
 		var capacity goast.Expr
 
 		if len(node.Arguments) == 1 {
@@ -177,13 +212,12 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 				return nil, fmt.Errorf("converting @set builtin capacity argument: %w", err)
 			}
 
-			switch node.Arguments[0].(type) {
-			case *ast.Prefix:
+			if isNegative(node.Arguments[0]) {
 				return nil, errors.New("@set capacity must be positive")
 			}
 		}
 
-		return component.BuiltinSet(keyType, capacity), nil
+		return component.BuiltinSet(setType, capacity), nil
 	case BuiltinSlice:
 		if len(node.TypeArguments) < 1 || len(node.TypeArguments) > 2 {
 			return nil, fmt.Errorf("@slice expects 1 or 2 type arguments, got %d", len(node.TypeArguments))
@@ -203,8 +237,7 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 			return nil, fmt.Errorf("converting @slice length argument: %w", err)
 		}
 
-		switch node.Arguments[0].(type) {
-		case *ast.Prefix:
+		if isNegative(node.Arguments[0]) {
 			return nil, errors.New("@slice length must be positive")
 		}
 
@@ -216,8 +249,7 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 				return nil, fmt.Errorf("converting @slice capacity argument: %w", err)
 			}
 
-			switch node.Arguments[1].(type) {
-			case *ast.Prefix:
+			if isNegative(node.Arguments[1]) {
 				return nil, errors.New("@slice capacity must be positive")
 			}
 		}
@@ -241,6 +273,29 @@ func (t *Transpiler) convertBuiltin(node *ast.Builtin) (goast.Expr, error) {
 		dstKind := node.TypeArguments[0].Kind()
 
 		return t.convertCast(arg, srcKind, dstKind)
+	case BuiltinCopy:
+		if len(node.Arguments) != 1 {
+			return nil, fmt.Errorf("@copy expects 1 argument, got %d", len(node.Arguments))
+		}
+
+		arg, err := t.convertExpr(node.Arguments[0])
+		if err != nil {
+			return nil, fmt.Errorf("converting @copy argument: %w", err)
+		}
+
+		typ := node.Arguments[0].Type()
+
+		// Option and result identifiers are unwrapped to their value.
+		if ident, ok := node.Arguments[0].(*ast.Identifier); ok {
+			switch v := ident.ValueType.(type) {
+			case *types.Option:
+				typ = v.Value
+			case *types.Result:
+				typ = v.Value
+			}
+		}
+
+		return t.convertCopy(arg, typ)
 	default:
 		return nil, fmt.Errorf("unknown builtin function '%s'", node.Name)
 	}
@@ -548,3 +603,24 @@ func uintNameForBits(bits int) string {
 		return "uint64"
 	}
 }
+
+// isNegative reports whether expr is a negated expression or a negative
+// literal.
+func isNegative(expr ast.Expression) bool {
+	switch n := expr.(type) {
+	case *ast.Prefix:
+		return true
+	case *ast.Int8Literal:
+		return n.Value < 0
+	case *ast.Int16Literal:
+		return n.Value < 0
+	case *ast.Int32Literal:
+		return n.Value < 0
+	case *ast.Int64Literal:
+		return n.Value < 0
+	case *ast.Int128Literal:
+		return n.Value.Sign() < 0
+	default:
+		return false
+	}
+}
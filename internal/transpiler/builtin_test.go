@@ -14,6 +14,39 @@ main : proc() = {
 		mustContain(t, got, "builtin.Print")
 	})
 
+	t.Run("print format string", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	x : int64 = 255
+	y := 1.5
+	@print("x = {x:>4x}, y = {y:.2f}")
+}`)
+		mustContain(t, got, `builtin.Print("x = " + builtin.Pad(builtin.IntVerb(x, 'x'), 4, false, false) + ", y = " + builtin.FloatVerb(y, 'f', 2, 64))`)
+	})
+
+	t.Run("print arguments", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	x : uint8 = 1
+	@print(x, true)
+}`)
+		mustContain(t, got, `builtin.Print(builtin.Uint(x) + " " + builtin.Bool(true))`)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	xs := []int64{1, 2}
+	s := @format("{xs}")
+	@print(s)
+}`)
+		mustContain(t, got, "builtin.Slice(xs, func(v int64) string {")
+		mustContain(t, got, "return builtin.Int(v)")
+	})
+
 	t.Run("if", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, `package p
@@ -75,6 +108,80 @@ main : proc() = {
 	})
 }
 
+func TestConvertBuiltinCopy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slice", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	xs : []int64 = {1, 2}
+	ys := @copy(xs)
+	@print(ys)
+}`)
+		mustContain(t, got, "var ys []int64 = go_slices.Clone(xs)")
+	})
+
+	t.Run("not_shared", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	x : int128 = 5
+	y := @copy(x)
+	@print(y)
+}`)
+		mustContain(t, got, "var y cog.Int128 = x")
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+Bag ~ struct {
+	name : utf8
+	items : []int64
+}
+
+main : proc() = {
+	bag : Bag = Bag{name = "a", items = {1}}
+	other := @copy(bag)
+	@print(other.name)
+}`)
+		mustContain(t, got, "func copy_Bag(x _Bag) _Bag {")
+		mustContain(t, got, "x.items = go_slices.Clone(x.items)")
+		mustContain(t, got, "var other _Bag = copy_Bag(bag)")
+		mustNotContain(t, got, "x.name =")
+	})
+
+	t.Run("exported", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+export Bag ~ struct {
+	items : []int64
+}
+
+main : proc() = {
+	bag : Bag = Bag{items = {1}}
+	other := @copy(bag)
+	@print(@len(other.items))
+}`)
+		mustContain(t, got, "func Copy_Bag(x Bag) Bag {")
+		mustContain(t, got, "var other Bag = Copy_Bag(bag)")
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	m : map<utf8, []int64> = {"a": {1}}
+	n := @copy(m)
+	@print(@len(n))
+}`)
+		mustContain(t, got, "func copy_map_utf8_slice_int64(x map[string][]int64) map[string][]int64 {")
+		mustContain(t, got, "y[k] = go_slices.Clone(v)")
+		mustContain(t, got, "var n map[string][]int64 = copy_map_utf8_slice_int64(m)")
+	})
+}
+
 func TestConvertBuiltinCast(t *testing.T) {
 	t.Parallel()
 
@@ -190,3 +297,35 @@ main : proc() = {
 		mustContain(t, got, "!= 0")
 	})
 }
+
+func TestConvertBuiltinCopyImport(t *testing.T) {
+	t.Parallel()
+
+	pkg := loadPackage(t, map[string]string{
+		"main.cog": `package main
+
+import (
+	"bag"
+)
+
+main : proc() = {
+	var b : bag.Bag = {items = {2, 1}}
+	c := @copy(b)
+	@print(c)
+}`,
+		"bag/bag.cog": `package bag
+
+export Bag ~ struct {
+	export (
+		items : []int64
+	)
+}`,
+	})
+
+	gotBag := transpileLoaded(t, pkg.Imports["bag"])
+	mustContain(t, gotBag, "func Copy_Bag(x Bag) Bag {")
+
+	gotMain := transpileLoaded(t, pkg)
+	mustContain(t, gotMain, "var c bag.Bag = bag.Copy_Bag(b)")
+	mustNotContain(t, gotMain, "func Copy_Bag")
+}
@@ -4,6 +4,8 @@ import (
 	goast "go/ast"
 	gotoken "go/token"
 	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
 )
 
 // blockCommentMarker is a sentinel prefix used to mark lines that originated
@@ -56,3 +58,32 @@ func toLineComments(text string) []*goast.Comment {
 
 	return comments
 }
+
+// docComments returns the comments on the lines directly above node, which
+// make up its doc comment.
+func docComments(preceding []ast.Statement, node ast.Node) []*ast.Comment {
+	ln, _ := node.Pos()
+	first := len(preceding)
+
+	for i := len(preceding) - 1; i >= 0; i-- {
+		comment, ok := preceding[i].(*ast.Comment)
+		if !ok {
+			break
+		}
+
+		commentLn, _ := comment.Pos()
+		if commentLn+uint32(strings.Count(comment.Text, "\n")) != ln-1 {
+			break
+		}
+
+		first = i
+		ln = commentLn
+	}
+
+	doc := make([]*ast.Comment, 0, len(preceding)-first)
+	for _, stmt := range preceding[first:] {
+		doc = append(doc, stmt.(*ast.Comment))
+	}
+
+	return doc
+}
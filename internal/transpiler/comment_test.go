@@ -14,6 +14,18 @@ main : proc() = {}`)
 		mustNotContain(t, got, "__cogReplaceMe")
 	})
 
+	t.Run("doc_comment_after_line_directive", func(t *testing.T) {
+		t.Parallel()
+		got := transpileWithPrint(t, `package p
+
+// double doubles x.
+// It is pure.
+double : func(x : int64) int64 = {
+	return x * 2
+}`)
+		mustContain(t, got, "//line test.cog:3\n// double doubles x.\n// It is pure.\nfunc double(")
+	})
+
 	t.Run("top_level_block_comment", func(t *testing.T) {
 		t.Parallel()
 		got := transpileWithPrint(t, `package p
@@ -2,6 +2,7 @@ package component
 
 import (
 	goast "go/ast"
+	gotoken "go/token"
 )
 
 // Pre-allocated builtin selectors.
@@ -16,6 +17,10 @@ var (
 		X:   builtinPkg,
 		Sel: &goast.Ident{Name: "Print"},
 	}
+	builtinRangeSel = &goast.SelectorExpr{
+		X:   builtinPkg,
+		Sel: &goast.Ident{Name: "Range"},
+	}
 
 	cogPkg = &goast.Ident{Name: "cog"}
 )
@@ -36,13 +41,8 @@ func BuiltinIf(ifType, boolType goast.Expr, args ...goast.Expr) *goast.CallExpr
 	}
 }
 
-// BuiltinMap generates make(map[K]V) or make(map[K]V, cap).
-func BuiltinMap(keyType, valueType, capacity goast.Expr) *goast.CallExpr {
-	mapType := &goast.MapType{
-		Key:   keyType,
-		Value: valueType,
-	}
-
+// BuiltinMap generates make(T) or make(T, cap) for map type T.
+func BuiltinMap(mapType, capacity goast.Expr) *goast.CallExpr {
 	args := []goast.Expr{mapType}
 	if capacity != nil {
 		args = append(args, capacity)
@@ -54,6 +54,36 @@ func BuiltinMap(keyType, valueType, capacity goast.Expr) *goast.CallExpr {
 	}
 }
 
+// BuiltinLen generates uint64(len(x)).
+func BuiltinLen(x goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.Ident{Name: "uint64"},
+		Args: []goast.Expr{&goast.CallExpr{
+			Fun:  &goast.Ident{Name: "len"},
+			Args: []goast.Expr{x},
+		}},
+	}
+}
+
+// BuiltinDelete generates delete(m, key).
+func BuiltinDelete(m, key goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun:  &goast.Ident{Name: "delete"},
+		Args: []goast.Expr{m, key},
+	}
+}
+
+// BuiltinRange generates builtin.Range[T](start, end, step, inclusive).
+func BuiltinRange(elemType, start, end, step goast.Expr, inclusive bool) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.IndexExpr{
+			X:     builtinRangeSel,
+			Index: elemType,
+		},
+		Args: []goast.Expr{start, end, step, BoolLit(inclusive)},
+	}
+}
+
 func BuiltinPrint(arg goast.Expr) *goast.CallExpr {
 	return &goast.CallExpr{
 		Fun:  builtinPrintSel,
@@ -61,6 +91,35 @@ func BuiltinPrint(arg goast.Expr) *goast.CallExpr {
 	}
 }
 
+// BuiltinFormat generates builtin.name(args...), a call of a formatter of the
+// builtin package.
+func BuiltinFormat(name string, args ...goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun:  Selector(builtinPkg, name),
+		Args: args,
+	}
+}
+
+// Concat generates the concatenation of string expressions, or "" if there
+// are none.
+func Concat(exprs ...goast.Expr) goast.Expr {
+	if len(exprs) == 0 {
+		return UTF8Lit("")
+	}
+
+	concat := exprs[0]
+
+	for _, expr := range exprs[1:] {
+		concat = &goast.BinaryExpr{
+			X:  concat,
+			Op: gotoken.ADD,
+			Y:  expr,
+		}
+	}
+
+	return concat
+}
+
 // BuiltinPtr generates new(T).
 func BuiltinPtr(valueType goast.Expr) *goast.CallExpr {
 	return &goast.CallExpr{
@@ -69,16 +128,8 @@ func BuiltinPtr(valueType goast.Expr) *goast.CallExpr {
 	}
 }
 
-// BuiltinSet generates make(cog.Set[K]) or make(cog.Set[K], cap).
-func BuiltinSet(keyType, capacity goast.Expr) *goast.CallExpr {
-	setType := &goast.IndexExpr{
-		X: &goast.SelectorExpr{
-			X:   cogPkg,
-			Sel: &goast.Ident{Name: "Set"},
-		},
-		Index: keyType,
-	}
-
+// BuiltinSet generates make(T) or make(T, cap) for set type T.
+func BuiltinSet(setType, capacity goast.Expr) *goast.CallExpr {
 	args := []goast.Expr{setType}
 	if capacity != nil {
 		args = append(args, capacity)
@@ -90,6 +141,58 @@ func BuiltinSet(keyType, capacity goast.Expr) *goast.CallExpr {
 	}
 }
 
+// Copy generates cog.Copy(x), a deep copy of x.
+func Copy(x goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   cogPkg,
+			Sel: &goast.Ident{Name: "Copy"},
+		},
+		Args: []goast.Expr{x},
+	}
+}
+
+// ASCIIMapType generates cog.ASCIIMap[K, V], the map type for ascii keys.
+func ASCIIMapType(keyType, valueType goast.Expr) *goast.IndexListExpr {
+	return &goast.IndexListExpr{
+		X: &goast.SelectorExpr{
+			X:   cogPkg,
+			Sel: &goast.Ident{Name: "ASCIIMap"},
+		},
+		Indices: []goast.Expr{keyType, valueType},
+	}
+}
+
+// ASCIISetType generates cog.ASCIISet[K], the set type for ascii values.
+func ASCIISetType(elemType goast.Expr) *goast.IndexExpr {
+	return &goast.IndexExpr{
+		X: &goast.SelectorExpr{
+			X:   cogPkg,
+			Sel: &goast.Ident{Name: "ASCIISet"},
+		},
+		Index: elemType,
+	}
+}
+
+// ASCIIKey generates string(key), the key of an ascii value in an ASCIIMap or
+// ASCIISet.
+func ASCIIKey(key goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun:  &goast.Ident{Name: "string"},
+		Args: []goast.Expr{key},
+	}
+}
+
+// All generates x.All(), the iterator over an ASCIIMap or ASCIISet.
+func All(x goast.Expr) *goast.CallExpr {
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   x,
+			Sel: &goast.Ident{Name: "All"},
+		},
+	}
+}
+
 // BuiltinSlice generates make([]T, len) or make([]T, len, cap).
 func BuiltinSlice(elemType, length, capacity goast.Expr) *goast.CallExpr {
 	sliceType := &goast.ArrayType{
@@ -59,6 +59,11 @@ var (
 	}
 
 	StopIdent = &goast.Ident{Name: "_stop"}
+
+	CogVoid = &goast.SelectorExpr{
+		X:   &goast.Ident{Name: "cog"},
+		Sel: &goast.Ident{Name: "Void"},
+	}
 )
 
 func ContextMain(ident *goast.Ident) *goast.DeclStmt {
@@ -224,3 +229,47 @@ func Signal(ctxIdent *goast.Ident, passCtx bool) []goast.Stmt {
 		},
 	}
 }
+
+// Async generates an async procedure call, which runs call in a goroutine
+// with a child of the parent context:
+//
+//	cog.Async(parent, func(ctx context.Context) <valueType> { return <call> })
+//
+// Without a return value, valueType is nil, and the goroutine returns cog.Void{}.
+func Async(parent goast.Expr, valueType goast.Expr, call *goast.CallExpr) *goast.CallExpr {
+	var body []goast.Stmt
+
+	if valueType == nil {
+		valueType = CogVoid
+
+		body = []goast.Stmt{
+			&goast.ExprStmt{X: call},
+			&goast.ReturnStmt{Results: []goast.Expr{
+				&goast.CompositeLit{Type: valueType},
+			}},
+		}
+	} else {
+		body = []goast.Stmt{
+			&goast.ReturnStmt{Results: []goast.Expr{call}},
+		}
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   &goast.Ident{Name: "cog"},
+			Sel: &goast.Ident{Name: "Async"},
+		},
+		Args: []goast.Expr{
+			parent,
+			&goast.FuncLit{
+				Type: &goast.FuncType{
+					Params: &goast.FieldList{List: []*goast.Field{ContextArg}},
+					Results: &goast.FieldList{List: []*goast.Field{
+						{Type: valueType},
+					}},
+				},
+				Body: &goast.BlockStmt{List: body},
+			},
+		},
+	}
+}
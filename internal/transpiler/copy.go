@@ -0,0 +1,413 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// copyFunc is a function generated to copy the values of type typ.
+type copyFunc struct {
+	name string
+	typ  types.Type
+}
+
+// convertCopy returns a deep copy of x, a value of type typ, which shares no
+// memory with x. Values of types without slices, maps, sets or references are
+// copied as is, slices and maps of such values are cloned, and other values
+// are copied by a function generated for their type. Named types declare
+// their copy function with them, so it is shared by the packages that use the
+// type, and the other types in the package that copies them. Values of which
+// the type is only known at run time are copied by cog.Copy.
+func (t *Transpiler) convertCopy(x goast.Expr, typ types.Type) (goast.Expr, error) {
+	if t.instance != nil && len(t.instance.env) > 0 {
+		typ = types.Specialize(typ, t.instance.env)
+	}
+
+	switch {
+	case !generated(typ):
+		t.addCogImport()
+		return component.Copy(x), nil
+	case !types.IsShared(typ):
+		return x, nil
+	case copiedByFunc(typ):
+		return component.Call(t.copyFunc(typ), x), nil
+	}
+
+	// ascii values are byte slices, so they are cloned like slices.
+	pkg := "maps"
+	if _, ok := copyUnderlying(typ).(*types.Slice); ok || typ.Kind() == types.ASCII {
+		pkg = "slices"
+	}
+
+	t.addStdLibImport(pkg)
+
+	return component.Call(component.Selector(component.IdentName(goStdLibAlias(pkg)), "Clone"), x), nil
+}
+
+// copyFunc returns the copy function of typ, of which the declaration is
+// emitted with the declarations of the file if typ is not a named type. The
+// copy function of an imported type is that of its package.
+func (t *Transpiler) copyFunc(typ types.Type) goast.Expr {
+	if alias, ok := typ.(*types.Alias); ok {
+		if alias.Package != "" {
+			return component.Selector(component.IdentName(alias.Package), copyFuncName(alias))
+		}
+
+		return component.IdentName(copyFuncName(alias))
+	}
+
+	key := typ.String()
+
+	if fn, ok := t.copies[key]; ok {
+		return component.IdentName(fn.name)
+	}
+
+	name := "copy_" + copyTypeName(typ)
+
+	// Types of which the names are the same are numbered.
+	unique := name
+
+	for n := 2; t.copyFuncNamed(unique); n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+
+	fn := &copyFunc{name: unique, typ: typ}
+	t.copies[key] = fn
+	t.copyQueue = append(t.copyQueue, fn)
+
+	return component.IdentName(fn.name)
+}
+
+func (t *Transpiler) copyFuncNamed(name string) bool {
+	for _, fn := range t.copies {
+		if fn.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// copyDecls returns the declarations of the copy functions used since the
+// last call, and of the copy functions they use.
+func (t *Transpiler) copyDecls() ([]goast.Decl, error) {
+	var decls []goast.Decl
+
+	for len(t.copyQueue) > 0 {
+		fn := t.copyQueue[0]
+		t.copyQueue = t.copyQueue[1:]
+
+		decl, err := t.copyFuncDecl(fn.name, fn.typ)
+		if err != nil {
+			return nil, err
+		}
+
+		decls = append(decls, decl)
+	}
+
+	return decls, nil
+}
+
+// copyFuncDecl returns the declaration of the function called name, which
+// copies a value of type typ.
+func (t *Transpiler) copyFuncDecl(name string, typ types.Type) (*goast.FuncDecl, error) {
+	goType, err := t.convertType(typ)
+	if err != nil {
+		return nil, fmt.Errorf("converting copied type: %w", err)
+	}
+
+	x := component.IdentName("x")
+
+	var body []goast.Stmt
+
+	switch u := copyUnderlying(typ).(type) {
+	case *types.Slice:
+		// A new slice is made of the copied elements, and nil stays nil.
+		i, y := component.IdentName("i"), component.IdentName("y")
+
+		elem, err := t.convertCopy(&goast.IndexExpr{X: x, Index: i}, u.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		body = []goast.Stmt{
+			returnNil(x),
+			component.AssignDef(y, component.Call(component.IdentName("make"), goType, component.Call(component.IdentName("len"), x))),
+			&goast.RangeStmt{
+				Key:  i,
+				Tok:  gotoken.DEFINE,
+				X:    x,
+				Body: component.BlockStmt(assign(&goast.IndexExpr{X: y, Index: i}, elem)),
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{y}},
+		}
+	case *types.Map:
+		k, v, y := component.IdentName("k"), component.IdentName("v"), component.IdentName("y")
+
+		value, err := t.convertCopy(v, u.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		body = []goast.Stmt{
+			returnNil(x),
+			component.AssignDef(y, component.Call(component.IdentName("make"), goType, component.Call(component.IdentName("len"), x))),
+			&goast.RangeStmt{
+				Key:   k,
+				Value: v,
+				Tok:   gotoken.DEFINE,
+				X:     x,
+				Body:  component.BlockStmt(assign(&goast.IndexExpr{X: y, Index: k}, value)),
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{y}},
+		}
+	case *types.Reference:
+		y := component.IdentName("y")
+
+		value, err := t.convertCopy(&goast.StarExpr{X: x}, u.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		body = []goast.Stmt{
+			returnNil(x),
+			component.AssignDef(y, value),
+			&goast.ReturnStmt{Results: []goast.Expr{&goast.UnaryExpr{Op: gotoken.AND, X: y}}},
+		}
+	case *types.Array:
+		// x is a copy of the array, of which the elements are replaced.
+		i := component.IdentName("i")
+
+		elem, err := t.convertCopy(&goast.IndexExpr{X: x, Index: i}, u.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		body = []goast.Stmt{
+			&goast.RangeStmt{
+				Key:  i,
+				Tok:  gotoken.DEFINE,
+				X:    x,
+				Body: component.BlockStmt(assign(&goast.IndexExpr{X: x, Index: i}, elem)),
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{x}},
+		}
+	default:
+		// x is a copy of the struct, of which the fields of shared types are
+		// replaced.
+		fields, ok := copyFields(u)
+		if !ok {
+			return nil, fmt.Errorf("cannot copy type %q", typ)
+		}
+
+		for _, field := range fields {
+			if !types.IsShared(field.typ) {
+				continue
+			}
+
+			value, err := t.convertCopy(component.Selector(x, field.name), field.typ)
+			if err != nil {
+				return nil, err
+			}
+
+			body = append(body, assign(component.Selector(x, field.name), value))
+		}
+
+		body = append(body, &goast.ReturnStmt{Results: []goast.Expr{x}})
+	}
+
+	return &goast.FuncDecl{
+		Name: component.IdentName(name),
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{List: []*goast.Field{{Names: []*goast.Ident{x}, Type: goType}}},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: goType}}},
+		},
+		Body: component.BlockStmt(body...),
+	}, nil
+}
+
+// copyField is a field of the Go struct of a copied value.
+type copyField struct {
+	name string
+	typ  types.Type
+}
+
+// copyFields returns the fields of the Go struct of typ, or false if typ is
+// not a struct in Go.
+func copyFields(typ types.Type) ([]copyField, bool) {
+	switch u := typ.(type) {
+	case *types.Struct:
+		fields := make([]copyField, len(u.Fields))
+
+		for i, field := range u.Fields {
+			fields[i] = copyField{name: component.ConvertExport(field.Name, field.Exported, false), typ: field.Type}
+		}
+
+		return fields, true
+	case *types.Tuple:
+		fields := make([]copyField, len(u.Types))
+
+		for i, elem := range u.Types {
+			fields[i] = copyField{name: component.ConvertExport("t"+strconv.Itoa(i), u.Exported, u.Global), typ: elem}
+		}
+
+		return fields, true
+	case *types.Union:
+		fields := make([]copyField, len(u.Variants))
+
+		for i, variant := range u.Variants {
+			fields[i] = copyField{name: unionVariant(u, i), typ: variant}
+		}
+
+		return fields, true
+	case *types.Option:
+		return []copyField{{name: "Value", typ: u.Value}}, true
+	case *types.Either:
+		return []copyField{{name: "Left", typ: u.Left}, {name: "Right", typ: u.Right}}, true
+	case *types.Result:
+		return []copyField{{name: "Value", typ: u.Value}, {name: "Error", typ: u.Error}}, true
+	default:
+		return nil, false
+	}
+}
+
+// copyUnderlying returns the type of which the values of typ are copied.
+func copyUnderlying(typ types.Type) types.Type {
+	if alias, ok := typ.(*types.Alias); ok {
+		// The underlying type of an alias is the type it names, of an option
+		// it is its value type.
+		return alias.Underlying()
+	}
+
+	return typ
+}
+
+// copiedByFunc reports whether the values of typ are copied by a generated
+// function, as they are not copied as is or cloned.
+func copiedByFunc(typ types.Type) bool {
+	if !types.IsShared(typ) {
+		return false
+	}
+
+	switch u := copyUnderlying(typ).(type) {
+	case *types.Slice:
+		return types.IsShared(u.Element)
+	case *types.Map:
+		return types.IsShared(u.Value)
+	case *types.Set:
+		// Set elements are keys, which are not changed.
+		return false
+	case *types.Basic:
+		// ascii values are byte slices.
+		return false
+	default:
+		return true
+	}
+}
+
+// generated reports whether a copy of typ can be generated, as it refers to no
+// type parameters, and no generic or local named types.
+func generated(typ types.Type) bool {
+	switch v := typ.(type) {
+	case *types.Alias:
+		return !v.IsTypeParam() && len(v.TypeParams) == 0 && v.Global
+	case *types.Slice:
+		return generated(v.Element)
+	case *types.Array:
+		return generated(v.Element)
+	case *types.Map:
+		return generated(v.Key) && generated(v.Value)
+	case *types.Set:
+		return generated(v.Element)
+	case *types.Option:
+		return generated(v.Value)
+	case *types.Reference:
+		return generated(v.Value)
+	case *types.Tuple:
+		return !slices.ContainsFunc(v.Types, func(elem types.Type) bool { return !generated(elem) })
+	case *types.Either:
+		return generated(v.Left) && generated(v.Right)
+	case *types.Union:
+		return !slices.ContainsFunc(v.Variants, func(variant types.Type) bool { return !generated(variant) })
+	case *types.Result:
+		return generated(v.Value) && generated(v.Error)
+	case *types.Struct:
+		return !slices.ContainsFunc(v.Fields, func(f *types.Field) bool { return !generated(f.Type) })
+	default:
+		return true
+	}
+}
+
+// copyFuncName returns the name of the copy function of the named type alias,
+// which is exported if the type is.
+func copyFuncName(alias *types.Alias) string {
+	name := component.ConvertExport(alias.Name, alias.Exported, alias.Global)
+
+	if alias.Exported {
+		return "Copy_" + name
+	}
+
+	return "copy_" + strings.TrimPrefix(name, "_")
+}
+
+// copyTypeName returns the name of typ in the name of its copy function.
+func copyTypeName(typ types.Type) string {
+	switch v := typ.(type) {
+	case *types.Alias:
+		return v.Name
+	case *types.Slice:
+		return "slice_" + copyTypeName(v.Element)
+	case *types.Array:
+		return "array_" + mangle(v.Length.String()) + "_" + copyTypeName(v.Element)
+	case *types.Map:
+		return "map_" + copyTypeName(v.Key) + "_" + copyTypeName(v.Value)
+	case *types.Set:
+		return "set_" + copyTypeName(v.Element)
+	case *types.Option:
+		return "option_" + copyTypeName(v.Value)
+	case *types.Reference:
+		return "ref_" + copyTypeName(v.Value)
+	case *types.Either:
+		return "either_" + copyTypeName(v.Left) + "_" + copyTypeName(v.Right)
+	case *types.Result:
+		return "result_" + copyTypeName(v.Value) + "_" + copyTypeName(v.Error)
+	case *types.Tuple:
+		return "tuple_" + copyTypeNames(v.Types)
+	case *types.Union:
+		return "union_" + copyTypeNames(v.Variants)
+	case *types.Struct:
+		return "struct"
+	default:
+		return mangle(typ.String())
+	}
+}
+
+func copyTypeNames(typs []types.Type) string {
+	names := make([]string, len(typs))
+
+	for i, typ := range typs {
+		names[i] = copyTypeName(typ)
+	}
+
+	return strings.Join(names, "_")
+}
+
+// returnNil returns the statement that returns nil if x is nil.
+func returnNil(x goast.Expr) goast.Stmt {
+	return component.IfStmt(
+		&goast.BinaryExpr{X: x, Op: gotoken.EQL, Y: component.IdentName("nil")},
+		[]goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{component.IdentName("nil")}}},
+		nil,
+	)
+}
+
+func assign(lhs, rhs goast.Expr) *goast.AssignStmt {
+	return &goast.AssignStmt{Lhs: []goast.Expr{lhs}, Tok: gotoken.ASSIGN, Rhs: []goast.Expr{rhs}}
+}
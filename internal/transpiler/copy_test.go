@@ -0,0 +1,134 @@
+package transpiler_test
+
+import (
+	"bytes"
+	goast "go/ast"
+	goformat "go/format"
+	goparser "go/parser"
+	gotoken "go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/transpiler"
+)
+
+// copyBenchSource is the Cog program of which the copy functions in
+// copy_gen_test.go of the cog package are generated.
+const copyBenchSource = `package main
+
+export Bench ~ struct {
+	export (
+		name : utf8
+		items : []int64
+		tags : map<utf8, []utf8>
+	)
+}
+
+main : proc() = {
+	bench : Bench = {name = "bench", items = {1}, tags = {"a": {"x"}}}
+	benches : []Bench = {bench}
+	ints : map<int64, int64> = {1: 1}
+	@print(@copy(bench), @copy(benches), @copy(ints))
+}`
+
+// TestCopyGenerated checks that copy_gen_test.go of the cog package holds the
+// declarations that the transpiler generates for copyBenchSource, so
+// BenchmarkCopy measures the code that @copy compiles to.
+func TestCopyGenerated(t *testing.T) {
+	t.Parallel()
+
+	toks, err := lexer.NewLexer(strings.NewReader(copyBenchSource)).Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lexer error: %v", err)
+	}
+
+	p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "")
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	f, err := p.Parse(t.Context(), "bench.cog")
+	if err != nil {
+		t.Fatalf("parser error: %v", err)
+	}
+
+	gofile, err := transpiler.NewTranspiler([]*ast.File{f}).Transpile()
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+
+	fset := gotoken.NewFileSet()
+
+	genFile, err := goparser.ParseFile(fset, filepath.Join("..", "..", "copy_gen_test.go"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := make(map[string]string)
+
+	for _, decl := range gofile.Decls {
+		if name, ok := copyBenchDecl(decl); ok {
+			want[name] = formatDecl(t, gotoken.NewFileSet(), decl)
+		}
+	}
+
+	for _, decl := range genFile.Decls {
+		name, ok := copyBenchDecl(decl)
+		if !ok {
+			continue
+		}
+
+		if got := formatDecl(t, fset, decl); got != want[name] {
+			t.Errorf("copy_gen_test.go declares %s as:\n%s\nwant:\n%s", name, got, want[name])
+		}
+
+		delete(want, name)
+	}
+
+	for name, decl := range want {
+		t.Errorf("copy_gen_test.go does not declare %s:\n%s", name, decl)
+	}
+
+	var buf bytes.Buffer
+	if err := goformat.Node(&buf, gotoken.NewFileSet(), gofile); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "go_maps.Clone(ints)") {
+		t.Error("map without shared values is not copied by maps.Clone")
+	}
+}
+
+// copyBenchDecl returns the name of decl if it declares the Bench type or a
+// copy function, of which the line directives are removed.
+func copyBenchDecl(decl goast.Decl) (string, bool) {
+	switch decl := decl.(type) {
+	case *goast.FuncDecl:
+		decl.Doc = nil
+		name := decl.Name.Name
+
+		return name, strings.HasPrefix(name, "copy_") || strings.HasPrefix(name, "Copy_")
+	case *goast.GenDecl:
+		if spec, ok := decl.Specs[0].(*goast.TypeSpec); ok && decl.Tok == gotoken.TYPE && spec.Name.Name == "Bench" {
+			decl.Doc, spec.Doc = nil, nil
+			return spec.Name.Name, true
+		}
+	}
+
+	return "", false
+}
+
+func formatDecl(t *testing.T, fset *gotoken.FileSet, decl goast.Decl) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := goformat.Node(&buf, fset, decl); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
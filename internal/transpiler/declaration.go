@@ -32,6 +32,10 @@ func (t *Transpiler) convertDecl(node ast.Node) ([]goast.Decl, error) {
 			return nil, nil
 		}
 
+		if g := t.instances.generic(n); g != nil && t.instance == nil {
+			return t.convertGeneric(n, g)
+		}
+
 		ident := t.symbols.Define(component.ConvertExport(n.Assignment.Identifier.Name, n.Assignment.Identifier.Exported, n.Assignment.Identifier.Global))
 
 		tok := gotoken.CONST
@@ -208,6 +212,11 @@ func (t *Transpiler) convertDecl(node ast.Node) ([]goast.Decl, error) {
 			compositeLiteral.Type = &goast.Ident{Name: litName}
 		}
 
+		if n.Assignment.Identifier.Qualifier == ast.QualifierCompileTime && !isConstant(expr) {
+			// Values of Cog types without a Go constant representation.
+			tok = gotoken.VAR
+		}
+
 		valueSpec := &goast.ValueSpec{
 			Names:  []*goast.Ident{ident},
 			Values: []goast.Expr{expr},
@@ -265,6 +274,12 @@ func (t *Transpiler) convertDecl(node ast.Node) ([]goast.Decl, error) {
 
 		funcDecl.Recv = component.Receiver(recIdent, recType)
 
+		// The Error method of an unexported type is unexported in Go, so the
+		// type gets an Error method forwarding to it to implement error.
+		if funcDecl.Name.Name != types.ErrorMethod.Name && isErrorMethod(n.Declaration.Assignment.Identifier) {
+			decls = append(decls, errorAdapter(funcDecl.Name, recType))
+		}
+
 		return decls, nil
 	case *ast.Type:
 		if n.Alias.Kind() == types.EnumKind || n.Alias.Kind() == types.ErrorKind {
@@ -297,17 +312,22 @@ func (t *Transpiler) convertDecl(node ast.Node) ([]goast.Decl, error) {
 			Specs: []goast.Spec{typeSpec},
 		})
 
-		if n.Alias.Kind() == types.ASCII {
-			// Generate hash type for ASCII alias, to allow usage of ASCII as map keys.
-			decls = append(decls, &goast.GenDecl{
-				Tok: gotoken.TYPE,
-				Specs: []goast.Spec{
-					&goast.TypeSpec{
-						Name: &goast.Ident{Name: component.ConvertExport(n.Identifier.Name, n.Identifier.Exported, n.Identifier.Global) + "Hash"},
-						Type: &goast.Ident{Name: gotypes.Typ[gotypes.Uint64].String()},
-					},
-				},
-			})
+		// The copy function of a named type is declared with it, so every
+		// package that copies the type uses the same function.
+		named := &types.Alias{
+			Name:     n.Identifier.Name,
+			Derived:  n.Alias,
+			Exported: n.Identifier.Exported,
+			Global:   n.Identifier.Global,
+		}
+
+		if len(n.TypeParameters) == 0 && generated(named) && copiedByFunc(named) {
+			copyDecl, err := t.copyFuncDecl(copyFuncName(named), named)
+			if err != nil {
+				return nil, fmt.Errorf("generating copy function: %w", err)
+			}
+
+			decls = append(decls, copyDecl)
 		}
 
 		return decls, nil
@@ -387,6 +407,10 @@ func (t *Transpiler) convertEnumDecl(n *ast.Type) ([]goast.Decl, error) {
 		exprs = append(exprs, expr)
 	}
 
+	if errType, ok := n.Alias.(*types.Error); ok && errType.HasPayload() {
+		return t.convertPayloadErrorDecl(errType, identifier, enumTypeIdent, valueType, exprs)
+	}
+
 	typeName := &goast.Ident{Name: identifier + "Type"}
 
 	enumValType, err := t.convertType(valueType)
@@ -394,7 +418,7 @@ func (t *Transpiler) convertEnumDecl(n *ast.Type) ([]goast.Decl, error) {
 		return nil, fmt.Errorf("converting enum value type: %w", err)
 	}
 
-	return []goast.Decl{
+	decls := []goast.Decl{
 		// Enum type declaration
 		&goast.GenDecl{
 			Tok: gotoken.TYPE,
@@ -437,19 +461,78 @@ func (t *Transpiler) convertEnumDecl(n *ast.Type) ([]goast.Decl, error) {
 				},
 			},
 		},
-	}, nil
+	}
+
+	if n.Alias.Kind() == types.ErrorKind {
+		// Error values implement the Go error interface, so they can be returned
+		// to Go code as an error.
+		var text goast.Expr = &goast.IndexExpr{
+			X:     &goast.Ident{Name: identifier},
+			Index: &goast.Ident{Name: "e"},
+		}
+
+		switch valueType.Kind() {
+		case types.UTF8, types.ASCII:
+			text = &goast.CallExpr{
+				Fun:  &goast.Ident{Name: gotypes.Typ[gotypes.String].String()},
+				Args: []goast.Expr{text},
+			}
+		default:
+			t.addStdLibImport("fmt")
+
+			text = &goast.CallExpr{
+				Fun:  component.Selector(&goast.Ident{Name: goStdLibAlias("fmt")}, "Sprint"),
+				Args: []goast.Expr{text},
+			}
+		}
+
+		decls = append(decls, &goast.FuncDecl{
+			Recv: component.Receiver(&goast.Ident{Name: "e"}, &goast.Ident{Name: enumName}),
+			Name: &goast.Ident{Name: "Error"},
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{},
+				Results: &goast.FieldList{List: []*goast.Field{{Type: &goast.Ident{Name: gotypes.Typ[gotypes.String].String()}}}},
+			},
+			Body: &goast.BlockStmt{List: []goast.Stmt{
+				&goast.ReturnStmt{Results: []goast.Expr{text}},
+			}},
+		})
+	}
+
+	return decls, nil
+}
+
+// isConstant reports whether expr is a Go constant expression.
+func isConstant(expr goast.Expr) bool {
+	switch e := expr.(type) {
+	case *goast.BasicLit:
+		return true
+	case *goast.Ident:
+		return e.Name == "true" || e.Name == "false"
+	case *goast.ParenExpr:
+		return isConstant(e.X)
+	case *goast.UnaryExpr:
+		return isConstant(e.X)
+	case *goast.BinaryExpr:
+		return isConstant(e.X) && isConstant(e.Y)
+	default:
+		return false
+	}
 }
 
 func mustBeVariable(t types.Kind) bool {
 	switch t {
 	case types.ArrayKind,
 		types.EitherKind,
+		types.IteratorKind,
 		types.MapKind,
 		types.ProcedureKind,
 		types.SetKind,
+		types.SignalKind,
 		types.SliceKind,
 		types.StructKind,
-		types.TupleKind:
+		types.TupleKind,
+		types.UnionKind:
 		return true
 	default:
 		return false
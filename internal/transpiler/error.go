@@ -0,0 +1,351 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	gotypes "go/types"
+	"strconv"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// convertPropagate converts r!? to the value of r. The statements that return
+// the error of r from the enclosing function are hoisted before the statement.
+func (t *Transpiler) convertPropagate(n *ast.Propagate) (goast.Expr, error) {
+	result, err := t.convertExpr(n.Left)
+	if err != nil {
+		return nil, fmt.Errorf("converting propagated result: %w", err)
+	}
+
+	returnType, err := t.convertType(n.ReturnType)
+	if err != nil {
+		return nil, fmt.Errorf("converting return type: %w", err)
+	}
+
+	ident := component.IdentName("_result" + strconv.FormatUint(uint64(t.resultCounter), 10))
+	t.resultCounter++
+
+	t.pre = append(t.pre,
+		component.AssignDef(ident, result),
+		component.IfStmt(component.Selector(ident, "IsError"), []goast.Stmt{
+			&goast.ReturnStmt{Results: []goast.Expr{&goast.CompositeLit{
+				Type: returnType,
+				Elts: []goast.Expr{
+					component.KeyValue(component.IdentName("Error"), component.Selector(ident, "Error")),
+					component.KeyValue(component.IdentName("IsError"), component.BoolLit(true)),
+				},
+			}}},
+		}, nil),
+	)
+
+	return component.Selector(ident, "Value"), nil
+}
+
+// convertPayloadErrorDecl converts an error type with variant payloads. A
+// value is a struct of its variant and its payload, of which plain variants
+// are variables and payload variants are constructor functions. The messages
+// of the variants are the values of the variable called identifier.
+func (t *Transpiler) convertPayloadErrorDecl(typ *types.Error, identifier, variantType string, messageType types.Type, messages []goast.Expr) ([]goast.Decl, error) {
+	errName := component.IdentName(identifier + "Error")
+	payloadName := component.IdentName(identifier + "Payload")
+	typeName := component.IdentName(identifier + "Type")
+
+	messageGoType, err := t.convertType(messageType)
+	if err != nil {
+		return nil, fmt.Errorf("converting error value type: %w", err)
+	}
+
+	// A parameter name used by several variants is one payload field.
+	var (
+		fields []*goast.Field
+		seen   = make(map[string]struct{})
+	)
+
+	for _, val := range typ.Values {
+		for _, param := range val.Params {
+			if _, ok := seen[param.Name]; ok {
+				continue
+			}
+
+			seen[param.Name] = struct{}{}
+
+			fieldType, err := t.convertType(param.Type)
+			if err != nil {
+				return nil, fmt.Errorf("converting payload field %q type: %w", param.Name, err)
+			}
+
+			fields = append(fields, &goast.Field{
+				Names: []*goast.Ident{component.IdentName(param.Name)},
+				Type:  fieldType,
+			})
+		}
+	}
+
+	decls := []goast.Decl{
+		typeDecl(errName, &goast.StructType{Fields: &goast.FieldList{List: []*goast.Field{
+			{Names: []*goast.Ident{component.IdentName("variant")}, Type: component.IdentName(variantType)},
+			{Names: []*goast.Ident{component.IdentName("payload")}, Type: payloadName},
+		}}}),
+		typeDecl(payloadName, &goast.StructType{Fields: &goast.FieldList{List: fields}}),
+		typeDecl(typeName, messageGoType),
+		&goast.GenDecl{
+			Tok: gotoken.VAR,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names:  []*goast.Ident{component.IdentName(identifier)},
+				Values: []goast.Expr{&goast.CompositeLit{Type: &goast.ArrayType{Elt: typeName}, Elts: messages}},
+			}},
+		},
+	}
+
+	variables := make([]goast.Spec, 0, len(typ.Values))
+
+	for i, val := range typ.Values {
+		name := component.IdentName(identifier + titleCaser.String(val.Name))
+		elts := []goast.Expr{component.KeyValue(component.IdentName("variant"), intLit(i))}
+
+		if len(val.Params) == 0 {
+			variables = append(variables, &goast.ValueSpec{
+				Names:  []*goast.Ident{name},
+				Values: []goast.Expr{&goast.CompositeLit{Type: errName, Elts: elts}},
+			})
+
+			continue
+		}
+
+		params := make([]*goast.Field, len(val.Params))
+		payload := make([]goast.Expr, len(val.Params))
+
+		for j, param := range val.Params {
+			paramType, err := t.convertType(param.Type)
+			if err != nil {
+				return nil, fmt.Errorf("converting payload parameter %q type: %w", param.Name, err)
+			}
+
+			params[j] = &goast.Field{Names: []*goast.Ident{component.IdentName(param.Name)}, Type: paramType}
+			payload[j] = component.KeyValue(component.IdentName(param.Name), component.IdentName(param.Name))
+		}
+
+		elts = append(elts, component.KeyValue(component.IdentName("payload"), &goast.CompositeLit{Type: payloadName, Elts: payload}))
+
+		decls = append(decls, &goast.FuncDecl{
+			Name: name,
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{List: params},
+				Results: &goast.FieldList{List: []*goast.Field{{Type: errName}}},
+			},
+			Body: component.BlockStmt(&goast.ReturnStmt{Results: []goast.Expr{&goast.CompositeLit{Type: errName, Elts: elts}}}),
+		})
+	}
+
+	if len(variables) > 0 {
+		decls = append(decls, &goast.GenDecl{Tok: gotoken.VAR, Specs: variables})
+	}
+
+	errorMethod, err := t.errorMethod(typ, identifier, errName)
+	if err != nil {
+		return nil, err
+	}
+
+	decls = append(decls, errorMethod)
+
+	if unwrap := unwrapMethod(typ, errName); unwrap != nil {
+		decls = append(decls, unwrap)
+	}
+
+	// errors.Is matches any value of the same variant.
+	target := component.IdentName("target")
+	other := component.IdentName("other")
+
+	decls = append(decls, errorMethodDecl(errName, "Is",
+		[]*goast.Field{{Names: []*goast.Ident{target}, Type: component.IdentName("error")}},
+		component.IdentName(gotypes.Typ[gotypes.Bool].String()),
+		&goast.AssignStmt{
+			Lhs: []goast.Expr{other, component.IdentName("ok")},
+			Tok: gotoken.DEFINE,
+			Rhs: []goast.Expr{component.TypeAssert(target, errName)},
+		},
+		&goast.ReturnStmt{Results: []goast.Expr{&goast.BinaryExpr{
+			X:  component.IdentName("ok"),
+			Op: gotoken.LAND,
+			Y: &goast.BinaryExpr{
+				X:  component.Selector(other, "variant"),
+				Op: gotoken.EQL,
+				Y:  component.Selector(component.IdentName("e"), "variant"),
+			},
+		}}},
+	))
+
+	return decls, nil
+}
+
+// errorMethod returns the Error method of a payload error type, which follows
+// the message of the variant by its payload in Cog formatting, and the message
+// of its cause.
+func (t *Transpiler) errorMethod(typ *types.Error, identifier string, errName *goast.Ident) (goast.Decl, error) {
+	e := component.IdentName("e")
+	text := component.IdentName("text")
+	payload := component.Selector(e, "payload")
+
+	f := &formatter{t: t}
+
+	var clauses []goast.Stmt
+
+	for i, val := range typ.Values {
+		if len(val.Params) == 0 {
+			continue
+		}
+
+		cause := val.Cause()
+
+		var (
+			body  []goast.Stmt
+			parts []goast.Expr
+		)
+
+		for _, param := range val.Params {
+			if param == cause {
+				continue
+			}
+
+			t.addBuiltinImport()
+
+			value, err := f.value(component.Selector(payload, param.Name), param.Type, true)
+			if err != nil {
+				return nil, fmt.Errorf("formatting payload field %q: %w", param.Name, err)
+			}
+
+			separator := ", "
+			if len(parts) == 0 {
+				separator = " ("
+			}
+
+			parts = append(parts, component.UTF8Lit(separator+param.Name+" = "), value)
+		}
+
+		if len(parts) > 0 {
+			parts = append(parts, component.UTF8Lit(")"))
+			body = append(body, appendText(text, component.Concat(parts...)))
+		}
+
+		if cause != nil {
+			field := component.Selector(payload, cause.Name)
+
+			causeText := appendText(text, component.Concat(
+				component.UTF8Lit(": "),
+				component.Call(component.Selector(field, "Error")),
+			))
+
+			if cause.Type == types.GoError {
+				// A Go error may be nil.
+				body = append(body, component.IfStmt(&goast.BinaryExpr{
+					X:  field,
+					Op: gotoken.NEQ,
+					Y:  component.IdentName("nil"),
+				}, []goast.Stmt{causeText}, nil))
+			} else {
+				body = append(body, causeText)
+			}
+		}
+
+		clauses = append(clauses, &goast.CaseClause{List: []goast.Expr{intLit(i)}, Body: body})
+	}
+
+	stringIdent := component.IdentName(gotypes.Typ[gotypes.String].String())
+
+	return errorMethodDecl(errName, "Error", nil, stringIdent,
+		component.AssignDef(text, component.Call(stringIdent, &goast.IndexExpr{
+			X:     component.IdentName(identifier),
+			Index: component.Selector(e, "variant"),
+		})),
+		&goast.SwitchStmt{
+			Tag:  component.Selector(e, "variant"),
+			Body: component.BlockStmt(clauses...),
+		},
+		&goast.ReturnStmt{Results: []goast.Expr{text}},
+	), nil
+}
+
+// unwrapMethod returns the Unwrap method of a payload error type, which
+// returns the cause of the variant, or nil if no variant wraps a cause.
+func unwrapMethod(typ *types.Error, errName *goast.Ident) goast.Decl {
+	var clauses []goast.Stmt
+
+	for i, val := range typ.Values {
+		cause := val.Cause()
+		if cause == nil {
+			continue
+		}
+
+		clauses = append(clauses, &goast.CaseClause{
+			List: []goast.Expr{intLit(i)},
+			Body: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{
+				component.Selector(component.Selector(component.IdentName("e"), "payload"), cause.Name),
+			}}},
+		})
+	}
+
+	if len(clauses) == 0 {
+		return nil
+	}
+
+	return errorMethodDecl(errName, "Unwrap", nil, component.IdentName("error"),
+		&goast.SwitchStmt{
+			Tag:  component.Selector(component.IdentName("e"), "variant"),
+			Body: component.BlockStmt(clauses...),
+		},
+		&goast.ReturnStmt{Results: []goast.Expr{component.IdentName("nil")}},
+	)
+}
+
+// isErrorMethod reports whether the method ident is the Error method of an
+// error type.
+func isErrorMethod(ident *ast.Identifier) bool {
+	procType, ok := ident.ValueType.(*types.Procedure)
+	if !ok {
+		return false
+	}
+
+	return (&types.Method{Name: ident.Name, Procedure: procType}).Is(types.ErrorMethod)
+}
+
+// errorAdapter returns the Error method of the receiver type recType, which
+// calls its Error method called name.
+func errorAdapter(name *goast.Ident, recType goast.Expr) goast.Decl {
+	return errorMethodDecl(recType, types.ErrorMethod.Name, nil, component.IdentName(gotypes.Typ[gotypes.String].String()),
+		&goast.ReturnStmt{Results: []goast.Expr{
+			component.Call(component.Selector(component.IdentName("e"), name.Name)),
+		}},
+	)
+}
+
+// errorMethodDecl returns the method name of the error type errName.
+func errorMethodDecl(errName goast.Expr, name string, params []*goast.Field, result goast.Expr, body ...goast.Stmt) *goast.FuncDecl {
+	return &goast.FuncDecl{
+		Recv: component.Receiver(component.IdentName("e"), errName),
+		Name: component.IdentName(name),
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{List: params},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: result}}},
+		},
+		Body: component.BlockStmt(body...),
+	}
+}
+
+// appendText returns the statement text += x.
+func appendText(text *goast.Ident, x goast.Expr) goast.Stmt {
+	return &goast.AssignStmt{
+		Lhs: []goast.Expr{text},
+		Tok: gotoken.ADD_ASSIGN,
+		Rhs: []goast.Expr{x},
+	}
+}
+
+func typeDecl(name *goast.Ident, typ goast.Expr) *goast.GenDecl {
+	return &goast.GenDecl{
+		Tok:   gotoken.TYPE,
+		Specs: []goast.Spec{&goast.TypeSpec{Name: name, Type: typ}},
+	}
+}
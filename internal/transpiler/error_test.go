@@ -0,0 +1,109 @@
+package transpiler_test
+
+import "testing"
+
+func TestConvertPropagate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same_error", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! MyError = {
+	x := get()!?
+	return x * 2
+}
+main : proc() = {}`)
+		mustContain(t, got, "_result0 := get()")
+		mustContain(t, got, "if _result0.IsError {")
+		mustContain(t, got, "return cog.Result[int64, _MyErrorError]{Error: _result0.Error, IsError: true}")
+		mustContain(t, got, "= _result0.Value")
+	})
+
+	t.Run("go_error", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+MyError ~ error { Fail }
+get : func() int64 ! MyError = {
+	return 1
+}
+double : func() int64 ! error = {
+	return get()!? * 2
+}
+main : proc() = {}`)
+		mustContain(t, got, "return cog.Result[int64, error]{Error: _result0.Error, IsError: true}")
+		mustContain(t, got, "return cog.Result[int64, error]{Value: _result0.Value * 2}")
+	})
+}
+
+func TestConvertPayloadError(t *testing.T) {
+	t.Parallel()
+
+	got := transpile(t, `package p
+MyError ~ error<utf8> {
+	NotFound(key : utf8) := "not found",
+	Timeout := "timeout",
+	Wrapped(key : utf8, cause : error) := "wrapped",
+}
+find : func(key : utf8) int64 ! MyError = {
+	if key == "" {
+		return MyError.Timeout
+	}
+
+	return MyError.NotFound(key)
+}
+main : proc() = {
+	r := find("k")
+	if !r? {
+		e := r!
+		@print(e.key)
+		@print(e == MyError.Timeout)
+	}
+}`)
+
+	mustContain(t, got, "type _MyErrorError struct {")
+	mustContain(t, got, "payload\t_MyErrorPayload")
+	mustContain(t, got, "func _MyErrorNotfound(key string) _MyErrorError {")
+	mustContain(t, got, "var _MyErrorTimeout = _MyErrorError{variant: 1}")
+	mustContain(t, got, "func (e _MyErrorError) Error() string {")
+	mustContain(t, got, "func (e _MyErrorError) Unwrap() error {")
+	mustContain(t, got, "func (e _MyErrorError) Is(target error) bool {")
+	mustContain(t, got, "Error: _MyErrorNotfound(key), IsError: true")
+	mustContain(t, got, "e.payload.key")
+	mustContain(t, got, "e.variant == _MyErrorTimeout.variant")
+}
+
+func TestConvertErrorInterface(t *testing.T) {
+	t.Parallel()
+
+	got := transpile(t, `package p
+Failure ~ interface {
+	Error : func() utf8
+}
+NotFound ~ struct {
+	key : utf8
+}
+NotFound.Error : func() utf8 = {
+	return "not found"
+}
+find : func(key : utf8) int64 ! Failure = {
+	return NotFound{key = key}
+}
+main : proc() = {
+	r := find("k")
+	if !r? {
+		e := r!
+		@print(e.Error())
+	}
+}`)
+
+	mustContain(t, got, "type _Failure interface {")
+	mustContain(t, got, "func (_NotFound) _Error() string {")
+	mustContain(t, got, "func (e _NotFound) Error() string {")
+	mustContain(t, got, "return e._Error()")
+	mustContain(t, got, "return cog.Result[int64, _Failure]{Error: _NotFound{key: key}, IsError: true}")
+	mustContain(t, got, "e.Error()")
+}
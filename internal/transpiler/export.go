@@ -0,0 +1,665 @@
+package transpiler
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	gotypes "go/types"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// exportImplName is the import name of the transpiled package in a Go facade.
+const exportImplName = "impl"
+
+// TranspileExport generates a Go facade for the exported symbols of the
+// package, wrapping the transpiled package imported from implPath. It must be
+// called after TranspileFiles. The facade exposes an idiomatic Go API:
+//   - procedures take a context.Context as first parameter
+//   - results are returned as (T, error)
+//   - optional references are returned as *T, other options as (T, bool)
+//   - ascii values are passed and returned as string, and procedures with
+//     ascii parameters return an error for strings that are not ASCII
+//
+// Types are re-exported as aliases of the transpiled types, so they keep their
+// methods.
+func (t *Transpiler) TranspileExport(implPath string) (*goast.File, error) {
+	fileIDs := make([]uint16, 0, len(t.files))
+	for id := range t.files {
+		fileIDs = append(fileIDs, id)
+	}
+
+	slices.Sort(fileIDs)
+
+	pkgName := t.files[fileIDs[0]].Package.Identifier.Name
+	errs := make([]error, 0)
+
+	t.imports = make(map[string]*goast.ImportSpec)
+	t.lastSourceLine = 0
+
+	gofile := &goast.File{
+		Name: goast.NewIdent(pkgName),
+	}
+
+	// Unused imports are pruned once the facade is generated.
+	t.addCogImport()
+
+	for _, id := range fileIDs {
+		f := t.files[id]
+		t.file = f
+
+		for i, stmt := range f.Statements {
+			switch s := stmt.(type) {
+			case *ast.GoImport:
+				for _, imprt := range s.Imports {
+					t.addStdLibImport(imprt.Name)
+				}
+			case *ast.Import:
+				t.addCogImports(s)
+			case *ast.Type:
+				if !s.Identifier.Exported {
+					continue
+				}
+
+				decls, err := t.exportType(s, f.Statements[:i])
+				if err != nil {
+					errs = append(errs, t.error(s.Identifier, fmt.Errorf("cannot export %q: %w", s.Identifier.Name, err)))
+					continue
+				}
+
+				gofile.Decls = append(gofile.Decls, decls...)
+			case *ast.Declaration:
+				if !s.Assignment.Identifier.Exported {
+					continue
+				}
+
+				decl, err := t.exportDeclaration(s, f.Statements[:i])
+				if err != nil {
+					errs = append(errs, t.error(s, fmt.Errorf("cannot export %q: %w", s.Assignment.Identifier.Name, err)))
+					continue
+				}
+
+				gofile.Decls = append(gofile.Decls, decl)
+			}
+		}
+	}
+
+	t.imports[exportImplName] = &goast.ImportSpec{
+		Name: &goast.Ident{Name: exportImplName},
+		Path: &goast.BasicLit{
+			Kind:  gotoken.STRING,
+			Value: `"` + implPath + `"`,
+		},
+	}
+
+	t.pruneExportImports(gofile)
+	t.finalizeImports(gofile)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("export errors:\n%w", err)
+	}
+
+	return gofile, nil
+}
+
+// exportDoc returns the doc comment for an exported declaration: the comments
+// on the lines directly above it, or a generated comment if there are none.
+func exportDoc(preceding []ast.Statement, node ast.Statement, goName string) *goast.CommentGroup {
+	var comments []*goast.Comment
+
+	for _, comment := range docComments(preceding, node) {
+		comments = append(comments, toLineComments(comment.Text)...)
+	}
+
+	if len(comments) > 0 {
+		return &goast.CommentGroup{List: comments}
+	}
+
+	var name, kind string
+
+	switch n := node.(type) {
+	case *ast.Type:
+		name, kind = n.Identifier.Name, "type"
+	case *ast.Declaration:
+		name, kind = n.Assignment.Identifier.Name, "value"
+
+		if procType, ok := n.Assignment.Identifier.ValueType.(*types.Procedure); ok {
+			kind = "procedure"
+			if procType.Function {
+				kind = "function"
+			}
+		}
+	}
+
+	return &goast.CommentGroup{List: []*goast.Comment{{
+		Text: fmt.Sprintf("// %s is the exported Cog %s %q.", goName, kind, name),
+	}}}
+}
+
+// exportType re-exports a type as an alias of the transpiled type. Enum and
+// error types also re-export their values.
+func (t *Transpiler) exportType(n *ast.Type, preceding []ast.Statement) ([]goast.Decl, error) {
+	name := component.ConvertExport(n.Identifier.Name, true, true)
+
+	var values []*types.EnumValue
+
+	switch a := n.Alias.(type) {
+	case *types.Enum:
+		values = a.Values
+		name += "Enum"
+	case *types.Error:
+		values = a.Values
+		name += "Error"
+	}
+
+	typeSpec := &goast.TypeSpec{
+		Name:   &goast.Ident{Name: name},
+		Assign: 1,
+		Type:   component.Selector(&goast.Ident{Name: exportImplName}, name),
+	}
+
+	if len(n.TypeParameters) > 0 {
+		typeParams, err := t.convertTypeParams(n.TypeParameters)
+		if err != nil {
+			return nil, fmt.Errorf("converting type parameters: %w", err)
+		}
+
+		typeSpec.TypeParams = typeParams
+		typeSpec.Type = typeArguments(typeSpec.Type, n.TypeParameters)
+	}
+
+	decls := []goast.Decl{&goast.GenDecl{
+		Doc:   exportDoc(preceding, n, name),
+		Tok:   gotoken.TYPE,
+		Specs: []goast.Spec{typeSpec},
+	}}
+
+	if len(values) == 0 {
+		return decls, nil
+	}
+
+	identifier := component.ConvertExport(n.Identifier.Name, true, true)
+	specs := make([]goast.Spec, len(values))
+
+	for i, value := range values {
+		valueName := identifier + titleCaser.String(value.Name)
+
+		specs[i] = &goast.ValueSpec{
+			Names:  []*goast.Ident{{Name: valueName}},
+			Values: []goast.Expr{component.Selector(&goast.Ident{Name: exportImplName}, valueName)},
+		}
+	}
+
+	decls = append(decls, &goast.GenDecl{
+		Tok:    gotoken.CONST,
+		Lparen: 1,
+		Specs:  specs,
+	})
+
+	return decls, nil
+}
+
+// exportDeclaration wraps an exported procedure, or re-exports a value.
+// Constants are re-exported as constants, other values through a getter.
+func (t *Transpiler) exportDeclaration(n *ast.Declaration, preceding []ast.Statement) (goast.Decl, error) {
+	ident := n.Assignment.Identifier
+	name := component.ConvertExport(ident.Name, true, true)
+	doc := exportDoc(preceding, n, name)
+	implIdent := component.Selector(&goast.Ident{Name: exportImplName}, name)
+
+	if ident.Qualifier == ast.QualifierDynamic {
+		return nil, errors.New("dynamic variables cannot be exported")
+	}
+
+	valueType := ident.ValueType
+	if types.IsNone(valueType) && n.Assignment.Expression != nil {
+		valueType = n.Assignment.Expression.Type()
+	}
+
+	if procType, ok := valueType.(*types.Procedure); ok && n.Assignment.Expression != nil {
+		return t.exportProcedure(name, procType, doc)
+	}
+
+	if unexported := unexportedType(valueType); unexported != "" {
+		return nil, fmt.Errorf("exported value uses unexported type %q", unexported)
+	}
+
+	// Only values of a Go basic type can be constants.
+	goType, _ := types.ToGo(valueType)
+	_, isBasic := goType.(*gotypes.Basic)
+
+	if ident.Qualifier != ast.QualifierVariable && isBasic && n.Assignment.Expression != nil {
+		return &goast.GenDecl{
+			Doc: doc,
+			Tok: gotoken.CONST,
+			Specs: []goast.Spec{&goast.ValueSpec{
+				Names:  []*goast.Ident{{Name: name}},
+				Values: []goast.Expr{implIdent},
+			}},
+		}, nil
+	}
+
+	getterType, err := t.exportedType(valueType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &goast.FuncDecl{
+		Doc:  doc,
+		Name: &goast.Ident{Name: name},
+		Type: &goast.FuncType{
+			Params:  &goast.FieldList{},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: getterType}}},
+		},
+		Body: &goast.BlockStmt{List: []goast.Stmt{
+			&goast.ReturnStmt{Results: []goast.Expr{toExported(implIdent, valueType)}},
+		}},
+	}, nil
+}
+
+// exportProcedure generates a Go function that calls the transpiled procedure
+// and adapts its parameters and return value.
+func (t *Transpiler) exportProcedure(name string, procType *types.Procedure, doc *goast.CommentGroup) (goast.Decl, error) {
+	if unexported := unexportedType(procType); unexported != "" {
+		return nil, fmt.Errorf("exported procedure uses unexported type %q", unexported)
+	}
+
+	params := make([]*goast.Field, 0, len(procType.Parameters)+1)
+	args := make([]goast.Expr, 0, len(procType.Parameters)+1)
+
+	if !procType.Function {
+		t.addStdLibImport("context")
+
+		params = append(params, component.ContextArg)
+		args = append(args, component.ContextVar)
+	}
+
+	// ascii parameters are checked before the call, as Go strings may hold
+	// any bytes.
+	var checks []*goast.Ident
+
+	for _, param := range procType.Parameters {
+		paramIdent := &goast.Ident{Name: param.Name}
+
+		paramType, arg, err := t.exportParameter(paramIdent, param.Type)
+		if err != nil {
+			return nil, fmt.Errorf("converting parameter %q: %w", param.Name, err)
+		}
+
+		if isPlainASCII(param.Type) {
+			checked := &goast.Ident{Name: "cog" + strings.ToUpper(param.Name[:1]) + param.Name[1:]}
+			checks = append(checks, paramIdent, checked)
+			arg = component.Selector(checked, "Value")
+		}
+
+		params = append(params, &goast.Field{
+			Names: []*goast.Ident{paramIdent},
+			Type:  paramType,
+		})
+		args = append(args, arg)
+	}
+
+	funcType := &goast.FuncType{
+		Params: &goast.FieldList{List: params},
+	}
+
+	var fun goast.Expr = component.Selector(&goast.Ident{Name: exportImplName}, name)
+
+	if len(procType.TypeParams) > 0 {
+		typeParams, err := t.convertTypeParams(procType.TypeParams)
+		if err != nil {
+			return nil, fmt.Errorf("converting type parameters: %w", err)
+		}
+
+		funcType.TypeParams = typeParams
+		fun = typeArguments(fun, procType.TypeParams)
+	}
+
+	call := &goast.CallExpr{Fun: fun, Args: args}
+
+	results, body, err := t.exportResults(call, procType.ReturnType)
+	if err != nil {
+		return nil, fmt.Errorf("converting return type: %w", err)
+	}
+
+	if len(checks) > 0 {
+		results, body = checkASCII(checks, results, body, procType.ReturnType)
+	}
+
+	if len(results) > 0 {
+		funcType.Results = &goast.FieldList{List: results}
+	}
+
+	return &goast.FuncDecl{
+		Doc:  doc,
+		Name: &goast.Ident{Name: name},
+		Type: funcType,
+		Body: &goast.BlockStmt{List: body},
+	}, nil
+}
+
+// exportParameter returns the facade type of a parameter, and the argument
+// passed to the transpiled procedure. Options are taken as a nilable pointer.
+func (t *Transpiler) exportParameter(ident *goast.Ident, typ types.Type) (goast.Expr, goast.Expr, error) {
+	opt, ok := typ.(*types.Option)
+	if !ok {
+		paramType, err := t.exportedType(typ)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return paramType, ident, nil
+	}
+
+	valueType, err := t.convertType(opt.Value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opt.Value.Kind() == types.ReferenceKind {
+		return valueType, &goast.CallExpr{
+			Fun:  component.Selector(&goast.Ident{Name: "cog"}, "OptionOf"),
+			Args: []goast.Expr{ident},
+		}, nil
+	}
+
+	return &goast.StarExpr{X: valueType}, &goast.CallExpr{
+		Fun:  component.Selector(&goast.Ident{Name: "cog"}, "OptionFromPointer"),
+		Args: []goast.Expr{ident},
+	}, nil
+}
+
+// checkASCII adds the checks of ascii parameters to the body of a facade
+// procedure, which return an error for a string that is not ASCII. checks
+// holds pairs of a parameter and the result of its check. Procedures that do
+// not return a result get an error result.
+func checkASCII(checks []*goast.Ident, results []*goast.Field, body []goast.Stmt, returnType types.Type) ([]*goast.Field, []goast.Stmt) {
+	if _, ok := returnType.(*types.Result); !ok {
+		nilErr := &goast.Ident{Name: "nil"}
+
+		if returnType == nil {
+			body = append(body, &goast.ReturnStmt{Results: []goast.Expr{nilErr}})
+		}
+
+		goast.Inspect(&goast.BlockStmt{List: body}, func(node goast.Node) bool {
+			if ret, ok := node.(*goast.ReturnStmt); ok {
+				ret.Results = append(ret.Results, nilErr)
+			}
+
+			return true
+		})
+
+		results = append(results, &goast.Field{Type: &goast.Ident{Name: "error"}})
+	}
+
+	stmts := make([]goast.Stmt, 0, len(checks)/2+len(body))
+
+	for i := 0; i < len(checks); i += 2 {
+		param, checked := checks[i], checks[i+1]
+
+		// cogS := cog.ASCIIFromGo(s)
+		stmts = append(stmts, component.AssignDef(checked, &goast.CallExpr{
+			Fun:  component.Selector(&goast.Ident{Name: "cog"}, "ASCIIFromGo"),
+			Args: []goast.Expr{param},
+		}))
+
+		// if cogS.IsError { var cogZero T; return cogZero, ..., cogS.Error }
+		var zeros []goast.Stmt
+
+		values := make([]goast.Expr, 0, len(results))
+
+		for j, result := range results[:len(results)-1] {
+			zero := &goast.Ident{Name: "cogZero"}
+			if j > 0 {
+				zero.Name += strconv.Itoa(j)
+			}
+
+			zeros = append(zeros, zeroValue(zero, result.Type))
+			values = append(values, zero)
+		}
+
+		values = append(values, component.Selector(checked, "Error"))
+
+		stmts = append(stmts, &goast.IfStmt{
+			Cond: component.Selector(checked, "IsError"),
+			Body: &goast.BlockStmt{List: append(zeros, &goast.ReturnStmt{Results: values})},
+		})
+	}
+
+	return results, append(stmts, body...)
+}
+
+// exportResults returns the facade results of a procedure and the body
+// calling it.
+func (t *Transpiler) exportResults(call goast.Expr, returnType types.Type) ([]*goast.Field, []goast.Stmt, error) {
+	if returnType == nil {
+		return nil, []goast.Stmt{&goast.ExprStmt{X: call}}, nil
+	}
+
+	result := &goast.Ident{Name: "cogResult"}
+	zero := &goast.Ident{Name: "cogZero"}
+
+	// cogResult := call
+	define := &goast.AssignStmt{
+		Lhs: []goast.Expr{result},
+		Tok: gotoken.DEFINE,
+		Rhs: []goast.Expr{call},
+	}
+
+	switch typ := returnType.(type) {
+	case *types.Result:
+		valueType, err := t.exportedType(typ.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		errValue := t.exportError(component.Selector(result, "Error"), typ.Error)
+
+		return []*goast.Field{{Type: valueType}, {Type: &goast.Ident{Name: "error"}}}, []goast.Stmt{
+			define,
+			&goast.IfStmt{
+				Cond: component.Selector(result, "IsError"),
+				Body: &goast.BlockStmt{List: []goast.Stmt{
+					zeroValue(zero, valueType),
+					&goast.ReturnStmt{Results: []goast.Expr{zero, errValue}},
+				}},
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{
+				toExported(component.Selector(result, "Value"), typ.Value),
+				&goast.Ident{Name: "nil"},
+			}},
+		}, nil
+	case *types.Option:
+		valueType, err := t.exportedType(typ.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		unset := &goast.UnaryExpr{Op: gotoken.NOT, X: component.Selector(result, "Set")}
+
+		if typ.Value.Kind() == types.ReferenceKind {
+			return []*goast.Field{{Type: valueType}}, []goast.Stmt{
+				define,
+				&goast.IfStmt{
+					Cond: unset,
+					Body: &goast.BlockStmt{List: []goast.Stmt{
+						&goast.ReturnStmt{Results: []goast.Expr{&goast.Ident{Name: "nil"}}},
+					}},
+				},
+				&goast.ReturnStmt{Results: []goast.Expr{component.Selector(result, "Value")}},
+			}, nil
+		}
+
+		return []*goast.Field{{Type: valueType}, {Type: &goast.Ident{Name: "bool"}}}, []goast.Stmt{
+			define,
+			&goast.IfStmt{
+				Cond: unset,
+				Body: &goast.BlockStmt{List: []goast.Stmt{
+					zeroValue(zero, valueType),
+					&goast.ReturnStmt{Results: []goast.Expr{zero, &goast.Ident{Name: "false"}}},
+				}},
+			},
+			&goast.ReturnStmt{Results: []goast.Expr{
+				toExported(component.Selector(result, "Value"), typ.Value),
+				&goast.Ident{Name: "true"},
+			}},
+		}, nil
+	}
+
+	resultType, err := t.exportedType(returnType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return []*goast.Field{{Type: resultType}}, []goast.Stmt{
+		&goast.ReturnStmt{Results: []goast.Expr{toExported(call, returnType)}},
+	}, nil
+}
+
+// exportError converts the error of a result to a Go error. Go errors and Cog
+// error types implement the error interface, other error values are formatted.
+func (t *Transpiler) exportError(expr goast.Expr, errType types.Type) goast.Expr {
+	if types.IsError(errType) {
+		return expr
+	}
+
+	t.addStdLibImport("fmt")
+
+	return &goast.CallExpr{
+		Fun: component.Selector(&goast.Ident{Name: goStdLibAlias("fmt")}, "Errorf"),
+		Args: []goast.Expr{
+			&goast.BasicLit{Kind: gotoken.STRING, Value: `"%v"`},
+			expr,
+		},
+	}
+}
+
+// exportedType converts a Cog type to its type in a Go facade.
+func (t *Transpiler) exportedType(typ types.Type) (goast.Expr, error) {
+	if isPlainASCII(typ) {
+		return &goast.Ident{Name: gotypes.Typ[gotypes.String].String()}, nil
+	}
+
+	return t.convertType(typ)
+}
+
+// toExported converts a transpiled value to its type in a Go facade.
+func toExported(expr goast.Expr, typ types.Type) goast.Expr {
+	if isPlainASCII(typ) {
+		return &goast.CallExpr{
+			Fun:  &goast.Ident{Name: gotypes.Typ[gotypes.String].String()},
+			Args: []goast.Expr{expr},
+		}
+	}
+
+	return expr
+}
+
+// isPlainASCII reports whether typ is the ascii type itself, rather than a
+// named type derived from it.
+func isPlainASCII(typ types.Type) bool {
+	return typ == types.Basics[types.ASCII]
+}
+
+// unexportedType returns the name of the first unexported type referenced by
+// typ, which cannot be named by users of a Go facade.
+func unexportedType(typ types.Type) string {
+	switch typ := typ.(type) {
+	case *types.Alias:
+		if typ.Constraint == nil && !typ.Exported && !strings.Contains(typ.Name, ".") {
+			return typ.Name
+		}
+	case *types.Array:
+		return unexportedType(typ.Element)
+	case *types.Slice:
+		return unexportedType(typ.Element)
+	case *types.Set:
+		return unexportedType(typ.Element)
+	case *types.Map:
+		if name := unexportedType(typ.Key); name != "" {
+			return name
+		}
+
+		return unexportedType(typ.Value)
+	case *types.Reference:
+		return unexportedType(typ.Value)
+	case *types.Option:
+		return unexportedType(typ.Value)
+	case *types.Result:
+		// The error is converted to a Go error.
+		return unexportedType(typ.Value)
+	case *types.Tuple:
+		for _, elem := range typ.Types {
+			if name := unexportedType(elem); name != "" {
+				return name
+			}
+		}
+	case *types.Procedure:
+		for _, param := range typ.Parameters {
+			if name := unexportedType(param.Type); name != "" {
+				return name
+			}
+		}
+
+		if typ.ReturnType != nil {
+			return unexportedType(typ.ReturnType)
+		}
+	}
+
+	return ""
+}
+
+// typeArguments instantiates a generic type or function with its own type
+// parameters.
+func typeArguments(expr goast.Expr, params []*types.Alias) goast.Expr {
+	indices := make([]goast.Expr, len(params))
+	for i, param := range params {
+		indices[i] = &goast.Ident{Name: param.Name}
+	}
+
+	return &goast.IndexListExpr{X: expr, Indices: indices}
+}
+
+// zeroValue declares name as the zero value of typ.
+func zeroValue(name *goast.Ident, typ goast.Expr) goast.Stmt {
+	return &goast.DeclStmt{Decl: &goast.GenDecl{
+		Tok: gotoken.VAR,
+		Specs: []goast.Spec{&goast.ValueSpec{
+			Names: []*goast.Ident{name},
+			Type:  typ,
+		}},
+	}}
+}
+
+// pruneExportImports removes imports that are not referenced by the facade.
+func (t *Transpiler) pruneExportImports(gofile *goast.File) {
+	used := make(map[string]bool)
+
+	goast.Inspect(gofile, func(node goast.Node) bool {
+		if sel, ok := node.(*goast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*goast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+
+		return true
+	})
+
+	for key, spec := range t.imports {
+		name := path.Base(key)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		if !used[name] {
+			delete(t.imports, key)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package transpiler_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	goprinter "go/printer"
+	gotoken "go/token"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/lexer"
+	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/transpiler"
+)
+
+// transpileExport runs the full pipeline and returns the generated Go facade.
+func transpileExport(t *testing.T, src string) string {
+	t.Helper()
+
+	l := lexer.NewLexer(strings.NewReader(src))
+
+	toks, err := l.Parse(t.Context())
+	if err != nil {
+		t.Fatalf("lex error: %v", err)
+	}
+
+	p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "")
+	if err != nil {
+		t.Fatalf("parser init error: %v", err)
+	}
+
+	f, err := p.Parse(t.Context(), "test.cog")
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tr := transpiler.NewTranspilerWithModule("example.com/lib/internal/impl", []*ast.File{f})
+
+	if _, err := tr.TranspileFiles(); err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+
+	gofile, err := tr.TranspileExport("example.com/lib/internal/impl")
+	if err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := goprinter.Fprint(&buf, gotoken.NewFileSet(), gofile); err != nil {
+		t.Fatalf("printing go ast: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestTranspileExport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("procedure_result", func(t *testing.T) {
+		t.Parallel()
+		got := transpileExport(t, `package lib
+ParseError ~ error<utf8> {
+	Empty := "empty",
+}
+// Parse parses a number.
+export parse : proc(s : utf8) int64 ! ParseError = {
+	return 1
+}`)
+		mustContain(t, got, "// Parse parses a number.")
+		mustContain(t, got, "func Parse(ctx go_context.Context, s string) (int64, error)")
+		mustContain(t, got, "cogResult := impl.Parse(ctx, s)")
+		mustContain(t, got, "return cogZero, cogResult.Error")
+		mustContain(t, got, "return cogResult.Value, nil")
+		mustContain(t, got, `impl "example.com/lib/internal/impl"`)
+	})
+
+	t.Run("function_option", func(t *testing.T) {
+		t.Parallel()
+		got := transpileExport(t, `package lib
+export first : func(xs : []int64) int64? = {
+	return xs[0]
+}
+export orDefault : func(x : int64?) int64 = {
+	return 0
+}`)
+		mustContain(t, got, "func First(xs []int64) (int64, bool)")
+		mustContain(t, got, "return cogResult.Value, true")
+		mustContain(t, got, "func OrDefault(x *int64) int64")
+		mustContain(t, got, "impl.OrDefault(cog.OptionFromPointer(x))")
+	})
+
+	t.Run("ascii", func(t *testing.T) {
+		t.Parallel()
+		got := transpileExport(t, `package lib
+export shout : func(s : ascii) ascii = {
+	return s
+}`)
+		mustContain(t, got, "func Shout(s string) (string, error)")
+		mustContain(t, got, "cogS := cog.ASCIIFromGo(s)")
+		mustContain(t, got, "return cogZero, cogS.Error")
+		mustContain(t, got, "return string(impl.Shout(cogS.Value)), nil")
+	})
+
+	t.Run("ascii_without_result", func(t *testing.T) {
+		t.Parallel()
+		got := transpileExport(t, `package lib
+export log : proc(s : ascii) = {
+	@print(s)
+}`)
+		mustContain(t, got, "func Log(ctx go_context.Context, s string) error")
+		mustContain(t, got, "return cogS.Error")
+		mustContain(t, got, "impl.Log(ctx, cogS.Value)")
+		mustContain(t, got, "return nil")
+	})
+
+	t.Run("types_and_values", func(t *testing.T) {
+		t.Parallel()
+		got := transpileExport(t, `package lib
+export Point ~ struct {
+	x : int64
+}
+export Status ~ enum<utf8> {
+	Open := "open",
+}
+export Limit : int64 = 10
+export primes : []int64 = {2, 3}
+hidden : int64 = 1`)
+		mustContain(t, got, "type Point = impl.Point")
+		mustContain(t, got, "type StatusEnum = impl.StatusEnum")
+		mustContain(t, got, "StatusOpen = impl.StatusOpen")
+		mustContain(t, got, "const Limit = impl.Limit")
+		mustContain(t, got, "func Primes() []int64")
+		mustContain(t, got, `// Limit is the exported Cog value "Limit".`)
+		mustNotContain(t, got, "Hidden")
+	})
+
+	t.Run("unexported_type_error", func(t *testing.T) {
+		t.Parallel()
+
+		src := `package lib
+point ~ struct {
+	x : int64
+}
+export origin : func() point = {
+	var p : point
+	return p
+}`
+
+		l := lexer.NewLexer(strings.NewReader(src))
+
+		toks, err := l.Parse(t.Context())
+		if err != nil {
+			t.Fatalf("lex error: %v", err)
+		}
+
+		p, err := parser.NewParserWithSymbols(toks, parser.NewSymbolTable(), false, "")
+		if err != nil {
+			t.Fatalf("parser init error: %v", err)
+		}
+
+		f, err := p.Parse(t.Context(), "test.cog")
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+
+		tr := transpiler.NewTranspiler([]*ast.File{f})
+
+		if _, err := tr.TranspileFiles(); err != nil {
+			t.Fatalf("transpile error: %v", err)
+		}
+
+		if _, err := tr.TranspileExport("lib/internal/impl"); err == nil {
+			t.Fatal("expected export error for unexported type")
+		}
+	})
+}
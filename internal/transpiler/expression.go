@@ -13,6 +13,10 @@ import (
 )
 
 func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
+	if literal, ok := t.folded[node]; ok {
+		node = literal
+	}
+
 	switch n := node.(type) {
 	case *ast.ArrayLiteral:
 		exprs := make([]goast.Expr, 0, len(n.Values))
@@ -37,6 +41,8 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 		}, nil
 	case *ast.ASCIILiteral:
 		return component.ASCIILit(n.Value), nil
+	case *ast.Async:
+		return t.convertAsync(n)
 	case *ast.BoolLiteral:
 		return component.BoolLit(n.Value), nil
 	case *ast.Builtin:
@@ -164,10 +170,24 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			fun = expr
 		}
 
+		typeArgs := n.TypeArgs
+
+		// Calls to generic functions call the instance for their type arguments.
+		if name, instTypeArgs, ok := t.callInstance(n); ok {
+			switch f := fun.(type) {
+			case *goast.Ident:
+				fun = &goast.Ident{Name: name}
+			case *goast.SelectorExpr:
+				fun = &goast.SelectorExpr{X: f.X, Sel: &goast.Ident{Name: name}}
+			}
+
+			typeArgs = instTypeArgs
+		}
+
 		// Wrap in IndexExpr/IndexListExpr for generic calls with type arguments.
-		if len(n.TypeArgs) > 0 {
-			indices := make([]goast.Expr, len(n.TypeArgs))
-			for i, ta := range n.TypeArgs {
+		if len(typeArgs) > 0 {
+			indices := make([]goast.Expr, len(typeArgs))
+			for i, ta := range typeArgs {
 				converted, err := t.convertType(ta)
 				if err != nil {
 					return nil, fmt.Errorf("converting type argument %d: %w", i, err)
@@ -231,24 +251,14 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 	case *ast.Float64Literal:
 		return component.Float64Lit(n.Value), nil
 	case *ast.GoCallExpression:
-		expr := &goast.CallExpr{
-			Fun: &goast.SelectorExpr{
-				X:   &goast.Ident{Name: goStdLibAlias(n.Import.Name)},
-				Sel: &goast.Ident{Name: n.CallIdentifier.Name},
-			},
-			Args: make([]goast.Expr, 0, len(n.Arguments)),
-		}
-
-		for _, arg := range n.Arguments {
-			goarg, err := t.convertExpr(arg)
-			if err != nil {
-				return nil, fmt.Errorf("converting call argument: %w", err)
-			}
-
-			expr.Args = append(expr.Args, goarg)
+		return t.convertGoCall(n)
+	case *ast.GoValue:
+		expr := &goast.SelectorExpr{
+			X:   &goast.Ident{Name: goStdLibAlias(n.Import.Name)},
+			Sel: &goast.Ident{Name: n.Identifier.Name},
 		}
 
-		return expr, nil
+		return t.adaptFromGo(expr, n.Identifier.ValueType, n.GoType)
 	case *ast.Identifier:
 		name := component.ConvertExport(n.Name, n.Exported, n.Global)
 
@@ -290,7 +300,14 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			return nil, fmt.Errorf("converting identifier: %w", err)
 		}
 
-		index, err := t.convertExpr(n.Index)
+		var index goast.Expr
+
+		if isASCIIKeyed(n.Identifier.Type()) {
+			index, err = t.convertASCIIKey(n.Index)
+		} else {
+			index, err = t.convertExpr(n.Index)
+		}
+
 		if err != nil {
 			return nil, fmt.Errorf("converting index: %w", err)
 		}
@@ -320,6 +337,20 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 				},
 				Args: []goast.Expr{rhs},
 			}, nil
+		case types.ErrorKind:
+			// Errors with a payload are equal if their variants are.
+			if errType, ok := n.Left.Type().Underlying().(*types.Error); ok && errType.HasPayload() {
+				binOp, err := convertBinaryOperator(n.Operator.Type)
+				if err != nil {
+					return nil, err
+				}
+
+				return &goast.BinaryExpr{
+					X:  component.Selector(lhs, "variant"),
+					Op: binOp,
+					Y:  component.Selector(rhs, "variant"),
+				}, nil
+			}
 		case types.Complex32:
 			t.addCogImport()
 
@@ -453,6 +484,22 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 					Op: gotoken.GEQ,
 					Y:  component.Int64Lit(0),
 				}, nil
+			case tokens.Percent:
+				return component.Call(component.Selector(lhs, "Mod"), rhs), nil
+			case tokens.BitAnd:
+				return component.Call(component.Selector(lhs, "And"), rhs), nil
+			case tokens.BitOr:
+				return component.Call(component.Selector(lhs, "Or"), rhs), nil
+			case tokens.BitXor:
+				return component.Call(component.Selector(lhs, "Xor"), rhs), nil
+			case tokens.BitClear:
+				t.addCogImport()
+
+				return component.Call(component.Selector(component.IdentName("cog"), "Uint128AndNot"), lhs, rhs), nil
+			case tokens.ShiftLeft:
+				return component.Call(component.Selector(lhs, "Lsh"), shiftCount(rhs)), nil
+			case tokens.ShiftRight:
+				return component.Call(component.Selector(lhs, "Rsh"), shiftCount(rhs)), nil
 			default:
 				return nil, fmt.Errorf("unsupported operator %q for uint128", n.Operator.Type)
 			}
@@ -527,6 +574,23 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 					Op: gotoken.GEQ,
 					Y:  component.Int64Lit(0),
 				}, nil
+			case tokens.Percent:
+				return component.Call(component.Selector(lhs, "Mod"), rhs), nil
+			case tokens.BitAnd:
+				return component.Call(component.Selector(lhs, "And"), rhs), nil
+			case tokens.BitOr:
+				return component.Call(component.Selector(lhs, "Or"), rhs), nil
+			case tokens.BitXor:
+				return component.Call(component.Selector(lhs, "Xor"), rhs), nil
+			case tokens.BitClear:
+				return component.Call(component.Selector(lhs, "AndNot"), rhs), nil
+			case tokens.ShiftLeft:
+				return component.Call(component.Selector(lhs, "LShiftN"), shiftCount(rhs)), nil
+			case tokens.ShiftRight:
+				// Int128.RShiftN rounds negative values toward zero.
+				t.addCogImport()
+
+				return component.Call(component.Selector(component.IdentName("cog"), "Int128Rsh"), lhs, shiftCount(rhs)), nil
 			default:
 				return nil, fmt.Errorf("unsupported operator %q for int128", n.Operator.Type)
 			}
@@ -537,6 +601,17 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			return nil, err
 		}
 
+		// An untyped constant shifted by a variable takes its type from the
+		// context in Go, so it is converted to the type of the literal.
+		if lit, ok := lhs.(*goast.BasicLit); ok && (binOp == gotoken.SHL || binOp == gotoken.SHR) {
+			litType, err := t.convertType(n.Left.Type())
+			if err != nil {
+				return nil, fmt.Errorf("converting shifted literal type: %w", err)
+			}
+
+			lhs = component.Call(litType, lit)
+		}
+
 		return &goast.BinaryExpr{
 			X:  lhs,
 			Op: binOp,
@@ -564,46 +639,22 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 		// TODO: handle not directly comparable types
 		exprs := make([]goast.Expr, len(n.Pairs))
 
-		mType := n.MapType.(*types.Map)
-		hasASCIIKey := mType.Key.Kind() == types.ASCII
+		hasASCIIKey := isASCIIKeyed(n.MapType)
 
 		for i, pair := range n.Pairs {
-			keyExpr, err := t.convertExpr(pair.Key)
-			if err != nil {
-				return nil, fmt.Errorf("converting map literal key %d: %w", i, err)
-			}
+			var (
+				keyExpr goast.Expr
+				err     error
+			)
 
 			if hasASCIIKey {
-				// Convert ascii literal keys to utf8 literals, since Go map keys must be comparable and byte slices are not.
-				var indexExpr goast.Expr
-
-				keyAlias, ok := pair.Key.Type().(*types.Alias)
-				if ok {
-					indexExpr = &goast.Ident{Name: component.ConvertExport(keyAlias.Name, keyAlias.Exported, keyAlias.Global) + "Hash"}
-				} else {
-					indexExpr = &goast.SelectorExpr{
-						X:   &goast.Ident{Name: "cog"},
-						Sel: &goast.Ident{Name: "ASCIIHash"},
-					}
-				}
-
-				keyExpr = &goast.CallExpr{
-					Fun: &goast.IndexExpr{
-						X: &goast.SelectorExpr{
-							X:   &goast.Ident{Name: "cog"},
-							Sel: &goast.Ident{Name: "HashASCII"},
-						},
-						Index: indexExpr,
-					},
-					Args: []goast.Expr{keyExpr},
-				}
+				keyExpr, err = t.convertASCIIKey(pair.Key)
 			} else {
-				kExpr, err := t.convertExpr(pair.Key)
-				if err != nil {
-					return nil, fmt.Errorf("converting map literal key %d: %w", i, err)
-				}
+				keyExpr, err = t.convertExpr(pair.Key)
+			}
 
-				keyExpr = kExpr
+			if err != nil {
+				return nil, fmt.Errorf("converting map literal key %d: %w", i, err)
 			}
 
 			valExpr, err := t.convertExpr(pair.Value)
@@ -695,7 +746,19 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			Op: unaryOp,
 			X:  right,
 		}, nil
+	case *ast.Propagate:
+		return t.convertPropagate(n)
+	case *ast.Range:
+		return t.convertRange(n)
 	case *ast.ProcedureLiteral:
+		literalType := n.ProcedureType
+
+		// An iterator literal is the procedure that yields its values.
+		iterType, isIterator := literalType.(*types.Iterator)
+		if isIterator {
+			literalType = iterType.Procedure()
+		}
+
 		stmts := make([]goast.Stmt, 0, len(n.Body.Statements))
 
 		if len(n.Body.Statements) > 0 {
@@ -705,7 +768,7 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 
 		// Register function parameters in the transpiler symbol table so that
 		// selector expressions (e.g. param.field) can resolve them.
-		if procType, ok := n.ProcedureType.(*types.Procedure); ok {
+		if procType, ok := literalType.(*types.Procedure); ok {
 			for _, param := range procType.Parameters {
 				t.symbols.Define(param.Name)
 				_ = t.symbols.MarkUsed(param.Name)
@@ -723,7 +786,7 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 		prevUsesDyn := t.usesDyn
 
 		t.usesDyn = false
-		if procType, ok := n.ProcedureType.(*types.Procedure); ok {
+		if procType, ok := literalType.(*types.Procedure); ok {
 			t.inFunc = procType.Function
 		} else {
 			t.inFunc = false
@@ -748,7 +811,17 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 		t.usesDyn = prevUsesDyn || bodyUsesDyn
 		t.inFunc = prevInFunc
 
-		procType, err := t.convertType(n.ProcedureType)
+		var (
+			procType goast.Expr
+			err      error
+		)
+
+		if isIterator {
+			procType, err = t.convertIteratorFunc(iterType)
+		} else {
+			procType, err = t.convertType(literalType)
+		}
+
 		if err != nil {
 			return nil, fmt.Errorf("converting procedure type: %w", err)
 		}
@@ -788,10 +861,14 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 
 		switch leftMost.ValueType.Kind() {
 		case types.EnumKind, types.ErrorKind:
-			enumName := ident
-			enumName.Name = enumName.Name + titleCaser.String(n.Field.Name)
+			if leftMost.ValueType.Kind() == types.ErrorKind && leftMost.Qualifier != ast.QualifierType {
+				// The payload field of an error value.
+				return component.Selector(component.Selector(ident, "payload"), n.Field.Name), nil
+			}
 
-			return enumName, nil
+			// The identifier of the type is shared by all its uses, so the
+			// variant gets an identifier of its own.
+			return component.IdentName(ident.Name + titleCaser.String(n.Field.Name)), nil
 		case types.GenericKind:
 			selExpr, err := t.convertExpr(n.Expression)
 			if err != nil {
@@ -799,6 +876,14 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			}
 
 			return component.Selector(selExpr, n.Field.Name), nil
+		case types.InterfaceKind:
+			selExpr, err := t.convertExpr(n.Expression)
+			if err != nil {
+				return nil, err
+			}
+
+			// Interface methods are exported, see convertMethod.
+			return component.Selector(selExpr, component.ConvertExport(n.Field.Name, true, false)), nil
 		case types.StructKind:
 			structType, ok := leftMost.ValueType.Underlying().(*types.Struct)
 			if !ok {
@@ -806,6 +891,14 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			}
 
 			field := structType.Field(n.Field.Name)
+			if field == nil && n.Field.Qualifier == ast.QualifierMethod {
+				// Methods are global, and named like their declaration.
+				return &goast.SelectorExpr{
+					X:   component.Ident(leftMost),
+					Sel: component.Ident(n.Field),
+				}, nil
+			}
+
 			if field != nil {
 				exported = field.Exported
 			}
@@ -821,39 +914,22 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 		// TODO: handle not directly comparable types
 		exprs := make([]goast.Expr, len(n.Values))
 
-		setType := n.SetType.(*types.Set)
-		isASCII := setType.Element.Kind() == types.ASCII
+		isASCII := isASCIIKeyed(n.SetType)
 
 		for i, v := range n.Values {
-			goExpr, err := t.convertExpr(v)
-			if err != nil {
-				return nil, fmt.Errorf("converting set literal value %d: %w", i, err)
-			}
+			var (
+				goExpr goast.Expr
+				err    error
+			)
 
 			if isASCII {
-				// Convert ascii literal keys to hash, since Go map keys must be comparable and byte slices are not.
-				var indexExpr goast.Expr
-
-				elemAlias, ok := setType.Element.(*types.Alias)
-				if ok {
-					indexExpr = &goast.Ident{Name: component.ConvertExport(elemAlias.Name, elemAlias.Exported, elemAlias.Global) + "Hash"}
-				} else {
-					indexExpr = &goast.SelectorExpr{
-						X:   &goast.Ident{Name: "cog"},
-						Sel: &goast.Ident{Name: "ASCIIHash"},
-					}
-				}
+				goExpr, err = t.convertASCIIKey(v)
+			} else {
+				goExpr, err = t.convertExpr(v)
+			}
 
-				goExpr = &goast.CallExpr{
-					Fun: &goast.IndexExpr{
-						X: &goast.SelectorExpr{
-							X:   &goast.Ident{Name: "cog"},
-							Sel: &goast.Ident{Name: "HashASCII"},
-						},
-						Index: indexExpr,
-					},
-					Args: []goast.Expr{goExpr},
-				}
+			if err != nil {
+				return nil, fmt.Errorf("converting set literal value %d: %w", i, err)
 			}
 
 			exprs[i] = &goast.KeyValueExpr{
@@ -862,37 +938,13 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			}
 		}
 
-		var indexExpr goast.Expr
-
-		if isASCII {
-			aliasType, ok := setType.Element.(*types.Alias)
-			if ok {
-				indexExpr = &goast.Ident{Name: component.ConvertExport(aliasType.Name, aliasType.Exported, aliasType.Global) + "Hash"}
-			} else {
-				indexExpr = &goast.SelectorExpr{
-					X:   &goast.Ident{Name: "cog"},
-					Sel: &goast.Ident{Name: "ASCIIHash"},
-				}
-			}
-		} else {
-			elemType, err := t.convertType(setType.Element)
-			if err != nil {
-				return nil, fmt.Errorf("converting set element type: %w", err)
-			}
-
-			indexExpr = elemType
+		setType, err := t.convertType(n.SetType)
+		if err != nil {
+			return nil, fmt.Errorf("converting set type: %w", err)
 		}
 
-		t.addCogImport()
-
 		return &goast.CompositeLit{
-			Type: &goast.IndexExpr{
-				X: &goast.SelectorExpr{
-					X:   &goast.Ident{Name: "cog"},
-					Sel: &goast.Ident{Name: "Set"},
-				},
-				Index: indexExpr,
-			},
+			Type: setType,
 			Elts: exprs,
 		}, nil
 	case *ast.SliceLiteral:
@@ -1072,6 +1124,29 @@ func (t *Transpiler) convertExpr(node ast.Expression) (goast.Expr, error) {
 			Type: goType,
 			Elts: kvs,
 		}, nil
+	case *ast.UnionLiteral:
+		unionType, ok := n.UnionType.Underlying().(*types.Union)
+		if !ok {
+			return nil, fmt.Errorf("unable to assert union type %q", n.UnionType)
+		}
+
+		expr, err := t.convertExpr(n.Value)
+		if err != nil {
+			return nil, fmt.Errorf("converting union literal value: %w", err)
+		}
+
+		goType, err := t.convertType(n.UnionType)
+		if err != nil {
+			return nil, fmt.Errorf("converting union literal type: %w", err)
+		}
+
+		return &goast.CompositeLit{
+			Type: goType,
+			Elts: []goast.Expr{
+				component.KeyValue(component.IdentName(unionTag(unionType)), intLit(n.Index)),
+				component.KeyValue(component.IdentName(unionVariant(unionType, n.Index)), expr),
+			},
+		}, nil
 	case *ast.ResultLiteral:
 		_, ok := n.ResultType.Underlying().(*types.Result)
 		if !ok {
@@ -1130,6 +1205,20 @@ func convertBinaryOperator(t tokens.Type) (gotoken.Token, error) {
 		return gotoken.MUL, nil
 	case tokens.Divide:
 		return gotoken.QUO, nil
+	case tokens.Percent:
+		return gotoken.REM, nil
+	case tokens.BitAnd:
+		return gotoken.AND, nil
+	case tokens.BitOr:
+		return gotoken.OR, nil
+	case tokens.BitXor:
+		return gotoken.XOR, nil
+	case tokens.BitClear:
+		return gotoken.AND_NOT, nil
+	case tokens.ShiftLeft:
+		return gotoken.SHL, nil
+	case tokens.ShiftRight:
+		return gotoken.SHR, nil
 	case tokens.NotEqual:
 		return gotoken.NEQ, nil
 	case tokens.Assign:
@@ -1155,6 +1244,12 @@ func convertBinaryOperator(t tokens.Type) (gotoken.Token, error) {
 	}
 }
 
+// shiftCount converts the shift count of a 128-bit shift to the uint taken by
+// its shift methods.
+func shiftCount(count goast.Expr) goast.Expr {
+	return component.Call(component.IdentName("uint"), count)
+}
+
 func convertUnaryOperator(t tokens.Type) (gotoken.Token, error) {
 	switch t {
 	case tokens.Not:
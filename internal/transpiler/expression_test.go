@@ -66,6 +66,86 @@ main : proc() = {
 		mustContain(t, got, "strings.ToUpper")
 	})
 
+	t.Run("go_value", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+goimport (
+	"math"
+	"os"
+	"time"
+)
+main : proc() = {
+	a := @go.math.MaxInt64
+	b := @go.math.MaxUint64
+	c := @go.time.Second * 2
+	d := @go.os.Args
+	@print(a)
+	@print(b)
+	@print(c)
+	@print(d)
+}`)
+		mustContain(t, got, "int64(go_math.MaxInt64)")
+		mustContain(t, got, "uint64(go_math.MaxUint64)")
+		mustContain(t, got, "int64(go_time.Second)")
+		mustContain(t, got, "= go_os.Args")
+	})
+
+	t.Run("go_type", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+goimport (
+	"bytes"
+	"fmt"
+)
+main : proc() = {
+	var buf : @go.bytes.Buffer
+	@go.fmt.Fprint(&buf, "hello")
+}`)
+		mustContain(t, got, "var buf go_bytes.Buffer")
+		mustContain(t, got, "go_fmt.Fprint(&buf")
+	})
+
+	t.Run("go_adapters", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+goimport (
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+main : proc() = {
+	a := @go.strconv.Atoi("1")
+	b := @go.os.Remove("file")
+	s : ascii ! error = @go.strings.ToUpper("s")
+	n : int64 = 2
+	h : int128 ! error = @go.big.NewInt(1)
+	u : uint128 ! error = @go.big.NewInt(1)
+	if s? {
+		r := @go.strings.Repeat(s, n)
+		@print(r)
+	}
+	if a? {
+		@print(a)
+	}
+	if b? {
+		@print(b)
+	}
+	if h? {
+		@print(h)
+	}
+	if u? {
+		@print(u)
+	}
+}`)
+		mustContain(t, got, "cog.ResultOf(int64(_value), _err)")
+		mustContain(t, got, "cog.ErrorOption(go_os.Remove(")
+		mustContain(t, got, "cog.ASCIIFromGo(go_strings.ToUpper(")
+		mustContain(t, got, "go_strings.Repeat(string(s.Value), int(n))")
+		mustContain(t, got, "cog.Int128FromBig(go_big.NewInt(")
+		mustContain(t, got, "cog.Uint128FromBig(go_big.NewInt(")
+	})
+
 	t.Run("selector", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, `package p
@@ -730,6 +810,23 @@ main : proc() = {
 	mustContain(t, got, "Red")
 }
 
+func TestConvertEnumValues(t *testing.T) {
+	t.Parallel()
+
+	got := transpile(t, `package p
+Color ~ enum<utf8> {
+	Red := "red",
+	Blue := "blue",
+}
+main : proc() = {
+	c := Color.Red
+	d := Color.Blue
+	@print(c, d)
+}`)
+	mustContain(t, got, "c _ColorEnum = _ColorRed")
+	mustContain(t, got, "d _ColorEnum = _ColorBlue")
+}
+
 func TestConvertMapBuiltin(t *testing.T) {
 	t.Parallel()
 
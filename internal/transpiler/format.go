@@ -0,0 +1,554 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	"slices"
+	"strconv"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// convertFormat converts the arguments of @print and @format to a string
+// expression, which separates the arguments by a space.
+func (t *Transpiler) convertFormat(args []ast.Expression) (goast.Expr, error) {
+	t.addBuiltinImport()
+
+	f := &formatter{t: t}
+
+	exprs := make([]goast.Expr, 0, 2*len(args))
+
+	for i, arg := range args {
+		if i > 0 {
+			exprs = append(exprs, component.UTF8Lit(" "))
+		}
+
+		if format, ok := arg.(*ast.FormatString); ok {
+			for _, part := range format.Parts {
+				if part.Text != "" {
+					exprs = append(exprs, component.UTF8Lit(part.Text))
+					continue
+				}
+
+				expr, err := f.part(part)
+				if err != nil {
+					return nil, fmt.Errorf("converting format string value: %w", err)
+				}
+
+				exprs = append(exprs, expr)
+			}
+
+			continue
+		}
+
+		expr, err := f.part(&ast.FormatPart{Value: arg, Spec: ast.FormatSpec{Precision: -1}})
+		if err != nil {
+			return nil, fmt.Errorf("converting argument %d: %w", i, err)
+		}
+
+		exprs = append(exprs, expr)
+	}
+
+	return component.Concat(exprs...), nil
+}
+
+// formatter generates the code that formats values with the formatters of the
+// builtin package, chosen by the static type of each value.
+type formatter struct {
+	t *Transpiler
+
+	// Named types being formatted, as a recursive type is formatted at run
+	// time.
+	aliases []*types.Alias
+
+	// Package of the named type being formatted, if it is of another package,
+	// which declares the names in its underlying type.
+	pkg string
+}
+
+// part returns a string expression of the value of part, formatted by its spec.
+func (f *formatter) part(part *ast.FormatPart) (goast.Expr, error) {
+	x, err := f.t.convertExpr(part.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	typ := part.ValueType()
+	spec := part.Spec
+
+	if lit, ok := x.(*goast.BasicLit); !(ok && lit.Kind == gotoken.STRING) && untypedConst(x) {
+		// An untyped constant would not satisfy the type constraint of a
+		// formatter.
+		goType, err := f.convertType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("converting formatted type: %w", err)
+		}
+
+		x = &goast.CallExpr{Fun: goType, Args: []goast.Expr{x}}
+	}
+
+	var str goast.Expr
+
+	switch spec.Verb {
+	case 'd', 'b', 'o', 'x', 'X':
+		str, err = f.integer(x, typ, spec.Verb)
+	case 'e', 'E', 'f', 'g', 'G':
+		str, err = f.float(x, typ, spec.Verb, spec.Precision)
+	case 'q':
+		str = component.BuiltinFormat("Quote", x)
+	default:
+		if spec.Precision >= 0 {
+			str, err = f.float(x, typ, 'f', spec.Precision)
+		} else {
+			str, err = f.value(x, typ, false)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Width > 0 {
+		// Numbers are aligned to the right by default, like in a table.
+		left := spec.Align == '<' || (spec.Align == 0 && !types.IsReal(typ))
+
+		str = component.BuiltinFormat("Pad", str, intLit(spec.Width), boolLit(left), boolLit(spec.Zero))
+	}
+
+	return str, nil
+}
+
+// untypedConst reports whether x is an untyped Go constant, which is made of
+// literals only.
+func untypedConst(x goast.Expr) bool {
+	switch x := x.(type) {
+	case *goast.BasicLit:
+		return true
+	case *goast.ParenExpr:
+		return untypedConst(x.X)
+	case *goast.UnaryExpr:
+		return untypedConst(x.X)
+	case *goast.BinaryExpr:
+		return untypedConst(x.X) && untypedConst(x.Y)
+	default:
+		return false
+	}
+}
+
+func (f *formatter) integer(x goast.Expr, typ types.Type, verb byte) (goast.Expr, error) {
+	name := "UintVerb"
+
+	switch typ.Kind() {
+	case types.Int128:
+		name = "Int128Verb"
+	case types.Uint128:
+		name = "Uint128Verb"
+	default:
+		if types.IsInt(typ) {
+			name = "IntVerb"
+		}
+	}
+
+	x, err := f.convertUnderlying(x, typ)
+	if err != nil {
+		return nil, err
+	}
+
+	return component.BuiltinFormat(name, x, charLit(verb)), nil
+}
+
+func (f *formatter) float(x goast.Expr, typ types.Type, verb byte, precision int) (goast.Expr, error) {
+	bitSize := 64
+
+	switch typ.Kind() {
+	case types.Float16:
+		converted, err := f.convertUnderlying(x, typ)
+		if err != nil {
+			return nil, err
+		}
+
+		x, bitSize = &goast.CallExpr{Fun: component.Selector(converted, "Float32")}, 32
+	case types.Float32:
+		bitSize = 32
+	}
+
+	if _, ok := typ.(*types.Basic); !ok || typ.Kind() != types.Float64 {
+		x = &goast.CallExpr{Fun: component.IdentName("float64"), Args: []goast.Expr{x}}
+	}
+
+	return component.BuiltinFormat("FloatVerb", x, charLit(verb), intLit(precision), intLit(bitSize)), nil
+}
+
+// value returns a string expression of x, a value of type typ, in Cog
+// spelling. Strings are quoted if nested in another value.
+func (f *formatter) value(x goast.Expr, typ types.Type, nested bool) (goast.Expr, error) {
+	switch typ.Kind() {
+	case types.EnumKind:
+		return f.enum(x, typ, nested)
+	case types.ErrorKind:
+		return &goast.CallExpr{Fun: component.Selector(x, "Error")}, nil
+	case types.GenericKind, types.AnyKind, types.InterfaceKind, types.GoKind, types.IteratorKind, types.ProcedureKind,
+		types.SignalKind:
+		return component.BuiltinFormat("Any", x), nil
+	}
+
+	// A composite value is formatted by a function literal of its own type.
+	goType := typ
+
+	if alias, ok := typ.(*types.Alias); ok {
+		if slices.Contains(f.aliases, alias) {
+			return component.BuiltinFormat("Any", x), nil
+		}
+
+		f.aliases = append(f.aliases, alias)
+		defer func() { f.aliases = f.aliases[:len(f.aliases)-1] }()
+
+		pkg := f.pkg
+		f.pkg = aliasPackage(alias, f.pkg)
+
+		defer func() { f.pkg = pkg }()
+
+		typ = alias.Underlying()
+
+		switch typ.Kind() {
+		case types.ArrayKind, types.StructKind, types.TupleKind, types.UnionKind:
+		default:
+			converted, err := f.convertUnderlying(x, alias)
+			if err != nil {
+				return nil, err
+			}
+
+			x, goType = converted, typ
+		}
+	}
+
+	switch typ := typ.(type) {
+	case *types.Basic:
+		return f.basic(x, typ, nested)
+	case *types.Option:
+		value, err := f.function(typ.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Option", x, value), nil
+	case *types.Result:
+		value, err := f.function(typ.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		errValue, err := f.function(typ.Error)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Result", x, value, errValue), nil
+	case *types.Either:
+		left, err := f.function(typ.Left)
+		if err != nil {
+			return nil, err
+		}
+
+		right, err := f.function(typ.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Either", x, left, right), nil
+	case *types.Reference:
+		value, err := f.function(typ.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Ref", x, value), nil
+	case *types.Slice:
+		elem, err := f.function(typ.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Slice", x, elem), nil
+	case *types.Set:
+		elem, err := f.function(keyType(typ.Element))
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Set", x, elem), nil
+	case *types.Map:
+		key, err := f.function(keyType(typ.Key))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := f.function(typ.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return component.BuiltinFormat("Map", x, key, value), nil
+	case *types.Array:
+		elem, err := f.function(typ.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		// An array value is not addressable, so it is sliced inside a function.
+		v := component.IdentName("v")
+
+		return f.call(x, goType, component.BuiltinFormat("Slice", &goast.SliceExpr{X: v}, elem))
+	case *types.Tuple:
+		v := component.IdentName("v")
+		exprs := []goast.Expr{component.UTF8Lit("(")}
+
+		for i, elemType := range typ.Types {
+			if i > 0 {
+				exprs = append(exprs, component.UTF8Lit(", "))
+			}
+
+			field := component.Selector(v, component.ConvertExport("t"+strconv.Itoa(i), typ.Exported, typ.Global))
+
+			elem, err := f.value(field, elemType, true)
+			if err != nil {
+				return nil, err
+			}
+
+			exprs = append(exprs, elem)
+		}
+
+		exprs = append(exprs, component.UTF8Lit(")"))
+
+		return f.call(x, goType, component.Concat(exprs...))
+	case *types.Struct:
+		v := component.IdentName("v")
+		exprs := []goast.Expr{component.UTF8Lit("{")}
+
+		for i, field := range typ.Fields {
+			sep := field.Name + " = "
+			if i > 0 {
+				sep = ", " + sep
+			}
+
+			value, err := f.value(component.Selector(v, component.ConvertExport(field.Name, field.Exported, false)), field.Type, true)
+			if err != nil {
+				return nil, err
+			}
+
+			exprs = append(exprs, component.UTF8Lit(sep), value)
+		}
+
+		exprs = append(exprs, component.UTF8Lit("}"))
+
+		return f.call(x, goType, component.Concat(exprs...))
+	case *types.Union:
+		if typ.Name != "" {
+			return component.BuiltinFormat("Any", x), nil
+		}
+
+		// The variant that is set is formatted, the last one by default to
+		// terminate the function.
+		v := component.IdentName("v")
+		clauses := make([]goast.Stmt, len(typ.Variants))
+
+		for i, variant := range typ.Variants {
+			value, err := f.value(component.Selector(v, unionVariant(typ, i)), variant, nested)
+			if err != nil {
+				return nil, err
+			}
+
+			clause := &goast.CaseClause{Body: []goast.Stmt{&goast.ReturnStmt{Results: []goast.Expr{value}}}}
+			if i < len(typ.Variants)-1 {
+				clause.List = []goast.Expr{intLit(i)}
+			}
+
+			clauses[i] = clause
+		}
+
+		fn, err := f.funcLitBody(goType, &goast.SwitchStmt{
+			Tag:  component.Selector(v, unionTag(typ)),
+			Body: &goast.BlockStmt{List: clauses},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &goast.CallExpr{Fun: fn, Args: []goast.Expr{x}}, nil
+	default:
+		return component.BuiltinFormat("Any", x), nil
+	}
+}
+
+// enum returns a string expression of the value of enum x.
+func (f *formatter) enum(x goast.Expr, typ types.Type, nested bool) (goast.Expr, error) {
+	alias, ok := typ.(*types.Alias)
+	if !ok {
+		return nil, fmt.Errorf("unable to cast enum %q to alias", typ)
+	}
+
+	enumType, ok := alias.Underlying().(*types.Enum)
+	if !ok {
+		return nil, fmt.Errorf("unable to assert enum type %q", typ)
+	}
+
+	valueType, err := f.convertType(enumType.ValueType)
+	if err != nil {
+		return nil, fmt.Errorf("converting enum value type: %w", err)
+	}
+
+	// The enum is an index into its values, which have a named type.
+	var values goast.Expr = component.IdentName(component.ConvertExport(alias.Name, alias.Exported, alias.Global))
+	if pkg := aliasPackage(alias, f.pkg); pkg != "" {
+		values = component.Selector(component.IdentName(pkg), component.ConvertExport(alias.Name, alias.Exported, alias.Global))
+	}
+
+	value := &goast.CallExpr{
+		Fun:  valueType,
+		Args: []goast.Expr{&goast.IndexExpr{X: values, Index: x}},
+	}
+
+	return f.value(value, enumType.ValueType, nested)
+}
+
+// convertType converts typ, of which the unqualified names are of the package
+// of the named type being formatted.
+func (f *formatter) convertType(typ types.Type) (goast.Expr, error) {
+	pkg := f.t.typePackage
+	f.t.typePackage = f.pkg
+
+	defer func() { f.t.typePackage = pkg }()
+
+	return f.t.convertType(typ)
+}
+
+func (f *formatter) basic(x goast.Expr, typ *types.Basic, nested bool) (goast.Expr, error) {
+	switch kind := typ.Kind(); kind {
+	case types.ASCII, types.UTF8:
+		if nested {
+			return component.BuiltinFormat("Quote", x), nil
+		}
+
+		if kind == types.ASCII {
+			return &goast.CallExpr{Fun: component.IdentName("string"), Args: []goast.Expr{x}}, nil
+		}
+
+		return x, nil
+	case types.Bool:
+		return component.BuiltinFormat("Bool", x), nil
+	case types.Int8, types.Int16, types.Int32, types.Int64:
+		return component.BuiltinFormat("Int", x), nil
+	case types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		return component.BuiltinFormat("Uint", x), nil
+	case types.Int128:
+		return component.BuiltinFormat("Int128", x), nil
+	case types.Uint128:
+		return component.BuiltinFormat("Uint128", x), nil
+	case types.Float16:
+		return component.BuiltinFormat("Float16", x), nil
+	case types.Float32:
+		return component.BuiltinFormat("Float32", x), nil
+	case types.Float64:
+		return component.BuiltinFormat("Float64", x), nil
+	case types.Complex32:
+		return component.BuiltinFormat("Complex64", &goast.CallExpr{Fun: component.Selector(x, "Complex64")}), nil
+	case types.Complex64:
+		return component.BuiltinFormat("Complex64", x), nil
+	case types.Complex128:
+		return component.BuiltinFormat("Complex128", x), nil
+	default:
+		return component.BuiltinFormat("Any", x), nil
+	}
+}
+
+// function returns a function literal that formats a value of type typ.
+func (f *formatter) function(typ types.Type) (*goast.FuncLit, error) {
+	v := component.IdentName("v")
+
+	str, err := f.value(v, typ, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.funcLit(typ, str)
+}
+
+// call returns a call of a function literal that returns str, with x as its
+// argument v of type typ.
+func (f *formatter) call(x goast.Expr, typ types.Type, str goast.Expr) (goast.Expr, error) {
+	fn, err := f.funcLit(typ, str)
+	if err != nil {
+		return nil, err
+	}
+
+	return &goast.CallExpr{Fun: fn, Args: []goast.Expr{x}}, nil
+}
+
+func (f *formatter) funcLit(typ types.Type, str goast.Expr) (*goast.FuncLit, error) {
+	return f.funcLitBody(typ, &goast.ReturnStmt{Results: []goast.Expr{str}})
+}
+
+// funcLitBody returns a function literal with body, that formats its argument
+// v of type typ.
+func (f *formatter) funcLitBody(typ types.Type, body ...goast.Stmt) (*goast.FuncLit, error) {
+	paramType, err := f.convertType(typ)
+	if err != nil {
+		return nil, fmt.Errorf("converting formatted type: %w", err)
+	}
+
+	return &goast.FuncLit{
+		Type: &goast.FuncType{
+			Params: &goast.FieldList{List: []*goast.Field{{
+				Names: []*goast.Ident{component.IdentName("v")},
+				Type:  paramType,
+			}}},
+			Results: &goast.FieldList{List: []*goast.Field{{Type: component.IdentName("string")}}},
+		},
+		Body: &goast.BlockStmt{List: body},
+	}, nil
+}
+
+// convertUnderlying converts x, a value of named type typ, to the Go type of
+// its underlying type, for the formatters that take a Cog runtime type.
+func (f *formatter) convertUnderlying(x goast.Expr, typ types.Type) (goast.Expr, error) {
+	alias, ok := typ.(*types.Alias)
+	if !ok {
+		return x, nil
+	}
+
+	goType, err := f.convertType(alias.Underlying())
+	if err != nil {
+		return nil, fmt.Errorf("converting formatted type: %w", err)
+	}
+
+	if _, ok := goType.(*goast.StarExpr); ok {
+		goType = &goast.ParenExpr{X: goType}
+	}
+
+	return &goast.CallExpr{Fun: goType, Args: []goast.Expr{x}}, nil
+}
+
+// keyType returns the Go key type of an ascii keyed map or set, which is a
+// string.
+func keyType(typ types.Type) types.Type {
+	if typ.Kind() == types.ASCII {
+		return types.Basics[types.UTF8]
+	}
+
+	return typ
+}
+
+func boolLit(b bool) *goast.Ident {
+	return component.IdentName(strconv.FormatBool(b))
+}
+
+func charLit(c byte) *goast.BasicLit {
+	return &goast.BasicLit{Kind: gotoken.CHAR, Value: strconv.QuoteRune(rune(c))}
+}
+
+func intLit(n int) *goast.BasicLit {
+	return &goast.BasicLit{Kind: gotoken.INT, Value: strconv.Itoa(n)}
+}
@@ -0,0 +1,240 @@
+package transpiler
+
+import (
+	"errors"
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+	gotypes "go/types"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+func (t *Transpiler) convertGoCall(n *ast.GoCallExpression) (goast.Expr, error) {
+	expr := &goast.CallExpr{
+		Fun: &goast.SelectorExpr{
+			X:   &goast.Ident{Name: goStdLibAlias(n.Import.Name)},
+			Sel: &goast.Ident{Name: n.CallIdentifier.Name},
+		},
+		Args: make([]goast.Expr, 0, len(n.Arguments)),
+	}
+
+	params := n.Signature.Params()
+
+	for i, arg := range n.Arguments {
+		goarg, err := t.convertExpr(arg)
+		if err != nil {
+			return nil, fmt.Errorf("converting call argument: %w", err)
+		}
+
+		var paramType gotypes.Type
+
+		if n.Signature.Variadic() && i >= params.Len()-1 {
+			paramType = params.At(params.Len() - 1).Type().(*gotypes.Slice).Elem()
+		} else {
+			paramType = params.At(i).Type()
+		}
+
+		goarg, err = t.adaptToGo(goarg, arg, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("adapting call argument: %w", err)
+		}
+
+		expr.Args = append(expr.Args, goarg)
+	}
+
+	results := n.Signature.Results()
+
+	switch returnType := n.ReturnType.(type) {
+	case nil, *types.Tuple:
+		// Results are discarded.
+		return expr, nil
+	case *types.Result:
+		if results.Len() == 2 {
+			return t.adaptGoResult(expr, returnType, results.At(0).Type())
+		}
+	case *types.Option:
+		t.addCogImport()
+
+		if types.IsNone(returnType.Value) || returnType.Value == types.GoError {
+			return component.Call(component.Selector(component.IdentName("cog"), "ErrorOption"), expr), nil
+		}
+
+		return component.Call(component.Selector(component.IdentName("cog"), "OptionOf"), expr), nil
+	}
+
+	return t.adaptFromGo(expr, n.ReturnType, results.At(0).Type())
+}
+
+// adaptGoResult converts the (value, error) results of a Go call to a result.
+// When the value needs an adapter, the call is wrapped in a function literal.
+func (t *Transpiler) adaptGoResult(call goast.Expr, returnType *types.Result, goType gotypes.Type) (goast.Expr, error) {
+	t.addCogImport()
+
+	resultOf := component.Selector(component.IdentName("cog"), "ResultOf")
+
+	target, err := types.ToGo(returnType.Value)
+	if err == nil && gotypes.Identical(target, goType) {
+		return component.Call(resultOf, call), nil
+	}
+
+	value, err := t.adaptFromGo(component.IdentName("_value"), returnType.Value, goType)
+	if err != nil {
+		return nil, err
+	}
+
+	resultType, err := t.convertType(returnType)
+	if err != nil {
+		return nil, fmt.Errorf("converting Go result type: %w", err)
+	}
+
+	return &goast.CallExpr{
+		Fun: &goast.FuncLit{
+			Type: &goast.FuncType{
+				Params:  &goast.FieldList{},
+				Results: &goast.FieldList{List: []*goast.Field{{Type: resultType}}},
+			},
+			Body: &goast.BlockStmt{
+				List: []goast.Stmt{
+					&goast.AssignStmt{
+						Lhs: []goast.Expr{component.IdentName("_value"), component.IdentName("_err")},
+						Tok: gotoken.DEFINE,
+						Rhs: []goast.Expr{call},
+					},
+					&goast.ReturnStmt{
+						Results: []goast.Expr{component.Call(resultOf, value, component.IdentName("_err"))},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// adaptFromGo converts a value coming from Go to the Go representation of its
+// Cog type. A value adapted to ascii, int128 or uint128 is a result, as the
+// adapter fails if the value does not fit.
+func (t *Transpiler) adaptFromGo(expr goast.Expr, cogType types.Type, goType gotypes.Type) (goast.Expr, error) {
+	result, ok := cogType.Underlying().(*types.Result)
+	if !ok || !types.AdaptableFromGo(goType, result.Value) {
+		return t.convertGoValue(expr, cogType, goType)
+	}
+
+	t.addCogImport()
+
+	switch result.Value.Kind() {
+	case types.ASCII:
+		return component.Call(component.Selector(component.IdentName("cog"), "ASCIIFromGo"), expr), nil
+	case types.Int128:
+		return component.Call(component.Selector(component.IdentName("cog"), "Int128FromBig"), expr), nil
+	case types.Uint128:
+		return component.Call(component.Selector(component.IdentName("cog"), "Uint128FromBig"), expr), nil
+	}
+
+	return nil, fmt.Errorf("no adapter from Go type %q to %q", goType, cogType)
+}
+
+// adaptToGo converts a Cog argument to the type of a Go parameter.
+func (t *Transpiler) adaptToGo(expr goast.Expr, arg ast.Expression, goType gotypes.Type) (goast.Expr, error) {
+	if ast.IsGoLiteral(arg) {
+		// Literals are untyped Go constants.
+		return expr, nil
+	}
+
+	argType := arg.Type()
+
+	if result, ok := argType.Underlying().(*types.Result); ok {
+		if _, ok := arg.(*ast.Identifier); ok {
+			// Checked result identifiers are converted to their value.
+			argType = result.Value
+		}
+	}
+
+	if opt, ok := argType.(*types.Option); ok && opt.Value.Kind() == types.ReferenceKind {
+		// An unset option holds a nil pointer.
+		expr = component.Selector(expr, "Value")
+		argType = opt.Value
+	}
+
+	switch {
+	case argType.Kind() == types.Int128 && types.AdaptableFromGo(goType, argType):
+		t.addCogImport()
+		return component.Call(component.Selector(component.IdentName("cog"), "Int128ToBig"), expr), nil
+	case argType.Kind() == types.Uint128 && types.AdaptableFromGo(goType, argType):
+		t.addCogImport()
+		return component.Call(component.Selector(component.IdentName("cog"), "Uint128ToBig"), expr), nil
+	case argType.Kind() == types.ASCII && types.AdaptableFromGo(goType, argType):
+		// cog.ASCII is a byte slice, which converts to both string and []byte.
+	default:
+		goArgType, err := types.ToGo(argType)
+		if err != nil || gotypes.AssignableTo(goArgType, goType) {
+			return expr, nil
+		}
+	}
+
+	typeExpr, err := t.goTypeExpr(goType)
+	if err != nil {
+		return nil, err
+	}
+
+	return component.Call(typeExpr, expr), nil
+}
+
+// convertGoValue converts a value coming from Go to the Go representation of
+// its Cog type. Untyped constants and named Go types (e.g. time.Duration) are
+// wrapped in an explicit conversion.
+func (t *Transpiler) convertGoValue(expr goast.Expr, cogType types.Type, goType gotypes.Type) (goast.Expr, error) {
+	target, err := types.ToGo(cogType)
+	if err != nil || gotypes.Identical(target, goType) || !gotypes.ConvertibleTo(goType, target) {
+		return expr, nil
+	}
+
+	typeExpr, err := t.convertType(cogType)
+	if err != nil {
+		return nil, fmt.Errorf("converting Go value type: %w", err)
+	}
+
+	switch typeExpr.(type) {
+	case *goast.StarExpr, *goast.FuncType:
+		typeExpr = &goast.ParenExpr{X: typeExpr}
+	}
+
+	return component.Call(typeExpr, expr), nil
+}
+
+// goTypeExpr converts a Go type to a type expression, importing the package of
+// named types.
+func (t *Transpiler) goTypeExpr(typ gotypes.Type) (goast.Expr, error) {
+	switch typ := typ.(type) {
+	case *gotypes.Basic:
+		return component.IdentName(typ.Name()), nil
+	case *gotypes.Alias:
+		return t.goTypeExpr(gotypes.Unalias(typ))
+	case *gotypes.Named:
+		pkg := typ.Obj().Pkg()
+		if pkg == nil {
+			return component.IdentName(typ.Obj().Name()), nil
+		}
+
+		t.addStdLibImport(pkg.Path())
+
+		return component.Selector(component.IdentName(goStdLibAlias(pkg.Path())), typ.Obj().Name()), nil
+	case *gotypes.Pointer:
+		elem, err := t.goTypeExpr(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &goast.ParenExpr{X: &goast.StarExpr{X: elem}}, nil
+	case *gotypes.Slice:
+		elem, err := t.goTypeExpr(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &goast.ArrayType{Elt: elem}, nil
+	}
+
+	return nil, errors.New("unsupported Go type " + typ.String())
+}
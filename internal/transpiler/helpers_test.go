@@ -2,6 +2,9 @@ package transpiler_test
 
 import (
 	"bytes"
+	"context"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/samborkent/cog/internal/ast"
 	"github.com/samborkent/cog/internal/lexer"
 	"github.com/samborkent/cog/internal/parser"
+	"github.com/samborkent/cog/internal/project"
 	"github.com/samborkent/cog/internal/transpiler"
 )
 
@@ -139,3 +143,67 @@ func mustFailTranspile(t *testing.T, src, want string) {
 		t.Errorf("expected error containing %q, got: %v", want, err)
 	}
 }
+
+// loadPackage writes files, by path relative to a temporary project root,
+// and loads the main package with the packages it imports.
+func loadPackage(t *testing.T, files map[string]string) *project.Package {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(src), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loader := &project.Loader{}
+
+	entryFiles, err := loader.Files(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, err := loader.Load(context.Background(), dir, entryFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pkg
+}
+
+// packageFiles returns the syntax trees of the files of pkg.
+func packageFiles(pkg *project.Package) []*ast.File {
+	out := make([]*ast.File, len(pkg.Files))
+	for i, file := range pkg.Files {
+		out[i] = file.AST
+	}
+
+	return out
+}
+
+// transpileLoaded transpiles the loaded package pkg of module testmod.
+func transpileLoaded(t *testing.T, pkg *project.Package, opts ...transpiler.TranspilerOption) string {
+	t.Helper()
+
+	tr := transpiler.NewTranspilerWithModule("testmod", packageFiles(pkg), opts...)
+
+	gofile, err := tr.Transpile()
+	if err != nil {
+		t.Fatalf("transpile error: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := goprinter.Fprint(&buf, gotoken.NewFileSet(), gofile); err != nil {
+		t.Fatalf("print error: %v", err)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,90 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	gotoken "go/token"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// convertRange converts a range expression to a call of builtin.Range, which
+// returns an iter.Seq of the integers in the range.
+func (t *Transpiler) convertRange(node *ast.Range) (goast.Expr, error) {
+	elemType, err := t.convertType(node.Start.Type())
+	if err != nil {
+		return nil, fmt.Errorf("converting range type: %w", err)
+	}
+
+	start, err := t.convertExpr(node.Start)
+	if err != nil {
+		return nil, fmt.Errorf("converting range start: %w", err)
+	}
+
+	end, err := t.convertExpr(node.End)
+	if err != nil {
+		return nil, fmt.Errorf("converting range end: %w", err)
+	}
+
+	var step goast.Expr = &goast.BasicLit{Kind: gotoken.INT, Value: "1"}
+
+	if node.Step != nil {
+		step, err = t.convertExpr(node.Step)
+		if err != nil {
+			return nil, fmt.Errorf("converting range step: %w", err)
+		}
+	}
+
+	t.addBuiltinImport()
+
+	return component.BuiltinRange(elemType, start, end, step, node.Inclusive()), nil
+}
+
+// convertIteratorFunc converts the procedure type of an iterator literal. The
+// procedure has the signature of a Go iterator, without a context.
+func (t *Transpiler) convertIteratorFunc(iterType *types.Iterator) (*goast.FuncType, error) {
+	yieldType, err := t.convertType(iterType.Yield())
+	if err != nil {
+		return nil, fmt.Errorf("converting yield type: %w", err)
+	}
+
+	return &goast.FuncType{
+		Params: &goast.FieldList{List: []*goast.Field{{
+			Names: []*goast.Ident{component.IdentName("yield")},
+			Type:  yieldType,
+		}}},
+	}, nil
+}
+
+// convertIteratorRange returns the Go range expression of a loop over iterator
+// x. A procedure that yields values takes a context when the file needs one,
+// so it is wrapped in an iterator function that passes the context.
+func (t *Transpiler) convertIteratorRange(x goast.Expr, typ types.Type) (goast.Expr, error) {
+	procType, ok := typ.Underlying().(*types.Procedure)
+	if !ok || procType.Function || !t.currentFileNeedsContext() {
+		return x, nil
+	}
+
+	iterType, _ := types.IteratorOf(typ)
+
+	funcType, err := t.convertIteratorFunc(iterType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.symbols.MarkUsed("ctx"); err != nil {
+		return nil, err
+	}
+
+	return &goast.FuncLit{
+		Type: funcType,
+		Body: &goast.BlockStmt{List: []goast.Stmt{
+			&goast.ExprStmt{X: &goast.CallExpr{
+				Fun:  x,
+				Args: []goast.Expr{component.ContextVar, component.IdentName("yield")},
+			}},
+		}},
+	}, nil
+}
@@ -15,8 +15,19 @@ func (t *Transpiler) convertMatch(n *ast.Match) ([]goast.Stmt, error) {
 		return nil, fmt.Errorf("converting match subject: %w", err)
 	}
 
+	// Instances of generic functions only emit the case they narrow to.
+	if body, ok := t.instance.branch(n); ok {
+		return t.convertMatchBranch(n, expr, body)
+	}
+
 	subjectType := n.Subject.Type()
 
+	if subjectType.Kind() == types.UnionKind {
+		if u, ok := subjectType.Underlying().(*types.Union); ok {
+			return t.convertSumMatch(n, expr, u)
+		}
+	}
+
 	if subjectType.Kind() == types.EitherKind {
 		eitherType := subjectType.(*types.Either)
 
@@ -119,9 +130,17 @@ func (t *Transpiler) convertMatch(n *ast.Match) ([]goast.Stmt, error) {
 			return nil, fmt.Errorf("converting match case type: %w", err)
 		}
 
-		stmts := make([]goast.Stmt, 0, len(c.Body))
+		stmts := make([]goast.Stmt, 0, len(c.Body)+1)
 
 		t.symbols = NewEnclosedSymbolTable(t.symbols)
+
+		// A matched type parameter subject is narrowed to the case type.
+		var narrowed *goast.Ident
+
+		if subject, ok := n.Subject.(*ast.Identifier); ok && matchParam(n) != nil {
+			narrowed = t.symbols.Define(component.ConvertExport(subject.Name, subject.Exported, subject.Global))
+		}
+
 		for _, stmt := range c.Body {
 			convStmt, err := t.convertStmt(stmt)
 			if err != nil {
@@ -133,6 +152,10 @@ func (t *Transpiler) convertMatch(n *ast.Match) ([]goast.Stmt, error) {
 
 		t.symbols = t.symbols.Outer
 
+		if narrowed != nil && narrowed.Name != "_" {
+			stmts = append([]goast.Stmt{component.AssignDef(narrowed, component.TypeAssert(tagExpr, caseType))}, stmts...)
+		}
+
 		cases = append(cases, &goast.CaseClause{
 			List: []goast.Expr{caseType},
 			Body: stmts,
@@ -165,3 +188,98 @@ func (t *Transpiler) convertMatch(n *ast.Match) ([]goast.Stmt, error) {
 
 	return []goast.Stmt{typeSwitch}, nil
 }
+
+// convertSumMatch converts match n on expr, a value of sum type u, to a switch
+// on the index of its variant. The variant is bound to the binding of n in
+// each case, if it is used.
+func (t *Transpiler) convertSumMatch(n *ast.Match, expr goast.Expr, u *types.Union) ([]goast.Stmt, error) {
+	switchStmt := &goast.SwitchStmt{
+		Body: &goast.BlockStmt{List: make([]goast.Stmt, 0, len(n.Cases)+1)},
+	}
+
+	// The subject is evaluated once.
+	if _, ok := n.Subject.(*ast.Identifier); !ok {
+		subject := component.IdentName("_subject")
+		switchStmt.Init = component.AssignDef(subject, expr)
+		expr = subject
+	}
+
+	switchStmt.Tag = component.Selector(expr, unionTag(u))
+
+	for j, c := range n.Cases {
+		i := u.Index(c.MatchType)
+
+		body, err := t.convertMatchBody(n, component.Selector(expr, unionVariant(u, i)), c.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		clause := &goast.CaseClause{Body: body}
+
+		// The last case of an exhaustive match is the default, so the switch
+		// terminates if every case does.
+		if n.Default != nil || j < len(n.Cases)-1 {
+			clause.List = []goast.Expr{intLit(i)}
+		}
+
+		switchStmt.Body.List = append(switchStmt.Body.List, clause)
+	}
+
+	if n.Default != nil {
+		body, err := t.convertMatchBody(n, expr, n.Default.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		switchStmt.Body.List = append(switchStmt.Body.List, &goast.CaseClause{Body: body})
+	}
+
+	return []goast.Stmt{switchStmt}, nil
+}
+
+// convertMatchBranch converts the statements body of match n, which an
+// instance of a generic function narrows to, to a block. The subject expr is
+// bound to the binding of n, if it is used.
+func (t *Transpiler) convertMatchBranch(n *ast.Match, expr goast.Expr, body []ast.Statement) ([]goast.Stmt, error) {
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	stmts, err := t.convertMatchBody(n, expr, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return []goast.Stmt{component.BlockStmt(stmts...)}, nil
+}
+
+// convertMatchBody converts the statements body of a case of match n. The
+// value is bound to the binding of n, if it is used.
+func (t *Transpiler) convertMatchBody(n *ast.Match, value goast.Expr, body []ast.Statement) ([]goast.Stmt, error) {
+	stmts := make([]goast.Stmt, 0, len(body)+1)
+
+	t.symbols = NewEnclosedSymbolTable(t.symbols)
+
+	var binding *goast.Ident
+
+	if n.Binding != nil {
+		binding = t.symbols.Define(n.Binding.Name)
+	}
+
+	for _, stmt := range body {
+		convStmt, err := t.convertStmt(stmt)
+		if err != nil {
+			return nil, fmt.Errorf("converting match case statement: %w", err)
+		}
+
+		stmts = append(stmts, convStmt...)
+	}
+
+	t.symbols = t.symbols.Outer
+
+	if binding != nil && binding.Name != "_" {
+		stmts = append([]goast.Stmt{component.AssignDef(binding, value)}, stmts...)
+	}
+
+	return stmts, nil
+}
@@ -9,7 +9,7 @@ func TestConvertMatchGeneric(t *testing.T) {
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ int32 | utf8>(x : T) = {
+show : proc<T ~ int32 | utf8>(x : T) = {
 	match x {
 	case int32:
 		@print(x)
@@ -30,7 +30,7 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ int32 | utf8>(x : T) = {
+show : proc<T ~ int32 | utf8>(x : T) = {
 	match val := x {
 	case int32:
 		@print(val)
@@ -49,7 +49,7 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ any>(x : T) = {
+show : proc<T ~ any>(x : T) = {
 	match x {
 	case int32:
 		@print(x)
@@ -68,12 +68,14 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ any>(x : T) = {
+show : proc<T ~ any>(x : T) = {
 	match x {
 	case int64:
 		@print(x)
 	case utf8:
 		@print(x)
+	default:
+		@print("other")
 	}
 }
 main : proc() = {}`)
@@ -88,7 +90,7 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ any>(x : T) = {
+show : proc<T ~ any>(x : T) = {
 	match val := x {
 	case int64:
 		@print(val)
@@ -106,7 +108,7 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ int8 | int16 | int32>(x : T) = {
+show : proc<T ~ int8 | int16 | int32>(x : T) = {
 	match x {
 	case int8:
 		@print(x)
@@ -127,10 +129,12 @@ main : proc() = {}`)
 		t.Parallel()
 
 		got := transpile(t, `package p
-show : func<T ~ int32 | utf8>(x : T) = {
+show : proc<T ~ int32 | utf8>(x : T) = {
 	match x {
 	case int32:
 		@print(x)
+	case utf8:
+		@print("utf8")
 	}
 }
 main : proc() = {}`)
@@ -139,3 +143,94 @@ main : proc() = {}`)
 		mustContain(t, got, ".(type)")
 	})
 }
+
+func TestConvertMatchSum(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exhaustive", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package p
+Shape ~ int32 | float64 | utf8
+describe : func(s : Shape) utf8 = {
+	match v := s {
+	case int32:
+		return "int32"
+	case utf8:
+		return v
+	case float64:
+		return "float64"
+	}
+}
+main : proc() = {
+	@print(describe("x"))
+}`)
+
+		mustContain(t, got, "type _Shape struct {\n\ttag\tuint8\n\tv0\tint32\n\tv1\tfloat64\n\tv2\tstring\n}")
+		mustContain(t, got, "switch s.tag {")
+		mustContain(t, got, "case 2:\n\t\tv := s.v2")
+
+		// The last case is the default, so the switch terminates.
+		mustContain(t, got, "default:")
+		mustNotContain(t, got, "case 1:")
+		mustContain(t, got, `describe(_Shape{tag: 2, v2: "x"})`)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package p
+Shape ~ int32 | utf8
+show : proc(s : Shape) = {
+	match s {
+	case int32:
+		@print("int32")
+	default:
+		@print("other")
+	}
+}
+main : proc() = {
+	n : int32 = 1
+	show(n)
+}`)
+
+		mustContain(t, got, "case 0:")
+		mustContain(t, got, "default:")
+		mustContain(t, got, "show(ctx, _Shape{tag: 0, v0: n})")
+	})
+
+	t.Run("subject_evaluated_once", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package p
+Shape ~ int32 | utf8
+make : func() Shape = {
+	return "x"
+}
+main : proc() = {
+	match v := make() {
+	case int32:
+		@print(v)
+	case utf8:
+		@print(v)
+	}
+}`)
+
+		mustContain(t, got, "switch _subject := make(); _subject.tag {")
+		mustContain(t, got, "v := _subject.v0")
+	})
+
+	t.Run("exported", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package p
+export Shape ~ int32 | utf8
+main : proc() = {
+	s : Shape = "x"
+	@print(s)
+}`)
+
+		mustContain(t, got, "type Shape struct {\n\tTag\tuint8\n\tV0\tint32\n\tV1\tstring\n}")
+		mustContain(t, got, `Shape{Tag: 1, V1: "x"}`)
+	})
+}
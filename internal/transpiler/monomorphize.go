@@ -0,0 +1,706 @@
+package transpiler
+
+import (
+	"fmt"
+	goast "go/ast"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/transpiler/component"
+	"github.com/samborkent/cog/internal/types"
+)
+
+// Instances are the copies emitted of the generic functions of a program.
+// A generic function that matches on its type parameters is emitted once for
+// each combination of type arguments its calls narrow, with only the matched
+// case of each match, and once as a Go generic fallback with only the default
+// case, for the other type arguments. Calls are rewritten to the copy for their
+// type arguments.
+//
+// Instances are collected for all packages of a program before any of them is
+// transpiled, as a package emits the copies used by the packages importing it.
+type Instances struct {
+	packages map[string]*monoPackage
+	generics map[*ast.Declaration]*generic
+	queue    []*instance
+}
+
+// monoPackage is a package of which the instances are collected.
+type monoPackage struct {
+	path     string
+	files    []*ast.File
+	imports  map[string]string // package paths by import path
+	generics map[string]*generic
+}
+
+// generic is the declaration of a global generic function.
+type generic struct {
+	pkg        *monoPackage
+	file       *ast.File
+	decl       *ast.Declaration
+	name       string // Go name
+	exported   bool
+	typeParams []*types.Alias
+	matches    map[string][]*ast.Match // matches on each type parameter, by name
+	instances  map[string]*instance    // by key of the type arguments
+	names      map[string]bool         // names of the instances
+}
+
+// instance is a copy of a generic function, specialized for the type arguments
+// of the type parameters a match narrows. The other type parameters remain
+// type parameters of the copy, of which the matches are lowered to their
+// default case, or to a type switch if the copy is open.
+type instance struct {
+	generic *generic
+	name    string
+	args    []types.Type          // type arguments by type parameter, nil if not specialized
+	env     map[string]types.Type // type arguments by type parameter name
+	open    bool                  // the type parameters may be instantiated with matched types
+	routed  [][]types.Type        // type arguments of the calls, by type parameter not specialized
+}
+
+func NewInstances() *Instances {
+	return &Instances{
+		packages: make(map[string]*monoPackage),
+		generics: make(map[*ast.Declaration]*generic),
+	}
+}
+
+// Add adds the files of the package with path pkgPath. Imports are the paths
+// of the packages it imports, by their import path.
+func (in *Instances) Add(pkgPath string, files []*ast.File, imports map[string]string) {
+	in.add(pkgPath, files, imports, true)
+}
+
+// AddScript adds the file of a script, as Add. The generic functions declared
+// by a script are local to its main function, and are not monomorphized.
+func (in *Instances) AddScript(pkgPath string, file *ast.File, imports map[string]string) {
+	in.add(pkgPath, []*ast.File{file}, imports, false)
+}
+
+func (in *Instances) add(pkgPath string, files []*ast.File, imports map[string]string, declares bool) {
+	pkg := &monoPackage{
+		path:     pkgPath,
+		files:    files,
+		imports:  imports,
+		generics: make(map[string]*generic),
+	}
+
+	for _, file := range files {
+		for _, stmt := range file.Statements {
+			decl, ok := stmt.(*ast.Declaration)
+			if !ok || !declares || !decl.Assignment.Identifier.Global {
+				continue
+			}
+
+			lit, ok := decl.Assignment.Expression.(*ast.ProcedureLiteral)
+			if !ok {
+				continue
+			}
+
+			procType, ok := lit.ProcedureType.(*types.Procedure)
+			if !ok || len(procType.TypeParams) == 0 {
+				continue
+			}
+
+			ident := decl.Assignment.Identifier
+
+			g := &generic{
+				pkg:        pkg,
+				file:       file,
+				decl:       decl,
+				name:       component.ConvertExport(ident.Name, ident.Exported, ident.Global),
+				exported:   ident.Exported,
+				typeParams: procType.TypeParams,
+				matches:    make(map[string][]*ast.Match),
+				instances:  make(map[string]*instance),
+				names:      make(map[string]bool),
+			}
+
+			ast.Inspect(lit.Body, func(node ast.Node) bool {
+				if m, ok := node.(*ast.Match); ok {
+					if param := matchParam(m); param != nil && slices.Contains(g.typeParams, param) {
+						g.matches[param.Name] = append(g.matches[param.Name], m)
+					}
+				}
+
+				return true
+			})
+
+			pkg.generics[ident.Name] = g
+			in.generics[decl] = g
+		}
+	}
+
+	in.packages[pkgPath] = pkg
+}
+
+// Collect collects the instances of the generic functions called by the
+// added packages, and by the instances themselves.
+func (in *Instances) Collect() {
+	for _, pkgPath := range slices.Sorted(maps.Keys(in.packages)) {
+		pkg := in.packages[pkgPath]
+
+		for _, file := range pkg.files {
+			for _, stmt := range file.Statements {
+				if decl, ok := stmt.(*ast.Declaration); ok && in.generics[decl] != nil {
+					continue
+				}
+
+				in.walk(pkg, file, stmt, nil)
+			}
+		}
+	}
+
+	for {
+		for len(in.queue) > 0 {
+			inst := in.queue[0]
+			in.queue = in.queue[1:]
+
+			g := inst.generic
+			in.walk(g.pkg, g.file, g.decl.Assignment.Expression, inst)
+		}
+
+		// Generic functions that are exported may be called from Go, and those
+		// that are not called at all are emitted as they are.
+		for _, g := range in.generics {
+			if g.exported || len(g.instances) == 0 {
+				in.instantiate(g, make([]types.Type, len(g.typeParams)), nil, true)
+			}
+		}
+
+		if len(in.queue) == 0 {
+			return
+		}
+	}
+}
+
+// walk collects the instances called by node, the body of inst, or a
+// declaration outside of generic functions if inst is nil.
+func (in *Instances) walk(pkg *monoPackage, file *ast.File, node ast.Node, inst *instance) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Match:
+			body, ok := inst.branch(n)
+			if !ok {
+				return true
+			}
+
+			for _, stmt := range body {
+				in.walk(pkg, file, stmt, inst)
+			}
+
+			return false
+		case *ast.Call:
+			g := in.callee(pkg, file, n)
+			if g == nil {
+				return true
+			}
+
+			typeArgs := inst.specialize(n.TypeArgs)
+			args, open := g.route(pkg, typeArgs)
+			in.instantiate(g, args, typeArgs, open)
+		}
+
+		return true
+	})
+}
+
+// instantiate adds the instance of g for the type arguments args, of which
+// those that are nil remain type parameters instantiated with typeArgs.
+// It is queued to be walked if it is new, or if it became open.
+func (in *Instances) instantiate(g *generic, args, typeArgs []types.Type, open bool) {
+	key := instanceKey(args)
+
+	inst, ok := g.instances[key]
+	if !ok {
+		inst = &instance{
+			generic: g,
+			name:    g.instanceName(args),
+			args:    args,
+			env:     make(map[string]types.Type),
+			routed:  make([][]types.Type, len(args)),
+		}
+
+		for i, arg := range args {
+			if arg != nil {
+				inst.env[g.typeParams[i].Name] = arg
+			}
+		}
+
+		g.instances[key] = inst
+		g.names[inst.name] = true
+	}
+
+	for i, arg := range typeArgs {
+		if args[i] == nil {
+			inst.routed[i] = append(inst.routed[i], arg)
+		}
+	}
+
+	if !ok || (open && !inst.open) {
+		inst.open = inst.open || open
+		in.queue = append(in.queue, inst)
+	}
+}
+
+// callee returns the generic function called by call in file of pkg, or nil
+// if it does not call a global generic function by name.
+func (in *Instances) callee(pkg *monoPackage, file *ast.File, call *ast.Call) *generic {
+	ident, ok := call.Expression.(*ast.Identifier)
+	if !ok {
+		return nil
+	}
+
+	if call.Package == "" {
+		if !ident.Global {
+			return nil
+		}
+
+		return pkg.generics[ident.Name]
+	}
+
+	// The package is referred to by the last element of its import path, of
+	// the imports of the file.
+	for _, stmt := range file.Statements {
+		imprt, ok := stmt.(*ast.Import)
+		if !ok {
+			continue
+		}
+
+		for _, i := range imprt.Imports {
+			if path.Base(i.Name) != call.Package {
+				continue
+			}
+
+			imported, ok := in.packages[pkg.imports[i.Name]]
+			if !ok {
+				return nil
+			}
+
+			return imported.generics[ident.Name]
+		}
+	}
+
+	return nil
+}
+
+// generic returns the generic function declared by decl, or nil.
+func (in *Instances) generic(decl *ast.Declaration) *generic {
+	if in == nil {
+		return nil
+	}
+
+	return in.generics[decl]
+}
+
+// route returns the type arguments of typeArgs, called from pkg, for which g is
+// specialized, and whether the instance must be open. A type argument is
+// specialized if it is concrete and a match has a case for it. Named types
+// are only specialized within their own package, as the package of g may not
+// be able to refer to them.
+func (g *generic) route(pkg *monoPackage, typeArgs []types.Type) ([]types.Type, bool) {
+	args := make([]types.Type, len(g.typeParams))
+	open := false
+
+	for i, tp := range g.typeParams {
+		if i >= len(typeArgs) {
+			open = true
+			continue
+		}
+
+		arg := typeArgs[i]
+		params, named := typeNames(arg)
+
+		switch {
+		case params:
+			open = true
+		case !g.narrows(tp.Name, arg):
+		case named && pkg != g.pkg:
+			open = true
+		default:
+			args[i] = arg
+		}
+	}
+
+	return args, open
+}
+
+// narrows reports whether a match on the type parameter name has a case for
+// typ.
+func (g *generic) narrows(name string, typ types.Type) bool {
+	for _, m := range g.matches[name] {
+		if m.Case(typ) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// instanceName returns the Go name of the instance of g for args: the name of
+// g suffixed by the type argument, or for those not specialized the type
+// parameter, of each type parameter. The generic fallback keeps the name of g.
+func (g *generic) instanceName(args []types.Type) string {
+	if !slices.ContainsFunc(args, func(arg types.Type) bool { return arg != nil }) {
+		return g.name
+	}
+
+	var name strings.Builder
+
+	_, _ = name.WriteString(g.name)
+
+	for i, arg := range args {
+		_ = name.WriteByte('_')
+
+		if arg == nil {
+			_, _ = name.WriteString(g.typeParams[i].Name)
+		} else {
+			_, _ = name.WriteString(mangle(arg.String()))
+		}
+	}
+
+	// Types of which the names mangle to the same name are numbered.
+	unique := name.String()
+
+	for n := 2; g.names[unique]; n++ {
+		unique = name.String() + "_" + strconv.Itoa(n)
+	}
+
+	return unique
+}
+
+// emitted returns the instances of g, sorted by name.
+func (g *generic) emitted() []*instance {
+	return slices.SortedFunc(maps.Values(g.instances), func(a, b *instance) int {
+		return strings.Compare(a.name, b.name)
+	})
+}
+
+// lookup returns the instance of g for args.
+func (g *generic) lookup(args []types.Type) (*instance, bool) {
+	inst, ok := g.instances[instanceKey(args)]
+	return inst, ok
+}
+
+// branch returns the statements of m that inst emits, if m is a match on a
+// type parameter that inst narrows: the case of a specialized type parameter,
+// or the default case of a type parameter that is not, unless inst is open.
+func (inst *instance) branch(m *ast.Match) ([]ast.Statement, bool) {
+	param := matchParam(m)
+	if inst == nil || param == nil {
+		return nil, false
+	}
+
+	if arg, ok := inst.env[param.Name]; ok {
+		if c := m.Case(arg); c != nil {
+			return c.Body, true
+		}
+	} else if inst.open {
+		return nil, false
+	}
+
+	if m.Default == nil {
+		return nil, true
+	}
+
+	return m.Default.Body, true
+}
+
+// specialize substitutes the specialized type parameters of inst in typeArgs.
+func (inst *instance) specialize(typeArgs []types.Type) []types.Type {
+	if inst == nil || len(inst.env) == 0 {
+		return typeArgs
+	}
+
+	out := make([]types.Type, len(typeArgs))
+
+	for i, arg := range typeArgs {
+		out[i] = types.Specialize(arg, inst.env)
+	}
+
+	return out
+}
+
+// constraint returns the constraint of the type parameter tp of inst. The
+// constraint of a closed instance excludes the types with a case of their own,
+// if no type argument of its calls has the same underlying type.
+func (inst *instance) constraint(tp *types.Alias) *types.Alias {
+	if inst.open || len(inst.generic.matches[tp.Name]) == 0 {
+		return tp
+	}
+
+	if _, ok := tp.ConstraintTypes(); !ok {
+		return tp
+	}
+
+	constraintTypes := goConstraintTypes(tp.Constraint, false)
+
+	i := slices.Index(inst.generic.typeParams, tp)
+	variants := make([]types.Type, 0, len(constraintTypes))
+
+	for _, typ := range constraintTypes {
+		routed := slices.ContainsFunc(inst.routed[i], func(arg types.Type) bool {
+			return types.Equal(arg.Underlying(), typ.Underlying())
+		})
+
+		if routed || !inst.generic.narrows(tp.Name, typ) {
+			variants = append(variants, typ)
+		}
+	}
+
+	if len(variants) == len(constraintTypes) || len(variants) == 0 {
+		return tp
+	}
+
+	return &types.Alias{
+		Name:       tp.Name,
+		Constraint: &types.Union{Variants: variants},
+	}
+}
+
+// goConstraintTypes returns the types of constraint. Like the Go constraint
+// of a named constraint, such as number, only the types of which the Go type
+// is predeclared are taken from it, so that their terms do not overlap with
+// the Cog runtime types, like float16 of which the Go type is a uint16.
+func goConstraintTypes(constraint types.Type, named bool) []types.Type {
+	union, ok := constraint.(*types.Union)
+	if !ok {
+		if named && !isGoBasic(constraint) {
+			return nil
+		}
+
+		return []types.Type{constraint}
+	}
+
+	var out []types.Type
+
+	for _, variant := range union.Variants {
+		out = append(out, goConstraintTypes(variant, named || union.Name != "")...)
+	}
+
+	return out
+}
+
+// isGoBasic reports whether typ is a basic type of which the Go type is
+// predeclared.
+func isGoBasic(typ types.Type) bool {
+	if _, ok := typ.(*types.Basic); !ok {
+		return false
+	}
+
+	switch typ.Kind() {
+	case types.Int8, types.Int16, types.Int32, types.Int64,
+		types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+		types.Float32, types.Float64, types.Complex64, types.Complex128,
+		types.Bool, types.UTF8:
+		return true
+	default:
+		return false
+	}
+}
+
+// matchParam returns the type parameter matched on by m, or nil if its
+// subject is not of a type parameter.
+func matchParam(m *ast.Match) *types.Alias {
+	alias, ok := m.Subject.Type().(*types.Alias)
+	if !ok || !alias.IsTypeParam() {
+		return nil
+	}
+
+	return alias
+}
+
+// instanceKey returns the key of the instance for args.
+func instanceKey(args []types.Type) string {
+	keys := make([]string, len(args))
+
+	for i, arg := range args {
+		if arg != nil {
+			keys[i] = arg.String()
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
+
+// mangle replaces each run of characters of a type name that cannot be used in
+// a Go identifier by an underscore.
+func mangle(name string) string {
+	var out strings.Builder
+
+	underscore := false
+
+	for _, r := range name {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			_, _ = out.WriteRune(r)
+			underscore = false
+
+			continue
+		}
+
+		if !underscore {
+			_ = out.WriteByte('_')
+			underscore = true
+		}
+	}
+
+	return out.String()
+}
+
+// typeNames reports whether typ refers to type parameters, and whether it
+// refers to named types.
+func typeNames(typ types.Type) (params, named bool) {
+	var visit func(typ types.Type)
+
+	visit = func(typ types.Type) {
+		switch v := typ.(type) {
+		case *types.Alias:
+			if v.IsTypeParam() {
+				params = true
+			} else {
+				named = true
+			}
+		case *types.Slice:
+			visit(v.Element)
+		case *types.Array:
+			visit(v.Element)
+		case *types.Map:
+			visit(v.Key)
+			visit(v.Value)
+		case *types.Set:
+			visit(v.Element)
+		case *types.Option:
+			visit(v.Value)
+		case *types.Reference:
+			visit(v.Value)
+		case *types.Signal:
+			if v.Value != nil {
+				visit(v.Value)
+			}
+		case *types.Tuple:
+			for _, elem := range v.Types {
+				visit(elem)
+			}
+		case *types.Either:
+			visit(v.Left)
+			visit(v.Right)
+		case *types.Union:
+			for _, variant := range v.Variants {
+				visit(variant)
+			}
+		case *types.Result:
+			visit(v.Value)
+			visit(v.Error)
+		case *types.Struct:
+			for _, f := range v.Fields {
+				visit(f.Type)
+			}
+		case *types.Procedure:
+			for _, p := range v.Parameters {
+				visit(p.Type)
+			}
+
+			if v.ReturnType != nil {
+				visit(v.ReturnType)
+			}
+		}
+	}
+
+	visit(typ)
+
+	return params, named
+}
+
+// convertGeneric converts the declaration of the generic function g to the
+// declarations of its instances.
+func (t *Transpiler) convertGeneric(n *ast.Declaration, g *generic) ([]goast.Decl, error) {
+	decls := make([]goast.Decl, 0, len(g.instances))
+
+	for i, inst := range g.emitted() {
+		t.instance = inst
+		gonodes, err := t.convertDecl(n)
+		t.instance = nil
+
+		if err != nil {
+			return nil, fmt.Errorf("converting instance %q: %w", inst.name, err)
+		}
+
+		funcDecl, ok := gonodes[0].(*goast.FuncDecl)
+		if !ok {
+			return nil, fmt.Errorf("unable to assert function declaration for %q", inst.name)
+		}
+
+		typeParams := make([]*types.Alias, 0, len(g.typeParams))
+
+		for j, tp := range g.typeParams {
+			if inst.args[j] == nil {
+				typeParams = append(typeParams, inst.constraint(tp))
+			}
+		}
+
+		// The function type may be cached, so it is copied.
+		funcType := *funcDecl.Type
+		funcType.TypeParams = nil
+
+		if len(typeParams) > 0 {
+			funcType.TypeParams, err = t.convertTypeParams(typeParams)
+			if err != nil {
+				return nil, fmt.Errorf("converting type parameters of instance %q: %w", inst.name, err)
+			}
+		}
+
+		funcDecl.Type = &funcType
+		funcDecl.Name = &goast.Ident{Name: inst.name}
+
+		// The first instance gets the line directive of the declaration.
+		if i > 0 {
+			t.attachLineDecl(gonodes, n)
+		}
+
+		decls = append(decls, gonodes...)
+	}
+
+	return decls, nil
+}
+
+// callInstance returns the Go name of the instance called by call, and the type
+// arguments of its type parameters, or false if call does not call a generic
+// function of which the instances were collected.
+func (t *Transpiler) callInstance(call *ast.Call) (string, []types.Type, bool) {
+	if t.instances == nil {
+		return "", nil, false
+	}
+
+	pkg, ok := t.instances.packages[t.pkgPath]
+	if !ok {
+		return "", nil, false
+	}
+
+	g := t.instances.callee(pkg, t.file, call)
+	if g == nil {
+		return "", nil, false
+	}
+
+	typeArgs := t.instance.specialize(call.TypeArgs)
+	args, _ := g.route(pkg, typeArgs)
+
+	inst, ok := g.lookup(args)
+	if !ok {
+		return "", nil, false
+	}
+
+	generic := make([]types.Type, 0, len(typeArgs))
+
+	for i, arg := range typeArgs {
+		if args[i] == nil {
+			generic = append(generic, arg)
+		}
+	}
+
+	return inst.name, generic, true
+}
@@ -0,0 +1,256 @@
+package transpiler_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samborkent/cog/internal/transpiler"
+)
+
+func TestMonomorphize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("specialized", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+process : func<T ~ int>(x : T) int64 = {
+	match x {
+	case int32:
+		return @cast<int64>(x)
+	case int64:
+		return x * 2
+	default:
+		return 0
+	}
+}
+main : proc() = {
+	b : int32 = 1
+	d : int16 = 2
+	@print(process(42) + process(b) + process(d))
+}`)
+
+		mustContain(t, got, "func process_int32(x int32) int64 {")
+		mustContain(t, got, "func process_int64(x int64) int64 {")
+		mustContain(t, got, "return x * 2")
+		mustContain(t, got, "process_int64(42) + process_int32(b) + process[int16](d)")
+		mustNotContain(t, got, "switch")
+
+		// The fallback excludes the types with a case of their own. Like the
+		// int constraint, it is made of the predeclared Go types only.
+		mustContain(t, got, "func process[T interface {\n\t~int8 | ~int16\n}](x T) int64 {")
+	})
+
+	t.Run("number_fallback", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+describe : func<T ~ number>(x : T) utf8 = {
+	match x {
+	case int64:
+		return "int64"
+	default:
+		return "number"
+	}
+}
+main : proc() = {
+	f : float32 = 1.5
+	@print(describe(1), describe(f))
+}`)
+
+		// The runtime types of float16 and the like would overlap with the
+		// terms of their underlying Go types.
+		mustContain(t, got, "func describe_int64(x int64) string {")
+		mustContain(t, got, "~int8 | ~int16 | ~int32 | ~float32 | ~float64 | ~complex64 | ~complex128 | ~uint8 | ~uint16 | ~uint32 | ~uint64\n}](x T) string {")
+		mustNotContain(t, got, "cog.Float16")
+	})
+
+	t.Run("exhaustive_without_fallback", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+show : proc<T ~ int32 | utf8>(x : T) = {
+	match x {
+	case int32:
+		@print(x + 1)
+	case utf8:
+		@print(x + "!")
+	}
+}
+main : proc() = {
+	show("hi")
+	n : int32 = 1
+	show(n)
+}`)
+
+		mustContain(t, got, "func show_int32(ctx go_context.Context, x int32) {")
+		mustContain(t, got, "func show_utf8(ctx go_context.Context, x string) {")
+		mustContain(t, got, `show_utf8(ctx, "hi")`)
+		mustContain(t, got, "show_int32(ctx, n)")
+		mustNotContain(t, got, "func show[")
+	})
+
+	t.Run("binding", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+show : proc<T ~ int32 | utf8>(x : T) = {
+	match v := x {
+	case int32:
+		@print(v)
+	case utf8:
+		@print("utf8")
+	}
+}
+main : proc() = {
+	show("hi")
+	n : int32 = 1
+	show(n)
+}`)
+
+		mustContain(t, got, "func show_int32(ctx go_context.Context, x int32) {")
+		mustContain(t, got, "func show_utf8(ctx go_context.Context, x string) {")
+
+		// The binding is only declared by the instance that uses it.
+		int32Body, utf8Body, _ := strings.Cut(got, "func show_utf8")
+		mustContain(t, int32Body, "v := x")
+		mustNotContain(t, utf8Body, "v := x")
+	})
+
+	t.Run("exported_fallback", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package p
+export Process : func<T ~ int>(x : T) int64 = {
+	match x {
+	case int64:
+		return x * 2
+	default:
+		return 0
+	}
+}
+main : proc() = {
+	@print(Process(1))
+}`)
+
+		// Go callers may call the fallback with any type of the constraint.
+		mustContain(t, got, "func Process[T ~int8 | ~int16 | ~int32 | ~int64](x T) int64 {")
+		mustContain(t, got, "case int64:\n\t\tx := any(x).(int64)")
+		mustContain(t, got, "func Process_int64(x int64) int64 {")
+		mustContain(t, got, "Process_int64(1)")
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+combine : proc<A ~ int, B ~ string>(a : A, b : B) = {
+	match a {
+	case int32:
+		@print("int32")
+	default:
+		@print("other")
+	}
+}
+main : proc() = {
+	n : int32 = 1
+	m : int16 = 2
+	combine(n, "a")
+	combine(m, "b")
+}`)
+
+		mustContain(t, got, "func combine_int32_B[B ~string](ctx go_context.Context, a int32, b B) {")
+		mustContain(t, got, "func combine[A interface {")
+		mustContain(t, got, `combine_int32_B[string](ctx, n, "a")`)
+		mustContain(t, got, `combine[int16, string](ctx, m, "b")`)
+	})
+
+	t.Run("transitive", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+inner : func<T ~ float>(x : T) float64 = {
+	match x {
+	case float32:
+		return 32.0
+	default:
+		return 64.0
+	}
+}
+outer : func<T ~ int>(x : T) float64 = {
+	match x {
+	case int64:
+		f : float32 = 1.0
+		return inner(f)
+	default:
+		return 0.0
+	}
+}
+main : proc() = {
+	@print(outer(1))
+}`)
+
+		mustContain(t, got, "func outer_int64(x int64) float64 {")
+		mustContain(t, got, "func inner_float32(x float32) float64 {")
+		mustContain(t, got, "return inner_float32(f)")
+		mustNotContain(t, got, "func inner[")
+		mustNotContain(t, got, "func outer[")
+	})
+
+	t.Run("without_match", func(t *testing.T) {
+		t.Parallel()
+
+		got := transpile(t, `package main
+identity : func<T ~ any>(x : T) T = {
+	return x
+}
+main : proc() = {
+	@print(identity(1))
+}`)
+
+		mustContain(t, got, "func identity[T any](x T) T {")
+		mustContain(t, got, "identity[int64](1)")
+	})
+}
+
+func TestMonomorphizeImport(t *testing.T) {
+	t.Parallel()
+
+	files := map[string]string{
+		"main.cog": `package main
+
+import (
+	"num"
+)
+
+main : proc() = {
+	f : float32 = 1.0
+	@print(num.Scale(1.5) + num.Scale(f))
+}`,
+		"num/num.cog": `package num
+
+export Scale : func<T ~ float>(x : T) float64 = {
+	match x {
+	case float64:
+		return x * 2.0
+	default:
+		return 0.0
+	}
+}`,
+	}
+
+	mainPkg := loadPackage(t, files)
+	numPkg := mainPkg.Imports["num"]
+
+	in := transpiler.NewInstances()
+	in.Add("testmod", packageFiles(mainPkg), map[string]string{"num": "testmod/num"})
+	in.Add("testmod/num", packageFiles(numPkg), nil)
+	in.Collect()
+
+	gotNum := transpileLoaded(t, numPkg, transpiler.WithInstances(in, "testmod/num"))
+	mustContain(t, gotNum, "func Scale_float64(x float64) float64 {")
+	mustContain(t, gotNum, "func Scale[T ~float32 | ~float64](x T) float64 {")
+
+	gotMain := transpileLoaded(t, mainPkg, transpiler.WithInstances(in, "testmod"))
+	mustContain(t, gotMain, "num.Scale_float64(1.5) + num.Scale[float32](f)")
+}
@@ -6,11 +6,13 @@ import (
 	goast "go/ast"
 	goprinter "go/printer"
 	"io"
+	"strconv"
 )
 
 // Print renders the Go AST file to w, with post-processing to:
 //   - strip placeholder declarations used to carry comments
 //   - reconstruct block comments from marker lines back to /* */ form
+//   - align the columns of //line directives with the indentation
 func (t *Transpiler) Print(w io.Writer, gofile *goast.File) error {
 	var buf bytes.Buffer
 
@@ -30,6 +32,7 @@ func (t *Transpiler) Print(w io.Writer, gofile *goast.File) error {
 var (
 	placeholderSentinel = []byte("__cogReplaceMe")
 	blockMarkerPrefix   = []byte("//" + blockCommentMarker)
+	lineDirectivePrefix = []byte("//line ")
 )
 
 // postProcess removes placeholder lines and reconstructs block comments.
@@ -98,5 +101,44 @@ func postProcess(data []byte) []byte {
 		i++
 	}
 
+	alignLineDirectives(result)
+
 	return bytes.Join(result, []byte("\n"))
 }
+
+// alignLineDirectives subtracts the indentation of the next line from the
+// column of each //line directive, so that Go reports the Cog column for the
+// first token on that line.
+func alignLineDirectives(lines [][]byte) {
+	for i := 0; i+1 < len(lines); i++ {
+		line := lines[i]
+		if !bytes.HasPrefix(line, lineDirectivePrefix) {
+			continue
+		}
+
+		colSep := bytes.LastIndexByte(line, ':')
+		if colSep < 0 {
+			continue
+		}
+
+		lnSep := bytes.LastIndexByte(line[:colSep], ':')
+		if lnSep < 0 {
+			continue
+		}
+
+		// Without a column, the last number is the line.
+		if _, err := strconv.Atoi(string(line[lnSep+1 : colSep])); err != nil {
+			continue
+		}
+
+		col, err := strconv.Atoi(string(line[colSep+1:]))
+		if err != nil {
+			continue
+		}
+
+		next := lines[i+1]
+		col = max(col-(len(next)-len(bytes.TrimLeft(next, " \t"))), 1)
+
+		lines[i] = strconv.AppendInt(bytes.Clone(line[:colSep+1]), int64(col), 10)
+	}
+}
@@ -69,6 +69,31 @@ func TestPostProcess(t *testing.T) {
 			wantContain: []string{"\t/* inside block  */"},
 			wantAbsent:  []string{"__cog_block__"},
 		},
+		{
+			name:        "line_directive_indented",
+			in:          "//line main.cog:4:3\n\t\tx := 1\n",
+			wantContain: []string{"//line main.cog:4:1\n"},
+		},
+		{
+			name:        "line_directive_column_kept",
+			in:          "//line main.cog:4:3\nfunc main() {\n",
+			wantContain: []string{"//line main.cog:4:3\n"},
+		},
+		{
+			name:        "line_directive_column_clamped",
+			in:          "//line main.cog:4:1\n\t\tx := 1\n",
+			wantContain: []string{"//line main.cog:4:1\n"},
+		},
+		{
+			name:        "line_directive_without_column",
+			in:          "//line main.cog:4\n\tx := 1\n",
+			wantContain: []string{"//line main.cog:4\n"},
+		},
+		{
+			name:        "line_directive_after_placeholder",
+			in:          "//line main.cog:7:5\n\tvar __cogReplaceMe int = 0\n\tx := 1\n",
+			wantContain: []string{"//line main.cog:7:4\n\tx := 1"},
+		},
 	}
 
 	for _, tt := range tests {
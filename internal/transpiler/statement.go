@@ -14,6 +14,12 @@ import (
 func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 	var returnStmts []goast.Stmt
 
+	// The expressions of node hoist the statements that run before it.
+	pre := t.pre
+	t.pre = nil
+
+	defer func() { t.pre = pre }()
+
 	switch n := node.(type) {
 	case *ast.Comment:
 		text := n.Text
@@ -25,9 +31,16 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 
 		return []goast.Stmt{&goast.DeclStmt{Decl: t.commentDecl(text)[0]}}, nil
 	case *ast.Assignment:
-		ident := &goast.Ident{Name: "_"}
+		var target goast.Expr = &goast.Ident{Name: "_"}
+
+		if n.Field != nil {
+			field, err := t.convertExpr(n.Field)
+			if err != nil {
+				return nil, err
+			}
 
-		if n.Identifier.Name != "_" {
+			target = field
+		} else if n.Identifier.Name != "_" {
 			name := component.ConvertExport(n.Identifier.Name, n.Identifier.Exported, n.Identifier.Global)
 
 			id, ok := t.symbols.Resolve(name)
@@ -49,12 +62,10 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 					return nil, err
 				}
 
-				return []goast.Stmt{
-					component.DynWrite(name, val),
-				}, nil
+				return append(t.pre, component.DynWrite(name, val)), nil
 			}
 
-			ident = id
+			target = id
 		}
 
 		expr, err := t.convertExpr(n.Expression)
@@ -62,7 +73,7 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 			return nil, err
 		}
 
-		if n.Identifier.ValueType.Kind() == types.OptionKind {
+		if n.Identifier.ValueType.Kind() == types.OptionKind && n.Expression.Type().Kind() != types.OptionKind {
 			optionType, err := t.convertType(n.Identifier.ValueType)
 			if err != nil {
 				return nil, fmt.Errorf("converting option value type: %w", err)
@@ -79,7 +90,7 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 		}
 
 		returnStmts = []goast.Stmt{&goast.AssignStmt{
-			Lhs: []goast.Expr{ident},
+			Lhs: []goast.Expr{target},
 			Tok: gotoken.ASSIGN,
 			Rhs: []goast.Expr{expr},
 		}}
@@ -104,8 +115,7 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 		ident := t.symbols.Define(n.Assignment.Identifier.Name)
 		typ := n.Assignment.Identifier.ValueType
 
-		ln, _ := node.Pos()
-		comment := &goast.Comment{Text: fmt.Sprintf("\n//line %s:%d", t.file.Name, ln)}
+		comment := &goast.Comment{Text: "\n" + t.lineDirective(node)}
 
 		if n.Assignment.Expression == nil {
 			declType, err := t.convertType(typ)
@@ -148,7 +158,7 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 			}
 		}
 
-		if typ.Kind() == types.OptionKind {
+		if typ.Kind() == types.OptionKind && n.Assignment.Expression.Type().Kind() != types.OptionKind {
 			// Wrap option type.
 			expr = &goast.CompositeLit{
 				Type: declType,
@@ -174,12 +184,18 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 			compositeLiteral.Type = &goast.Ident{Name: litName}
 		}
 
+		tok := gotoken.VAR
+
+		if n.Assignment.Identifier.Qualifier == ast.QualifierCompileTime && isConstant(expr) {
+			tok = gotoken.CONST
+		}
+
 		returnStmts = []goast.Stmt{&goast.DeclStmt{
 			Decl: &goast.GenDecl{
 				Doc: &goast.CommentGroup{
 					List: []*goast.Comment{comment},
 				},
-				Tok: gotoken.VAR,
+				Tok: tok,
 				Specs: []goast.Spec{
 					&goast.ValueSpec{
 						Names:  []*goast.Ident{ident},
@@ -199,11 +215,41 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 			X: expr,
 		}}
 	case *ast.ForStatement:
+		// The index of a Go range over an array, slice or string is an int,
+		// which is converted to the Cog index type at the start of the body.
+		var index *goast.Ident
+
+		if n.Index != nil && hasIntIndex(n.Range.Type()) {
+			t.symbols = NewEnclosedSymbolTable(t.symbols)
+			index = t.symbols.Define(n.Index.Name)
+		}
+
 		body, err := t.convertForBlock(n.Loop)
+
+		if index != nil {
+			t.symbols = t.symbols.Outer
+		}
+
 		if err != nil {
 			return nil, err
 		}
 
+		if index != nil && index.Name != "_" {
+			indexType, err := t.convertType(n.Index.ValueType)
+			if err != nil {
+				return nil, fmt.Errorf("converting loop index type: %w", err)
+			}
+
+			body.List = append([]goast.Stmt{&goast.AssignStmt{
+				Lhs: []goast.Expr{index},
+				Tok: gotoken.DEFINE,
+				Rhs: []goast.Expr{&goast.CallExpr{
+					Fun:  indexType,
+					Args: []goast.Expr{&goast.Ident{Name: n.Index.Name}},
+				}},
+			}}, body.List...)
+		}
+
 		var stmt goast.Stmt
 
 		if n.Range == nil {
@@ -229,16 +275,44 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 				tok = gotoken.DEFINE
 			}
 
-			if n.Index != nil && n.Value != nil {
+			isSet := n.Range.Type().Kind() == types.SetKind
+			_, isIterator := types.IteratorOf(n.Range.Type())
+
+			if isIterator {
+				// An iterator yields the value first, and then the index.
+				if n.Value != nil {
+					key = &goast.Ident{Name: n.Value.Name}
+				} else if n.Index != nil {
+					key = &goast.Ident{Name: "_"}
+				}
+
+				if n.Index != nil {
+					val = &goast.Ident{Name: n.Index.Name}
+				}
+
+				rangeExpr, err = t.convertIteratorRange(rangeExpr, n.Range.Type())
+				if err != nil {
+					return nil, err
+				}
+			} else if n.Index != nil && n.Value != nil {
 				key = &goast.Ident{Name: n.Index.Name}
 				val = &goast.Ident{Name: n.Value.Name}
 			} else if n.Index != nil && n.Value == nil {
 				key = &goast.Ident{Name: n.Index.Name}
+			} else if n.Index == nil && n.Value != nil && isSet {
+				// The values of a set are the keys of the Go map.
+				key = &goast.Ident{Name: n.Value.Name}
 			} else if n.Index == nil && n.Value != nil {
 				key = &goast.Ident{Name: "_"}
 				val = &goast.Ident{Name: n.Value.Name}
 			}
 
+			// The keys of ascii maps and sets are stored as strings, their
+			// iterator gives the ascii keys.
+			if isASCIIKeyed(n.Range.Type()) && (n.Index != nil || isSet && n.Value != nil) {
+				rangeExpr = component.All(rangeExpr)
+			}
+
 			stmt = &goast.RangeStmt{
 				Key:   key,
 				Value: val,
@@ -436,14 +510,14 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 		return nil, fmt.Errorf("unknown statement type '%T'", n)
 	}
 
+	returnStmts = append(t.pre, returnStmts...)
+
 	// Attach //line directives to statements that don't already carry one.
 	// Comments return early above; declarations embed their own //line inline.
 	switch node.(type) {
 	case *ast.Comment, *ast.Declaration:
 	default:
-		ln, _ := node.Pos()
-		lineComment := fmt.Sprintf("\n//line %s:%d", t.file.Name, ln)
-		lineDecl := &goast.DeclStmt{Decl: t.commentDecl(lineComment)[0]}
+		lineDecl := &goast.DeclStmt{Decl: t.commentDecl("\n" + t.lineDirective(node))[0]}
 		returnStmts = append([]goast.Stmt{lineDecl}, returnStmts...)
 	}
 
@@ -452,3 +526,21 @@ func (t *Transpiler) convertStmt(node ast.Statement) ([]goast.Stmt, error) {
 
 	return returnStmts, nil
 }
+
+// hasIntIndex reports whether Go ranges over a value of type typ with an int
+// index, which are arrays, slices and strings.
+func hasIntIndex(typ types.Type) bool {
+	if _, ok := types.IteratorOf(typ); ok {
+		return false
+	}
+
+	switch typ.Underlying().(type) {
+	case *types.Array, *types.Slice:
+		return true
+	case *types.Basic:
+		kind := typ.Underlying().Kind()
+		return kind == types.ASCII || kind == types.UTF8
+	default:
+		return false
+	}
+}
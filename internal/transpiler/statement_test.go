@@ -63,6 +63,47 @@ main : proc() = {
 		mustContain(t, got, "range")
 	})
 
+	t.Run("for_range", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	for i in 10..=0 by -2 {
+		@print(i)
+	}
+}`)
+		mustContain(t, got, "range builtin.Range[int64](10, 0, -2, true)")
+	})
+
+	t.Run("for_yield_procedure", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+count : proc(yield : func(v : int64) bool) = {
+	for i in 0..3 {
+		if !yield(i) {
+			return
+		}
+	}
+}
+main : proc() = {
+	for v in count {
+		@print(v)
+	}
+}`)
+		mustContain(t, got, "count(ctx, yield)")
+	})
+
+	t.Run("iterator_type", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	evens : iter<int64> = 0..10 by 2
+	for v in evens {
+		@print(v)
+	}
+}`)
+		mustContain(t, got, "var evens go_iter.Seq[int64] = builtin.Range[int64](0, 10, 2, false)")
+	})
+
 	t.Run("switch_bool", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, `package p
@@ -265,4 +306,53 @@ export greet : proc() = {
 main : proc() = {}`)
 		mustContain(t, got, "func Greet")
 	})
+
+	t.Run("field_assignment", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+Counter ~ struct {
+	count : int64
+}
+(var c : &Counter).Inc : proc() = {
+	c.count = c.count + 1
+}
+main : proc() = {}`)
+		mustContain(t, got, "c.count = c.count + 1")
+	})
+
+	t.Run("copy_immutable_to_var", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	xs : []int64 = {1, 2}
+	var ys := xs
+	@print(xs, ys)
+}`)
+		mustContain(t, got, "var ys []int64 = go_slices.Clone(xs)")
+		mustNotContain(t, got, "Clone(ys)")
+	})
+
+	t.Run("copy_field_assignment", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+Bag ~ struct {
+	items : []int64
+}
+(var b : &Bag).Set : proc(xs : []int64) = {
+	b.items = xs
+}
+main : proc() = {}`)
+		mustContain(t, got, "b.items = go_slices.Clone(xs)")
+	})
+
+	t.Run("no_copy_same_mutability", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+main : proc() = {
+	xs : []int64 = {1, 2}
+	ys := xs
+	@print(ys)
+}`)
+		mustNotContain(t, got, "Clone")
+	})
 }
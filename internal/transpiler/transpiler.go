@@ -11,6 +11,10 @@ import (
 	"strings"
 
 	"github.com/samborkent/cog/internal/ast"
+	"github.com/samborkent/cog/internal/comptime"
+	"github.com/samborkent/cog/internal/diag"
+	"github.com/samborkent/cog/internal/mutability"
+	"github.com/samborkent/cog/internal/purity"
 	"github.com/samborkent/cog/internal/transpiler/component"
 	"github.com/samborkent/cog/internal/types"
 	"golang.org/x/text/cases"
@@ -27,7 +31,14 @@ type Transpiler struct {
 	nodes        map[uint64]ast.Node
 	imports      map[string]*goast.ImportSpec // Key: import name
 	goModulePath string                       // Go module path for resolving cog import paths
-
+	importPaths  map[string]string            // Go import paths by cog import path, overriding goModulePath
+
+	folded         comptime.Folded      // literals of the expressions evaluated at compile time
+	copies         map[string]*copyFunc // copy functions generated for the package, by type
+	copyQueue      []*copyFunc          // copy functions of which the declaration is not emitted yet
+	instances      *Instances           // instances of the generic functions of the program
+	pkgPath        string               // path of the package in instances
+	instance       *instance            // instance of the generic function being transpiled
 	symbols        *SymbolTable
 	dynDefaults    map[string]ast.Expression // Default expressions for dynamic variables
 	inFunc         bool
@@ -35,8 +46,11 @@ type Transpiler struct {
 	usesDyn        bool            // set during body conversion when a dyn var is read or written
 	needsContext   map[uint16]bool // per-file tracking of context requirement by file ID
 	ifLabelCounter uint32
+	resultCounter  uint32       // number of results propagated with !?
+	pre            []goast.Stmt // statements hoisted out of the expressions of a statement
 
 	typeCache      map[types.Type]goast.Expr
+	typePackage    string              // package of the type being converted, if it is of another package
 	dynComments    map[string]string   // dyn field name → trailing comment text
 	skipComments   map[uint64]struct{} // hashes of comments consumed by dyn fields
 	lastSourceLine uint32              // tracks the source line of the previous statement
@@ -44,6 +58,24 @@ type Transpiler struct {
 
 type TranspilerOption func(*Transpiler)
 
+// WithImportPaths sets the Go import paths of cog imports, by cog import path.
+// Imports that are not in paths are resolved relative to the Go module path.
+func WithImportPaths(paths map[string]string) TranspilerOption {
+	return func(t *Transpiler) {
+		t.importPaths = paths
+	}
+}
+
+// WithInstances sets the instances of the generic functions of the program,
+// collected for all its packages, of which this is the package with path
+// pkgPath. By default the instances are collected for the transpiled files.
+func WithInstances(in *Instances, pkgPath string) TranspilerOption {
+	return func(t *Transpiler) {
+		t.instances = in
+		t.pkgPath = pkgPath
+	}
+}
+
 func NewTranspiler(files []*ast.File, opts ...TranspilerOption) *Transpiler {
 	return newTranspilerWithOptions("", files, opts...)
 }
@@ -75,6 +107,7 @@ func newTranspilerWithOptions(goModulePath string, files []*ast.File, opts ...Tr
 		dynDefaults:  make(map[string]ast.Expression),
 		needsContext: make(map[uint16]bool),
 		typeCache:    make(map[types.Type]goast.Expr),
+		copies:       make(map[string]*copyFunc),
 		dynComments:  make(map[string]string),
 		skipComments: make(map[uint64]struct{}),
 	}
@@ -87,6 +120,10 @@ func newTranspilerWithOptions(goModulePath string, files []*ast.File, opts ...Tr
 }
 
 func (t *Transpiler) Transpile() (*goast.File, error) {
+	if err := t.fold(); err != nil {
+		return nil, err
+	}
+
 	if err := t.predeclareGlobals(); err != nil {
 		return nil, err
 	}
@@ -121,7 +158,7 @@ func (t *Transpiler) Transpile() (*goast.File, error) {
 		f := t.files[id]
 		t.file = f // set current file for line directives
 
-		for _, stmt := range f.Statements {
+		for i, stmt := range f.Statements {
 			// Skip comments already consumed by dyn field annotations.
 			if comment, ok := stmt.(*ast.Comment); ok {
 				if _, skip := t.skipComments[comment.Hash()]; skip {
@@ -139,12 +176,17 @@ func (t *Transpiler) Transpile() (*goast.File, error) {
 			default:
 				gonodes, err := t.convertDecl(s)
 				if err != nil {
-					errs = append(errs, fmt.Errorf("\t%s: %w", s.String(), err))
+					errs = append(errs, t.error(s, err))
 					continue
 				}
 
 				if _, isComment := s.(*ast.Comment); !isComment {
-					t.attachLineDecl(gonodes, s)
+					var documented bool
+
+					gofile.Decls, documented = t.attachDoc(gofile.Decls, gonodes, f.Statements[:i], s)
+					if !documented {
+						t.attachLineDecl(gonodes, s)
+					}
 				}
 
 				ln, _ := s.Pos()
@@ -155,6 +197,13 @@ func (t *Transpiler) Transpile() (*goast.File, error) {
 		}
 	}
 
+	copyDecls, err := t.copyDecls()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	gofile.Decls = append(gofile.Decls, copyDecls...)
+
 	t.finalizeImports(gofile)
 
 	if err := errors.Join(errs...); err != nil {
@@ -187,6 +236,10 @@ func (t *Transpiler) currentFileNeedsContext() bool {
 }
 
 func (t *Transpiler) TranspileFiles() ([]*goast.File, error) {
+	if err := t.fold(); err != nil {
+		return nil, err
+	}
+
 	if err := t.predeclareGlobals(); err != nil {
 		return nil, err
 	}
@@ -224,7 +277,7 @@ func (t *Transpiler) TranspileFiles() ([]*goast.File, error) {
 			gofile.Decls = append(gofile.Decls, t.buildDynDecls()...)
 		}
 
-		for _, stmt := range f.Statements {
+		for i, stmt := range f.Statements {
 			if comment, ok := stmt.(*ast.Comment); ok {
 				if _, skip := t.skipComments[comment.Hash()]; skip {
 					continue
@@ -241,12 +294,17 @@ func (t *Transpiler) TranspileFiles() ([]*goast.File, error) {
 			default:
 				gonodes, err := t.convertDecl(s)
 				if err != nil {
-					errs = append(errs, fmt.Errorf("\t%s: %w", s.String(), err))
+					errs = append(errs, t.error(s, err))
 					continue
 				}
 
 				if _, isComment := s.(*ast.Comment); !isComment {
-					t.attachLineDecl(gonodes, s)
+					var documented bool
+
+					gofile.Decls, documented = t.attachDoc(gofile.Decls, gonodes, f.Statements[:i], s)
+					if !documented {
+						t.attachLineDecl(gonodes, s)
+					}
 				}
 
 				ln, _ := s.Pos()
@@ -256,6 +314,15 @@ func (t *Transpiler) TranspileFiles() ([]*goast.File, error) {
 			}
 		}
 
+		// The copy functions used by the file are declared in it, unless
+		// they were used by a file before it.
+		copyDecls, err := t.copyDecls()
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		gofile.Decls = append(gofile.Decls, copyDecls...)
+
 		t.finalizeImports(gofile)
 		gofiles[i] = gofile
 	}
@@ -271,6 +338,16 @@ func (t *Transpiler) TranspileFiles() ([]*goast.File, error) {
 // All statements are placed inside a func main() body. Type aliases and
 // enum declarations are emitted as top-level declarations.
 func (t *Transpiler) TranspileScript() (*goast.File, error) {
+	if t.instances == nil {
+		t.instances = NewInstances()
+		t.instances.AddScript(t.pkgPath, t.files[0], t.importPaths)
+		t.instances.Collect()
+	}
+
+	if err := t.fold(); err != nil {
+		return nil, err
+	}
+
 	t.imports = make(map[string]*goast.ImportSpec)
 	t.lastSourceLine = 0
 
@@ -307,7 +384,7 @@ func (t *Transpiler) TranspileScript() (*goast.File, error) {
 			case *ast.Method, *ast.Type:
 				gonodes, err := t.convertDecl(s)
 				if err != nil {
-					errs = append(errs, fmt.Errorf("\t%s: %w", s.String(), err))
+					errs = append(errs, t.error(s, err))
 					continue
 				}
 
@@ -315,7 +392,7 @@ func (t *Transpiler) TranspileScript() (*goast.File, error) {
 			default:
 				goStmts, err := t.convertStmt(stmt)
 				if err != nil {
-					errs = append(errs, fmt.Errorf("\t%s: %w", stmt.String(), err))
+					errs = append(errs, t.error(stmt, err))
 					continue
 				}
 
@@ -352,6 +429,13 @@ func (t *Transpiler) TranspileScript() (*goast.File, error) {
 
 	gofile.Decls = append(gofile.Decls, t.setMemoryLimit(), mainFunc)
 
+	copyDecls, err := t.copyDecls()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	gofile.Decls = append(gofile.Decls, copyDecls...)
+
 	t.finalizeImports(gofile)
 
 	if err := errors.Join(errs...); err != nil {
@@ -361,6 +445,41 @@ func (t *Transpiler) TranspileScript() (*goast.File, error) {
 	return gofile, nil
 }
 
+// fold checks that the funcs of all files have no side effects and that their
+// immutable variables are not changed, evaluates their comp values and calls
+// to comp funcs, and collects the instances of their generic functions if
+// they were not given.
+func (t *Transpiler) fold() error {
+	files := make([]*ast.File, 0, len(t.files))
+
+	for _, id := range slices.Sorted(maps.Keys(t.files)) {
+		files = append(files, t.files[id])
+	}
+
+	if err := purity.Check(files); err != nil {
+		return fmt.Errorf("purity errors:\n%w", err)
+	}
+
+	if err := mutability.Check(files); err != nil {
+		return fmt.Errorf("mutability errors:\n%w", err)
+	}
+
+	folded, err := comptime.Fold(files)
+	if err != nil {
+		return fmt.Errorf("compile-time evaluation errors:\n%w", err)
+	}
+
+	t.folded = folded
+
+	if t.instances == nil {
+		t.instances = NewInstances()
+		t.instances.Add(t.pkgPath, files, t.importPaths)
+		t.instances.Collect()
+	}
+
+	return nil
+}
+
 // predeclareGlobals scans all files to populate symbols, dynDefaults, and needsContext.
 func (t *Transpiler) predeclareGlobals() error {
 	errs := make([]error, 0)
@@ -454,7 +573,9 @@ func (t *Transpiler) addCogImports(node *ast.Import) {
 		importPath := imprt.Name
 
 		goPath := importPath
-		if t.goModulePath != "" {
+		if p, ok := t.importPaths[importPath]; ok {
+			goPath = p
+		} else if t.goModulePath != "" {
 			goPath = t.goModulePath + "/" + importPath
 		}
 
@@ -608,8 +729,7 @@ func (t *Transpiler) attachLineDecl(decls []goast.Decl, node ast.Node) {
 		return
 	}
 
-	ln, _ := node.Pos()
-	comment := &goast.Comment{Text: fmt.Sprintf("//line %s:%d", t.file.Name, ln)}
+	comment := &goast.Comment{Text: t.lineDirective(node)}
 
 	// Attach to the first declaration where a Doc comment is applicable.
 	for i := range decls {
@@ -641,6 +761,94 @@ func (t *Transpiler) attachLineDecl(decls []goast.Decl, node ast.Node) {
 	}
 }
 
+// attachDoc moves the doc comment of node from its placeholder declarations
+// at the end of decls to the first declaration in gonodes, after a //line
+// directive for the start of the doc comment, so the doc comment stays
+// attached to the declaration. It returns decls without the placeholders, and
+// false if node has no doc comment.
+func (t *Transpiler) attachDoc(decls, gonodes []goast.Decl, preceding []ast.Statement, node ast.Node) ([]goast.Decl, bool) {
+	doc := docComments(preceding, node)
+	if t.file.Name == "" || len(doc) == 0 || len(doc) > len(decls) {
+		return decls, false
+	}
+
+	for _, comment := range doc {
+		if _, skip := t.skipComments[comment.Hash()]; skip {
+			return decls, false
+		}
+	}
+
+	var target **goast.CommentGroup
+
+	for _, decl := range gonodes {
+		if d, ok := decl.(*goast.GenDecl); ok {
+			target = &d.Doc
+			break
+		}
+
+		if d, ok := decl.(*goast.FuncDecl); ok {
+			target = &d.Doc
+			break
+		}
+	}
+
+	if target == nil {
+		return decls, false
+	}
+
+	placeholders := decls[len(decls)-len(doc):]
+
+	ln, _ := doc[0].Pos()
+	directive := fmt.Sprintf("//line %s:%d", t.file.Name, ln)
+
+	var comments []*goast.Comment
+
+	for i, placeholder := range placeholders {
+		list := placeholder.(*goast.GenDecl).Doc.List
+
+		// The lines of the doc comment are not separated by blank lines, and
+		// a blank line before it goes before the directive.
+		if text, ok := strings.CutPrefix(list[0].Text, "\n"); ok {
+			list = append([]*goast.Comment{{Text: text}}, list[1:]...)
+
+			if i == 0 {
+				directive = "\n" + directive
+			}
+		}
+
+		comments = append(comments, list...)
+	}
+
+	comments = append([]*goast.Comment{{Text: directive}}, comments...)
+
+	if *target != nil {
+		comments = append(comments, (*target).List...)
+	}
+
+	*target = &goast.CommentGroup{List: comments}
+
+	return decls[:len(decls)-len(doc)], true
+}
+
+// lineDirective returns a //line directive mapping the Go code that follows it
+// to the position of node in the Cog source. The column is relative to the
+// start of the next line, and corrected for its indentation when printing.
+func (t *Transpiler) lineDirective(node ast.Node) string {
+	start := diag.Start(node)
+
+	if start.Col == 0 {
+		return fmt.Sprintf("//line %s:%d", t.file.Name, start.Ln)
+	}
+
+	return fmt.Sprintf("//line %s:%d:%d", t.file.Name, start.Ln, start.Col)
+}
+
+// error returns the diagnostic for a node of the current file that could not
+// be transpiled.
+func (t *Transpiler) error(node ast.Node, err error) *diag.Diagnostic {
+	return diag.At(t.file, node, diag.CodeTranspile, err.Error())
+}
+
 func (t *Transpiler) setMemoryLimit() *goast.FuncDecl {
 	t.addStdLibImport("runtime/debug")
 	t.addGoImport("github.com/pbnjay/memory")
@@ -23,6 +23,14 @@ func TestTranspile(t *testing.T) {
 		mustContain(t, got, "//line test.cog:")
 	})
 
+	t.Run("line_directive_column", func(t *testing.T) {
+		t.Parallel()
+		got := transpileWithPrint(t, "package p\nmain : proc() = {\n\tif true {\n\t\t@print(\"x\")\n\t}\n}")
+		mustContain(t, got, "//line test.cog:2:1\n")
+		mustContain(t, got, "//line test.cog:3:1\n\tif true {")
+		mustContain(t, got, "//line test.cog:4:1\n\t\tbuiltin.Print(")
+	})
+
 	t.Run("builtin_import_added", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, "package p\nmain : proc() = {\n\t@print(\"hello\")\n}")
@@ -14,9 +14,16 @@ import (
 )
 
 func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
-	// Try to retrieve type expression from cache.
+	// Within an instance of a generic function, its specialized type
+	// parameters are replaced by their type arguments.
+	if t.instance != nil && len(t.instance.env) > 0 {
+		typ = types.Specialize(typ, t.instance.env)
+	}
+
+	// Try to retrieve type expression from cache. Types of another package
+	// are not cached, as their names are qualified.
 	expr, ok := t.typeCache[typ]
-	if ok {
+	if ok && t.typePackage == "" {
 		return expr, nil
 	}
 
@@ -31,7 +38,13 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 		}
 
 		expr = &goast.Ident{Name: name}
-		t.typeCache[typ] = expr
+		if pkg := aliasPackage(alias, t.typePackage); pkg != "" {
+			expr = component.Selector(component.IdentName(pkg), name)
+		}
+
+		if t.typePackage == "" {
+			t.typeCache[typ] = expr
+		}
 
 		return expr, nil
 	}
@@ -43,9 +56,18 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 			return nil, errors.New("unable to assert array type")
 		}
 
-		lenExpr, err := t.convertExpr(sliceType.Length.(ast.Expression))
-		if err != nil {
-			return nil, fmt.Errorf("converting array length expression: %w", err)
+		var lenExpr goast.Expr
+
+		if length, ok := sliceType.Length.(ast.Expression); ok {
+			converted, err := t.convertExpr(length)
+			if err != nil {
+				return nil, fmt.Errorf("converting array length expression: %w", err)
+			}
+
+			lenExpr = converted
+		} else {
+			// Constant length of an array type imported from Go.
+			lenExpr = &goast.BasicLit{Kind: gotoken.INT, Value: sliceType.Length.String()}
 		}
 
 		elemType, err := t.convertType(sliceType.Element)
@@ -131,26 +153,9 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 			return nil, errors.New("unable to assert map type")
 		}
 
-		var keyExpr goast.Expr
-
-		if mapType.Key.Kind() == types.ASCII {
-			// ASCII is an alias of []byte, which is not a valid map key type in Go. Use string instead.
-			aliasType, ok := mapType.Key.(*types.Alias)
-			if ok {
-				keyExpr = &goast.Ident{Name: component.ConvertExport(aliasType.Name, aliasType.Exported, aliasType.Global) + "Hash"}
-			} else {
-				keyExpr = &goast.SelectorExpr{
-					X:   &goast.Ident{Name: "cog"},
-					Sel: &goast.Ident{Name: "ASCIIHash"},
-				}
-			}
-		} else {
-			keyType, err := t.convertType(mapType.Key)
-			if err != nil {
-				return nil, fmt.Errorf("converting map key type: %w", err)
-			}
-
-			keyExpr = keyType
+		keyType, err := t.convertType(mapType.Key)
+		if err != nil {
+			return nil, fmt.Errorf("converting map key type: %w", err)
 		}
 
 		valType, err := t.convertType(mapType.Value)
@@ -158,9 +163,16 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 			return nil, fmt.Errorf("converting map value type: %w", err)
 		}
 
-		expr = &goast.MapType{
-			Key:   keyExpr,
-			Value: valType,
+		if mapType.Key.Kind() == types.ASCII {
+			// ASCII is a byte slice, which is not a valid map key type in Go.
+			t.addCogImport()
+
+			expr = component.ASCIIMapType(keyType, valType)
+		} else {
+			expr = &goast.MapType{
+				Key:   keyType,
+				Value: valType,
+			}
 		}
 	case types.OptionKind:
 		optionType, ok := typ.(*types.Option)
@@ -232,6 +244,39 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 		}
 
 		expr = funcType
+	case types.IteratorKind:
+		iterType, ok := typ.(*types.Iterator)
+		if !ok {
+			return nil, errors.New("unable to assert iterator type")
+		}
+
+		valueType, err := t.convertType(iterType.Value)
+		if err != nil {
+			return nil, fmt.Errorf("converting iterator value type: %w", err)
+		}
+
+		t.addStdLibImport("iter")
+
+		if iterType.Index == nil {
+			expr = &goast.IndexExpr{
+				X:     component.Selector(component.IdentName(goStdLibAlias("iter")), "Seq"),
+				Index: valueType,
+			}
+
+			break
+		}
+
+		indexType, err := t.convertType(iterType.Index)
+		if err != nil {
+			return nil, fmt.Errorf("converting iterator index type: %w", err)
+		}
+
+		// The loop variables follow the order of the yielded values, so the
+		// value comes first.
+		expr = &goast.IndexListExpr{
+			X:       component.Selector(component.IdentName(goStdLibAlias("iter")), "Seq2"),
+			Indices: []goast.Expr{valueType, indexType},
+		}
 	case types.ReferenceKind:
 		refType, ok := typ.(*types.Reference)
 		if !ok {
@@ -259,42 +304,58 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 			X:   &goast.Ident{Name: "cog"},
 			Sel: &goast.Ident{Name: "Uint128"},
 		}
-	case types.SetKind:
-		setType, ok := typ.(*types.Set)
+	case types.SignalKind:
+		signalType, ok := typ.(*types.Signal)
 		if !ok {
-			return nil, errors.New("unable to assert set type")
+			return nil, errors.New("unable to assert signal type")
 		}
 
-		var indexExpr goast.Expr
+		var valueType goast.Expr = component.CogVoid
 
-		if setType.Element.Kind() == types.ASCII {
-			// ASCII is an alias of []byte, which is not a valid map key type in Go. Use hash of ASCII instead.
-			aliasType, ok := setType.Element.(*types.Alias)
-			if ok {
-				indexExpr = &goast.Ident{Name: component.ConvertExport(aliasType.Name, aliasType.Exported, aliasType.Global) + "Hash"}
-			} else {
-				indexExpr = &goast.SelectorExpr{
-					X:   &goast.Ident{Name: "cog"},
-					Sel: &goast.Ident{Name: "ASCIIHash"},
-				}
-			}
-		} else {
-			elemType, err := t.convertType(setType.Element)
+		if signalType.Value != nil {
+			converted, err := t.convertType(signalType.Value)
 			if err != nil {
-				return nil, fmt.Errorf("converting set element type: %w", err)
+				return nil, fmt.Errorf("converting signal value type: %w", err)
 			}
 
-			indexExpr = elemType
+			valueType = converted
 		}
 
 		t.addCogImport()
 
-		expr = &goast.IndexExpr{
-			X: &goast.SelectorExpr{
-				X:   &goast.Ident{Name: "cog"},
-				Sel: &goast.Ident{Name: "Set"},
+		expr = &goast.StarExpr{
+			X: &goast.IndexExpr{
+				X: &goast.SelectorExpr{
+					X:   &goast.Ident{Name: "cog"},
+					Sel: &goast.Ident{Name: "Future"},
+				},
+				Index: valueType,
 			},
-			Index: indexExpr,
+		}
+	case types.SetKind:
+		setType, ok := typ.(*types.Set)
+		if !ok {
+			return nil, errors.New("unable to assert set type")
+		}
+
+		elemType, err := t.convertType(setType.Element)
+		if err != nil {
+			return nil, fmt.Errorf("converting set element type: %w", err)
+		}
+
+		t.addCogImport()
+
+		if setType.Element.Kind() == types.ASCII {
+			// ASCII is a byte slice, which is not a valid map key type in Go.
+			expr = component.ASCIISetType(elemType)
+		} else {
+			expr = &goast.IndexExpr{
+				X: &goast.SelectorExpr{
+					X:   &goast.Ident{Name: "cog"},
+					Sel: &goast.Ident{Name: "Set"},
+				},
+				Index: elemType,
+			}
 		}
 	case types.SliceKind:
 		sliceType, ok := typ.(*types.Slice)
@@ -379,6 +440,42 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 			X:       component.Selector(component.IdentName("cog"), "Either"),
 			Indices: []goast.Expr{leftType, rightType},
 		}
+	case types.UnionKind:
+		unionType, ok := typ.(*types.Union)
+		if !ok {
+			return nil, errors.New("unable to assert union type")
+		}
+
+		if unionType.Name != "" {
+			return nil, fmt.Errorf("constraint %q used as a type", unionType)
+		}
+
+		// A sum type is a struct of the index of its variant, and a field per
+		// variant of which only the one at the index is set.
+		fields := make([]*goast.Field, 0, len(unionType.Variants)+1)
+
+		fields = append(fields, &goast.Field{
+			Names: []*goast.Ident{{Name: unionTag(unionType)}},
+			Type:  component.IdentName("uint8"),
+		})
+
+		for i, variant := range unionType.Variants {
+			variantType, err := t.convertType(variant)
+			if err != nil {
+				return nil, fmt.Errorf("converting sum type variant %d type: %w", i, err)
+			}
+
+			fields = append(fields, &goast.Field{
+				Names: []*goast.Ident{{Name: unionVariant(unionType, i)}},
+				Type:  variantType,
+			})
+		}
+
+		expr = &goast.StructType{
+			Fields: &goast.FieldList{
+				List: fields,
+			},
+		}
 	case types.ResultKind:
 		resultType, ok := typ.(*types.Result)
 		if !ok {
@@ -408,6 +505,21 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 		expr = &goast.Ident{Name: gotypes.TypeString(gotypes.Typ[gotypes.String], nil)}
 	case types.AnyKind:
 		expr = &goast.Ident{Name: "any"}
+	case types.GoKind:
+		goType, ok := typ.(*types.GoType)
+		if !ok {
+			return nil, errors.New("unable to assert Go type")
+		}
+
+		if goType.Import == "" {
+			// Predeclared Go type, e.g. error.
+			expr = component.IdentName(goType.Name)
+			break
+		}
+
+		t.addStdLibImport(goType.Import)
+
+		expr = component.Selector(component.IdentName(goStdLibAlias(goType.Import)), goType.Name)
 	case types.GenericKind:
 		tp, ok := typ.(*types.Alias)
 		if !ok || !tp.IsTypeParam() {
@@ -419,11 +531,36 @@ func (t *Transpiler) convertType(typ types.Type) (goast.Expr, error) {
 		return nil, fmt.Errorf("unknown type %q", typ)
 	}
 
-	t.typeCache[typ] = expr
+	if t.typePackage == "" {
+		t.typeCache[typ] = expr
+	}
 
 	return expr, nil
 }
 
+// aliasPackage returns the name of the package that qualifies the named type
+// alias, which is empty for the types of the package itself. The unqualified
+// names in a type of another package, pkg, are of that package.
+func aliasPackage(alias *types.Alias, pkg string) string {
+	if alias.Package != "" || !alias.Global || alias.IsTypeParam() {
+		return alias.Package
+	}
+
+	return pkg
+}
+
+// unionTag returns the name of the field of the Go struct of sum type u that
+// holds the index of the variant that is set.
+func unionTag(u *types.Union) string {
+	return component.ConvertExport("tag", u.Exported, u.Global)
+}
+
+// unionVariant returns the name of the field of the Go struct of sum type u
+// that holds variant i.
+func unionVariant(u *types.Union, i int) string {
+	return component.ConvertExport("v"+strconv.Itoa(i), u.Exported, u.Global)
+}
+
 // convertConstraint maps a cog constraint type to its Go equivalent.
 // For compound constraints (int, uint, etc.) it emits an inline tilde-union
 // with the Go-native subset of the constraint's types.
@@ -447,6 +584,14 @@ func (t *Transpiler) convertConstraint(typ types.Type) (goast.Expr, error) {
 			return nil, fmt.Errorf("converting concrete constraint type: %w", err)
 		}
 
+		// Only the underlying types of Go can be approximated, which excludes
+		// named types and the cog types of which the Go type is a struct, like
+		// int128.
+		_, isIdent := expr.(*goast.Ident)
+		if _, isBasic := typ.(*types.Basic); !isIdent || !isBasic {
+			return expr, nil
+		}
+
 		return &goast.UnaryExpr{Op: gotoken.TILDE, X: expr}, nil
 	}
 
@@ -169,6 +169,20 @@ outer : func(a : int64) int64 ! MyError = {
 main : proc() = {}`)
 		mustContain(t, got, "return inner(a)")
 	})
+
+	t.Run("signal_type", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+work : proc() = {}
+wait : proc(a : signal<int64>, b : signal) int64 = {
+	@await(b)
+	return @await(a)
+}
+main : proc() = {}`)
+		mustContain(t, got, "a *cog.Future[int64], b *cog.Future[cog.Void]")
+		mustContain(t, got, "b.Await()")
+		mustContain(t, got, "return a.Await()")
+	})
 }
 
 func TestConvertEnumDecl(t *testing.T) {
@@ -187,6 +201,17 @@ main : proc() = {}`)
 		mustContain(t, got, "_StatusClosed")
 		mustContain(t, got, "type _StatusType string")
 	})
+
+	t.Run("error_implements_error", func(t *testing.T) {
+		t.Parallel()
+		got := transpile(t, `package p
+MyError ~ error<utf8> {
+	NotFound := "not found",
+}
+main : proc() = {}`)
+		mustContain(t, got, "func (e _MyErrorError) Error() string")
+		mustContain(t, got, "return string(_MyError[e])")
+	})
 }
 
 func TestGenericConstraintTranspilation(t *testing.T) {
@@ -300,7 +325,7 @@ main : proc() = {}`)
 	t.Run("generic_func_constraint", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, `package p
-showNum : func<T ~ number>(x : T) = {
+showNum : proc<T ~ number>(x : T) = {
 	@print(x)
 }
 main : proc() = {
@@ -372,7 +397,7 @@ main : proc() = {}`)
 	t.Run("generic_func_constrained_call", func(t *testing.T) {
 		t.Parallel()
 		got := transpile(t, `package p
-show : func<T ~ int>(x : T) = {
+show : proc<T ~ int>(x : T) = {
 	@print(x)
 }
 main : proc() = {
@@ -3,7 +3,8 @@ package types
 type Alias struct {
 	Name       string
 	Derived    Type
-	Constraint Type // non-nil when this alias acts as a type parameter
+	Constraint Type   // non-nil when this alias acts as a type parameter
+	Package    string // name of the package that declares the type, empty for the package itself
 	Exported   bool
 	Global     bool
 	TypeParams []*Alias
@@ -99,6 +100,45 @@ func (a *Alias) SatisfiedBy(concrete Type) bool {
 	return Satisfies(concrete, a.Constraint)
 }
 
+// ConstraintTypes returns the concrete types that satisfy this type
+// parameter's constraint, or false if they cannot be listed, as for any or
+// interface constraints.
+func (a *Alias) ConstraintTypes() ([]Type, bool) {
+	if a.Constraint == nil {
+		return nil, false
+	}
+
+	return constraintTypes(a.Constraint)
+}
+
+func constraintTypes(constraint Type) ([]Type, bool) {
+	union, ok := constraint.(*Union)
+	if !ok {
+		if constraint.Kind() == AnyKind || isStructuralSentinel(constraint) {
+			return nil, false
+		}
+
+		if _, ok := constraint.Underlying().(*Interface); ok {
+			return nil, false
+		}
+
+		return []Type{constraint}, true
+	}
+
+	var out []Type
+
+	for _, variant := range union.Variants {
+		variants, ok := constraintTypes(variant)
+		if !ok {
+			return nil, false
+		}
+
+		out = append(out, variants...)
+	}
+
+	return out, true
+}
+
 // Instantiate substitutes TypeParam references in derived with concrete types.
 // typeArgs maps type parameter names to their concrete replacements.
 func (a *Alias) Instantiate(typeArgs map[string]Type) Type {
@@ -130,6 +170,12 @@ func SubstituteType(t Type, args map[string]Type) Type {
 		return &Map{Key: SubstituteType(v.Key, args), Value: SubstituteType(v.Value, args)}
 	case *Set:
 		return &Set{Element: SubstituteType(v.Element, args)}
+	case *Signal:
+		if v.Value == nil {
+			return v
+		}
+
+		return &Signal{Value: SubstituteType(v.Value, args)}
 	case *Option:
 		return &Option{Value: SubstituteType(v.Value, args)}
 	case *Reference:
@@ -202,3 +248,146 @@ func SubstituteType(t Type, args map[string]Type) Type {
 		return t
 	}
 }
+
+// Specialize replaces the type parameters in t that have a type argument in
+// args. Unlike SubstituteType, named types are kept, so that a specialized type
+// can be emitted in the package declaring its names. Type parameters with a
+// type argument are removed from procedure types, and t itself is returned if
+// it does not refer to any of them.
+func Specialize(t Type, args map[string]Type) Type {
+	switch v := t.(type) {
+	case *Alias:
+		if concrete, ok := args[v.Name]; ok && v.Constraint != nil {
+			return concrete
+		}
+
+		return v
+	case *Slice:
+		if elem := Specialize(v.Element, args); elem != v.Element {
+			return &Slice{Element: elem}
+		}
+	case *Array:
+		if elem := Specialize(v.Element, args); elem != v.Element {
+			return &Array{Element: elem, Length: v.Length}
+		}
+	case *Map:
+		key, value := Specialize(v.Key, args), Specialize(v.Value, args)
+		if key != v.Key || value != v.Value {
+			return &Map{Key: key, Value: value}
+		}
+	case *Set:
+		if elem := Specialize(v.Element, args); elem != v.Element {
+			return &Set{Element: elem}
+		}
+	case *Signal:
+		if v.Value == nil {
+			return v
+		}
+
+		if value := Specialize(v.Value, args); value != v.Value {
+			return &Signal{Value: value}
+		}
+	case *Option:
+		if value := Specialize(v.Value, args); value != v.Value {
+			return &Option{Value: value}
+		}
+	case *Reference:
+		if value := Specialize(v.Value, args); value != v.Value {
+			return &Reference{Value: value}
+		}
+	case *Iterator:
+		value := Specialize(v.Value, args)
+
+		index := v.Index
+		if index != nil {
+			index = Specialize(index, args)
+		}
+
+		if value != v.Value || index != v.Index {
+			return &Iterator{Value: value, Index: index}
+		}
+	case *Tuple:
+		if elems, ok := specializeAll(v.Types, args); ok {
+			return &Tuple{Types: elems, Exported: v.Exported, Global: v.Global}
+		}
+	case *Either:
+		left, right := Specialize(v.Left, args), Specialize(v.Right, args)
+		if left != v.Left || right != v.Right {
+			return &Either{Left: left, Right: right, Exported: v.Exported, Global: v.Global}
+		}
+	case *Union:
+		if variants, ok := specializeAll(v.Variants, args); ok {
+			return &Union{Variants: variants, Exported: v.Exported, Global: v.Global}
+		}
+	case *Result:
+		value, err := Specialize(v.Value, args), Specialize(v.Error, args)
+		if value != v.Value || err != v.Error {
+			return &Result{Value: value, Error: err}
+		}
+	case *Struct:
+		changed := false
+		fields := make([]*Field, len(v.Fields))
+
+		for i, f := range v.Fields {
+			fields[i] = f
+
+			if typ := Specialize(f.Type, args); typ != f.Type {
+				fields[i] = &Field{Name: f.Name, Type: typ, Exported: f.Exported, PointerLike: f.PointerLike}
+				changed = true
+			}
+		}
+
+		if changed {
+			return &Struct{Fields: fields, Methods: v.Methods, IsComplex: v.IsComplex}
+		}
+	case *Procedure:
+		changed := false
+		typeParams := make([]*Alias, 0, len(v.TypeParams))
+
+		for _, tp := range v.TypeParams {
+			if _, ok := args[tp.Name]; ok {
+				changed = true
+				continue
+			}
+
+			typeParams = append(typeParams, tp)
+		}
+
+		params := make([]*Parameter, len(v.Parameters))
+
+		for i, p := range v.Parameters {
+			params[i] = p
+
+			if typ := Specialize(p.Type, args); typ != p.Type {
+				params[i] = &Parameter{Name: p.Name, Optional: p.Optional, Type: typ, Default: p.Default}
+				changed = true
+			}
+		}
+
+		retType := v.ReturnType
+		if retType != nil {
+			retType = Specialize(retType, args)
+			changed = changed || retType != v.ReturnType
+		}
+
+		if changed {
+			return &Procedure{Function: v.Function, TypeParams: typeParams, Parameters: params, ReturnType: retType}
+		}
+	}
+
+	return t
+}
+
+// specializeAll specializes each of ts, and reports whether any of them
+// changed.
+func specializeAll(ts []Type, args map[string]Type) ([]Type, bool) {
+	changed := false
+	out := make([]Type, len(ts))
+
+	for i, typ := range ts {
+		out[i] = Specialize(typ, args)
+		changed = changed || out[i] != typ
+	}
+
+	return out, changed
+}
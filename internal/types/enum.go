@@ -10,8 +10,9 @@ type Enum struct {
 }
 
 type EnumValue struct {
-	Name  string
-	Value expression
+	Name   string
+	Value  expression
+	Params []*Parameter // payload of an error variant
 }
 
 func (*Enum) Kind() Kind {
@@ -6,7 +6,8 @@ var _ Type = &Error{}
 
 // Error represents an error enum type. Typeless errors (ValueType == nil)
 // print as their variant name. Typed errors require ascii or utf8 value type.
-// TODO: In the future, also allow interface{ String() string } and interface{ Error() string }.
+// Variants may carry a payload, of which an error typed parameter is the
+// cause the variant wraps.
 type Error struct {
 	ValueType Type // nil for typeless, ASCII or UTF8 for typed
 	Values    []*EnumValue
@@ -30,11 +31,27 @@ func (e *Error) String() string {
 			str.WriteString("\n")
 		}
 
+		str.WriteString(val.Name)
+
+		if len(val.Params) > 0 {
+			str.WriteString("(")
+
+			for j, param := range val.Params {
+				if j > 0 {
+					str.WriteString(", ")
+				}
+
+				str.WriteString(param.Name + " : " + param.Type.String())
+			}
+
+			str.WriteString(")")
+		}
+
 		if e.ValueType != nil {
-			str.WriteString(val.Name + " := " + val.Value.String() + ",\n")
-		} else {
-			str.WriteString(val.Name + ",\n")
+			str.WriteString(" := " + val.Value.String())
 		}
+
+		str.WriteString(",\n")
 	}
 
 	str.WriteString("}")
@@ -45,3 +62,52 @@ func (e *Error) String() string {
 func (e *Error) Underlying() Type {
 	return e
 }
+
+// Value returns the variant called name, or nil.
+func (e *Error) Value(name string) *EnumValue {
+	for _, val := range e.Values {
+		if val.Name == name {
+			return val
+		}
+	}
+
+	return nil
+}
+
+// HasPayload reports whether a variant of e carries a payload, in which case
+// a value of e is more than the index of its variant.
+func (e *Error) HasPayload() bool {
+	for _, val := range e.Values {
+		if len(val.Params) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Field returns the payload parameter called name. Payload parameters of the
+// same name have the same type in every variant.
+func (e *Error) Field(name string) *Parameter {
+	for _, val := range e.Values {
+		for _, param := range val.Params {
+			if param.Name == name {
+				return param
+			}
+		}
+	}
+
+	return nil
+}
+
+// Cause returns the payload parameter of variant val that holds the error it
+// wraps, or nil.
+func (val *EnumValue) Cause() *Parameter {
+	for _, param := range val.Params {
+		if IsError(param.Type) {
+			return param
+		}
+	}
+
+	return nil
+}
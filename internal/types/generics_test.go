@@ -355,6 +355,42 @@ func TestTypeParamAlias(t *testing.T) {
 			t.Errorf("Alias.Underlying() should return constraint, got %T", tp.Underlying())
 		}
 	})
+
+	t.Run("constraint_types", func(t *testing.T) {
+		t.Parallel()
+
+		tp := &Alias{
+			Name:       "T",
+			Constraint: &Union{Variants: []Type{Constraints["string"], Basics[Int32]}},
+		}
+
+		got, ok := tp.ConstraintTypes()
+		if !ok {
+			t.Fatal("T ~ string | int32 should list its constraint types")
+		}
+
+		want := []Type{Basics[ASCII], Basics[UTF8], Basics[Int32]}
+		if len(got) != len(want) {
+			t.Fatalf("ConstraintTypes() = %v, want %v", got, want)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("ConstraintTypes()[%d] = %s, want %s", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("constraint_types_unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		for _, constraint := range []Type{Any, Constraints["comparable"]} {
+			tp := &Alias{Name: "T", Constraint: constraint}
+			if _, ok := tp.ConstraintTypes(); ok {
+				t.Errorf("T ~ %s should not list its constraint types", constraint)
+			}
+		}
+	})
 }
 
 func TestSatisfies(t *testing.T) {
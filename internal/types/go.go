@@ -0,0 +1,369 @@
+package types
+
+import (
+	"fmt"
+	gotypes "go/types"
+	"strconv"
+)
+
+// goBasics maps Go basic kinds to Cog basic kinds. Platform-sized integers are
+// widened to their 64-bit Cog counterpart and untyped constants take their
+// default type.
+var goBasics = map[gotypes.BasicKind]Kind{
+	gotypes.Bool:           Bool,
+	gotypes.Int:            Int64,
+	gotypes.Int8:           Int8,
+	gotypes.Int16:          Int16,
+	gotypes.Int32:          Int32,
+	gotypes.Int64:          Int64,
+	gotypes.Uint:           Uint64,
+	gotypes.Uint8:          Uint8,
+	gotypes.Uint16:         Uint16,
+	gotypes.Uint32:         Uint32,
+	gotypes.Uint64:         Uint64,
+	gotypes.Uintptr:        Uint64,
+	gotypes.Float32:        Float32,
+	gotypes.Float64:        Float64,
+	gotypes.Complex64:      Complex64,
+	gotypes.Complex128:     Complex128,
+	gotypes.String:         UTF8,
+	gotypes.UntypedBool:    Bool,
+	gotypes.UntypedInt:     Int64,
+	gotypes.UntypedRune:    Int32,
+	gotypes.UntypedFloat:   Float64,
+	gotypes.UntypedComplex: Complex128,
+	gotypes.UntypedString:  UTF8,
+}
+
+// cogBasics maps Cog basic kinds to the Go type they are transpiled to. Kinds
+// backed by a type from the cog runtime package (ascii, complex32, float16,
+// int128, uint128) have no Go builtin equivalent and are left out.
+var cogBasics = map[Kind]gotypes.BasicKind{
+	Bool:       gotypes.Bool,
+	Complex64:  gotypes.Complex64,
+	Complex128: gotypes.Complex128,
+	Int8:       gotypes.Int8,
+	Int16:      gotypes.Int16,
+	Int32:      gotypes.Int32,
+	Int64:      gotypes.Int64,
+	Float32:    gotypes.Float32,
+	Float64:    gotypes.Float64,
+	Uint8:      gotypes.Uint8,
+	Uint16:     gotypes.Uint16,
+	Uint32:     gotypes.Uint32,
+	Uint64:     gotypes.Uint64,
+	UTF8:       gotypes.String,
+}
+
+// GoError is the Go error interface, used as the error type of results
+// returned by Go functions.
+var GoError = &GoType{
+	Name: "error",
+	Go:   gotypes.Universe.Lookup("error").Type(),
+}
+
+// goArrayLength is the constant length of an array type imported from Go.
+type goArrayLength int64
+
+func (l goArrayLength) String() string {
+	return strconv.FormatInt(int64(l), 10)
+}
+
+func (l goArrayLength) Type() Type {
+	return Basics[Int64]
+}
+
+// FromGo converts a Go type to its Cog equivalent. Named types are converted
+// through their underlying type when possible, otherwise they are kept as an
+// opaque [GoType].
+func FromGo(t gotypes.Type) (Type, error) {
+	switch t := t.(type) {
+	case *gotypes.Basic:
+		kind, ok := goBasics[t.Kind()]
+		if !ok {
+			return nil, fmt.Errorf("unsupported Go type %q", t)
+		}
+
+		return Basics[kind], nil
+	case *gotypes.Alias:
+		return FromGo(gotypes.Unalias(t))
+	case *gotypes.Named:
+		if isGoError(t) {
+			return GoError, nil
+		}
+
+		underlying, err := FromGo(t.Underlying())
+		if err == nil {
+			return underlying, nil
+		}
+
+		if t.Obj().Pkg() == nil || t.TypeArgs().Len() > 0 {
+			return nil, fmt.Errorf("unsupported Go type %q", t)
+		}
+
+		return &GoType{
+			Import: t.Obj().Pkg().Path(),
+			Name:   t.Obj().Name(),
+			Go:     t,
+		}, nil
+	case *gotypes.Array:
+		elem, err := FromGo(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Array{Element: elem, Length: goArrayLength(t.Len())}, nil
+	case *gotypes.Slice:
+		elem, err := FromGo(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Slice{Element: elem}, nil
+	case *gotypes.Map:
+		key, err := FromGo(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := FromGo(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Map{Key: key, Value: value}, nil
+	case *gotypes.Pointer:
+		value, err := FromGo(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return &Reference{Value: value}, nil
+	case *gotypes.Interface:
+		if t.Empty() {
+			return Any, nil
+		}
+	case *gotypes.Signature:
+		return FromGoSignature(t)
+	}
+
+	return nil, fmt.Errorf("unsupported Go type %q", t)
+}
+
+// FromGoSignature converts a Go function signature to a Cog procedure type.
+// Go functions may have side effects, so they are never marked as pure. A
+// variadic parameter keeps its slice type. Results are adapted to Cog:
+//   - (T, error) becomes T ! error
+//   - a lone error or pointer becomes an option, set when non-nil
+//   - any other multiple results are returned as a tuple
+func FromGoSignature(sig *gotypes.Signature) (*Procedure, error) {
+	if sig.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("unsupported generic Go function %q", sig)
+	}
+
+	proc := &Procedure{
+		Parameters: make([]*Parameter, sig.Params().Len()),
+	}
+
+	for i := range sig.Params().Len() {
+		param := sig.Params().At(i)
+
+		paramType, err := FromGo(param.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		proc.Parameters[i] = &Parameter{
+			Name: param.Name(),
+			Type: paramType,
+		}
+	}
+
+	results := make([]Type, sig.Results().Len())
+
+	for i := range sig.Results().Len() {
+		result := sig.Results().At(i)
+
+		// Results are often discarded, so an unsupported type is only an error
+		// once the value is used.
+		resultType, err := FromGo(result.Type())
+		if err != nil {
+			resultType = None
+		}
+
+		results[i] = resultType
+	}
+
+	switch len(results) {
+	case 0:
+	case 1:
+		switch sig.Results().At(0).Type().(type) {
+		case *gotypes.Pointer:
+			proc.ReturnType = &Option{Value: results[0]}
+		default:
+			if results[0] == GoError {
+				proc.ReturnType = &Option{Value: GoError}
+			} else {
+				proc.ReturnType = results[0]
+			}
+		}
+	case 2:
+		if results[1] == GoError && results[0] != GoError && !IsNone(results[0]) {
+			proc.ReturnType = &Result{Value: results[0], Error: GoError}
+			break
+		}
+
+		fallthrough
+	default:
+		proc.ReturnType = &Tuple{Types: results}
+	}
+
+	return proc, nil
+}
+
+// AdaptableToGo reports whether a Cog value of type src can be passed to Go
+// as type dst. Besides Go assignability, the transpiler converts between
+// types with the same Cog representation (e.g. int64 to int or time.Duration),
+// ascii to string, int128/uint128 to *big.Int, and an optional reference to a
+// nilable pointer.
+func AdaptableToGo(src Type, dst gotypes.Type) bool {
+	if opt, ok := src.(*Option); ok && opt.Value.Kind() == ReferenceKind {
+		// An optional reference is passed to Go as a nilable pointer.
+		return AdaptableToGo(opt.Value, dst)
+	}
+
+	switch src.Kind() {
+	case ASCII:
+		if isGoString(dst) || isGoBytes(dst) {
+			return true
+		}
+	case Int128, Uint128:
+		if isGoBigInt(dst) {
+			return true
+		}
+	case ErrorKind, InterfaceKind, StructKind:
+		// Cog errors implement the Go error interface.
+		if IsError(src) && isGoError(dst) {
+			return true
+		}
+	}
+
+	goSrc, err := ToGo(src)
+	if err != nil {
+		return false
+	}
+
+	if gotypes.AssignableTo(goSrc, dst) {
+		return true
+	}
+
+	dstType, err := FromGo(dst)
+	if err != nil {
+		return false
+	}
+
+	return Equal(src, dstType) && gotypes.ConvertibleTo(goSrc, dst)
+}
+
+// AdaptableFromGo reports whether a Go value of type src can be used as the
+// Cog type dst through an adapter: string and []byte to ascii, and *big.Int to
+// int128/uint128.
+func AdaptableFromGo(src gotypes.Type, dst Type) bool {
+	switch dst.Kind() {
+	case ASCII:
+		return isGoString(src) || isGoBytes(src)
+	case Int128, Uint128:
+		return isGoBigInt(src)
+	}
+
+	return false
+}
+
+func isGoError(t gotypes.Type) bool {
+	return gotypes.Identical(t, GoError.Go)
+}
+
+func isGoString(t gotypes.Type) bool {
+	basic, ok := t.Underlying().(*gotypes.Basic)
+	return ok && basic.Kind() == gotypes.String
+}
+
+func isGoBytes(t gotypes.Type) bool {
+	slice, ok := t.Underlying().(*gotypes.Slice)
+	if !ok {
+		return false
+	}
+
+	basic, ok := slice.Elem().Underlying().(*gotypes.Basic)
+
+	return ok && basic.Kind() == gotypes.Byte
+}
+
+func isGoBigInt(t gotypes.Type) bool {
+	ptr, ok := t.(*gotypes.Pointer)
+	if !ok {
+		return false
+	}
+
+	named, ok := ptr.Elem().(*gotypes.Named)
+
+	return ok && named.Obj().Pkg() != nil &&
+		named.Obj().Pkg().Path() == "math/big" && named.Obj().Name() == "Int"
+}
+
+// ToGo converts a Cog type to the Go type it is transpiled to.
+func ToGo(t Type) (gotypes.Type, error) {
+	switch t := t.(type) {
+	case *Basic:
+		kind, ok := cogBasics[t.Kind()]
+		if !ok {
+			return nil, fmt.Errorf("type %q has no Go equivalent", t)
+		}
+
+		return gotypes.Typ[kind], nil
+	case *anyType:
+		return gotypes.Universe.Lookup("any").Type(), nil
+	case *GoType:
+		return t.Go, nil
+	case *Slice:
+		elem, err := ToGo(t.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		return gotypes.NewSlice(elem), nil
+	case *Array:
+		length, ok := t.Length.(goArrayLength)
+		if !ok {
+			return nil, fmt.Errorf("type %q has no Go equivalent", t)
+		}
+
+		elem, err := ToGo(t.Element)
+		if err != nil {
+			return nil, err
+		}
+
+		return gotypes.NewArray(elem, int64(length)), nil
+	case *Map:
+		key, err := ToGo(t.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := ToGo(t.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return gotypes.NewMap(key, value), nil
+	case *Reference:
+		value, err := ToGo(t.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		return gotypes.NewPointer(value), nil
+	}
+
+	return nil, fmt.Errorf("type %q has no Go equivalent", t)
+}
@@ -0,0 +1,249 @@
+package types
+
+import (
+	gotypes "go/types"
+	"testing"
+)
+
+func TestFromGoBasic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   gotypes.BasicKind
+		want Kind
+	}{
+		{gotypes.Bool, Bool},
+		{gotypes.Int, Int64},
+		{gotypes.Uint, Uint64},
+		{gotypes.Byte, Uint8},
+		{gotypes.Rune, Int32},
+		{gotypes.Float32, Float32},
+		{gotypes.String, UTF8},
+		{gotypes.UntypedInt, Int64},
+	}
+
+	for _, tt := range tests {
+		got, err := FromGo(gotypes.Typ[tt.in])
+		if err != nil {
+			t.Fatalf("FromGo(%s) error: %v", gotypes.Typ[tt.in], err)
+		}
+
+		if got.Kind() != tt.want {
+			t.Errorf("FromGo(%s) = %s, want %s", gotypes.Typ[tt.in], got, tt.want)
+		}
+	}
+}
+
+func TestFromGoComposite(t *testing.T) {
+	t.Parallel()
+
+	in := gotypes.NewMap(gotypes.Typ[gotypes.String], gotypes.NewSlice(gotypes.Typ[gotypes.Int]))
+
+	got, err := FromGo(in)
+	if err != nil {
+		t.Fatalf("FromGo error: %v", err)
+	}
+
+	if got.String() != "map[utf8][]int64" {
+		t.Errorf("FromGo = %s, want map[utf8][]int64", got)
+	}
+
+	arr, err := FromGo(gotypes.NewArray(gotypes.Typ[gotypes.Uint8], 4))
+	if err != nil {
+		t.Fatalf("FromGo error: %v", err)
+	}
+
+	if arr.String() != "[4]uint8" {
+		t.Errorf("FromGo = %s, want [4]uint8", arr)
+	}
+}
+
+func TestFromGoUnsupported(t *testing.T) {
+	t.Parallel()
+
+	if _, err := FromGo(gotypes.Typ[gotypes.UnsafePointer]); err == nil {
+		t.Error("expected error for unsafe.Pointer")
+	}
+
+	if _, err := FromGo(gotypes.NewChan(gotypes.SendRecv, gotypes.Typ[gotypes.Int])); err == nil {
+		t.Error("expected error for channel")
+	}
+}
+
+func TestFromGoSignature(t *testing.T) {
+	t.Parallel()
+
+	params := gotypes.NewTuple(
+		gotypes.NewParam(0, nil, "s", gotypes.Typ[gotypes.String]),
+		gotypes.NewParam(0, nil, "n", gotypes.Typ[gotypes.Int]),
+	)
+	results := gotypes.NewTuple(gotypes.NewParam(0, nil, "", gotypes.Typ[gotypes.String]))
+
+	proc, err := FromGoSignature(gotypes.NewSignatureType(nil, nil, nil, params, results, false))
+	if err != nil {
+		t.Fatalf("FromGoSignature error: %v", err)
+	}
+
+	if proc.Function {
+		t.Error("Go functions should not be pure")
+	}
+
+	if len(proc.Parameters) != 2 || proc.Parameters[1].Type != Basics[Int64] {
+		t.Errorf("unexpected parameters: %s", proc)
+	}
+
+	if proc.ReturnType != Basics[UTF8] {
+		t.Errorf("ReturnType = %v, want utf8", proc.ReturnType)
+	}
+}
+
+func TestFromGoSignatureResults(t *testing.T) {
+	t.Parallel()
+
+	errType := gotypes.Universe.Lookup("error").Type()
+	intType := gotypes.Typ[gotypes.Int]
+
+	tests := []struct {
+		name    string
+		results []gotypes.Type
+		want    string
+	}{
+		{"value_error", []gotypes.Type{intType, errType}, "int64 ! error"},
+		{"error", []gotypes.Type{errType}, "error?"},
+		{"pointer", []gotypes.Type{gotypes.NewPointer(intType)}, "&int64?"},
+		{"tuple", []gotypes.Type{intType, intType}, "(int64, int64)"},
+	}
+
+	for _, tt := range tests {
+		vars := make([]*gotypes.Var, len(tt.results))
+		for i, r := range tt.results {
+			vars[i] = gotypes.NewParam(0, nil, "", r)
+		}
+
+		sig := gotypes.NewSignatureType(nil, nil, nil, nil, gotypes.NewTuple(vars...), false)
+
+		proc, err := FromGoSignature(sig)
+		if err != nil {
+			t.Fatalf("%s: FromGoSignature error: %v", tt.name, err)
+		}
+
+		if got := proc.ReturnType.String(); got != tt.want {
+			t.Errorf("%s: ReturnType = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdaptableToGo(t *testing.T) {
+	t.Parallel()
+
+	bigInt := gotypes.NewPointer(gotypes.NewNamed(
+		gotypes.NewTypeName(0, gotypes.NewPackage("math/big", "big"), "Int", nil),
+		gotypes.NewStruct(nil, nil), nil))
+
+	tests := []struct {
+		src  Type
+		dst  gotypes.Type
+		want bool
+	}{
+		{Basics[Int64], gotypes.Typ[gotypes.Int], true},
+		{Basics[Int32], gotypes.Typ[gotypes.Int], false},
+		{Basics[Int64], gotypes.Typ[gotypes.String], false},
+		{Basics[ASCII], gotypes.Typ[gotypes.String], true},
+		{Basics[ASCII], gotypes.NewSlice(gotypes.Typ[gotypes.Byte]), true},
+		{Basics[UTF8], gotypes.NewSlice(gotypes.Typ[gotypes.Byte]), false},
+		{Basics[Int128], bigInt, true},
+		{Basics[Uint128], bigInt, true},
+		{Basics[Int128], gotypes.Typ[gotypes.Int64], false},
+	}
+
+	for _, tt := range tests {
+		if got := AdaptableToGo(tt.src, tt.dst); got != tt.want {
+			t.Errorf("AdaptableToGo(%s, %s) = %t, want %t", tt.src, tt.dst, got, tt.want)
+		}
+	}
+
+	if !AdaptableFromGo(bigInt, Basics[Int128]) || !AdaptableFromGo(gotypes.Typ[gotypes.String], Basics[ASCII]) {
+		t.Error("expected adapters from Go")
+	}
+}
+
+func TestToGo(t *testing.T) {
+	t.Parallel()
+
+	got, err := ToGo(&Slice{Element: Basics[UTF8]})
+	if err != nil {
+		t.Fatalf("ToGo error: %v", err)
+	}
+
+	if got.String() != "[]string" {
+		t.Errorf("ToGo = %s, want []string", got)
+	}
+
+	for _, kind := range []Kind{ASCII, Float16, Complex32, Int128, Uint128} {
+		if _, err := ToGo(Basics[kind]); err == nil {
+			t.Errorf("ToGo(%s): expected error", kind)
+		}
+	}
+}
+
+func TestGoRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for kind := range cogBasics {
+		goType, err := ToGo(Basics[kind])
+		if err != nil {
+			t.Fatalf("ToGo(%s) error: %v", kind, err)
+		}
+
+		back, err := FromGo(goType)
+		if err != nil {
+			t.Fatalf("FromGo(%s) error: %v", goType, err)
+		}
+
+		if back != Basics[kind] {
+			t.Errorf("round trip %s -> %s -> %s", kind, goType, back)
+		}
+	}
+}
+
+func TestFromGoNamed(t *testing.T) {
+	t.Parallel()
+
+	pkg := gotypes.NewPackage("bytes", "bytes")
+
+	buffer := gotypes.NewNamed(gotypes.NewTypeName(0, pkg, "Buffer", nil), nil, nil)
+	buffer.SetUnderlying(gotypes.NewStruct([]*gotypes.Var{
+		gotypes.NewField(0, pkg, "buf", gotypes.NewSlice(gotypes.Typ[gotypes.Byte]), false),
+		gotypes.NewField(0, pkg, "c", gotypes.NewChan(gotypes.SendRecv, gotypes.Typ[gotypes.Int]), false),
+	}, nil))
+
+	got, err := FromGo(buffer)
+	if err != nil {
+		t.Fatalf("FromGo error: %v", err)
+	}
+
+	goType, ok := got.(*GoType)
+	if !ok {
+		t.Fatalf("FromGo = %T, want *GoType", got)
+	}
+
+	if goType.String() != "@go.bytes.Buffer" {
+		t.Errorf("String() = %q, want %q", goType.String(), "@go.bytes.Buffer")
+	}
+
+	back, err := ToGo(goType)
+	if err != nil || back != buffer {
+		t.Errorf("ToGo = %v, %v, want %v", back, err, buffer)
+	}
+
+	if !Equal(goType, &GoType{Import: "bytes", Name: "Buffer", Go: buffer}) {
+		t.Error("expected equal Go types")
+	}
+
+	duration := gotypes.NewNamed(gotypes.NewTypeName(0, gotypes.NewPackage("time", "time"), "Duration", nil), gotypes.Typ[gotypes.Int64], nil)
+
+	got, err = FromGo(duration)
+	if err != nil || got != Basics[Int64] {
+		t.Errorf("FromGo(time.Duration) = %v, %v, want int64", got, err)
+	}
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	gotypes "go/types"
+	"path"
+)
+
+var _ Type = &GoType{}
+
+// GoType is a named Go type without a Cog equivalent. It is opaque to Cog and
+// can only be passed around and handed back to Go.
+type GoType struct {
+	Import string // Go import path of the declaring package, empty for predeclared types
+	Name   string
+	Go     gotypes.Type
+}
+
+func (t *GoType) Kind() Kind {
+	return GoKind
+}
+
+func (t *GoType) String() string {
+	if t.Import == "" {
+		return t.Name
+	}
+
+	return "@go." + path.Base(t.Import) + "." + t.Name
+}
+
+func (t *GoType) Underlying() Type {
+	return t
+}
@@ -1,5 +1,7 @@
 package types
 
+import gotypes "go/types"
+
 // AssignableTo reports whether a value of type src can be assigned to a
 // variable of type dst. This is true when the types are Equal, or when
 // dst is an Option type and src equals the option's inner type, or when
@@ -27,15 +29,20 @@ func AssignableTo(src, dst Type) bool {
 
 	// Allow assigning T or E to T ! E (Result[T, E]).
 	if r, ok := dst.(*Result); ok {
-		return Equal(src, r.Value) || Equal(src, r.Error)
+		return Equal(src, r.Value) || Equal(src, r.Error) || (IsError(src) && ErrorAssignableTo(src, r.Error))
 	}
 
 	if a, ok := dst.(*Alias); ok {
 		if r, ok := a.Underlying().(*Result); ok {
-			return Equal(src, r.Value) || Equal(src, r.Error)
+			return Equal(src, r.Value) || Equal(src, r.Error) || (IsError(src) && ErrorAssignableTo(src, r.Error))
 		}
 	}
 
+	// Allow assigning a type to an interface it implements.
+	if dst.Kind() == InterfaceKind {
+		return Implements(src, dst.Underlying().(*Interface))
+	}
+
 	return false
 }
 
@@ -100,9 +107,23 @@ func Equal(a, b Type) bool {
 	case *Set:
 		bt := bu.(*Set)
 		return Equal(at.Element, bt.Element)
+	case *Signal:
+		bt := bu.(*Signal)
+		if at.Value == nil || bt.Value == nil {
+			return at.Value == nil && bt.Value == nil
+		}
+
+		return Equal(at.Value, bt.Value)
 	case *Reference:
 		bt := bu.(*Reference)
 		return Equal(at.Value, bt.Value)
+	case *Iterator:
+		bt := bu.(*Iterator)
+		if at.Index == nil || bt.Index == nil {
+			return at.Index == nil && bt.Index == nil && Equal(at.Value, bt.Value)
+		}
+
+		return Equal(at.Value, bt.Value) && Equal(at.Index, bt.Index)
 	case *Tuple:
 		bt := bu.(*Tuple)
 		if len(at.Types) != len(bt.Types) {
@@ -184,6 +205,8 @@ func Equal(a, b Type) bool {
 		}
 
 		return true
+	case *GoType:
+		return gotypes.Identical(at.Go, bu.(*GoType).Go)
 	default:
 		// Basic types: Kind equality is sufficient.
 		return true
@@ -242,8 +265,9 @@ func Satisfies(concrete, constraint Type) bool {
 	return Equal(concrete, constraint)
 }
 
-// Implements reports whether a concrete type implements the given interface,
-// i.e. has all required methods with matching signatures.
+// Implements reports whether a type implements the given interface, i.e. has
+// all required methods with matching signatures. Cog errors and the Go error
+// interface have the Error method.
 func Implements(concrete Type, iface *Interface) bool {
 	// Unwrap aliases to find the underlying struct.
 	underlying := concrete
@@ -256,16 +280,29 @@ func Implements(concrete Type, iface *Interface) bool {
 		underlying = a.Underlying()
 	}
 
-	s, ok := underlying.(*Struct)
-	if !ok {
-		return false
+	var methods []*Method
+
+	switch v := underlying.(type) {
+	case *Struct:
+		methods = v.Methods
+	case *Interface:
+		methods = v.Methods
+	case *Error:
+		// Cog errors only have the Error method.
+		methods = []*Method{ErrorMethod}
+	default:
+		if underlying != GoError {
+			return false
+		}
+
+		methods = []*Method{ErrorMethod}
 	}
 
 	for _, required := range iface.Methods {
 		found := false
 
-		for _, m := range s.Methods {
-			if m.Name == required.Name && Equal(m.Procedure, required.Procedure) {
+		for _, m := range methods {
+			if m.Is(required) {
 				found = true
 				break
 			}
@@ -279,6 +316,21 @@ func Implements(concrete Type, iface *Interface) bool {
 	return true
 }
 
+// ErrorAssignableTo reports whether an error of type src can be used as an
+// error of type dst. The Go error interface accepts any error, and an
+// interface accepts any error that implements it.
+func ErrorAssignableTo(src, dst Type) bool {
+	if dst == GoError {
+		return IsError(src)
+	}
+
+	if dst.Kind() == InterfaceKind {
+		return Implements(src, dst.Underlying().(*Interface))
+	}
+
+	return src.String() == dst.String() && Equal(src, dst)
+}
+
 // isStructuralSentinel reports whether a type is one of the zero-value
 // sentinels used in the comparable constraint definition.
 func isStructuralSentinel(t Type) bool {
@@ -9,6 +9,18 @@ type Interface struct {
 type Method struct {
 	Name      string
 	Procedure *Procedure
+	Mutable   bool // declared with a var reference receiver, so it can change the value it is called on
+}
+
+// ErrorMethod is the method that makes an interface or struct an error type.
+var ErrorMethod = &Method{
+	Name:      "Error",
+	Procedure: &Procedure{Function: true, ReturnType: Basics[UTF8]},
+}
+
+// Is reports whether m has the name and signature of other.
+func (m *Method) Is(other *Method) bool {
+	return m.Name == other.Name && Equal(m.Procedure, other.Procedure)
 }
 
 func (n *Interface) Kind() Kind {
@@ -201,6 +201,76 @@ func TestImplements(t *testing.T) {
 			t.Error("partial should not implement readWrite")
 		}
 	})
+
+	t.Run("interface_with_methods", func(t *testing.T) {
+		t.Parallel()
+
+		named := &Interface{
+			Methods: []*Method{
+				{Name: "Name", Procedure: stringProc},
+				{Name: "String", Procedure: stringProc},
+			},
+		}
+
+		if !Implements(named, stringer) {
+			t.Error("interface with String method should implement Stringer")
+		}
+
+		if Implements(stringer, named) {
+			t.Error("Stringer should not implement interface with more methods")
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		failure := &Interface{Methods: []*Method{ErrorMethod}}
+
+		if !Implements(&Error{}, failure) {
+			t.Error("Cog error should implement interface with Error method")
+		}
+
+		if !Implements(GoError, failure) {
+			t.Error("Go error should implement interface with Error method")
+		}
+
+		if Implements(GoError, stringer) {
+			t.Error("Go error should not implement Stringer")
+		}
+	})
+}
+
+func TestErrorAssignableTo(t *testing.T) {
+	t.Parallel()
+
+	failure := &Alias{Name: "Failure", Derived: &Interface{Methods: []*Method{ErrorMethod}}}
+	parseError := &Alias{Name: "ParseError", Derived: &Struct{Methods: []*Method{ErrorMethod}}}
+	myError := &Alias{Name: "MyError", Derived: &Error{}}
+	otherError := &Alias{Name: "OtherError", Derived: &Error{}}
+
+	tests := []struct {
+		name     string
+		src, dst Type
+		want     bool
+	}{
+		{"same_error", myError, myError, true},
+		{"other_error", myError, otherError, false},
+		{"to_go_error", parseError, GoError, true},
+		{"error_to_interface", myError, failure, true},
+		{"struct_to_interface", parseError, failure, true},
+		{"go_error_to_interface", GoError, failure, true},
+		{"interface_to_struct", failure, parseError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := ErrorAssignableTo(tt.src, tt.dst); got != tt.want {
+				t.Errorf("ErrorAssignableTo(%s, %s) = %v, want %v", tt.src, tt.dst, got, tt.want)
+			}
+		})
+	}
 }
 
 func TestSatisfiesInterface(t *testing.T) {
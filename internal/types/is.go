@@ -1,5 +1,7 @@
 package types
 
+import "slices"
+
 func IsBool(t Type) bool {
 	return t.Kind() == Bool
 }
@@ -29,7 +31,13 @@ func IsNumber(t Type) bool {
 
 func IsIterator(t Type) bool {
 	kind := t.Kind()
-	return IsString(t) || kind == ArrayKind || kind == SliceKind || kind == MapKind || kind == SetKind || kind == EnumKind
+	if kind == ProcedureKind {
+		_, ok := IteratorOf(t)
+		return ok
+	}
+
+	return IsString(t) || kind == ArrayKind || kind == SliceKind || kind == MapKind || kind == SetKind || kind == EnumKind ||
+		kind == IteratorKind
 }
 
 func IsReal(t Type) bool {
@@ -108,8 +116,67 @@ func IsComparable(t Type) bool {
 	}
 }
 
+// IsShared reports whether a value of type t shares memory with its copies,
+// so a change through one copy is visible through the others. These are
+// slices, maps, sets and references, ascii values, which are byte slices in
+// Go, and the types that contain them.
+func IsShared(t Type) bool {
+	switch v := t.Underlying().(type) {
+	case *Slice, *Map, *Set, *Reference:
+		return true
+	case *Basic:
+		return v.Kind() == ASCII
+	case *Array:
+		return v.Element != nil && IsShared(v.Element)
+	case *Struct:
+		return slices.ContainsFunc(v.Fields, func(f *Field) bool { return IsShared(f.Type) })
+	case *Tuple:
+		return slices.ContainsFunc(v.Types, IsShared)
+	case *Either:
+		return IsShared(v.Left) || IsShared(v.Right)
+	case *Union:
+		return slices.ContainsFunc(v.Variants, IsShared)
+	case *Result:
+		return IsShared(v.Value) || IsShared(v.Error)
+	case *Option:
+		// The underlying type of an option is its value type, but not of a
+		// named option.
+		return IsShared(v.Value)
+	default:
+		return false
+	}
+}
+
 // Pointer types are types which are pointer types under the hood.
 func IsPointer(t Type) bool {
 	kind := t.Kind()
-	return kind == ReferenceKind || kind == SliceKind || kind == SetKind || kind == MapKind || kind == ProcedureKind
+	return kind == ReferenceKind || kind == SliceKind || kind == SetKind || kind == MapKind || kind == ProcedureKind || kind == SignalKind ||
+		kind == IteratorKind
+}
+
+// IsError reports whether t is a Cog error type, the Go error interface, or an
+// interface or struct with the Error : func() utf8 method.
+func IsError(t Type) bool {
+	return t.Kind() == ErrorKind || t == GoError || HasErrorMethod(t)
+}
+
+// HasErrorMethod reports whether t is an interface or struct with the
+// Error : func() utf8 method.
+func HasErrorMethod(t Type) bool {
+	var methods []*Method
+
+	switch u := t.Underlying().(type) {
+	case *Interface:
+		methods = u.Methods
+	case *Struct:
+		methods = u.Methods
+	}
+
+	for _, m := range methods {
+		if m.Is(ErrorMethod) {
+			return true
+		}
+	}
+
+	return false
 }
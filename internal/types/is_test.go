@@ -355,6 +355,19 @@ func TestIsIterator(t *testing.T) {
 		t.Error("IsIterator(enum) = false")
 	}
 
+	it := &Iterator{Value: Basics[Int64], Index: Basics[UTF8]}
+	if !IsIterator(it) {
+		t.Error("IsIterator(iter<int64, utf8>) = false")
+	}
+
+	if !IsIterator(it.Procedure()) {
+		t.Error("IsIterator(proc(yield : func(v : int64, k : utf8) bool)) = false")
+	}
+
+	if IsIterator(&Procedure{Parameters: []*Parameter{{Name: "v", Type: Basics[Int64]}}}) {
+		t.Error("IsIterator(proc(v : int64)) = true")
+	}
+
 	if IsIterator(Basics[Int64]) {
 		t.Error("IsIterator(int64) = true")
 	}
@@ -394,3 +407,39 @@ func TestIsComparable(t *testing.T) {
 		})
 	}
 }
+
+func TestIsShared(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		typ  Type
+		want bool
+	}{
+		{"basic int64", Basics[Int64], false},
+		{"basic utf8", Basics[UTF8], false},
+		{"slice", &Slice{Element: Basics[Int64]}, true},
+		{"map", &Map{Key: Basics[UTF8], Value: Basics[Int64]}, true},
+		{"set", &Set{Element: Basics[Int64]}, true},
+		{"reference", &Reference{Value: Basics[Int64]}, true},
+		{"array of basic", &Array{Element: Basics[Int64]}, false},
+		{"array of slice", &Array{Element: &Slice{Element: Basics[Int64]}}, true},
+		{"struct with basic fields", &Struct{Fields: []*Field{{Name: "x", Type: Basics[Int64]}}}, false},
+		{"struct with map field", &Struct{Fields: []*Field{{Name: "x", Type: &Map{Key: Basics[UTF8], Value: Basics[Int64]}}}}, true},
+		{"tuple with slice", &Tuple{Types: []Type{Basics[Int64], &Slice{Element: Basics[UTF8]}}}, true},
+		{"option of slice", &Option{Value: &Slice{Element: Basics[Int64]}}, true},
+		{"option of basic", &Option{Value: Basics[Int64]}, false},
+		{"result of slice", &Result{Value: &Slice{Element: Basics[Int64]}, Error: GoError}, true},
+		{"procedure", &Procedure{Function: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsShared(tt.typ); got != tt.want {
+				t.Errorf("IsShared(%v) = %v, want %v", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package types
+
+var _ Type = &Iterator{}
+
+// Iterator is a sequence of values that can be looped over with for ... in:
+// iter<V>, or iter<V, K> if every value comes with an index or key. It is the
+// type of a range expression, and of a procedure that passes the values to
+// its yield parameter.
+type Iterator struct {
+	Value Type
+	Index Type // may be nil
+}
+
+func (i *Iterator) Kind() Kind {
+	return IteratorKind
+}
+
+func (i *Iterator) String() string {
+	if i.Index == nil {
+		return "iter<" + i.Value.String() + ">"
+	}
+
+	return "iter<" + i.Value.String() + ", " + i.Index.String() + ">"
+}
+
+func (i *Iterator) Underlying() Type {
+	return i
+}
+
+// Yield returns the type of the yield parameter of the iterator procedure,
+// which returns false when the loop stops: func(v : V, k : K) bool.
+func (i *Iterator) Yield() *Procedure {
+	yield := &Procedure{
+		Function:   true,
+		Parameters: []*Parameter{{Name: "v", Type: i.Value}},
+		ReturnType: Basics[Bool],
+	}
+
+	if i.Index != nil {
+		yield.Parameters = append(yield.Parameters, &Parameter{Name: "k", Type: i.Index})
+	}
+
+	return yield
+}
+
+// Procedure returns the type of the procedure that yields the values of the
+// iterator: proc(yield : func(v : V) bool).
+func (i *Iterator) Procedure() *Procedure {
+	return &Procedure{
+		Parameters: []*Parameter{{Name: "yield", Type: i.Yield()}},
+	}
+}
+
+// IteratorOf returns the iterator type of t, which is either an iterator, or a
+// procedure with a single yield parameter: proc(yield : func(v : V) bool).
+func IteratorOf(t Type) (*Iterator, bool) {
+	switch u := t.Underlying().(type) {
+	case *Iterator:
+		return u, true
+	case *Procedure:
+		if len(u.TypeParams) > 0 || len(u.Parameters) != 1 || u.ReturnType != nil {
+			return nil, false
+		}
+
+		yield, ok := u.Parameters[0].Type.Underlying().(*Procedure)
+		if !ok || len(yield.Parameters) == 0 || len(yield.Parameters) > 2 ||
+			yield.ReturnType == nil || !IsBool(yield.ReturnType) {
+			return nil, false
+		}
+
+		it := &Iterator{Value: yield.Parameters[0].Type}
+		if len(yield.Parameters) == 2 {
+			it.Index = yield.Parameters[1].Type
+		}
+
+		return it, true
+	default:
+		return nil, false
+	}
+}
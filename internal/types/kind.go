@@ -45,6 +45,7 @@ const (
 	ErrorKind
 	MapKind
 	SetKind
+	SignalKind
 	StructKind
 
 	// Combined types
@@ -58,6 +59,10 @@ const (
 
 	// Function type
 	ProcedureKind
+	IteratorKind
+
+	// Opaque Go type
+	GoKind
 )
 
 func (t Kind) String() string {
@@ -114,6 +119,8 @@ func (t Kind) String() string {
 		return "map"
 	case SetKind:
 		return "set"
+	case SignalKind:
+		return "signal"
 	case StructKind:
 		return "struct"
 	case TupleKind:
@@ -128,6 +135,10 @@ func (t Kind) String() string {
 		return "result"
 	case ProcedureKind:
 		return "proc"
+	case IteratorKind:
+		return "iter"
+	case GoKind:
+		return "go"
 	case ArrayKind:
 		return "array"
 	case SliceKind:
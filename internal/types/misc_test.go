@@ -235,3 +235,96 @@ func TestInstantiate(t *testing.T) {
 		}
 	})
 }
+
+func TestSpecialize(t *testing.T) {
+	t.Parallel()
+
+	tp := &Alias{Name: "T", Constraint: Any}
+	named := &Alias{Name: "Point", Derived: &Struct{Fields: []*Field{{Name: "x", Type: Basics[Int64]}}}}
+	args := map[string]Type{"T": Basics[Int32]}
+
+	t.Run("keeps_named_types", func(t *testing.T) {
+		t.Parallel()
+
+		result := Specialize(&Map{Key: named, Value: tp}, args)
+
+		m, ok := result.(*Map)
+		if !ok {
+			t.Fatalf("expected *Map, got %T", result)
+		}
+
+		if m.Key != named {
+			t.Errorf("expected key %s, got %s", named, m.Key)
+		}
+
+		if m.Value.Kind() != Int32 {
+			t.Errorf("expected value Int32, got %s", m.Value.Kind())
+		}
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		t.Parallel()
+
+		typ := &Slice{Element: named}
+		if result := Specialize(typ, args); result != typ {
+			t.Errorf("expected the type itself, got %s", result)
+		}
+	})
+
+	t.Run("procedure_type_params", func(t *testing.T) {
+		t.Parallel()
+
+		u := &Alias{Name: "U", Constraint: Any}
+		proc := &Procedure{
+			Function:   true,
+			TypeParams: []*Alias{tp, u},
+			Parameters: []*Parameter{{Name: "x", Type: tp}, {Name: "y", Type: u}},
+			ReturnType: tp,
+		}
+
+		result, ok := Specialize(proc, args).(*Procedure)
+		if !ok {
+			t.Fatalf("expected *Procedure, got %T", result)
+		}
+
+		if len(result.TypeParams) != 1 || result.TypeParams[0] != u {
+			t.Errorf("expected type parameters [U], got %v", result.TypeParams)
+		}
+
+		if result.Parameters[0].Type.Kind() != Int32 || result.Parameters[1].Type != u {
+			t.Errorf("expected parameters (int32, U), got (%s, %s)", result.Parameters[0].Type, result.Parameters[1].Type)
+		}
+
+		if result.ReturnType.Kind() != Int32 {
+			t.Errorf("expected return type Int32, got %s", result.ReturnType)
+		}
+	})
+}
+
+func TestUnionIndex(t *testing.T) {
+	t.Parallel()
+
+	meters := &Alias{Name: "Meters", Derived: Basics[Float64]}
+	union := &Union{Variants: []Type{Basics[Int32], meters, Basics[Float64]}}
+
+	tests := []struct {
+		name string
+		typ  Type
+		want int
+	}{
+		{"first", Basics[Int32], 0},
+		{"named", meters, 1},
+		{"underlying of named", Basics[Float64], 2},
+		{"not a variant", Basics[Int64], -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := union.Index(tt.typ); got != tt.want {
+				t.Errorf("Index(%v) = %d, want %d", tt.typ, got, tt.want)
+			}
+		})
+	}
+}
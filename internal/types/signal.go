@@ -0,0 +1,25 @@
+package types
+
+var _ Type = &Signal{}
+
+// Signal is the handle of a procedure called async. Value is the return type
+// of the procedure, or nil if it returns nothing.
+type Signal struct {
+	Value Type
+}
+
+func (s *Signal) Kind() Kind {
+	return SignalKind
+}
+
+func (s *Signal) String() string {
+	if s.Value == nil {
+		return "signal"
+	}
+
+	return "signal<" + s.Value.String() + ">"
+}
+
+func (s *Signal) Underlying() Type {
+	return s
+}
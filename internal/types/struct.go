@@ -36,6 +36,17 @@ func (s *Struct) Field(name string) *Field {
 	return s.Fields[index]
 }
 
+func (s *Struct) Method(name string) *Method {
+	index := slices.IndexFunc(s.Methods, func(method *Method) bool {
+		return method.Name == name
+	})
+	if index == -1 {
+		return nil
+	}
+
+	return s.Methods[index]
+}
+
 func (s *Struct) String() string {
 	if len(s.Fields) == 0 {
 		return "struct{}"
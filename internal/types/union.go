@@ -4,6 +4,12 @@ import (
 	"strings"
 )
 
+// UnionMaxVariants is the maximum number of variants of a sum type, of which
+// the index is a uint8.
+const UnionMaxVariants = 256
+
+// Union is a type constraint, or a sum type if it has no name and is used as
+// the type of a value.
 type Union struct {
 	Name     string // non-empty for builtin constraints (e.g., "int", "comparable")
 	Variants []Type
@@ -36,3 +42,15 @@ func (t *Union) String() string {
 func (t *Union) Underlying() Type {
 	return t
 }
+
+// Index returns the index of the variant of t that is typ, or -1 if typ is not
+// a variant of t. Named variants are distinct from their underlying types.
+func (t *Union) Index(typ Type) int {
+	for i, v := range t.Variants {
+		if v.String() == typ.String() && Equal(v, typ) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,102 @@
+package cog
+
+import (
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/ryanavella/wide"
+	u128 "lukechampine.com/uint128"
+)
+
+// Adapters used by generated code at @go call sites.
+
+// ResultOf converts a Go (value, error) pair to a Result.
+func ResultOf[T any](value T, err error) Result[T, error] {
+	if err != nil {
+		return Result[T, error]{Error: err, IsError: true}
+	}
+
+	return Result[T, error]{Value: value}
+}
+
+// OptionOf converts a nilable Go pointer to an Option, which is set when the
+// pointer is non-nil.
+func OptionOf[T any](ptr *T) Option[*T] {
+	return Option[*T]{Value: ptr, Set: ptr != nil}
+}
+
+// OptionFromPointer converts a nilable Go pointer to an Option of the value it
+// points to, which is set when the pointer is non-nil.
+func OptionFromPointer[T any](ptr *T) Option[T] {
+	if ptr == nil {
+		return Option[T]{}
+	}
+
+	return Option[T]{Value: *ptr, Set: true}
+}
+
+// ErrorOption converts a Go error to an Option, which is set when err is
+// non-nil.
+func ErrorOption(err error) Option[error] {
+	return Option[error]{Value: err, Set: err != nil}
+}
+
+// ASCIIFromGo converts a Go string or byte slice to ASCII, or returns an error
+// if it contains a byte that is not ASCII.
+func ASCIIFromGo[T ~string | ~[]byte](v T) Result[ASCII, error] {
+	for i := range len(v) {
+		if v[i] >= utf8.RuneSelf {
+			return Result[ASCII, error]{Error: fmt.Errorf("cog: non-ASCII byte %#x at index %d", v[i], i), IsError: true}
+		}
+	}
+
+	return Result[ASCII, error]{Value: ASCII(v)}
+}
+
+var (
+	minInt128  = new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 127))
+	maxInt128  = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 127), big.NewInt(1))
+	maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+)
+
+// Int128FromBig converts a big.Int to an Int128, or returns an error if it
+// overflows. A nil big.Int is zero.
+func Int128FromBig(v *big.Int) Result[Int128, error] {
+	if v == nil {
+		return Result[Int128, error]{}
+	}
+
+	if v.Cmp(minInt128) < 0 || v.Cmp(maxInt128) > 0 {
+		return Result[Int128, error]{Error: fmt.Errorf("cog: %s overflows int128", v), IsError: true}
+	}
+
+	return Result[Int128, error]{Value: wide.Int128FromBigInt(v)}
+}
+
+// Int128ToBig converts an Int128 to a big.Int.
+func Int128ToBig(v Int128) *big.Int {
+	// Int128.String formats as hexadecimal with a 0x prefix.
+	b, _ := new(big.Int).SetString(v.String(), 0)
+	return b
+}
+
+// Uint128FromBig converts a big.Int to a Uint128, or returns an error if it is
+// negative or overflows. A nil big.Int is zero.
+func Uint128FromBig(v *big.Int) Result[Uint128, error] {
+	if v == nil {
+		return Result[Uint128, error]{}
+	}
+
+	if v.Sign() < 0 || v.Cmp(maxUint128) > 0 {
+		return Result[Uint128, error]{Error: fmt.Errorf("cog: %s overflows uint128", v), IsError: true}
+	}
+
+	// u128.FromBig shifts its argument in place.
+	return Result[Uint128, error]{Value: u128.FromBig(new(big.Int).Set(v))}
+}
+
+// Uint128ToBig converts a Uint128 to a big.Int.
+func Uint128ToBig(v Uint128) *big.Int {
+	return v.Big()
+}
@@ -0,0 +1,113 @@
+package cog
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestResultOf(t *testing.T) {
+	t.Parallel()
+
+	ok := ResultOf(42, nil)
+	if ok.IsError || ok.Value != 42 {
+		t.Errorf("ResultOf(42, nil) = %+v", ok)
+	}
+
+	errFail := errors.New("fail")
+
+	failed := ResultOf(0, errFail)
+	if !failed.IsError || !errors.Is(failed.Error, errFail) {
+		t.Errorf("ResultOf(0, err) = %+v", failed)
+	}
+}
+
+func TestOptionOf(t *testing.T) {
+	t.Parallel()
+
+	value := 1
+
+	if opt := OptionOf(&value); !opt.Set || opt.Value != &value {
+		t.Errorf("OptionOf(&value) = %+v", opt)
+	}
+
+	if opt := OptionOf[int](nil); opt.Set {
+		t.Errorf("OptionOf(nil) = %+v", opt)
+	}
+
+	if opt := OptionFromPointer(&value); !opt.Set || opt.Value != value {
+		t.Errorf("OptionFromPointer(&value) = %+v", opt)
+	}
+
+	if opt := OptionFromPointer[int](nil); opt.Set {
+		t.Errorf("OptionFromPointer(nil) = %+v", opt)
+	}
+
+	if opt := ErrorOption(nil); opt.Set {
+		t.Errorf("ErrorOption(nil) = %+v", opt)
+	}
+}
+
+func TestASCIIFromGo(t *testing.T) {
+	t.Parallel()
+
+	if got := ASCIIFromGo("shout"); got.IsError || string(got.Value) != "shout" {
+		t.Errorf("ASCIIFromGo(\"shout\") = %+v", got)
+	}
+
+	if got := ASCIIFromGo([]byte("ok")); got.IsError || string(got.Value) != "ok" {
+		t.Errorf("ASCIIFromGo([]byte(\"ok\")) = %+v", got)
+	}
+
+	if got := ASCIIFromGo("café"); !got.IsError {
+		t.Errorf("ASCIIFromGo(\"café\") = %+v, want error", got)
+	}
+}
+
+func TestBigConversions(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"0", "-7", "170141183460469231731687303715884105727", "-170141183460469231731687303715884105728"} {
+		want, _ := new(big.Int).SetString(s, 10)
+
+		got := Int128FromBig(want)
+		if got.IsError {
+			t.Fatalf("Int128FromBig(%s): %v", want, got.Error)
+		}
+
+		if b := Int128ToBig(got.Value); b.Cmp(want) != 0 {
+			t.Errorf("int128 round trip of %s = %s", want, b)
+		}
+	}
+
+	want, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+
+	got := Uint128FromBig(want)
+	if got.IsError {
+		t.Fatalf("Uint128FromBig(%s): %v", want, got.Error)
+	}
+
+	if b := Uint128ToBig(got.Value); b.Cmp(want) != 0 {
+		t.Errorf("uint128 round trip of %s = %s", want, b)
+	}
+
+	if got := Int128FromBig(nil); got.IsError || !got.Value.Eq(Int128{}) {
+		t.Error("Int128FromBig(nil) should be zero")
+	}
+
+	for _, s := range []string{"170141183460469231731687303715884105728", "-170141183460469231731687303715884105729"} {
+		v, _ := new(big.Int).SetString(s, 10)
+
+		if got := Int128FromBig(v); !got.IsError {
+			t.Errorf("Int128FromBig(%s) = %+v, want error", v, got)
+		}
+	}
+
+	for _, s := range []string{"-1", "340282366920938463463374607431768211456"} {
+		v, _ := new(big.Int).SetString(s, 10)
+
+		if got := Uint128FromBig(v); !got.IsError {
+			t.Errorf("Uint128FromBig(%s) = %+v, want error", v, got)
+		}
+	}
+}
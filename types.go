@@ -2,7 +2,6 @@ package cog
 
 import (
 	"bytes"
-	"hash/maphash"
 	"math"
 	"math/big"
 
@@ -13,7 +12,6 @@ import (
 
 type (
 	ASCII             []byte
-	ASCIIHash         uint64
 	Float16           = f16.Float16
 	Int128            = wide.Int128
 	Set[T comparable] map[T]struct{}
@@ -46,10 +44,11 @@ func Uint128FromString(s string) Uint128 {
 	return v
 }
 
-// Int128FromString parses a decimal string into an Int128.
+// Int128FromString parses an integer literal into an Int128: decimal, or
+// binary, octal or hexadecimal with a 0b, 0o or 0x prefix.
 func Int128FromString(s string) Int128 {
 	v := new(big.Int)
-	v.SetString(s, 10)
+	v.SetString(s, 0)
 
 	return wide.Int128FromBigInt(v)
 }
@@ -58,13 +57,6 @@ func (a ASCII) Equal(b ASCII) bool {
 	return bytes.Equal(a, b)
 }
 
-var seed = maphash.MakeSeed()
-
-// HashASCII hashes an ASCII value to a uint64 to use for map and set keys.
-func HashASCII[Out ~uint64, In ~[]byte](in In) Out {
-	return Out(maphash.Bytes(seed, in))
-}
-
 type Option[T any] struct {
 	Value T
 	Set   bool
@@ -156,6 +148,21 @@ func Int128ToUint128(v Int128) Uint128 {
 	return u128.New(lo, hi)
 }
 
+// Uint128AndNot returns the bit clear u &^ v, which Uint128 has no method for.
+func Uint128AndNot(u, v Uint128) Uint128 {
+	return u.And(v.Xor(u128.Max))
+}
+
+// Int128Rsh returns the arithmetic right shift x >> n. Int128.RShiftN rounds
+// negative values toward zero, where >> rounds them down.
+func Int128Rsh(x Int128, n uint) Int128 {
+	if x.IsNeg() {
+		return x.Not().RShiftN(n).Not()
+	}
+
+	return x.RShiftN(n)
+}
+
 // Float16Frombits converts a uint16 to a Float16.
 func Float16Frombits(bits uint16) Float16 {
 	return f16.Frombits(bits)
@@ -0,0 +1,42 @@
+package cog
+
+import (
+	"testing"
+
+	"github.com/ryanavella/wide"
+)
+
+func TestUint128AndNot(t *testing.T) {
+	t.Parallel()
+
+	u := Uint128FromString("340282366920938463463374607431768211455") // 2^128 - 1
+	v := Uint128From64(0xff)
+
+	want := Uint128FromString("340282366920938463463374607431768211200")
+	if got := Uint128AndNot(u, v); !got.Equals(want) {
+		t.Errorf("Uint128AndNot(%s, %s) = %s, want %s", u, v, got, want)
+	}
+}
+
+func TestInt128Rsh(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		x    int64
+		n    uint
+		want int64
+	}{
+		{x: 12, n: 2, want: 3},
+		{x: -12, n: 2, want: -3},
+		{x: -3, n: 1, want: -2},
+		{x: -1, n: 127, want: -1},
+		{x: 1, n: 128, want: 0},
+	}
+
+	for _, tt := range tests {
+		got := Int128Rsh(wide.Int128FromInt64(tt.x), tt.n)
+		if !got.Eq(wide.Int128FromInt64(tt.want)) {
+			t.Errorf("Int128Rsh(%d, %d) = %s, want %d", tt.x, tt.n, got, tt.want)
+		}
+	}
+}